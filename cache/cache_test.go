@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveLoad_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested")
+	want := map[string][]string{"pins": {"vm-1", "vm-2"}}
+
+	if err := Save(dir, "state.json", want); err != nil {
+		t.Fatalf("Save() returned an unexpected error: %v", err)
+	}
+	var got map[string][]string
+	ok, err := Load(dir, "state.json", &got)
+	if err != nil {
+		t.Fatalf("Load() returned an unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatal("Load() reported a missing entry after Save()")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	var v []string
+	ok, err := Load(t.TempDir(), "missing.json", &v)
+	if err != nil || ok {
+		t.Fatalf("expected a missing entry without error, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestLoad_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]string
+	if _, err := Load(dir, "bad.json", &v); err == nil {
+		t.Fatal("Load() did not return an error for corrupt data")
+	}
+}
+
+func TestDir_EnvOverride(t *testing.T) {
+	t.Setenv("GCP_RIDER_CACHE_DIR", "/tmp/rider-cache")
+	d, err := Dir()
+	if err != nil {
+		t.Fatalf("Dir() returned an unexpected error: %v", err)
+	}
+	if d != "/tmp/rider-cache" {
+		t.Errorf("expected the env override, got %q", d)
+	}
+}
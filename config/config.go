@@ -0,0 +1,232 @@
+// Package config loads the user's gcp-rider settings from disk.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// zonePattern matches plausible zone names such as "us-central1-a" or
+// "northamerica-northeast1-b".
+var zonePattern = regexp.MustCompile(`^[a-z]+(-[a-z]+)*[0-9]+-[a-z]$`)
+
+// Config holds the user's settings. The zero value is a valid configuration
+// that keeps all defaults.
+type Config struct {
+	// Keys remaps actions to keys, e.g. {"ssh": ["s"]}. Actions that are not
+	// listed keep their default bindings.
+	Keys map[string][]string `json:"keys,omitempty"`
+	// Theme names a built-in color theme: "dark" (the default) or "light".
+	Theme string `json:"theme,omitempty"`
+	// Spinner names the loading animation, one of the bubbles spinner
+	// presets such as "line", "minidot" or "globe". It defaults to "dot".
+	Spinner string `json:"spinner,omitempty"`
+	// Prices overrides the bundled hourly price table, keyed by machine type.
+	Prices map[string]float64 `json:"prices,omitempty"`
+	// Projects lists the projects shown together when -projects-file is not
+	// given. It takes precedence over GCP_PROJECT_ID.
+	Projects []string `json:"projects,omitempty"`
+	// ProjectZones maps projects to the zone used for zone-scoped
+	// operations when an instance's own zone is not known, and to preselect
+	// in the zone picker, e.g. {"shop-prod": "europe-west1-b"}.
+	ProjectZones map[string]string `json:"project_zones,omitempty"`
+	// AbbreviateZones shows zones in the list in short form, e.g. "usc1-a"
+	// for "us-central1-a".
+	AbbreviateZones bool `json:"abbreviate_zones,omitempty"`
+	// NameDisplay is how instances are named in the list and details:
+	// NameDisplayShort, the default when it is empty, or NameDisplayFull,
+	// which tells apart instances of the same name in several projects.
+	NameDisplay string `json:"name_display,omitempty"`
+	// HideStopped starts with TERMINATED and SUSPENDED instances hidden from
+	// the list. They can be shown again with a key.
+	HideStopped bool `json:"hide_stopped,omitempty"`
+	// Mouse starts with mouse support on, to select instances by clicking
+	// and move with the wheel. It can be turned off with a key.
+	Mouse bool `json:"mouse,omitempty"`
+	// SSHArgs are appended to every gcloud compute ssh invocation, e.g.
+	// ["--", "-A"] for agent forwarding.
+	SSHArgs []string `json:"ssh_args,omitempty"`
+	// PreSSHHook is a shell command run before every SSH session, e.g. to
+	// refresh a VPN or a token. It gets the instance name, zone and project
+	// as $1, $2 and $3, and the session is not opened if it fails. No hook
+	// is run when it is empty.
+	PreSSHHook string `json:"pre_ssh_hook,omitempty"`
+	// SSHTransport is how SSH sessions are opened: SSHTransportGcloud, the
+	// default when it is empty, or SSHTransportDirect.
+	SSHTransport string `json:"ssh_transport,omitempty"`
+	// SSHJumpHost is the host that direct SSH goes through to reach
+	// instances without an external IP, as in ssh -J, e.g.
+	// "me@bastion.example.com".
+	SSHJumpHost string `json:"ssh_jump_host,omitempty"`
+	// Queries are named views of the list that can be switched between with
+	// a key.
+	Queries []SavedQuery `json:"queries,omitempty"`
+	// ProdProjects are patterns, in the syntax of path.Match, of the
+	// projects holding production, which the header highlights. When unset
+	// it is DefaultProdProjects; an empty list highlights none.
+	ProdProjects []string `json:"prod_projects,omitempty"`
+	// SensitiveProjects are patterns, in the syntax of path.Match, of the
+	// projects whose instances cannot be suspended, resumed or changed
+	// until the project ID is typed to unlock them for the session, e.g.
+	// ["*-prod"]. Nothing is locked when it is empty.
+	SensitiveProjects []string `json:"sensitive_projects,omitempty"`
+	// LabelColumns are label keys shown as columns of their own, after the
+	// default columns of the list and of -output text, e.g. ["team", "owner"].
+	LabelColumns []string `json:"label_columns,omitempty"`
+}
+
+// The SSH transports: gcloud compute ssh, which manages keys and can
+// tunnel through IAP, or plain ssh to the external IP of the instance.
+const (
+	SSHTransportGcloud = "gcloud"
+	SSHTransportDirect = "ssh"
+)
+
+// SSHTransports lists the valid SSH transports.
+var SSHTransports = []string{SSHTransportGcloud, SSHTransportDirect}
+
+// The ways of naming instances: by their name alone, or as
+// "NAME.ZONE.PROJECT", the form of the SSH config aliases.
+const (
+	NameDisplayShort = "short"
+	NameDisplayFull  = "full"
+)
+
+// NameDisplays lists the valid ways of naming instances.
+var NameDisplays = []string{NameDisplayShort, NameDisplayFull}
+
+// DefaultProdProjects matches the projects highlighted as production when
+// the config does not list any.
+var DefaultProdProjects = []string{"*prod*"}
+
+// SavedQuery is a named combination of filter, sort order and columns,
+// e.g. a "prod-running" query with the filter "status:running
+// label:env=prod" sorted by name.
+type SavedQuery struct {
+	Name string `json:"name"`
+	// Filter uses the same syntax as the filter typed in the list.
+	Filter string `json:"filter,omitempty"`
+	// Sort orders the list by "name", "zone", "status", "machine-type",
+	// "created" or a label such as "label:team". The API order is kept when
+	// it is empty.
+	Sort string `json:"sort,omitempty"`
+	// Columns lists the columns shown after the name, in order, such as
+	// "zone" or "label:team". The default columns are shown when it is empty.
+	Columns []string `json:"columns,omitempty"`
+	// Project limits the query to one of the listed projects.
+	Project string `json:"project,omitempty"`
+}
+
+// Path returns the location of the config file. It can be overridden with
+// the GCP_RIDER_CONFIG environment variable.
+func Path() (string, error) {
+	if p := os.Getenv("GCP_RIDER_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "gcp-rider", "config.json"), nil
+}
+
+// Load reads the config file at path. A missing file is not an error and
+// yields the default configuration.
+func Load(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if err := checkQueries(cfg.Queries); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if err := checkProjectZones(cfg.ProjectZones); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if err := checkProjectPatterns("production", cfg.ProdProjects); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if err := checkProjectPatterns("sensitive", cfg.SensitiveProjects); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if err := checkLabelColumns(cfg.LabelColumns); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if cfg.SSHTransport != "" && !slices.Contains(SSHTransports, cfg.SSHTransport) {
+		return cfg, fmt.Errorf("invalid config %s: unknown ssh_transport %q, must be %s", path, cfg.SSHTransport, strings.Join(SSHTransports, " or "))
+	}
+	if cfg.NameDisplay != "" && !slices.Contains(NameDisplays, cfg.NameDisplay) {
+		return cfg, fmt.Errorf("invalid config %s: unknown name_display %q, must be %s", path, cfg.NameDisplay, strings.Join(NameDisplays, " or "))
+	}
+	return cfg, nil
+}
+
+// checkQueries reports saved queries without a name or sharing a name. The
+// sort order and columns are checked by the tui package, which defines them.
+func checkQueries(queries []SavedQuery) error {
+	seen := make(map[string]bool, len(queries))
+	for i, q := range queries {
+		if q.Name == "" {
+			return fmt.Errorf("saved query %d has no name", i+1)
+		}
+		if seen[q.Name] {
+			return fmt.Errorf("saved query %q is defined twice", q.Name)
+		}
+		seen[q.Name] = true
+	}
+	return nil
+}
+
+// checkProjectZones reports default zones that do not look like zone names,
+// such as regions or zone URLs.
+func checkProjectZones(zones map[string]string) error {
+	for project, zone := range zones {
+		if project == "" {
+			return fmt.Errorf("default zone %q has no project", zone)
+		}
+		if !zonePattern.MatchString(zone) {
+			return fmt.Errorf("default zone %q of project %s is not a zone name like us-central1-a", zone, project)
+		}
+	}
+	return nil
+}
+
+// checkProjectPatterns reports project patterns of the given kind, such as
+// "production", that are malformed.
+func checkProjectPatterns(kind string, patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("%s project pattern %q: %w", kind, p, err)
+		}
+	}
+	return nil
+}
+
+// checkLabelColumns reports label columns that are empty or listed twice.
+func checkLabelColumns(keys []string) error {
+	seen := make(map[string]bool, len(keys))
+	for i, k := range keys {
+		if k == "" {
+			return fmt.Errorf("label column %d has no label key", i+1)
+		}
+		if seen[k] {
+			return fmt.Errorf("label column %q is listed twice", k)
+		}
+		seen[k] = true
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load() returned an unexpected error: %v", err)
+	}
+	if cfg.Keys != nil {
+		t.Errorf("expected default config, got %+v", cfg)
+	}
+}
+
+func TestLoad_Keys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"keys": {"ssh": ["s"], "quit": ["x", "ctrl+q"]}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() returned an unexpected error: %v", err)
+	}
+	if got := cfg.Keys["quit"]; len(got) != 2 || got[1] != "ctrl+q" {
+		t.Errorf("unexpected quit keys: %v", got)
+	}
+}
+
+func TestLoad_Projects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"projects": ["proj-a", "proj-b"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() returned an unexpected error: %v", err)
+	}
+	if len(cfg.Projects) != 2 || cfg.Projects[1] != "proj-b" {
+		t.Errorf("unexpected projects: %v", cfg.Projects)
+	}
+}
+
+func TestLoad_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"keys": `), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Fatal("Load() did not return an error for invalid JSON")
+	}
+}
+
+func TestLoad_Queries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"queries": [{"name": "prod-running", "filter": "status:running label:env=prod", "sort": "name", "columns": ["zone", "status"]}]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() returned an unexpected error: %v", err)
+	}
+	if len(cfg.Queries) != 1 || cfg.Queries[0].Sort != "name" || len(cfg.Queries[0].Columns) != 2 {
+		t.Errorf("unexpected queries: %+v", cfg.Queries)
+	}
+}
+
+func TestLoad_InvalidQueries(t *testing.T) {
+	for name, data := range map[string]string{
+		"unnamed":   `{"queries": [{"filter": "web"}]}`,
+		"duplicate": `{"queries": [{"name": "web"}, {"name": "web", "sort": "zone"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Fatal("Load() did not return an error")
+			}
+		})
+	}
+}
+
+func TestLoad_ProjectZones(t *testing.T) {
+	for data, valid := range map[string]bool{
+		`{"project_zones": {"shop-prod": "europe-west1-b", "shop-dev": "northamerica-northeast1-a"}}`: true,
+		`{"project_zones": {"shop-prod": "europe-west1"}}`:                                            false,
+		`{"project_zones": {"shop-prod": "zones/europe-west1-b"}}`:                                    false,
+		`{"project_zones": {"shop-prod": "Europe-West1-B"}}`:                                          false,
+		`{"project_zones": {"": "europe-west1-b"}}`:                                                   false,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if valid && err != nil {
+			t.Errorf("Load(%s) returned an unexpected error: %v", data, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("Load(%s) did not return an error", data)
+		}
+		if valid && cfg.ProjectZones["shop-prod"] != "europe-west1-b" {
+			t.Errorf("unexpected project zones: %v", cfg.ProjectZones)
+		}
+	}
+}
+
+func TestLoad_ProdProjects(t *testing.T) {
+	for data, valid := range map[string]bool{
+		`{"prod_projects": ["*-prod", "billing"]}`: true,
+		`{"prod_projects": []}`:                    true,
+		`{"prod_projects": ["[prod"]}`:             false,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if valid && err != nil {
+			t.Errorf("Load(%s) returned an unexpected error: %v", data, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("Load(%s) did not return an error", data)
+		}
+		if valid && cfg.ProdProjects == nil {
+			t.Errorf("Load(%s) should keep an explicit list apart from an unset one", data)
+		}
+	}
+}
+
+func TestLoad_SensitiveProjects(t *testing.T) {
+	for data, valid := range map[string]bool{
+		`{"sensitive_projects": ["*-prod"]}`: true,
+		`{"sensitive_projects": ["[prod"]}`:  false,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if valid && err != nil {
+			t.Errorf("Load(%s) returned an unexpected error: %v", data, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("Load(%s) did not return an error", data)
+		}
+	}
+}
+
+func TestLoad_LabelColumns(t *testing.T) {
+	for data, valid := range map[string]bool{
+		`{"label_columns": ["team", "owner"]}`: true,
+		`{"label_columns": ["team", ""]}`:      false,
+		`{"label_columns": ["team", "team"]}`:  false,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if valid && err != nil {
+			t.Errorf("Load(%s) returned an unexpected error: %v", data, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("Load(%s) did not return an error", data)
+		}
+		if valid && len(cfg.LabelColumns) != 2 {
+			t.Errorf("unexpected label columns: %v", cfg.LabelColumns)
+		}
+	}
+}
+
+func TestLoad_SSHTransport(t *testing.T) {
+	for data, valid := range map[string]bool{
+		`{}`:                          true,
+		`{"ssh_transport": "gcloud"}`: true,
+		`{"ssh_transport": "ssh", "ssh_jump_host": "bastion"}`: true,
+		`{"ssh_transport": "mosh"}`:                            false,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if valid && err != nil {
+			t.Errorf("Load(%s) returned an unexpected error: %v", data, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("Load(%s) did not return an error", data)
+		}
+	}
+}
+
+func TestLoad_NameDisplay(t *testing.T) {
+	for data, valid := range map[string]bool{
+		`{"name_display": "short"}`: true,
+		`{"name_display": "full"}`:  true,
+		`{"name_display": "fqdn"}`:  false,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if valid && err != nil {
+			t.Errorf("Load(%s) returned an unexpected error: %v", data, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("Load(%s) did not return an error", data)
+		}
+	}
+}
+
+func TestPath_EnvOverride(t *testing.T) {
+	t.Setenv("GCP_RIDER_CONFIG", "/tmp/custom.json")
+	p, err := Path()
+	if err != nil {
+		t.Fatalf("Path() returned an unexpected error: %v", err)
+	}
+	if p != "/tmp/custom.json" {
+		t.Errorf("expected the env override, got %q", p)
+	}
+}
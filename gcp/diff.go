@@ -0,0 +1,55 @@
+package gcp
+
+// StatusChange records an instance whose status differs between two lists.
+type StatusChange struct {
+	Instance  Instance
+	OldStatus string
+}
+
+// InstanceDiff describes how a list of instances changed.
+type InstanceDiff struct {
+	Added   []Instance
+	Removed []Instance
+	Changed []StatusChange
+}
+
+// Empty reports whether the lists were identical in membership and status.
+func (d InstanceDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// instanceKey identifies an instance across fetches. Names are only unique
+// within a project and zone.
+func instanceKey(vm Instance) string {
+	return vm.ProjectID + "/" + vm.Zone + "/" + vm.Name
+}
+
+// DiffInstances compares a previous list of instances against the current
+// one. Added and changed instances follow the order of curr, removed ones the
+// order of prev.
+func DiffInstances(prev, curr []Instance) InstanceDiff {
+	before := make(map[string]Instance, len(prev))
+	for _, vm := range prev {
+		before[instanceKey(vm)] = vm
+	}
+	after := make(map[string]bool, len(curr))
+
+	var d InstanceDiff
+	for _, vm := range curr {
+		key := instanceKey(vm)
+		after[key] = true
+		old, ok := before[key]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, vm)
+		case old.Status != vm.Status:
+			d.Changed = append(d.Changed, StatusChange{Instance: vm, OldStatus: old.Status})
+		}
+	}
+	for _, vm := range prev {
+		if !after[instanceKey(vm)] {
+			d.Removed = append(d.Removed, vm)
+		}
+	}
+	return d
+}
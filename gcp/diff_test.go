@@ -0,0 +1,42 @@
+package gcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffInstances(t *testing.T) {
+	prev := []Instance{
+		{Name: "web-1", Zone: "z-1", Status: "RUNNING"},
+		{Name: "web-2", Zone: "z-1", Status: "RUNNING"},
+		{Name: "db-1", Zone: "z-2", Status: "TERMINATED"},
+	}
+	curr := []Instance{
+		{Name: "web-1", Zone: "z-1", Status: "RUNNING"},
+		{Name: "db-1", Zone: "z-2", Status: "RUNNING"},
+		{Name: "web-2", Zone: "z-3", Status: "RUNNING"},
+	}
+
+	got := DiffInstances(prev, curr)
+	want := InstanceDiff{
+		Added:   []Instance{{Name: "web-2", Zone: "z-3", Status: "RUNNING"}},
+		Removed: []Instance{{Name: "web-2", Zone: "z-1", Status: "RUNNING"}},
+		Changed: []StatusChange{{Instance: Instance{Name: "db-1", Zone: "z-2", Status: "RUNNING"}, OldStatus: "TERMINATED"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DiffInstances() =\n%+v\nwant\n%+v", got, want)
+	}
+	if got.Empty() {
+		t.Error("expected a non-empty diff")
+	}
+}
+
+func TestDiffInstances_NoChanges(t *testing.T) {
+	vms := []Instance{{Name: "web-1", Zone: "z-1", Status: "RUNNING"}}
+	if d := DiffInstances(vms, vms); !d.Empty() {
+		t.Errorf("expected an empty diff, got %+v", d)
+	}
+	if d := DiffInstances(nil, nil); !d.Empty() {
+		t.Errorf("expected an empty diff for empty lists, got %+v", d)
+	}
+}
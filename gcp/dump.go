@@ -0,0 +1,86 @@
+package gcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Dumper writes the raw requests and responses of instance listings to JSON
+// files, one per listing of a project, for debugging fields that do not come
+// through as expected.
+type Dumper struct {
+	// Dir is the directory the dumps are written to. It is created if
+	// needed.
+	Dir string
+	// Redact replaces the metadata values of the dumped instances with
+	// "REDACTED", as they may hold secrets such as service account keys or
+	// startup scripts with credentials.
+	Redact bool
+}
+
+// apiDump is the content of a dump file.
+type apiDump struct {
+	Project  string            `json:"project"`
+	Time     string            `json:"time"`
+	Requests []json.RawMessage `json:"requests"`
+	// Scopes holds the instances listed per scope, e.g. "zones/us-east1-b",
+	// as the API returned them.
+	Scopes map[string]json.RawMessage `json:"scopes"`
+}
+
+// write dumps the requests and listed pages of a listing of projectID to a
+// new file in d.Dir named after the project and time.
+func (d *Dumper) write(projectID string, requests []proto.Message, pages map[string]*computepb.InstancesScopedList, now time.Time) error {
+	dump := apiDump{
+		Project: projectID,
+		Time:    now.UTC().Format(time.RFC3339Nano),
+		Scopes:  make(map[string]json.RawMessage, len(pages)),
+	}
+	for _, req := range requests {
+		raw, err := protojson.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("failed to dump request: %w", err)
+		}
+		dump.Requests = append(dump.Requests, raw)
+	}
+	for scope, page := range pages {
+		if d.Redact {
+			redactMetadata(page.GetInstances())
+		}
+		raw, err := protojson.Marshal(page)
+		if err != nil {
+			return fmt.Errorf("failed to dump %s: %w", scope, err)
+		}
+		dump.Scopes[scope] = raw
+	}
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to dump the listing: %w", err)
+	}
+	if err := os.MkdirAll(d.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+	path := filepath.Join(d.Dir, fmt.Sprintf("instances-%s-%s.json", projectID, now.UTC().Format("20060102T150405.000000000")))
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write dump: %w", err)
+	}
+	return nil
+}
+
+// redactMetadata replaces the metadata values of instances in place.
+func redactMetadata(instances []*computepb.Instance) {
+	for _, instance := range instances {
+		for _, item := range instance.GetMetadata().GetItems() {
+			if item.Value != nil {
+				item.Value = proto.String("REDACTED")
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestFetchInstances_Dump(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{
+			"items": {
+				"zones/us-central1-a": {
+					"instances": [{
+						"name": "instance-1",
+						"zone": "https://www.googleapis.com/compute/v1/projects/proj/zones/us-central1-a",
+						"metadata": {"items": [{"key": "startup-script", "value": "export TOKEN=secret"}]}
+					}]
+				}
+			}
+		}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	for _, redact := range []bool{false, true} {
+		dir := t.TempDir()
+		list, err := client.FetchInstances(ctx, "test-project", FetchOptions{Dump: &Dumper{Dir: dir, Redact: redact}})
+		if err != nil {
+			t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+		}
+		if len(list.Instances) != 1 || list.Instances[0].Metadata["startup-script"] != "export TOKEN=secret" {
+			t.Errorf("the dump should not change the listing, got %+v", list.Instances)
+		}
+
+		files, _ := filepath.Glob(filepath.Join(dir, "instances-test-project-*.json"))
+		if len(files) != 1 {
+			t.Fatalf("expected one dump file, got %v", files)
+		}
+		data, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dump apiDump
+		if err := json.Unmarshal(data, &dump); err != nil {
+			t.Fatalf("the dump is not valid JSON: %v", err)
+		}
+		if dump.Project != "test-project" || len(dump.Requests) != 1 || !strings.Contains(string(dump.Requests[0]), "returnPartialSuccess") {
+			t.Errorf("unexpected dump header: %+v", dump)
+		}
+		scope := string(dump.Scopes["zones/us-central1-a"])
+		if !strings.Contains(scope, `"instance-1"`) {
+			t.Errorf("expected the raw instance in the dump, got %s", scope)
+		}
+		if got := strings.Contains(scope, "secret"); got == redact {
+			t.Errorf("with redact=%v, the dump holds the metadata value: %v\n%s", redact, got, scope)
+		}
+	}
+}
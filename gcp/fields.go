@@ -0,0 +1,109 @@
+package gcp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Field names a group of the optional Instance fields that a listing
+// populates. Converting them is most of the cost of listing a huge fleet, so
+// FetchOptions.Fields can leave out those that are not needed.
+type Field string
+
+// The optional field groups. The ID, name, zone, status, machine type,
+// hostname, timestamps and deletion protection are always populated.
+const (
+	// FieldNetwork is Network, Subnetwork, ExternalIP and InternalIP.
+	FieldNetwork Field = "network"
+	// FieldImage is Image and BootLicense, read from the boot disk.
+	FieldImage Field = "image"
+	// FieldMetadata is Metadata.
+	FieldMetadata Field = "metadata"
+	// FieldLabels is Labels and Tags.
+	FieldLabels Field = "labels"
+	// FieldDetails is ResourcePolicies, GPUs, the scheduling policy, the
+	// upcoming maintenance, the reservation affinity and the Shielded and
+	// Confidential VM flags.
+	FieldDetails Field = "details"
+)
+
+// AllFields are the optional field groups, in the order they are listed to
+// users.
+var AllFields = []Field{FieldNetwork, FieldImage, FieldMetadata, FieldLabels, FieldDetails}
+
+// ParseFields parses a comma-separated list of field group names, such as
+// "network,labels". An empty list selects none of them, and "all" returns
+// nil to select every one.
+func ParseFields(s string) ([]Field, error) {
+	if s == "all" {
+		return nil, nil
+	}
+	fields := []Field{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(AllFields, Field(name)) {
+			names := make([]string, len(AllFields))
+			for i, f := range AllFields {
+				names[i] = string(f)
+			}
+			return nil, fmt.Errorf("unknown field %q, must be one of %s", name, strings.Join(names, ", "))
+		}
+		fields = append(fields, Field(name))
+	}
+	return fields, nil
+}
+
+// fieldMaskHeader is the header asking the API for a partial response, with
+// only the fields it lists.
+const fieldMaskHeader = "X-Goog-FieldMask"
+
+// instanceMask returns the fields of the API instances that are read to
+// populate fields, in the syntax of a partial response field mask, so that
+// the others are not even sent.
+func instanceMask(fields fieldSet) string {
+	mask := []string{"id", "name", "zone", "status", "machineType", "hostname",
+		"creationTimestamp", "lastStartTimestamp", "lastStopTimestamp", "deletionProtection"}
+	if fields.has(FieldNetwork) {
+		mask = append(mask, "networkInterfaces(network,subnetwork,networkIP,accessConfigs/natIP)")
+	}
+	if fields.has(FieldImage) {
+		mask = append(mask, "disks(boot,initializeParams/sourceImage,licenses)")
+	}
+	if fields.has(FieldMetadata) {
+		mask = append(mask, "metadata/items")
+	}
+	if fields.has(FieldLabels) {
+		mask = append(mask, "tags/items", "labels")
+	}
+	if fields.has(FieldDetails) {
+		mask = append(mask, "resourcePolicies", "guestAccelerators/acceleratorCount",
+			"scheduling(automaticRestart,onHostMaintenance,provisioningModel,preemptible)",
+			"resourceStatus/upcomingMaintenance", "reservationAffinity", "shieldedInstanceConfig", "confidentialInstanceConfig")
+	}
+	return strings.Join(mask, ",")
+}
+
+// fieldSet is the set of field groups to populate; nil holds them all.
+type fieldSet map[Field]bool
+
+// newFieldSet returns the set of fields, or nil for every field if fields is
+// nil.
+func newFieldSet(fields []Field) fieldSet {
+	if fields == nil {
+		return nil
+	}
+	set := make(fieldSet, len(fields))
+	for _, f := range fields {
+		set[f] = true
+	}
+	return set
+}
+
+// has reports whether f is to be populated.
+func (s fieldSet) has(f Field) bool {
+	return s == nil || s[f]
+}
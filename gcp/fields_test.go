@@ -0,0 +1,65 @@
+package gcp
+
+import (
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields("network, labels")
+	if err != nil {
+		t.Fatalf("ParseFields() returned an unexpected error: %v", err)
+	}
+	if want := []Field{FieldNetwork, FieldLabels}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("expected %v, got %v", want, fields)
+	}
+	if fields, err := ParseFields(""); err != nil || fields == nil || len(fields) != 0 {
+		t.Errorf("an empty list should select no field, got %v, %v", fields, err)
+	}
+	if fields, err := ParseFields("all"); err != nil || fields != nil {
+		t.Errorf("all should select every field, got %v, %v", fields, err)
+	}
+	if _, err := ParseFields("network,disks"); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestNewInstanceFields(t *testing.T) {
+	instance := &computepb.Instance{
+		Name:        proto.String("instance-1"),
+		Status:      proto.String("RUNNING"),
+		MachineType: proto.String("zones/z-1/machineTypes/e2-small"),
+		Labels:      map[string]string{"env": "prod"},
+		Tags:        &computepb.Tags{Items: []string{"web"}},
+		Metadata: &computepb.Metadata{Items: []*computepb.Items{
+			{Key: proto.String("enable-oslogin"), Value: proto.String("true")},
+		}},
+		NetworkInterfaces: []*computepb.NetworkInterface{{
+			Network:       proto.String("global/networks/prod-vpc"),
+			AccessConfigs: []*computepb.AccessConfig{{NatIP: proto.String("203.0.113.7")}},
+		}},
+		Disks: []*computepb.AttachedDisk{{
+			Boot:             proto.Bool(true),
+			InitializeParams: &computepb.AttachedDiskInitializeParams{SourceImage: proto.String("global/images/debian-12")},
+		}},
+		GuestAccelerators: []*computepb.AcceleratorConfig{{AcceleratorCount: proto.Int32(1)}},
+	}
+
+	vm := newInstanceFields(instance, newFieldSet([]Field{FieldLabels}))
+	if vm.Name != "instance-1" || vm.Status != "RUNNING" || vm.MachineType != "e2-small" {
+		t.Errorf("the essential fields should always be populated, got %+v", vm)
+	}
+	if vm.Labels["env"] != "prod" || len(vm.Tags) != 1 {
+		t.Errorf("expected the labels and tags, got %v and %v", vm.Labels, vm.Tags)
+	}
+	if vm.Metadata != nil || vm.Network != "" || vm.ExternalIP != "" || vm.Image != "" || vm.GPUs != 0 {
+		t.Errorf("expected the other fields to be left empty, got %+v", vm)
+	}
+
+	if vm := newInstanceFields(instance, nil); vm.Metadata == nil || vm.Network != "prod-vpc" || vm.ExternalIP == "" || vm.Image == "" || vm.GPUs != 1 {
+		t.Errorf("expected every field to be populated, got %+v", vm)
+	}
+}
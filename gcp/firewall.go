@@ -0,0 +1,134 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+)
+
+// ErrFirewallsForbidden is returned when the caller may not list the
+// project's firewall rules.
+var ErrFirewallsForbidden = errors.New("not allowed to list firewall rules in this project")
+
+// FirewallRule is an enabled ingress rule that allows traffic.
+type FirewallRule struct {
+	Name    string
+	Network string
+	// TargetTags limits the rule to instances with one of these tags. An
+	// empty list applies the rule to every instance in the network.
+	TargetTags   []string
+	SourceRanges []string
+	Allowed      []FirewallAllow
+}
+
+// FirewallAllow is a protocol, and optionally ports, allowed by a rule.
+type FirewallAllow struct {
+	Protocol string
+	// Ports lists ports and port ranges, e.g. "22" or "8000-8080". An empty
+	// list allows every port.
+	Ports []string
+}
+
+// String formats the allowed traffic like gcloud does, e.g. "tcp:22,80".
+func (a FirewallAllow) String() string {
+	if len(a.Ports) == 0 {
+		return a.Protocol
+	}
+	return a.Protocol + ":" + strings.Join(a.Ports, ",")
+}
+
+// AppliesTo reports whether the rule targets vm. This is a simple tag match:
+// rules targeting service accounts are treated as not applying.
+func (r FirewallRule) AppliesTo(vm Instance) bool {
+	if r.Network != vm.Network {
+		return false
+	}
+	if len(r.TargetTags) == 0 {
+		return true
+	}
+	for _, tag := range vm.Tags {
+		if slices.Contains(r.TargetTags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenToInternet reports whether the rule allows traffic from any address.
+func (r FirewallRule) OpenToInternet() bool {
+	return slices.Contains(r.SourceRanges, "0.0.0.0/0") || slices.Contains(r.SourceRanges, "::/0")
+}
+
+// RulesFor returns the rules that apply to vm, in the given order.
+func RulesFor(vm Instance, rules []FirewallRule) []FirewallRule {
+	var applied []FirewallRule
+	for _, r := range rules {
+		if r.AppliesTo(vm) {
+			applied = append(applied, r)
+		}
+	}
+	return applied
+}
+
+// FetchFirewallRules lists the enabled ingress allow rules of a project.
+// Deny rules and rule priorities are not taken into account.
+func (c *realClient) FetchFirewallRules(ctx context.Context, projectID string) ([]FirewallRule, error) {
+	it := c.firewallsClient.List(ctx, &computepb.ListFirewallsRequest{Project: projectID})
+	var rules []FirewallRule
+	for {
+		fw, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			if isForbidden(err) {
+				return nil, ErrFirewallsForbidden
+			}
+			return nil, fmt.Errorf("failed to list firewall rules: %w", err)
+		}
+		if r, ok := newFirewallRule(fw); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// newFirewallRule converts an API firewall, reporting false for rules that
+// cannot open an instance to traffic.
+func newFirewallRule(fw *computepb.Firewall) (FirewallRule, bool) {
+	if fw.GetDisabled() || fw.GetDirection() != "INGRESS" || len(fw.GetAllowed()) == 0 {
+		return FirewallRule{}, false
+	}
+	if len(fw.GetTargetServiceAccounts()) > 0 {
+		// Tag matching cannot tell which instances these rules apply to.
+		return FirewallRule{}, false
+	}
+	r := FirewallRule{
+		Name:         fw.GetName(),
+		Network:      resourceName(fw.GetNetwork()),
+		TargetTags:   fw.GetTargetTags(),
+		SourceRanges: fw.GetSourceRanges(),
+	}
+	for _, a := range fw.GetAllowed() {
+		r.Allowed = append(r.Allowed, FirewallAllow{Protocol: a.GetIPProtocol(), Ports: a.GetPorts()})
+	}
+	return r, true
+}
+
+// isForbidden reports whether err is a permission error from the API.
+func isForbidden(err error) bool {
+	var gErr *googleapi.Error
+	return errors.As(err, &gErr) && gErr.Code == 403
+}
+
+// isNotFound reports whether err is an API error for a missing resource.
+func isNotFound(err error) bool {
+	var gErr *googleapi.Error
+	return errors.As(err, &gErr) && gErr.Code == 404
+}
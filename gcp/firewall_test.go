@@ -0,0 +1,99 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestFirewallRule_AppliesTo(t *testing.T) {
+	vm := Instance{Name: "web-1", Network: "prod", Tags: []string{"web", "ssh"}}
+	tests := []struct {
+		name string
+		rule FirewallRule
+		want bool
+	}{
+		{"all instances", FirewallRule{Network: "prod"}, true},
+		{"matching tag", FirewallRule{Network: "prod", TargetTags: []string{"db", "ssh"}}, true},
+		{"other tags", FirewallRule{Network: "prod", TargetTags: []string{"db"}}, false},
+		{"other network", FirewallRule{Network: "staging"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.AppliesTo(vm); got != tt.want {
+			t.Errorf("%s: AppliesTo() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFirewallAllow_String(t *testing.T) {
+	if got := (FirewallAllow{Protocol: "tcp", Ports: []string{"22", "8000-8080"}}).String(); got != "tcp:22,8000-8080" {
+		t.Errorf("unexpected string %q", got)
+	}
+	if got := (FirewallAllow{Protocol: "icmp"}).String(); got != "icmp" {
+		t.Errorf("unexpected string %q", got)
+	}
+}
+
+func TestFetchFirewallRules_WithMockServer(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{
+			"items": [
+				{"name": "allow-ssh", "network": "global/networks/prod", "direction": "INGRESS", "sourceRanges": ["0.0.0.0/0"], "targetTags": ["ssh"], "allowed": [{"IPProtocol": "tcp", "ports": ["22"]}]},
+				{"name": "disabled", "network": "global/networks/prod", "direction": "INGRESS", "disabled": true, "allowed": [{"IPProtocol": "tcp"}]},
+				{"name": "egress", "network": "global/networks/prod", "direction": "EGRESS", "allowed": [{"IPProtocol": "tcp"}]},
+				{"name": "deny-all", "network": "global/networks/prod", "direction": "INGRESS", "denied": [{"IPProtocol": "all"}]},
+				{"name": "by-account", "network": "global/networks/prod", "direction": "INGRESS", "targetServiceAccounts": ["sa@example.com"], "allowed": [{"IPProtocol": "tcp"}]}
+			]
+		}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	rules, err := client.FetchFirewallRules(ctx, "test-project")
+	if err != nil {
+		t.Fatalf("FetchFirewallRules() returned an unexpected error: %v", err)
+	}
+	want := []FirewallRule{{
+		Name:         "allow-ssh",
+		Network:      "prod",
+		TargetTags:   []string{"ssh"},
+		SourceRanges: []string{"0.0.0.0/0"},
+		Allowed:      []FirewallAllow{{Protocol: "tcp", Ports: []string{"22"}}},
+	}}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("expected %+v, got %+v", want, rules)
+	}
+	if !rules[0].OpenToInternet() {
+		t.Error("expected allow-ssh to be open to the internet")
+	}
+}
+
+func TestFetchFirewallRules_Forbidden(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintln(w, `{"error": {"code": 403, "message": "Required 'compute.firewalls.list' permission"}}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	if _, err := client.FetchFirewallRules(ctx, "test-project"); !errors.Is(err, ErrFirewallsForbidden) {
+		t.Fatalf("expected ErrFirewallsForbidden, got %v", err)
+	}
+}
@@ -2,31 +2,268 @@
 package gcp
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	compute "cloud.google.com/go/compute/apiv1"
 	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/googleapis/gax-go/v2/callctx"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
+	logging "google.golang.org/api/logging/v2"
 	"google.golang.org/api/option"
+	"google.golang.org/protobuf/proto"
 )
 
 // Instance holds the essential information for a GCP VM instance.
 type Instance struct {
-	Name string
-	Zone string
+	ID          string
+	ProjectID   string
+	Name        string
+	Zone        string
+	Status      string
+	MachineType string
+	// Hostname is the custom hostname of the instance, if one was set.
+	Hostname string
+	// Network and Subnetwork are the names of the VPC network and subnet of
+	// the first network interface, empty when the instance has none.
+	Network    string
+	Subnetwork string
+	// ExternalIP is the first external IPv4 address of the instance, empty
+	// when it is not reachable from the internet.
+	ExternalIP string
+	// InternalIP is the private address of the first network interface.
+	InternalIP string
+	// Image is the image the boot disk was created from, e.g.
+	// "debian-12-bookworm-v20240415" or "family/debian-12". It is empty when
+	// the API does not report it, as the list calls do not.
+	Image string
+	// BootLicense is the license of the boot disk, e.g. "debian-12-bookworm",
+	// which names the OS of the image it was created from. The list calls
+	// report it where they leave out Image.
+	BootLicense string
+	// Tags are the network tags used to target firewall rules.
+	Tags []string
+	// ResourcePolicies are the names of the resource policies attached to
+	// the instance, such as start and stop schedules.
+	ResourcePolicies []string
+	// Labels are the user-defined labels of the instance.
+	Labels map[string]string
+	// Metadata holds the custom metadata entries, such as startup-script.
+	Metadata map[string]string
+	// GPUs is the number of attached accelerators.
+	GPUs int
+	// Preemptible is set for legacy preemptible instances; Spot instances
+	// have ProvisioningModel "SPOT" instead.
+	Preemptible        bool
+	DeletionProtection bool
+	// ShieldedVM is set when any Shielded VM feature (Secure Boot, vTPM or
+	// integrity monitoring) is enabled. ConfidentialVM is set when the
+	// instance runs with Confidential Computing.
+	ShieldedVM     bool
+	ConfidentialVM bool
+
+	// Lifecycle timestamps in RFC3339 format, empty when unknown. Use
+	// ParseTimestamp to read them.
+	CreatedAt   string
+	LastStartAt string
+	LastStopAt  string
+
+	// Scheduling policy. The zero values are used when the instance has no
+	// scheduling config.
+	AutomaticRestart  bool
+	OnHostMaintenance string
+	ProvisioningModel string
+	// Maintenance is the upcoming or ongoing host maintenance, nil when none
+	// is announced.
+	Maintenance *Maintenance
+	// ReservationAffinity is which reservations the instance consumes, nil
+	// when the API does not report it.
+	ReservationAffinity *ReservationAffinity
+}
+
+// ReservationAffinity is which reservations an instance may consume.
+type ReservationAffinity struct {
+	// Type is ANY_RESERVATION, SPECIFIC_RESERVATION or NO_RESERVATION.
+	Type string
+	// Key and Values select the reservations of SPECIFIC_RESERVATION, e.g.
+	// the key "compute.googleapis.com/reservation-name" with the names of
+	// the reservations.
+	Key    string
+	Values []string
+}
+
+// Maintenance is a host maintenance event announced for an instance, during
+// which it may be live migrated or rebooted.
+//
+// This is best effort: the API only announces maintenance for some machine
+// families, usually no more than a few days ahead, and most events happen
+// without ever being announced.
+type Maintenance struct {
+	// Type is SCHEDULED, UNSCHEDULED or MULTIPLE.
+	Type string
+	// Status is PENDING before the window starts and ONGOING during it.
+	Status string
+	// The window in RFC3339 format, empty when unknown. Use ParseTimestamp
+	// to read them.
+	WindowStart string
+	WindowEnd   string
+	// CanReschedule is set when the maintenance can be triggered early with
+	// gcloud compute instances perform-maintenance.
+	CanReschedule bool
+}
+
+// ComputeDisabledError is returned when the Compute Engine API is not enabled
+// on a project. Err holds the raw API error.
+type ComputeDisabledError struct {
+	ProjectID string
+	Err       error
+}
+
+func (e *ComputeDisabledError) Error() string {
+	return fmt.Sprintf("the Compute Engine API is not enabled for project %s", e.ProjectID)
+}
+
+func (e *ComputeDisabledError) Unwrap() error { return e.Err }
+
+// EnableCommand returns the gcloud command that enables the API.
+func (e *ComputeDisabledError) EnableCommand() string {
+	return "gcloud services enable compute.googleapis.com --project " + e.ProjectID
+}
+
+// EnableURL returns the console page where the API can be enabled.
+func (e *ComputeDisabledError) EnableURL() string {
+	return "https://console.cloud.google.com/apis/library/compute.googleapis.com?project=" + e.ProjectID
+}
+
+// ZoneExhaustedError is returned when an instance cannot start because its
+// zone has run out of the resources it needs. Err holds the raw API error.
+type ZoneExhaustedError struct {
+	Zone     string
+	Instance string
+	Err      error
+}
+
+func (e *ZoneExhaustedError) Error() string {
+	return fmt.Sprintf("zone %s does not have enough resources to start %s", e.Zone, e.Instance)
+}
+
+func (e *ZoneExhaustedError) Unwrap() error { return e.Err }
+
+// ZoneError records a zone whose instances could not be listed.
+type ZoneError struct {
+	ProjectID string
+	Zone      string
+	Err       error
+}
+
+func (e ZoneError) Error() string { return fmt.Sprintf("%s: %v", e.Zone, e.Err) }
+
+func (e ZoneError) Unwrap() error { return e.Err }
+
+// FetchOptions controls how instances are listed.
+type FetchOptions struct {
+	// MaxResults stops the listing once this many instances have been
+	// collected. Zero means no limit.
+	MaxResults int
+	// HomeRegion, if set, orders the instances so that those in the zones
+	// nearest to this region come first.
+	HomeRegion string
+	// Zones, if set, lists only the instances in these zones, one zone at a
+	// time, which is faster than listing every zone of a large project.
+	Zones []string
+	// Progress, if set, is called with the number of instances loaded so
+	// far as results arrive. It must not block.
+	Progress func(loaded int)
+	// Concurrency limits how many projects FetchInstancesMulti lists at
+	// once. Zero means runtime.GOMAXPROCS(0).
+	Concurrency int
+	// Throttled, if set, is called with how long listing pauses whenever
+	// the API rate limits it. It must not block.
+	Throttled func(wait time.Duration)
+	// Dump, if set, writes the raw requests and responses of each listing
+	// to a file. A dump that cannot be written fails the listing.
+	Dump *Dumper
+	// Fields, if not nil, limits the optional instance fields populated to
+	// these groups; the others are left empty. Nil populates them all.
+	Fields []Field
+	// SkipZoneWarnings leaves out the zones the aggregated listing warns
+	// about from ZoneErrors, saving the time spent collecting them; only
+	// their number is kept, in SkippedZoneWarnings.
+	SkipZoneWarnings bool
+	// Strict fails the listing when any zone cannot be listed, rather than
+	// returning the instances of the others with ZoneErrors. It overrides
+	// SkipZoneWarnings.
+	Strict bool
+	// Statuses, if set, lists only the instances in these states, filtered
+	// by the API so that the others are not even sent. See ParseStatuses.
+	Statuses []string
+}
+
+// InstanceList is the result of listing the instances of a project.
+type InstanceList struct {
+	Instances []Instance
+	// Truncated is set when MaxResults cut the listing short.
+	Truncated bool
+	// ProjectErrors holds the projects that could not be listed when
+	// fetching several projects at once.
+	ProjectErrors []ProjectError
+	// ZoneErrors holds the zones that could not be listed; the instances of
+	// the other zones are still returned.
+	ZoneErrors []ZoneError
+	// SkippedZoneWarnings counts the zones that could not be listed but
+	// were left out of ZoneErrors under FetchOptions.SkipZoneWarnings.
+	SkippedZoneWarnings int
 }
 
 // Client is an interface for a GCP client, allowing for mock implementations.
 type Client interface {
-	FetchInstances(ctx context.Context, projectID string) ([]Instance, error)
+	FetchInstances(ctx context.Context, projectID string, opts FetchOptions) (InstanceList, error)
+	GetInstance(ctx context.Context, projectID, zone, name string) (Instance, error)
+	SetMachineType(ctx context.Context, projectID, zone, name, machineType string) error
+	SetDeletionProtection(ctx context.Context, projectID, zone, name string, enabled bool) error
+	StartInstance(ctx context.Context, projectID, zone, name string) error
+	SuspendInstance(ctx context.Context, projectID, zone, name string) error
+	ResumeInstance(ctx context.Context, projectID, zone, name string) error
+	FetchLogs(ctx context.Context, projectID, instanceID string, limit int) ([]LogEntry, error)
+	FetchFirewallRules(ctx context.Context, projectID string) ([]FirewallRule, error)
+	GetInstanceIAM(ctx context.Context, projectID, zone, name string) ([]IAMBinding, error)
+	TestInstancePermissions(ctx context.Context, projectID, zone, name string, permissions []string) ([]string, error)
+	ListZones(ctx context.Context, projectID string) ([]string, error)
+	GetMachineType(ctx context.Context, projectID, zone, machineType string) (MachineTypeSpec, error)
+	RegionQuotas(ctx context.Context, projectID, region string) ([]Quota, error)
 	Close() error
 }
 
 // realClient is the concrete implementation of the Client interface.
 type realClient struct {
-	computeClient *compute.InstancesClient
+	computeClient      *compute.InstancesClient
+	firewallsClient    *compute.FirewallsClient
+	zonesClient        *compute.ZonesClient
+	machineTypesClient *compute.MachineTypesClient
+	regionsClient      *compute.RegionsClient
+	loggingService     *logging.Service
+	throttle           *throttle
+}
+
+// EndpointEnv names the environment variable that points the client at a fake
+// Compute and Logging server for local development, e.g.
+// "http://localhost:8080".
+const EndpointEnv = "GCP_RIDER_ENDPOINT"
+
+// EndpointOptions returns the client options for talking to the fake server at
+// endpoint without credentials, or nil if endpoint is empty.
+func EndpointOptions(endpoint string) []option.ClientOption {
+	if endpoint == "" {
+		return nil
+	}
+	return []option.ClientOption{option.WithEndpoint(endpoint), option.WithoutAuthentication()}
 }
 
 // NewClient creates a new real GCP client that conforms to the Client interface.
@@ -35,35 +272,598 @@ func NewClient(ctx context.Context, opts ...option.ClientOption) (Client, error)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create instances client: %w", err)
 	}
-	return &realClient{computeClient: c}, nil
+	fc, err := compute.NewFirewallsRESTClient(ctx, opts...)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to create firewalls client: %w", err)
+	}
+	zc, err := compute.NewZonesRESTClient(ctx, opts...)
+	if err != nil {
+		c.Close()
+		fc.Close()
+		return nil, fmt.Errorf("failed to create zones client: %w", err)
+	}
+	mc, err := compute.NewMachineTypesRESTClient(ctx, opts...)
+	if err != nil {
+		c.Close()
+		fc.Close()
+		zc.Close()
+		return nil, fmt.Errorf("failed to create machine types client: %w", err)
+	}
+	rc, err := compute.NewRegionsRESTClient(ctx, opts...)
+	if err != nil {
+		c.Close()
+		fc.Close()
+		zc.Close()
+		mc.Close()
+		return nil, fmt.Errorf("failed to create regions client: %w", err)
+	}
+	ls, err := logging.NewService(ctx, opts...)
+	if err != nil {
+		c.Close()
+		fc.Close()
+		zc.Close()
+		mc.Close()
+		rc.Close()
+		return nil, fmt.Errorf("failed to create logging client: %w", err)
+	}
+	return &realClient{
+		computeClient:      c,
+		firewallsClient:    fc,
+		zonesClient:        zc,
+		machineTypesClient: mc,
+		regionsClient:      rc,
+		loggingService:     ls,
+		throttle:           &throttle{base: time.Second},
+	}, nil
 }
 
-// FetchInstances retrieves a list of VM instances from a given project.
-func (c *realClient) FetchInstances(ctx context.Context, projectID string) ([]Instance, error) {
-	req := &computepb.AggregatedListInstancesRequest{
-		Project: projectID,
+// FetchInstances retrieves a list of VM instances from a given project. All
+// zones are listed at once unless opts.Zones limits the listing to some. A
+// listing that is rate limited is started over after backing off.
+func (c *realClient) FetchInstances(ctx context.Context, projectID string, opts FetchOptions) (InstanceList, error) {
+	col := &instanceCollector{projectID: projectID, opts: opts, fields: newFieldSet(opts.Fields)}
+	if len(opts.Zones) > 0 {
+		list, err := c.fetchZones(ctx, col)
+		if err != nil {
+			return InstanceList{}, err
+		}
+		return list, col.dump()
+	}
+	err := c.throttle.do(ctx, opts.Throttled, func() error {
+		col.list = InstanceList{}
+		col.requests, col.pages = nil, nil
+		return c.fetchAggregated(ctx, col)
+	})
+	var ze ZoneError
+	if errors.As(err, &ze) {
+		return InstanceList{}, err
+	}
+	if err != nil {
+		return InstanceList{}, listError(projectID, err)
+	}
+	if err := col.dump(); err != nil {
+		return InstanceList{}, err
+	}
+	return orderInstances(col.list, opts), nil
+}
+
+// fetchAggregated lists the instances of every zone at once.
+func (c *realClient) fetchAggregated(ctx context.Context, col *instanceCollector) error {
+	projectID := col.projectID
+	req := &computepb.AggregatedListInstancesRequest{Project: projectID}
+	if !col.opts.Strict {
+		req.ReturnPartialSuccess = proto.Bool(true)
+	}
+	if f := statusFilter(col.opts.Statuses); f != "" {
+		req.Filter = proto.String(f)
+	}
+	col.recordRequest(req)
+	if col.fields != nil {
+		ctx = callctx.SetHeaders(ctx, fieldMaskHeader, "nextPageToken,warning,items/*/warning,items/*/instances("+instanceMask(col.fields)+")")
 	}
 	it := c.computeClient.AggregatedList(ctx, req)
-	var vms []Instance
 	for {
 		pair, err := it.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to iterate over instances: %w", err)
+			return err
+		}
+		if w := pair.Value.GetWarning(); w != nil && w.GetCode() != "NO_RESULTS_ON_PAGE" {
+			ze := ZoneError{ProjectID: projectID, Zone: zoneName(pair.Key), Err: errors.New(w.GetMessage())}
+			switch {
+			case col.opts.Strict:
+				return fmt.Errorf("failed to list instances in zone %w", ze)
+			case col.opts.SkipZoneWarnings:
+				col.list.SkippedZoneWarnings++
+			default:
+				col.list.ZoneErrors = append(col.list.ZoneErrors, ze)
+			}
 		}
+		col.record(pair.Key, pair.Value)
 		if pair.Value != nil && len(pair.Value.Instances) > 0 {
-			for _, instance := range pair.Value.Instances {
-				zone := path.Base(*instance.Zone)
-				vms = append(vms, Instance{Name: *instance.Name, Zone: zone})
+			if !col.add(pair.Value.Instances) {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// fetchZones lists the instances of the zones in col.opts.Zones one by one.
+// Zones that fail are reported in ZoneErrors; an error is only returned if
+// every zone failed, the API is disabled or the listing is strict.
+func (c *realClient) fetchZones(ctx context.Context, col *instanceCollector) (InstanceList, error) {
+	var errs []error
+	for _, zone := range col.opts.Zones {
+		var more bool
+		err := c.throttle.do(ctx, col.opts.Throttled, func() error {
+			var err error
+			more, err = c.fetchZone(ctx, col, zone)
+			return err
+		})
+		if err != nil {
+			if isServiceDisabled(err) {
+				return InstanceList{}, listError(col.projectID, err)
+			}
+			ze := ZoneError{ProjectID: col.projectID, Zone: zone, Err: err}
+			if col.opts.Strict {
+				return InstanceList{}, fmt.Errorf("failed to list instances in zone %w", ze)
+			}
+			col.list.ZoneErrors = append(col.list.ZoneErrors, ze)
+			errs = append(errs, ze)
+			continue
+		}
+		if !more {
+			break
+		}
+	}
+	if len(errs) == len(col.opts.Zones) {
+		return InstanceList{}, fmt.Errorf("failed to list instances in every zone: %w", errors.Join(errs...))
+	}
+	return orderInstances(col.list, col.opts), nil
+}
+
+// fetchZone lists the instances of one zone. It reports false once
+// MaxResults has been reached and the listing should stop. On error, the
+// instances of the zone added so far are dropped so it can be listed again.
+func (c *realClient) fetchZone(ctx context.Context, col *instanceCollector, zone string) (bool, error) {
+	before := len(col.list.Instances)
+	req := &computepb.ListInstancesRequest{Project: col.projectID, Zone: zone}
+	if f := statusFilter(col.opts.Statuses); f != "" {
+		req.Filter = proto.String(f)
+	}
+	col.recordRequest(req)
+	if col.fields != nil {
+		ctx = callctx.SetHeaders(ctx, fieldMaskHeader, "nextPageToken,warning,items("+instanceMask(col.fields)+")")
+	}
+	it := c.computeClient.List(ctx, req)
+	for {
+		instance, err := it.Next()
+		if err == iterator.Done {
+			return true, nil
+		}
+		if err != nil {
+			col.list.Instances = col.list.Instances[:before]
+			delete(col.pages, "zones/"+zone)
+			return false, err
+		}
+		col.record("zones/"+zone, &computepb.InstancesScopedList{Instances: []*computepb.Instance{instance}})
+		if !col.add([]*computepb.Instance{instance}) {
+			return false, nil
+		}
+	}
+}
+
+// listError wraps an error from listing the instances of a project.
+func listError(projectID string, err error) error {
+	if isServiceDisabled(err) {
+		return &ComputeDisabledError{ProjectID: projectID, Err: err}
+	}
+	return fmt.Errorf("failed to iterate over instances: %w", err)
+}
+
+// instanceCollector accumulates listed instances, enforcing opts.MaxResults
+// and reporting opts.Progress.
+type instanceCollector struct {
+	projectID string
+	opts      FetchOptions
+	list      InstanceList
+	// strings deduplicates the strings of the collected instances.
+	strings stringPool
+	// fields are the optional fields populated, from opts.Fields.
+	fields fieldSet
+	// requests and pages keep the raw listing for opts.Dump, with pages
+	// keyed by scope.
+	requests []proto.Message
+	pages    map[string]*computepb.InstancesScopedList
+}
+
+// recordRequest keeps req for the dump, if one was asked for.
+func (c *instanceCollector) recordRequest(req proto.Message) {
+	if c.opts.Dump != nil {
+		c.requests = append(c.requests, proto.Clone(req))
+	}
+}
+
+// record keeps a copy of a page of results for the dump, if one was asked
+// for, before add clears it. Pages of the same scope are merged.
+func (c *instanceCollector) record(scope string, page *computepb.InstancesScopedList) {
+	if c.opts.Dump == nil || page == nil {
+		return
+	}
+	page = proto.Clone(page).(*computepb.InstancesScopedList)
+	if c.pages == nil {
+		c.pages = make(map[string]*computepb.InstancesScopedList)
+	}
+	if prev, ok := c.pages[scope]; ok {
+		prev.Instances = append(prev.Instances, page.Instances...)
+		return
+	}
+	c.pages[scope] = page
+}
+
+// dump writes the recorded listing, if a dump was asked for.
+func (c *instanceCollector) dump() error {
+	if c.opts.Dump == nil {
+		return nil
+	}
+	return c.opts.Dump.write(c.projectID, c.requests, c.pages, time.Now())
+}
+
+// add appends instances to the list. It reports false once MaxResults has
+// been reached and the listing should stop. Each entry of instances is
+// cleared once converted, so that the API copy can be freed while the rest
+// of the page is still being read.
+func (c *instanceCollector) add(instances []*computepb.Instance) bool {
+	if c.strings == nil {
+		c.strings = make(stringPool)
+	}
+	for i, instance := range instances {
+		if c.opts.MaxResults > 0 && len(c.list.Instances) == c.opts.MaxResults {
+			c.list.Truncated = true
+			return false
+		}
+		vm := newInstanceFields(instance, c.fields)
+		vm.ProjectID = c.projectID
+		c.strings.internInstance(&vm)
+		c.list.Instances = append(c.list.Instances, vm)
+		instances[i] = nil
+	}
+	if c.opts.Progress != nil {
+		c.opts.Progress(len(c.list.Instances))
+	}
+	return true
+}
+
+// ListZones returns the names of the zones available to a project.
+func (c *realClient) ListZones(ctx context.Context, projectID string) ([]string, error) {
+	it := c.zonesClient.List(ctx, &computepb.ListZonesRequest{Project: projectID})
+	var zones []string
+	for {
+		zone, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			if isServiceDisabled(err) {
+				return nil, &ComputeDisabledError{ProjectID: projectID, Err: err}
+			}
+			return nil, fmt.Errorf("failed to list zones: %w", err)
+		}
+		zones = append(zones, zone.GetName())
+	}
+	return zones, nil
+}
+
+// orderInstances applies the ordering requested in opts.
+func orderInstances(list InstanceList, opts FetchOptions) InstanceList {
+	if opts.HomeRegion != "" {
+		SortByProximity(list.Instances, opts.HomeRegion)
+	}
+	return list
+}
+
+// newInstance converts an API instance into an Instance.
+func newInstance(instance *computepb.Instance) Instance {
+	return newInstanceFields(instance, nil)
+}
+
+// newInstanceFields converts an API instance into an Instance, populating
+// only the optional fields in fields.
+func newInstanceFields(instance *computepb.Instance, fields fieldSet) Instance {
+	var id string
+	if instance.Id != nil {
+		id = strconv.FormatUint(*instance.Id, 10)
+	}
+	vm := Instance{
+		ID:          id,
+		Name:        instance.GetName(),
+		Zone:        zoneName(instance.GetZone()),
+		Status:      instance.GetStatus(),
+		MachineType: resourceName(instance.GetMachineType()),
+		Hostname:    instance.GetHostname(),
+		CreatedAt:   instance.GetCreationTimestamp(),
+		LastStartAt: instance.GetLastStartTimestamp(),
+		LastStopAt:  instance.GetLastStopTimestamp(),
+
+		DeletionProtection: instance.GetDeletionProtection(),
+	}
+	if fields.has(FieldLabels) {
+		vm.Tags = instance.GetTags().GetItems()
+		vm.Labels = instance.GetLabels()
+	}
+	if items := instance.GetMetadata().GetItems(); len(items) > 0 && fields.has(FieldMetadata) {
+		vm.Metadata = make(map[string]string, len(items))
+		for _, item := range items {
+			vm.Metadata[item.GetKey()] = item.GetValue()
+		}
+	}
+	if fields.has(FieldImage) {
+		for _, disk := range instance.GetDisks() {
+			if disk.GetBoot() {
+				vm.Image = imageName(disk.GetInitializeParams().GetSourceImage())
+				if licenses := disk.GetLicenses(); len(licenses) > 0 {
+					vm.BootLicense = resourceName(licenses[0])
+				}
+				break
+			}
+		}
+	}
+	if fields.has(FieldNetwork) {
+		if nics := instance.GetNetworkInterfaces(); len(nics) > 0 && nics[0] != nil {
+			vm.Network = resourceName(nics[0].GetNetwork())
+			vm.Subnetwork = resourceName(nics[0].GetSubnetwork())
+			vm.InternalIP = nics[0].GetNetworkIP()
+		}
+		vm.ExternalIP = externalIP(instance.GetNetworkInterfaces())
+	}
+	if !fields.has(FieldDetails) {
+		return vm
+	}
+	for _, policy := range instance.GetResourcePolicies() {
+		if name := resourceName(policy); name != "" {
+			vm.ResourcePolicies = append(vm.ResourcePolicies, name)
+		}
+	}
+	for _, acc := range instance.GetGuestAccelerators() {
+		vm.GPUs += int(acc.GetAcceleratorCount())
+	}
+	if s := instance.GetScheduling(); s != nil {
+		vm.AutomaticRestart = s.GetAutomaticRestart()
+		vm.OnHostMaintenance = s.GetOnHostMaintenance()
+		vm.ProvisioningModel = s.GetProvisioningModel()
+		vm.Preemptible = s.GetPreemptible()
+	}
+	if um := instance.GetResourceStatus().GetUpcomingMaintenance(); um != nil {
+		vm.Maintenance = &Maintenance{
+			Type:          um.GetType(),
+			Status:        um.GetMaintenanceStatus(),
+			WindowStart:   um.GetWindowStartTime(),
+			WindowEnd:     um.GetWindowEndTime(),
+			CanReschedule: um.GetCanReschedule(),
+		}
+	}
+	if ra := instance.GetReservationAffinity(); ra != nil {
+		vm.ReservationAffinity = &ReservationAffinity{
+			Type:   ra.GetConsumeReservationType(),
+			Key:    ra.GetKey(),
+			Values: ra.GetValues(),
+		}
+	}
+	if s := instance.GetShieldedInstanceConfig(); s != nil {
+		vm.ShieldedVM = s.GetEnableSecureBoot() || s.GetEnableVtpm() || s.GetEnableIntegrityMonitoring()
+	}
+	if c := instance.GetConfidentialInstanceConfig(); c != nil {
+		vm.ConfidentialVM = c.GetEnableConfidentialCompute() ||
+			(c.GetConfidentialInstanceType() != "" && c.GetConfidentialInstanceType() != "CONFIDENTIAL_INSTANCE_TYPE_UNSPECIFIED")
+	}
+	return vm
+}
+
+// externalIP returns the first external address assigned to any of nics.
+func externalIP(nics []*computepb.NetworkInterface) string {
+	for _, nic := range nics {
+		for _, ac := range nic.GetAccessConfigs() {
+			if ip := ac.GetNatIP(); ip != "" {
+				return ip
 			}
 		}
 	}
-	return vms, nil
+	return ""
+}
+
+// ParseTimestamp parses an RFC3339 timestamp as returned by the Compute API.
+func ParseTimestamp(ts string) (time.Time, error) {
+	return time.Parse(time.RFC3339, ts)
+}
+
+// CompareCreated orders instances from the oldest to the newest by creation
+// time. Instances without a creation time come last.
+func CompareCreated(a, b Instance) int {
+	ta, errA := ParseTimestamp(a.CreatedAt)
+	tb, errB := ParseTimestamp(b.CreatedAt)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return 1
+	case errB != nil:
+		return -1
+	}
+	return ta.Compare(tb)
+}
+
+// CompareNewest orders instances from the newest to the oldest by creation
+// time. Instances whose creation time cannot be parsed come last, and
+// instances created at the same time are ordered by name so the order is
+// deterministic.
+func CompareNewest(a, b Instance) int {
+	ta, errA := ParseTimestamp(a.CreatedAt)
+	tb, errB := ParseTimestamp(b.CreatedAt)
+	switch {
+	case errA != nil && errB != nil:
+	case errA != nil:
+		return 1
+	case errB != nil:
+		return -1
+	default:
+		if c := tb.Compare(ta); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(a.Name, b.Name)
+}
+
+// resourceName returns the last path segment of a resource URL, or an empty
+// string if the URL is empty.
+func resourceName(url string) string {
+	if url == "" {
+		return ""
+	}
+	return path.Base(url)
+}
+
+// zoneName returns the zone of a zone URL or scope key, e.g. "us-east1-b"
+// for ".../zones/us-east1-b" or "zones/us-east1-b". Regional URLs and
+// scopes, e.g. "regions/us-east1", give the region as the closest location
+// known; anything else gives its last path segment.
+func zoneName(url string) string {
+	segments := strings.Split(strings.Trim(url, "/"), "/")
+	for i := len(segments) - 2; i >= 0; i-- {
+		if (segments[i] == "zones" || segments[i] == "regions") && segments[i+1] != "" {
+			return segments[i+1]
+		}
+	}
+	return resourceName(url)
+}
+
+// imageName shortens an image URL to the part after "images/", keeping the
+// "family/" prefix of image families so they can be told apart from images.
+func imageName(url string) string {
+	if _, name, ok := strings.Cut(url, "images/"); ok {
+		return name
+	}
+	return resourceName(url)
+}
+
+// SetMachineType changes the machine type of a stopped instance and waits for
+// the operation to complete. The API rejects the call unless the instance is
+// TERMINATED.
+func (c *realClient) SetMachineType(ctx context.Context, projectID, zone, name, machineType string) error {
+	req := &computepb.SetMachineTypeInstanceRequest{
+		Project:  projectID,
+		Zone:     zone,
+		Instance: name,
+		InstancesSetMachineTypeRequestResource: &computepb.InstancesSetMachineTypeRequest{
+			MachineType: proto.String(fmt.Sprintf("zones/%s/machineTypes/%s", zone, machineType)),
+		},
+	}
+	op, err := c.computeClient.SetMachineType(ctx, req)
+	return waitForOperation(ctx, op, err, "change machine type of")
+}
+
+// ErrInstanceNotFound is returned when an instance no longer exists.
+var ErrInstanceNotFound = errors.New("instance not found")
+
+// GetInstance fetches a single instance, e.g. to refresh it without listing
+// the whole project. It returns ErrInstanceNotFound if the instance has been
+// deleted.
+func (c *realClient) GetInstance(ctx context.Context, projectID, zone, name string) (Instance, error) {
+	instance, err := c.computeClient.Get(ctx, &computepb.GetInstanceRequest{
+		Project:  projectID,
+		Zone:     zone,
+		Instance: name,
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return Instance{}, fmt.Errorf("%w: %s in %s/%s", ErrInstanceNotFound, name, projectID, zone)
+		}
+		return Instance{}, fmt.Errorf("failed to get instance: %w", err)
+	}
+	vm := newInstance(instance)
+	vm.ProjectID = projectID
+	return vm, nil
+}
+
+// SetDeletionProtection turns deletion protection of an instance on or off
+// and waits for the operation to complete.
+func (c *realClient) SetDeletionProtection(ctx context.Context, projectID, zone, name string, enabled bool) error {
+	op, err := c.computeClient.SetDeletionProtection(ctx, &computepb.SetDeletionProtectionInstanceRequest{
+		Project:            projectID,
+		Zone:               zone,
+		Resource:           name,
+		DeletionProtection: proto.Bool(enabled),
+	})
+	return waitForOperation(ctx, op, err, "change deletion protection of")
+}
+
+// StartInstance starts a stopped instance and waits for the operation to complete.
+func (c *realClient) StartInstance(ctx context.Context, projectID, zone, name string) error {
+	op, err := c.computeClient.Start(ctx, &computepb.StartInstanceRequest{
+		Project:  projectID,
+		Zone:     zone,
+		Instance: name,
+	})
+	err = waitForOperation(ctx, op, err, "start")
+	if isZoneExhausted(err) {
+		return &ZoneExhaustedError{Zone: zone, Instance: name, Err: err}
+	}
+	return err
+}
+
+// SuspendInstance suspends a running instance, preserving its memory, and
+// waits for the operation to complete.
+func (c *realClient) SuspendInstance(ctx context.Context, projectID, zone, name string) error {
+	op, err := c.computeClient.Suspend(ctx, &computepb.SuspendInstanceRequest{
+		Project:  projectID,
+		Zone:     zone,
+		Instance: name,
+	})
+	return waitForOperation(ctx, op, err, "suspend")
+}
+
+// ResumeInstance resumes a suspended instance and waits for the operation to complete.
+func (c *realClient) ResumeInstance(ctx context.Context, projectID, zone, name string) error {
+	op, err := c.computeClient.Resume(ctx, &computepb.ResumeInstanceRequest{
+		Project:  projectID,
+		Zone:     zone,
+		Instance: name,
+	})
+	return waitForOperation(ctx, op, err, "resume")
+}
+
+// waitForOperation waits for the operation started by an instance action,
+// wrapping errors from starting or running it with the action's name.
+func waitForOperation(ctx context.Context, op *compute.Operation, err error, action string) error {
+	if err != nil {
+		return fmt.Errorf("failed to %s instance: %w", action, err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("failed to %s instance: %w", action, err)
+	}
+	return nil
+}
+
+// isZoneExhausted reports whether err says the zone is out of the resources
+// an instance needs, either when the request is made or once the operation
+// finishes.
+func isZoneExhausted(err error) bool {
+	var gErr *googleapi.Error
+	if !errors.As(err, &gErr) {
+		return false
+	}
+	for _, item := range gErr.Errors {
+		if strings.HasPrefix(item.Reason, "ZONE_RESOURCE_POOL_EXHAUSTED") {
+			return true
+		}
+	}
+	return strings.Contains(gErr.Message, "ZONE_RESOURCE_POOL_EXHAUSTED") || strings.Contains(gErr.Body, "ZONE_RESOURCE_POOL_EXHAUSTED")
 }
 
 // Close closes the underlying client connection.
 func (c *realClient) Close() error {
-	return c.computeClient.Close()
-}
\ No newline at end of file
+	return errors.Join(c.computeClient.Close(), c.firewallsClient.Close(), c.zonesClient.Close(), c.machineTypesClient.Close(), c.regionsClient.Close())
+}
@@ -2,13 +2,21 @@ package gcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"path"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
+	"cloud.google.com/go/compute/apiv1/computepb"
 	"google.golang.org/api/option"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestFetchInstances_Success_WithMockServer(t *testing.T) {
@@ -47,14 +55,15 @@ func TestFetchInstances_Success_WithMockServer(t *testing.T) {
 		t.Fatalf("Failed to create client for test: %v", err)
 	}
 
-	instances, err := client.FetchInstances(ctx, "test-project")
+	list, err := client.FetchInstances(ctx, "test-project", FetchOptions{})
 	if err != nil {
 		t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
 	}
+	instances := list.Instances
 
 	expected := []Instance{
-		{Name: "instance-1", Zone: "us-central1-a"},
-		{Name: "instance-2", Zone: "europe-west1-b"},
+		{ProjectID: "test-project", Name: "instance-1", Zone: "us-central1-a"},
+		{ProjectID: "test-project", Name: "instance-2", Zone: "europe-west1-b"},
 	}
 
 	// The order of items from a map is not guaranteed, so we need to sort for a stable test.
@@ -90,8 +99,946 @@ func TestFetchInstances_Error_WithMockServer(t *testing.T) {
 		t.Fatalf("Failed to create client for test: %v", err)
 	}
 
-	_, err = client.FetchInstances(ctx, "test-project")
+	_, err = client.FetchInstances(ctx, "test-project", FetchOptions{})
 	if err == nil {
 		t.Fatal("FetchInstances() did not return an error when one was expected")
 	}
 }
+
+func TestSetMachineType_WithMockServer(t *testing.T) {
+	var gotPath, gotBody string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			gotPath, gotBody = r.URL.Path, string(body)
+		}
+		// Both the initial call and the operation poll get a finished operation.
+		fmt.Fprintln(w, `{"name": "op-1", "status": "DONE"}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	if err := client.SetMachineType(ctx, "test-project", "us-central1-a", "instance-1", "e2-small"); err != nil {
+		t.Fatalf("SetMachineType() returned an unexpected error: %v", err)
+	}
+
+	wantPath := "/compute/v1/projects/test-project/zones/us-central1-a/instances/instance-1/setMachineType"
+	if gotPath != wantPath {
+		t.Errorf("expected path %q, got %q", wantPath, gotPath)
+	}
+	if !strings.Contains(gotBody, "zones/us-central1-a/machineTypes/e2-small") {
+		t.Errorf("expected body to reference the new machine type, got %s", gotBody)
+	}
+}
+
+func TestSetMachineType_Error(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintln(w, `{"error": {"code": 400, "message": "Instance is running"}}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	err = client.SetMachineType(ctx, "test-project", "us-central1-a", "instance-1", "e2-small")
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to change machine type of instance: ") {
+		t.Errorf("expected the error worded like the other instance actions, got %v", err)
+	}
+}
+
+func TestSetDeletionProtection_WithMockServer(t *testing.T) {
+	var gotPath, gotQuery string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			gotPath, gotQuery = r.URL.Path, r.URL.Query().Get("deletionProtection")
+		}
+		fmt.Fprintln(w, `{"name": "op-1", "status": "DONE"}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	for _, enabled := range []bool{true, false} {
+		if err := client.SetDeletionProtection(ctx, "test-project", "us-central1-a", "instance-1", enabled); err != nil {
+			t.Fatalf("SetDeletionProtection(%t) returned an unexpected error: %v", enabled, err)
+		}
+		if want := "/compute/v1/projects/test-project/zones/us-central1-a/instances/instance-1/setDeletionProtection"; gotPath != want {
+			t.Errorf("expected path %q, got %q", want, gotPath)
+		}
+		if want := strconv.FormatBool(enabled); gotQuery != want {
+			t.Errorf("expected deletionProtection=%s, got %q", want, gotQuery)
+		}
+	}
+}
+
+func TestFetchLogs_WithMockServer(t *testing.T) {
+	var gotBody string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		fmt.Fprintln(w, `{
+			"entries": [
+				{"timestamp": "2025-01-01T00:00:02Z", "severity": "ERROR", "textPayload": "disk full"},
+				{"timestamp": "2025-01-01T00:00:01Z", "severity": "INFO", "jsonPayload": {"message": "booted"}}
+			]
+		}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	entries, err := client.FetchLogs(ctx, "test-project", "12345", 10)
+	if err != nil {
+		t.Fatalf("FetchLogs() returned an unexpected error: %v", err)
+	}
+
+	if !strings.Contains(gotBody, `resource.labels.instance_id=\"12345\"`) {
+		t.Errorf("expected the filter to select the instance, got %s", gotBody)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Message != "disk full" || entries[0].Severity != "ERROR" {
+		t.Errorf("unexpected first entry: %+v", entries[0])
+	}
+	if !strings.Contains(entries[1].Message, "booted") {
+		t.Errorf("expected the JSON payload to be used as the message, got %q", entries[1].Message)
+	}
+}
+
+func TestFetchLogs_LoggingDisabled(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintln(w, `{"error": {"code": 403, "message": "Cloud Logging API has not been used in project 1 before or it is disabled.", "errors": [{"reason": "accessNotConfigured"}]}}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	_, err = client.FetchLogs(ctx, "test-project", "12345", 10)
+	if !errors.Is(err, ErrLoggingDisabled) {
+		t.Fatalf("expected ErrLoggingDisabled, got %v", err)
+	}
+}
+
+func TestFetchInstances_ComputeDisabled(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintln(w, `{"error": {"code": 403, "message": "Compute Engine API has not been used in project 1 before or it is disabled.", "errors": [{"reason": "accessNotConfigured"}], "details": [{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "SERVICE_DISABLED"}]}}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	_, err = client.FetchInstances(ctx, "test-project", FetchOptions{})
+	var disabled *ComputeDisabledError
+	if !errors.As(err, &disabled) {
+		t.Fatalf("expected a ComputeDisabledError, got %v", err)
+	}
+	if disabled.ProjectID != "test-project" {
+		t.Errorf("expected project test-project, got %q", disabled.ProjectID)
+	}
+	if !strings.Contains(disabled.Err.Error(), "has not been used in project 1") {
+		t.Errorf("expected the raw error to be kept, got %v", disabled.Err)
+	}
+	if want := "gcloud services enable compute.googleapis.com --project test-project"; disabled.EnableCommand() != want {
+		t.Errorf("expected %q, got %q", want, disabled.EnableCommand())
+	}
+}
+
+func TestNewInstance_Scheduling(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name: proto.String("instance-1"),
+		Zone: proto.String("https://www.googleapis.com/compute/v1/projects/proj/zones/us-central1-a"),
+		Scheduling: &computepb.Scheduling{
+			AutomaticRestart:  proto.Bool(true),
+			OnHostMaintenance: proto.String("MIGRATE"),
+			ProvisioningModel: proto.String("SPOT"),
+		},
+	})
+	if !vm.AutomaticRestart || vm.OnHostMaintenance != "MIGRATE" || vm.ProvisioningModel != "SPOT" {
+		t.Errorf("unexpected scheduling fields: %+v", vm)
+	}
+
+	vm = newInstance(&computepb.Instance{Name: proto.String("instance-2")})
+	if vm.AutomaticRestart || vm.OnHostMaintenance != "" || vm.ProvisioningModel != "" {
+		t.Errorf("expected zero scheduling fields without a scheduling config, got %+v", vm)
+	}
+}
+
+func TestNewInstance_Maintenance(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name: proto.String("instance-1"),
+		ResourceStatus: &computepb.ResourceStatus{
+			UpcomingMaintenance: &computepb.UpcomingMaintenance{
+				Type:              proto.String("SCHEDULED"),
+				MaintenanceStatus: proto.String("PENDING"),
+				WindowStartTime:   proto.String("2024-05-01T10:00:00Z"),
+				WindowEndTime:     proto.String("2024-05-01T14:00:00Z"),
+				CanReschedule:     proto.Bool(true),
+			},
+		},
+	})
+	want := Maintenance{Type: "SCHEDULED", Status: "PENDING", WindowStart: "2024-05-01T10:00:00Z", WindowEnd: "2024-05-01T14:00:00Z", CanReschedule: true}
+	if vm.Maintenance == nil || *vm.Maintenance != want {
+		t.Errorf("expected maintenance %+v, got %+v", want, vm.Maintenance)
+	}
+
+	vm = newInstance(&computepb.Instance{Name: proto.String("instance-2"), ResourceStatus: &computepb.ResourceStatus{}})
+	if vm.Maintenance != nil {
+		t.Errorf("expected no maintenance when none is announced, got %+v", vm.Maintenance)
+	}
+}
+
+func TestNewInstance_ReservationAffinity(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name: proto.String("instance-1"),
+		ReservationAffinity: &computepb.ReservationAffinity{
+			ConsumeReservationType: proto.String("SPECIFIC_RESERVATION"),
+			Key:                    proto.String("compute.googleapis.com/reservation-name"),
+			Values:                 []string{"res-1"},
+		},
+	})
+	want := ReservationAffinity{Type: "SPECIFIC_RESERVATION", Key: "compute.googleapis.com/reservation-name", Values: []string{"res-1"}}
+	if !reflect.DeepEqual(vm.ReservationAffinity, &want) {
+		t.Errorf("expected reservation affinity %+v, got %+v", want, vm.ReservationAffinity)
+	}
+
+	vm = newInstance(&computepb.Instance{Name: proto.String("instance-2")})
+	if vm.ReservationAffinity != nil {
+		t.Errorf("expected no reservation affinity when none is reported, got %+v", vm.ReservationAffinity)
+	}
+}
+
+func TestNewInstance_Flags(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name:               proto.String("instance-1"),
+		DeletionProtection: proto.Bool(true),
+		GuestAccelerators: []*computepb.AcceleratorConfig{
+			{AcceleratorCount: proto.Int32(2)},
+			{AcceleratorCount: proto.Int32(1)},
+		},
+		Scheduling: &computepb.Scheduling{Preemptible: proto.Bool(true)},
+	})
+	if vm.GPUs != 3 || !vm.Preemptible || !vm.DeletionProtection {
+		t.Errorf("unexpected flags: %+v", vm)
+	}
+}
+
+func TestNewInstance_Security(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name:                       proto.String("instance-1"),
+		ShieldedInstanceConfig:     &computepb.ShieldedInstanceConfig{EnableVtpm: proto.Bool(true)},
+		ConfidentialInstanceConfig: &computepb.ConfidentialInstanceConfig{ConfidentialInstanceType: proto.String("SEV_SNP")},
+	})
+	if !vm.ShieldedVM || !vm.ConfidentialVM {
+		t.Errorf("expected a shielded confidential VM, got %+v", vm)
+	}
+
+	vm = newInstance(&computepb.Instance{
+		Name:                   proto.String("instance-2"),
+		ShieldedInstanceConfig: &computepb.ShieldedInstanceConfig{EnableSecureBoot: proto.Bool(false)},
+	})
+	if vm.ShieldedVM || vm.ConfidentialVM {
+		t.Errorf("expected neither without the features enabled, got %+v", vm)
+	}
+}
+
+func TestNewInstance_Hostname(t *testing.T) {
+	vm := newInstance(&computepb.Instance{Name: proto.String("instance-1"), Hostname: proto.String("api.internal.example.com")})
+	if vm.Hostname != "api.internal.example.com" {
+		t.Errorf("expected the custom hostname, got %q", vm.Hostname)
+	}
+
+	vm = newInstance(&computepb.Instance{Name: proto.String("instance-2")})
+	if vm.Hostname != "" {
+		t.Errorf("expected no hostname, got %q", vm.Hostname)
+	}
+}
+
+func TestNewInstance_Image(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name: proto.String("instance-1"),
+		Disks: []*computepb.AttachedDisk{
+			{Boot: proto.Bool(false), InitializeParams: &computepb.AttachedDiskInitializeParams{SourceImage: proto.String("projects/other/global/images/data")}},
+			{Boot: proto.Bool(true), InitializeParams: &computepb.AttachedDiskInitializeParams{SourceImage: proto.String("https://www.googleapis.com/compute/v1/projects/debian-cloud/global/images/family/debian-12")}},
+		},
+	})
+	if vm.Image != "family/debian-12" {
+		t.Errorf("expected the boot disk image family, got %q", vm.Image)
+	}
+
+	// The list calls leave out the source image but keep the licenses.
+	vm = newInstance(&computepb.Instance{
+		Name: proto.String("instance-3"),
+		Disks: []*computepb.AttachedDisk{
+			{Boot: proto.Bool(true), Licenses: []string{"https://www.googleapis.com/compute/v1/projects/debian-cloud/global/licenses/debian-12-bookworm"}},
+		},
+	})
+	if vm.Image != "" || vm.BootLicense != "debian-12-bookworm" {
+		t.Errorf("expected only the boot disk license, got image %q and license %q", vm.Image, vm.BootLicense)
+	}
+
+	vm = newInstance(&computepb.Instance{
+		Name:  proto.String("instance-2"),
+		Disks: []*computepb.AttachedDisk{{Boot: proto.Bool(true)}},
+	})
+	if vm.Image != "" {
+		t.Errorf("expected no image when the API does not report one, got %q", vm.Image)
+	}
+}
+
+func TestNewInstance_LabelsAndMetadata(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name:   proto.String("instance-1"),
+		Labels: map[string]string{"env": "prod"},
+		Metadata: &computepb.Metadata{Items: []*computepb.Items{
+			{Key: proto.String("startup-script"), Value: proto.String("#!/bin/sh\necho hi")},
+		}},
+	})
+	if vm.Labels["env"] != "prod" {
+		t.Errorf("unexpected labels: %v", vm.Labels)
+	}
+	if vm.Metadata["startup-script"] != "#!/bin/sh\necho hi" {
+		t.Errorf("unexpected metadata: %v", vm.Metadata)
+	}
+
+	vm = newInstance(&computepb.Instance{Name: proto.String("instance-2")})
+	if vm.Labels != nil || vm.Metadata != nil {
+		t.Errorf("expected no labels or metadata, got %v and %v", vm.Labels, vm.Metadata)
+	}
+}
+
+func TestNewInstance_Network(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name: proto.String("instance-1"),
+		NetworkInterfaces: []*computepb.NetworkInterface{{
+			Network:    proto.String("https://www.googleapis.com/compute/v1/projects/proj/global/networks/prod-vpc"),
+			Subnetwork: proto.String("https://www.googleapis.com/compute/v1/projects/proj/regions/us-central1/subnetworks/web"),
+			NetworkIP:  proto.String("10.0.0.7"),
+		}},
+	})
+	if vm.Network != "prod-vpc" || vm.Subnetwork != "web" || vm.InternalIP != "10.0.0.7" {
+		t.Errorf("unexpected network fields: %q, %q, %q", vm.Network, vm.Subnetwork, vm.InternalIP)
+	}
+
+	vm = newInstance(&computepb.Instance{Name: proto.String("instance-3"), Tags: &computepb.Tags{Items: []string{"web", "ssh"}}})
+	if !reflect.DeepEqual(vm.Tags, []string{"web", "ssh"}) {
+		t.Errorf("unexpected tags %v", vm.Tags)
+	}
+
+	for _, nics := range [][]*computepb.NetworkInterface{nil, {nil}} {
+		vm = newInstance(&computepb.Instance{Name: proto.String("instance-2"), NetworkInterfaces: nics})
+		if vm.Network != "" || vm.Subnetwork != "" || vm.ExternalIP != "" {
+			t.Errorf("expected no network for %v, got %q, %q, %q", nics, vm.Network, vm.Subnetwork, vm.ExternalIP)
+		}
+	}
+}
+
+func TestNewInstance_ResourcePolicies(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name: proto.String("instance-1"),
+		ResourcePolicies: []string{
+			"https://www.googleapis.com/compute/v1/projects/proj/regions/us-central1/resourcePolicies/office-hours",
+			"",
+		},
+	})
+	if !reflect.DeepEqual(vm.ResourcePolicies, []string{"office-hours"}) {
+		t.Errorf("unexpected resource policies %v", vm.ResourcePolicies)
+	}
+
+	vm = newInstance(&computepb.Instance{Name: proto.String("instance-2")})
+	if vm.ResourcePolicies != nil {
+		t.Errorf("expected no resource policies, got %v", vm.ResourcePolicies)
+	}
+}
+
+func TestNewInstance_ExternalIP(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name: proto.String("instance-1"),
+		NetworkInterfaces: []*computepb.NetworkInterface{
+			{AccessConfigs: []*computepb.AccessConfig{{Name: proto.String("External NAT")}}},
+			{AccessConfigs: []*computepb.AccessConfig{{NatIP: proto.String("34.1.2.3")}, {NatIP: proto.String("34.1.2.4")}}},
+		},
+	})
+	if vm.ExternalIP != "34.1.2.3" {
+		t.Errorf("expected the first assigned external IP, got %q", vm.ExternalIP)
+	}
+}
+
+func TestFetchInstances_MaxResults(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{
+			"items": {
+				"zones/us-central1-a": {
+					"instances": [
+						{"name": "instance-1", "zone": "zones/us-central1-a"},
+						{"name": "instance-2", "zone": "zones/us-central1-a"},
+						{"name": "instance-3", "zone": "zones/us-central1-a"}
+					]
+				}
+			}
+		}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	list, err := client.FetchInstances(ctx, "test-project", FetchOptions{MaxResults: 2})
+	if err != nil {
+		t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+	}
+	if len(list.Instances) != 2 || !list.Truncated {
+		t.Errorf("expected exactly 2 instances and a truncated list, got %d (truncated=%v)", len(list.Instances), list.Truncated)
+	}
+
+	list, err = client.FetchInstances(ctx, "test-project", FetchOptions{MaxResults: 3})
+	if err != nil {
+		t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+	}
+	if len(list.Instances) != 3 || list.Truncated {
+		t.Errorf("expected all 3 instances without truncation, got %d (truncated=%v)", len(list.Instances), list.Truncated)
+	}
+}
+
+func TestGetInstance_WithMockServer(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/compute/v1/projects/test-project/zones/us-central1-a/instances/vm-1" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"error": {"code": 404, "message": "The resource was not found"}}`)
+			return
+		}
+		fmt.Fprintln(w, `{"name": "vm-1", "zone": "https://www.googleapis.com/compute/v1/projects/test-project/zones/us-central1-a", "status": "RUNNING"}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	vm, err := client.GetInstance(ctx, "test-project", "us-central1-a", "vm-1")
+	if err != nil {
+		t.Fatalf("GetInstance() returned an unexpected error: %v", err)
+	}
+	if want := (Instance{ProjectID: "test-project", Name: "vm-1", Zone: "us-central1-a", Status: "RUNNING"}); !reflect.DeepEqual(vm, want) {
+		t.Errorf("expected %+v, got %+v", want, vm)
+	}
+
+	if _, err := client.GetInstance(ctx, "test-project", "us-central1-a", "vm-2"); !errors.Is(err, ErrInstanceNotFound) {
+		t.Errorf("expected ErrInstanceNotFound, got %v", err)
+	}
+}
+
+func TestStartInstance_WithMockServer(t *testing.T) {
+	var gotPath string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			gotPath = r.URL.Path
+		}
+		fmt.Fprintln(w, `{"name": "op-1", "status": "DONE"}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	if err := client.StartInstance(ctx, "test-project", "us-central1-a", "instance-1"); err != nil {
+		t.Fatalf("StartInstance() returned an unexpected error: %v", err)
+	}
+	if want := "/compute/v1/projects/test-project/zones/us-central1-a/instances/instance-1/start"; gotPath != want {
+		t.Errorf("expected path %q, got %q", want, gotPath)
+	}
+}
+
+func TestStartInstance_ZoneExhausted(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"name": "op-1", "status": "DONE", "httpErrorStatusCode": 503, "httpErrorMessage": "SERVICE UNAVAILABLE",
+			"error": {"errors": [{"code": "ZONE_RESOURCE_POOL_EXHAUSTED", "message": "The zone does not have enough resources available to fulfill the request."}]}}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	err = client.StartInstance(ctx, "test-project", "us-central1-a", "instance-1")
+	var exhausted *ZoneExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("expected a ZoneExhaustedError, got %v", err)
+	}
+	if exhausted.Zone != "us-central1-a" || exhausted.Instance != "instance-1" {
+		t.Errorf("unexpected zone or instance in %+v", exhausted)
+	}
+	if !strings.Contains(exhausted.Err.Error(), "ZONE_RESOURCE_POOL_EXHAUSTED") {
+		t.Errorf("expected the raw error to be kept, got %v", exhausted.Err)
+	}
+}
+
+func TestStartInstance_OtherError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintln(w, `{"error": {"code": 400, "message": "The instance is not stopped."}}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	err = client.StartInstance(ctx, "test-project", "us-central1-a", "instance-1")
+	var exhausted *ZoneExhaustedError
+	if err == nil || errors.As(err, &exhausted) {
+		t.Fatalf("expected a plain API error, got %v", err)
+	}
+}
+
+func TestFetchInstances_HomeRegion(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{
+			"items": {
+				"zones/asia-east1-a": {"instances": [{"name": "taiwan", "zone": "zones/asia-east1-a"}]},
+				"zones/europe-west1-b": {"instances": [{"name": "belgium", "zone": "zones/europe-west1-b"}]}
+			}
+		}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	for _, tt := range []struct{ home, first string }{{"europe-west2", "belgium"}, {"asia-east2", "taiwan"}} {
+		list, err := client.FetchInstances(ctx, "test-project", FetchOptions{HomeRegion: tt.home})
+		if err != nil {
+			t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+		}
+		if len(list.Instances) != 2 || list.Instances[0].Name != tt.first {
+			t.Errorf("with home region %s expected %s first, got %v", tt.home, tt.first, list.Instances)
+		}
+	}
+}
+
+func TestNewInstance_Timestamps(t *testing.T) {
+	vm := newInstance(&computepb.Instance{
+		Name:               proto.String("instance-1"),
+		CreationTimestamp:  proto.String("2024-01-02T03:04:05.678-08:00"),
+		LastStartTimestamp: proto.String("2024-02-01T00:00:00.000-08:00"),
+	})
+	if vm.CreatedAt != "2024-01-02T03:04:05.678-08:00" || vm.LastStartAt == "" || vm.LastStopAt != "" {
+		t.Errorf("unexpected timestamps: %+v", vm)
+	}
+	if _, err := ParseTimestamp(vm.CreatedAt); err != nil {
+		t.Errorf("ParseTimestamp() failed on an API timestamp: %v", err)
+	}
+}
+
+func TestCompareNewest(t *testing.T) {
+	vms := []Instance{
+		{Name: "old", CreatedAt: "2023-01-01T00:00:00Z"},
+		{Name: "unknown-b", CreatedAt: "yesterday"},
+		{Name: "new", CreatedAt: "2024-06-01T12:00:00-07:00"},
+		{Name: "twin-b", CreatedAt: "2024-03-01T00:00:00Z"},
+		{Name: "unknown-a"},
+		{Name: "twin-a", CreatedAt: "2024-03-01T01:00:00+01:00"},
+	}
+	slices.SortStableFunc(vms, CompareNewest)
+
+	var got []string
+	for _, vm := range vms {
+		got = append(got, vm.Name)
+	}
+	want := []string{"new", "twin-a", "twin-b", "old", "unknown-a", "unknown-b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sorting with CompareNewest gave %v, want %v", got, want)
+	}
+}
+
+func TestSuspendResume_WithMockServer(t *testing.T) {
+	var gotPaths []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			gotPaths = append(gotPaths, r.URL.Path)
+		}
+		fmt.Fprintln(w, `{"name": "op-1", "status": "DONE"}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	if err := client.SuspendInstance(ctx, "test-project", "us-central1-a", "instance-1"); err != nil {
+		t.Fatalf("SuspendInstance() returned an unexpected error: %v", err)
+	}
+	if err := client.ResumeInstance(ctx, "test-project", "us-central1-a", "instance-1"); err != nil {
+		t.Fatalf("ResumeInstance() returned an unexpected error: %v", err)
+	}
+	want := []string{
+		"/compute/v1/projects/test-project/zones/us-central1-a/instances/instance-1/suspend",
+		"/compute/v1/projects/test-project/zones/us-central1-a/instances/instance-1/resume",
+	}
+	if !reflect.DeepEqual(gotPaths, want) {
+		t.Errorf("expected paths %v, got %v", want, gotPaths)
+	}
+}
+
+func TestSuspendInstance_UnsupportedError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintln(w, `{"error": {"code": 400, "message": "Suspend is not supported for this machine type."}}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	err = client.SuspendInstance(ctx, "test-project", "us-central1-a", "instance-1")
+	if err == nil || !strings.Contains(err.Error(), "Suspend is not supported") {
+		t.Fatalf("expected the API error to be surfaced, got %v", err)
+	}
+}
+
+func TestFetchInstances_Zones(t *testing.T) {
+	var gotPaths []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPaths = append(gotPaths, r.URL.Path)
+		zone := path.Base(path.Dir(r.URL.Path))
+		fmt.Fprintf(w, `{"items": [{"name": "in-%s", "zone": "zones/%s"}]}`, zone, zone)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	list, err := client.FetchInstances(ctx, "test-project", FetchOptions{Zones: []string{"us-central1-a", "europe-west1-b"}})
+	if err != nil {
+		t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+	}
+	wantPaths := []string{
+		"/compute/v1/projects/test-project/zones/us-central1-a/instances",
+		"/compute/v1/projects/test-project/zones/europe-west1-b/instances",
+	}
+	if !reflect.DeepEqual(gotPaths, wantPaths) {
+		t.Errorf("expected requests %v, got %v", wantPaths, gotPaths)
+	}
+	if len(list.Instances) != 2 || list.Instances[0].Name != "in-us-central1-a" || list.Instances[1].Zone != "europe-west1-b" {
+		t.Errorf("unexpected instances: %+v", list.Instances)
+	}
+}
+
+func TestListZones_WithMockServer(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/compute/v1/projects/test-project/zones" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		fmt.Fprintln(w, `{"items": [{"name": "us-central1-a"}, {"name": "europe-west1-b"}]}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	zones, err := client.ListZones(ctx, "test-project")
+	if err != nil {
+		t.Fatalf("ListZones() returned an unexpected error: %v", err)
+	}
+	if want := []string{"us-central1-a", "europe-west1-b"}; !reflect.DeepEqual(zones, want) {
+		t.Errorf("expected %v, got %v", want, zones)
+	}
+}
+
+func TestFetchInstances_PartialAggregatedFailure(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("returnPartialSuccess") != "true" {
+			t.Errorf("expected returnPartialSuccess to be requested, got %q", r.URL.RawQuery)
+		}
+		fmt.Fprintln(w, `{
+			"items": {
+				"zones/us-central1-a": {"instances": [{"name": "instance-1", "zone": "zones/us-central1-a"}]},
+				"zones/us-east1-b": {"warning": {"code": "UNREACHABLE", "message": "The resource 'us-east1-b' is unreachable"}},
+				"zones/us-west1-a": {"warning": {"code": "NO_RESULTS_ON_PAGE", "message": "There are no results for scope 'zones/us-west1-a' on this page."}},
+				"zones/europe-west1-b": {"warning": {"code": "UNREACHABLE", "message": "The resource 'europe-west1-b' is unreachable"}}
+			}
+		}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	list, err := client.FetchInstances(ctx, "test-project", FetchOptions{})
+	if err != nil {
+		t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+	}
+	if len(list.Instances) != 1 || list.Instances[0].Name != "instance-1" {
+		t.Errorf("expected the reachable instance, got %+v", list.Instances)
+	}
+	var zones []string
+	for _, ze := range list.ZoneErrors {
+		zones = append(zones, ze.Zone)
+	}
+	slices.Sort(zones)
+	if want := []string{"europe-west1-b", "us-east1-b"}; !reflect.DeepEqual(zones, want) {
+		t.Errorf("expected unreachable zones %v, got %v", want, zones)
+	}
+}
+
+func TestZoneName(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a", "us-central1-a"},
+		{"zones/us-central1-a", "us-central1-a"},
+		{"projects/p/zones/us-central1-a/", "us-central1-a"},
+		{"https://www.googleapis.com/compute/v1/projects/p/regions/us-central1", "us-central1"},
+		{"regions/europe-west1", "europe-west1"},
+		{"projects/zones/zones/us-east1-b", "us-east1-b"},
+		{"us-east1-b", "us-east1-b"},
+		{"global", "global"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := zoneName(tt.url); got != tt.want {
+			t.Errorf("zoneName(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestFetchInstances_RegionalScopes(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{
+			"items": {
+				"zones/us-central1-a": {"instances": [{"name": "instance-1", "zone": "https://www.googleapis.com/compute/v1/projects/test-project/zones/us-central1-a"}]},
+				"regions/us-east1": {"instances": [{"name": "instance-2", "zone": "https://www.googleapis.com/compute/v1/projects/test-project/regions/us-east1"}]},
+				"regions/europe-west1": {"warning": {"code": "UNREACHABLE", "message": "The resource 'europe-west1' is unreachable"}}
+			}
+		}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	list, err := client.FetchInstances(ctx, "test-project", FetchOptions{})
+	if err != nil {
+		t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+	}
+	zones := make(map[string]string)
+	for _, vm := range list.Instances {
+		zones[vm.Name] = vm.Zone
+	}
+	if want := map[string]string{"instance-1": "us-central1-a", "instance-2": "us-east1"}; !reflect.DeepEqual(zones, want) {
+		t.Errorf("expected zones %v, got %v", want, zones)
+	}
+	if len(list.ZoneErrors) != 1 || list.ZoneErrors[0].Zone != "europe-west1" {
+		t.Errorf("expected the regional scope to be reported by region, got %+v", list.ZoneErrors)
+	}
+}
+
+func TestFetchInstances_SkipZoneWarnings(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{
+			"items": {
+				"zones/us-central1-a": {"instances": [{"name": "instance-1", "zone": "zones/us-central1-a"}]},
+				"zones/us-east1-b": {"warning": {"code": "UNREACHABLE", "message": "The resource 'us-east1-b' is unreachable"}}
+			}
+		}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	list, err := client.FetchInstances(ctx, "test-project", FetchOptions{SkipZoneWarnings: true})
+	if err != nil {
+		t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+	}
+	if len(list.Instances) != 1 || len(list.ZoneErrors) != 0 {
+		t.Errorf("expected the instance without zone errors, got %+v", list)
+	}
+	if list.SkippedZoneWarnings != 1 {
+		t.Errorf("expected the skipped warning to be counted, got %d", list.SkippedZoneWarnings)
+	}
+}
+
+func TestFetchInstances_FieldMask(t *testing.T) {
+	var masks []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		masks = append(masks, r.Header.Get("X-Goog-FieldMask"))
+		fmt.Fprintln(w, `{}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	for _, opts := range []FetchOptions{{}, {Fields: []Field{FieldLabels}}, {Fields: []Field{}, Zones: []string{"us-central1-a"}}} {
+		if _, err := client.FetchInstances(ctx, "test-project", opts); err != nil {
+			t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+		}
+	}
+	base := "id,name,zone,status,machineType,hostname,creationTimestamp,lastStartTimestamp,lastStopTimestamp,deletionProtection"
+	want := []string{
+		"",
+		"nextPageToken,warning,items/*/warning,items/*/instances(" + base + ",tags/items,labels)",
+		"nextPageToken,warning,items(" + base + ")",
+	}
+	if !reflect.DeepEqual(masks, want) {
+		t.Errorf("field masks = %q, want %q", masks, want)
+	}
+}
+
+func TestFetchInstances_PartialZoneFailure(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zone := path.Base(path.Dir(r.URL.Path))
+		if zone == "us-east1-b" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, `{"error": {"code": 503, "message": "zone unavailable"}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"items": [{"name": "in-%s", "zone": "zones/%s"}]}`, zone, zone)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	list, err := client.FetchInstances(ctx, "test-project", FetchOptions{Zones: []string{"us-east1-b", "us-central1-a"}})
+	if err != nil {
+		t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+	}
+	if len(list.Instances) != 1 || list.Instances[0].Name != "in-us-central1-a" {
+		t.Errorf("expected the instance of the reachable zone, got %+v", list.Instances)
+	}
+	if len(list.ZoneErrors) != 1 || list.ZoneErrors[0].Zone != "us-east1-b" {
+		t.Errorf("expected us-east1-b to be reported, got %+v", list.ZoneErrors)
+	}
+
+	if _, err := client.FetchInstances(ctx, "test-project", FetchOptions{Zones: []string{"us-east1-b"}}); err == nil {
+		t.Error("expected an error when every zone fails")
+	}
+
+	var ze ZoneError
+	if _, err := client.FetchInstances(ctx, "test-project", FetchOptions{Zones: []string{"us-east1-b", "us-central1-a"}, Strict: true}); !errors.As(err, &ze) || ze.Zone != "us-east1-b" {
+		t.Errorf("expected a strict listing to fail on us-east1-b, got %v", err)
+	}
+}
+
+func TestFetchInstances_Strict(t *testing.T) {
+	var query string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		fmt.Fprintln(w, `{"items": {"zones/us-central1-a": {"instances": [{"name": "instance-1", "zone": "zones/us-central1-a"}]}}}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	if _, err := client.FetchInstances(ctx, "test-project", FetchOptions{Strict: true}); err != nil {
+		t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+	}
+	if strings.Contains(query, "returnPartialSuccess") {
+		t.Errorf("a strict listing should not ask for partial success, got %q", query)
+	}
+}
+
+func TestFetchInstances_StrictZoneWarning(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{
+			"items": {
+				"zones/us-central1-a": {"instances": [{"name": "instance-1", "zone": "zones/us-central1-a"}]},
+				"zones/us-east1-b": {"warning": {"code": "UNREACHABLE", "message": "The resource 'us-east1-b' is unreachable"}}
+			}
+		}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	for _, opts := range []FetchOptions{{Strict: true}, {Strict: true, SkipZoneWarnings: true}} {
+		var ze ZoneError
+		if _, err := client.FetchInstances(ctx, "test-project", opts); !errors.As(err, &ze) || ze.Zone != "us-east1-b" {
+			t.Errorf("expected a strict listing to fail on us-east1-b with %+v, got %v", opts, err)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// Package gcptest provides an in-memory gcp.Client for tests.
+package gcptest
+
+import (
+	"context"
+	"fmt"
+	"gcp-rider/gcp"
+	"slices"
+	"sync"
+)
+
+// FakeClient is a gcp.Client backed by an in-memory list of instances. The
+// instance actions change the stored status the way the Compute API would,
+// and reject the same invalid transitions. It is safe for concurrent use.
+type FakeClient struct {
+	mu        sync.Mutex
+	instances []gcp.Instance
+	logs      map[string][]gcp.LogEntry
+	firewalls []gcp.FirewallRule
+	iam       map[string][]gcp.IAMBinding
+	perms     map[string][]string
+	specs     map[string]gcp.MachineTypeSpec
+	quotas    map[string][]gcp.Quota
+	errs      map[string]error
+	closed    bool
+}
+
+var _ gcp.Client = (*FakeClient)(nil)
+
+// NewFakeClient returns a FakeClient holding the given instances. Instances
+// without a project belong to every project.
+func NewFakeClient(instances ...gcp.Instance) *FakeClient {
+	return &FakeClient{
+		instances: append([]gcp.Instance(nil), instances...),
+		logs:      make(map[string][]gcp.LogEntry),
+		iam:       make(map[string][]gcp.IAMBinding),
+		perms:     make(map[string][]string),
+		specs:     make(map[string]gcp.MachineTypeSpec),
+		quotas:    make(map[string][]gcp.Quota),
+		errs:      make(map[string]error),
+	}
+}
+
+// AddLogs appends log entries for the instance with the given ID, newest
+// first as FetchLogs returns them.
+func (f *FakeClient) AddLogs(instanceID string, entries ...gcp.LogEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs[instanceID] = append(f.logs[instanceID], entries...)
+}
+
+// AddFirewallRules adds rules returned by FetchFirewallRules for every
+// project.
+func (f *FakeClient) AddFirewallRules(rules ...gcp.FirewallRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.firewalls = append(f.firewalls, rules...)
+}
+
+// SetIAM sets the IAM bindings returned by GetInstanceIAM for the named
+// instance. Instances without bindings have an empty policy.
+func (f *FakeClient) SetIAM(projectID, zone, name string, bindings ...gcp.IAMBinding) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.iam[projectID+"/"+zone+"/"+name] = bindings
+}
+
+// SetPermissions sets the permissions the caller holds on the named
+// instance. Instances without permissions set grant every permission.
+func (f *FakeClient) SetPermissions(projectID, zone, name string, permissions ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.perms[projectID+"/"+zone+"/"+name] = permissions
+}
+
+// SetMachineTypeSpec sets the spec GetMachineType returns for the machine
+// type in zone. Machine types without a spec are not found.
+func (f *FakeClient) SetMachineTypeSpec(zone, machineType string, spec gcp.MachineTypeSpec) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.specs[zone+"/"+machineType] = spec
+}
+
+// SetQuotas sets the quotas RegionQuotas returns for region in every
+// project. Regions without quotas have none.
+func (f *FakeClient) SetQuotas(region string, quotas ...gcp.Quota) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.quotas[region] = quotas
+}
+
+// SetError makes every later call to the named method, e.g. "StartInstance",
+// fail with err. A nil err clears it.
+func (f *FakeClient) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// SetStatus changes the status of an instance directly, e.g. to simulate it
+// being stopped outside the application.
+func (f *FakeClient) SetStatus(projectID, zone, name, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	vm, err := f.find(projectID, zone, name)
+	if err != nil {
+		return err
+	}
+	vm.Status = status
+	return nil
+}
+
+// Instance returns a copy of the stored instance.
+func (f *FakeClient) Instance(projectID, zone, name string) (gcp.Instance, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	vm, err := f.find(projectID, zone, name)
+	if err != nil {
+		return gcp.Instance{}, false
+	}
+	return *vm, true
+}
+
+// Closed reports whether Close has been called.
+func (f *FakeClient) Closed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// FetchInstances returns the instances of the project, honoring
+// opts.Zones, opts.MaxResults and opts.HomeRegion.
+func (f *FakeClient) FetchInstances(ctx context.Context, projectID string, opts gcp.FetchOptions) (gcp.InstanceList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs["FetchInstances"]; err != nil {
+		return gcp.InstanceList{}, err
+	}
+	var list gcp.InstanceList
+	for _, vm := range f.instances {
+		if !inProject(vm, projectID) || (len(opts.Zones) > 0 && !slices.Contains(opts.Zones, vm.Zone)) ||
+			(len(opts.Statuses) > 0 && !slices.Contains(opts.Statuses, vm.Status)) {
+			continue
+		}
+		if opts.MaxResults > 0 && len(list.Instances) == opts.MaxResults {
+			list.Truncated = true
+			break
+		}
+		vm.ProjectID = projectID
+		list.Instances = append(list.Instances, vm)
+		if opts.Progress != nil {
+			opts.Progress(len(list.Instances))
+		}
+	}
+	if opts.HomeRegion != "" {
+		gcp.SortByProximity(list.Instances, opts.HomeRegion)
+	}
+	return list, nil
+}
+
+// GetInstance returns the stored instance, or gcp.ErrInstanceNotFound if
+// there is none.
+func (f *FakeClient) GetInstance(ctx context.Context, projectID, zone, name string) (gcp.Instance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs["GetInstance"]; err != nil {
+		return gcp.Instance{}, err
+	}
+	vm, err := f.find(projectID, zone, name)
+	if err != nil {
+		return gcp.Instance{}, err
+	}
+	found := *vm
+	found.ProjectID = projectID
+	return found, nil
+}
+
+// SetMachineType changes the machine type of a TERMINATED instance.
+func (f *FakeClient) SetMachineType(ctx context.Context, projectID, zone, name, machineType string) error {
+	return f.update("SetMachineType", projectID, zone, name, func(vm *gcp.Instance) error {
+		if vm.Status != "TERMINATED" {
+			return fmt.Errorf("instance %s must be stopped to change its machine type, but is %s", name, vm.Status)
+		}
+		vm.MachineType = machineType
+		return nil
+	})
+}
+
+// SetDeletionProtection turns deletion protection of an instance on or off.
+func (f *FakeClient) SetDeletionProtection(ctx context.Context, projectID, zone, name string, enabled bool) error {
+	return f.update("SetDeletionProtection", projectID, zone, name, func(vm *gcp.Instance) error {
+		vm.DeletionProtection = enabled
+		return nil
+	})
+}
+
+// StartInstance moves a stopped instance to RUNNING. Starting a running
+// instance is a no-op, as in the Compute API.
+func (f *FakeClient) StartInstance(ctx context.Context, projectID, zone, name string) error {
+	return f.transition("StartInstance", projectID, zone, name, "RUNNING", "TERMINATED", "STOPPED", "RUNNING")
+}
+
+// SuspendInstance moves a RUNNING instance to SUSPENDED.
+func (f *FakeClient) SuspendInstance(ctx context.Context, projectID, zone, name string) error {
+	return f.transition("SuspendInstance", projectID, zone, name, "SUSPENDED", "RUNNING")
+}
+
+// ResumeInstance moves a SUSPENDED instance to RUNNING.
+func (f *FakeClient) ResumeInstance(ctx context.Context, projectID, zone, name string) error {
+	return f.transition("ResumeInstance", projectID, zone, name, "RUNNING", "SUSPENDED")
+}
+
+// FetchLogs returns up to limit of the entries added for the instance.
+func (f *FakeClient) FetchLogs(ctx context.Context, projectID, instanceID string, limit int) ([]gcp.LogEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs["FetchLogs"]; err != nil {
+		return nil, err
+	}
+	entries := f.logs[instanceID]
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return append([]gcp.LogEntry(nil), entries...), nil
+}
+
+// FetchFirewallRules returns the rules added with AddFirewallRules.
+func (f *FakeClient) FetchFirewallRules(ctx context.Context, projectID string) ([]gcp.FirewallRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs["FetchFirewallRules"]; err != nil {
+		return nil, err
+	}
+	return append([]gcp.FirewallRule(nil), f.firewalls...), nil
+}
+
+// GetInstanceIAM returns the bindings set with SetIAM.
+func (f *FakeClient) GetInstanceIAM(ctx context.Context, projectID, zone, name string) ([]gcp.IAMBinding, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs["GetInstanceIAM"]; err != nil {
+		return nil, err
+	}
+	return append([]gcp.IAMBinding(nil), f.iam[projectID+"/"+zone+"/"+name]...), nil
+}
+
+// TestInstancePermissions returns those of permissions granted with
+// SetPermissions, or all of them if none were set for the instance.
+func (f *FakeClient) TestInstancePermissions(ctx context.Context, projectID, zone, name string, permissions []string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs["TestInstancePermissions"]; err != nil {
+		return nil, err
+	}
+	held, ok := f.perms[projectID+"/"+zone+"/"+name]
+	if !ok {
+		return append([]string(nil), permissions...), nil
+	}
+	var granted []string
+	for _, p := range permissions {
+		if slices.Contains(held, p) {
+			granted = append(granted, p)
+		}
+	}
+	return granted, nil
+}
+
+// ListZones returns the zones of the stored instances, sorted by name.
+func (f *FakeClient) ListZones(ctx context.Context, projectID string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs["ListZones"]; err != nil {
+		return nil, err
+	}
+	var zones []string
+	for _, vm := range f.instances {
+		if inProject(vm, projectID) && !slices.Contains(zones, vm.Zone) {
+			zones = append(zones, vm.Zone)
+		}
+	}
+	slices.Sort(zones)
+	return zones, nil
+}
+
+// GetMachineType returns the spec set with SetMachineTypeSpec.
+func (f *FakeClient) GetMachineType(ctx context.Context, projectID, zone, machineType string) (gcp.MachineTypeSpec, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs["GetMachineType"]; err != nil {
+		return gcp.MachineTypeSpec{}, err
+	}
+	spec, ok := f.specs[zone+"/"+machineType]
+	if !ok {
+		return gcp.MachineTypeSpec{}, fmt.Errorf("machine type %s not found in %s", machineType, zone)
+	}
+	return spec, nil
+}
+
+// RegionQuotas returns the quotas set with SetQuotas.
+func (f *FakeClient) RegionQuotas(ctx context.Context, projectID, region string) ([]gcp.Quota, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs["RegionQuotas"]; err != nil {
+		return nil, err
+	}
+	return append([]gcp.Quota(nil), f.quotas[region]...), nil
+}
+
+// Close marks the client as closed.
+func (f *FakeClient) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+// transition sets the status of an instance to to, if its current status is
+// one of from.
+func (f *FakeClient) transition(method, projectID, zone, name, to string, from ...string) error {
+	return f.update(method, projectID, zone, name, func(vm *gcp.Instance) error {
+		for _, s := range from {
+			if vm.Status == s {
+				vm.Status = to
+				return nil
+			}
+		}
+		return fmt.Errorf("instance %s cannot become %s from %s", name, to, vm.Status)
+	})
+}
+
+// update applies fn to the stored instance, unless an error was set for
+// method.
+func (f *FakeClient) update(method, projectID, zone, name string, fn func(*gcp.Instance) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errs[method]; err != nil {
+		return err
+	}
+	vm, err := f.find(projectID, zone, name)
+	if err != nil {
+		return err
+	}
+	return fn(vm)
+}
+
+// find returns the stored instance. f.mu must be held.
+func (f *FakeClient) find(projectID, zone, name string) (*gcp.Instance, error) {
+	for i := range f.instances {
+		vm := &f.instances[i]
+		if inProject(*vm, projectID) && vm.Zone == zone && vm.Name == name {
+			return vm, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s in %s/%s", gcp.ErrInstanceNotFound, name, projectID, zone)
+}
+
+// inProject reports whether vm belongs to the project.
+func inProject(vm gcp.Instance, projectID string) bool {
+	return vm.ProjectID == "" || vm.ProjectID == projectID
+}
@@ -0,0 +1,73 @@
+package gcptest
+
+import (
+	"context"
+	"errors"
+	"gcp-rider/gcp"
+	"testing"
+)
+
+func TestFakeClient_Transitions(t *testing.T) {
+	ctx := context.Background()
+	f := NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"})
+
+	if err := f.SuspendInstance(ctx, "proj", "z-1", "vm-1"); err == nil {
+		t.Fatal("expected suspending a stopped instance to fail")
+	}
+	steps := []struct {
+		action func(context.Context, string, string, string) error
+		want   string
+	}{
+		{f.StartInstance, "RUNNING"},
+		{f.SuspendInstance, "SUSPENDED"},
+		{f.ResumeInstance, "RUNNING"},
+	}
+	for _, s := range steps {
+		if err := s.action(ctx, "proj", "z-1", "vm-1"); err != nil {
+			t.Fatalf("unexpected error moving to %s: %v", s.want, err)
+		}
+		if vm, _ := f.Instance("proj", "z-1", "vm-1"); vm.Status != s.want {
+			t.Fatalf("expected %s, got %s", s.want, vm.Status)
+		}
+	}
+
+	if err := f.SetMachineType(ctx, "proj", "z-1", "vm-1", "e2-small"); err == nil {
+		t.Fatal("expected resizing a running instance to fail")
+	}
+	if err := f.SetStatus("proj", "z-1", "vm-1", "TERMINATED"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.SetMachineType(ctx, "proj", "z-1", "vm-1", "e2-small"); err != nil {
+		t.Fatalf("unexpected error resizing a stopped instance: %v", err)
+	}
+	if vm, _ := f.Instance("proj", "z-1", "vm-1"); vm.MachineType != "e2-small" {
+		t.Errorf("expected e2-small, got %q", vm.MachineType)
+	}
+}
+
+func TestFakeClient_FetchInstances(t *testing.T) {
+	f := NewFakeClient(
+		gcp.Instance{ProjectID: "proj-a", Name: "a-1", Zone: "us-central1-a"},
+		gcp.Instance{ProjectID: "proj-b", Name: "b-1", Zone: "us-central1-a"},
+		gcp.Instance{ProjectID: "proj-a", Name: "a-2", Zone: "europe-west1-b"},
+	)
+
+	list, err := f.FetchInstances(context.Background(), "proj-a", gcp.FetchOptions{HomeRegion: "europe-west1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Instances) != 2 || list.Instances[0].Name != "a-2" || list.Instances[1].Name != "a-1" {
+		t.Errorf("unexpected instances: %+v", list.Instances)
+	}
+
+	list, _ = f.FetchInstances(context.Background(), "proj-a", gcp.FetchOptions{MaxResults: 1})
+	if len(list.Instances) != 1 || !list.Truncated {
+		t.Errorf("expected one instance and a truncated list, got %+v", list)
+	}
+
+	wantErr := errors.New("permission denied")
+	f.SetError("FetchInstances", wantErr)
+	if _, err := f.FetchInstances(context.Background(), "proj-a", gcp.FetchOptions{}); !errors.Is(err, wantErr) {
+		t.Errorf("expected the configured error, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+)
+
+// ErrIAMForbidden is returned when the caller may not read the IAM policy
+// of an instance.
+var ErrIAMForbidden = errors.New("not allowed to read the IAM policy of this instance")
+
+// IAMBinding grants a role on an instance to a set of members.
+type IAMBinding struct {
+	Role string
+	// Members are principals such as "user:alice@example.com" or
+	// "group:ops@example.com".
+	Members []string
+	// Condition is the title, or else the expression, of the condition
+	// limiting the binding, empty when it is unconditional.
+	Condition string
+}
+
+// GetInstanceIAM returns the bindings of the instance's own IAM policy,
+// sorted by role. Access granted on the project or folder is not included.
+// An instance without a policy of its own has no bindings.
+func (c *realClient) GetInstanceIAM(ctx context.Context, projectID, zone, name string) ([]IAMBinding, error) {
+	policy, err := c.computeClient.GetIamPolicy(ctx, &computepb.GetIamPolicyInstanceRequest{
+		Project:  projectID,
+		Zone:     zone,
+		Resource: name,
+	})
+	if err != nil {
+		if isForbidden(err) {
+			return nil, ErrIAMForbidden
+		}
+		return nil, fmt.Errorf("failed to get IAM policy: %w", err)
+	}
+	return newIAMBindings(policy), nil
+}
+
+// TestInstancePermissions returns which of permissions the caller holds on
+// the instance, whether granted on the instance itself or inherited from the
+// project and above.
+func (c *realClient) TestInstancePermissions(ctx context.Context, projectID, zone, name string, permissions []string) ([]string, error) {
+	resp, err := c.computeClient.TestIamPermissions(ctx, &computepb.TestIamPermissionsInstanceRequest{
+		Project:  projectID,
+		Zone:     zone,
+		Resource: name,
+		TestPermissionsRequestResource: &computepb.TestPermissionsRequest{
+			Permissions: permissions,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to test permissions: %w", err)
+	}
+	return resp.GetPermissions(), nil
+}
+
+// newIAMBindings converts the bindings of an API policy, dropping those
+// without members.
+func newIAMBindings(policy *computepb.Policy) []IAMBinding {
+	var bindings []IAMBinding
+	for _, b := range policy.GetBindings() {
+		if len(b.GetMembers()) == 0 {
+			continue
+		}
+		binding := IAMBinding{Role: b.GetRole(), Members: b.GetMembers()}
+		if cond := b.GetCondition(); cond != nil {
+			binding.Condition = cond.GetTitle()
+			if binding.Condition == "" {
+				binding.Condition = cond.GetExpression()
+			}
+		}
+		bindings = append(bindings, binding)
+	}
+	sort.SliceStable(bindings, func(i, j int) bool { return bindings[i].Role < bindings[j].Role })
+	return bindings
+}
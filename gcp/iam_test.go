@@ -0,0 +1,115 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestGetInstanceIAM_WithMockServer(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/compute/v1/projects/test-project/zones/us-central1-a/instances/vm-1/getIamPolicy" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `{
+			"version": 3,
+			"etag": "BwXhqDlOmEM=",
+			"bindings": [
+				{"role": "roles/compute.osAdminLogin", "members": ["user:alice@example.com", "group:ops@example.com"]},
+				{"role": "roles/compute.instanceAdmin.v1", "members": ["serviceAccount:ci@test-project.iam.gserviceaccount.com"],
+				 "condition": {"title": "business hours", "expression": "request.time.getHours('UTC') < 18"}},
+				{"role": "roles/compute.viewer", "members": []}
+			]
+		}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	bindings, err := client.GetInstanceIAM(ctx, "test-project", "us-central1-a", "vm-1")
+	if err != nil {
+		t.Fatalf("GetInstanceIAM() returned an unexpected error: %v", err)
+	}
+	want := []IAMBinding{
+		{Role: "roles/compute.instanceAdmin.v1", Members: []string{"serviceAccount:ci@test-project.iam.gserviceaccount.com"}, Condition: "business hours"},
+		{Role: "roles/compute.osAdminLogin", Members: []string{"user:alice@example.com", "group:ops@example.com"}},
+	}
+	if !reflect.DeepEqual(bindings, want) {
+		t.Errorf("expected %+v, got %+v", want, bindings)
+	}
+}
+
+func TestGetInstanceIAM_EmptyPolicy(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"version": 1, "etag": "ACAB"}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	bindings, err := client.GetInstanceIAM(ctx, "test-project", "us-central1-a", "vm-1")
+	if err != nil {
+		t.Fatalf("GetInstanceIAM() returned an unexpected error: %v", err)
+	}
+	if len(bindings) != 0 {
+		t.Errorf("expected no bindings, got %+v", bindings)
+	}
+}
+
+func TestGetInstanceIAM_Forbidden(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintln(w, `{"error": {"code": 403, "message": "Required 'compute.instances.getIamPolicy' permission"}}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	if _, err := client.GetInstanceIAM(ctx, "test-project", "us-central1-a", "vm-1"); !errors.Is(err, ErrIAMForbidden) {
+		t.Fatalf("expected ErrIAMForbidden, got %v", err)
+	}
+}
+
+func TestTestInstancePermissions_WithMockServer(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/compute/v1/projects/test-project/zones/us-central1-a/instances/vm-1/testIamPermissions" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `{"permissions": ["compute.instances.osLogin"]}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	granted, err := client.TestInstancePermissions(ctx, "test-project", "us-central1-a", "vm-1", []string{"compute.instances.osLogin", "compute.instances.setMetadata"})
+	if err != nil {
+		t.Fatalf("TestInstancePermissions() returned an unexpected error: %v", err)
+	}
+	if want := []string{"compute.instances.osLogin"}; !reflect.DeepEqual(granted, want) {
+		t.Errorf("expected %v, got %v", want, granted)
+	}
+}
@@ -0,0 +1,88 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+// tokenInfoURL is the endpoint that tells which account an access token
+// belongs to.
+const tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// CallerIdentity returns the email of the account the application default
+// credentials act as: the service account of a key file or impersonation, or
+// else the account the token was issued to, as reported by the token info
+// endpoint.
+func CallerIdentity(ctx context.Context) (string, error) {
+	creds, err := google.FindDefaultCredentials(ctx, "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return "", err
+	}
+	if email := credentialsEmail(creds.JSON); email != "" {
+		return email, nil
+	}
+	token, err := creds.TokenSource.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to get an access token: %w", err)
+	}
+	return tokenEmail(ctx, http.DefaultClient, tokenInfoURL, token.AccessToken)
+}
+
+// credentialsEmail returns the service account named by a credentials file,
+// or "" if it does not name one, as with user credentials.
+func credentialsEmail(data []byte) string {
+	var creds struct {
+		ClientEmail      string `json:"client_email"`
+		ImpersonationURL string `json:"service_account_impersonation_url"`
+	}
+	if len(data) == 0 || json.Unmarshal(data, &creds) != nil {
+		return ""
+	}
+	if creds.ClientEmail != "" {
+		return creds.ClientEmail
+	}
+	// e.g. ".../projects/-/serviceAccounts/ci@p.iam.gserviceaccount.com:generateAccessToken"
+	if _, rest, ok := strings.Cut(creds.ImpersonationURL, "/serviceAccounts/"); ok {
+		email, _, _ := strings.Cut(rest, ":")
+		return email
+	}
+	return ""
+}
+
+// tokenEmail asks the token info endpoint at endpoint for the email of the
+// account token was issued to. The token is posted as the access_token form
+// value the endpoint documents, rather than put in the URL, which proxies
+// and servers may log.
+func tokenEmail(ctx context.Context, client *http.Client, endpoint, token string) (string, error) {
+	form := url.Values{"access_token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to look up the token: %s", resp.Status)
+	}
+	var info struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", fmt.Errorf("failed to read the token info: %w", err)
+	}
+	if info.Email == "" {
+		return "", errors.New("the token does not say which account it belongs to")
+	}
+	return info.Email, nil
+}
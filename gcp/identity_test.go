@@ -0,0 +1,61 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCredentialsEmail(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"service account key", `{"type": "service_account", "client_email": "ci@p.iam.gserviceaccount.com"}`, "ci@p.iam.gserviceaccount.com"},
+		{"impersonation", `{"type": "impersonated_service_account", "service_account_impersonation_url": "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/ops@p.iam.gserviceaccount.com:generateAccessToken"}`, "ops@p.iam.gserviceaccount.com"},
+		{"user", `{"type": "authorized_user", "client_id": "x.apps.googleusercontent.com"}`, ""},
+		{"none", ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := credentialsEmail([]byte(tt.json)); got != tt.want {
+				t.Errorf("credentialsEmail() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenEmail(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "" {
+			t.Errorf("expected no query, got %q", r.URL.RawQuery)
+		}
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "" {
+			t.Errorf("expected the token to be posted as a form value, got %s with Authorization %q", r.Method, r.Header.Get("Authorization"))
+		}
+		switch r.PostFormValue("access_token") {
+		case "user-token":
+			fmt.Fprintln(w, `{"email": "alice@example.com", "scope": "https://www.googleapis.com/auth/cloud-platform"}`)
+		case "anonymous-token":
+			fmt.Fprintln(w, `{"scope": "https://www.googleapis.com/auth/cloud-platform"}`)
+		default:
+			http.Error(w, `{"error": "invalid_token"}`, http.StatusBadRequest)
+		}
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	email, err := tokenEmail(ctx, mockServer.Client(), mockServer.URL, "user-token")
+	if err != nil || email != "alice@example.com" {
+		t.Errorf("expected alice@example.com, got %q (%v)", email, err)
+	}
+	if _, err := tokenEmail(ctx, mockServer.Client(), mockServer.URL, "anonymous-token"); err == nil {
+		t.Error("expected an error for a token without an email")
+	}
+	if _, err := tokenEmail(ctx, mockServer.Client(), mockServer.URL, "expired"); err == nil {
+		t.Error("expected an error for a rejected token")
+	}
+}
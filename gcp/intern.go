@@ -0,0 +1,74 @@
+package gcp
+
+import "strings"
+
+// stringPool deduplicates the strings of listed instances. Zones, machine
+// types, labels and metadata such as startup scripts repeat across a fleet,
+// and names cut from API resource URLs would otherwise keep the whole URL in
+// memory for as long as the instance is listed.
+type stringPool map[string]string
+
+// intern returns the pooled copy of s, adding a copy that shares no memory
+// with s if there is none yet.
+func (p stringPool) intern(s string) string {
+	if s == "" {
+		return ""
+	}
+	if pooled, ok := p[s]; ok {
+		return pooled
+	}
+	s = strings.Clone(s)
+	p[s] = s
+	return s
+}
+
+// internInstance replaces the repeated strings of vm with their pooled copies.
+// The labels, metadata, tags and resource policies are copied so that vm
+// shares nothing with the API response it was converted from.
+func (p stringPool) internInstance(vm *Instance) {
+	for _, s := range []*string{
+		&vm.Zone, &vm.Status, &vm.MachineType, &vm.Network, &vm.Subnetwork,
+		&vm.Image, &vm.BootLicense, &vm.OnHostMaintenance, &vm.ProvisioningModel,
+	} {
+		*s = p.intern(*s)
+	}
+	vm.Labels = p.internMap(vm.Labels)
+	vm.Metadata = p.internMap(vm.Metadata)
+	vm.Tags = p.internSlice(vm.Tags)
+	vm.ResourcePolicies = p.internSlice(vm.ResourcePolicies)
+	if vm.Maintenance != nil {
+		mt := *vm.Maintenance
+		mt.Type, mt.Status = p.intern(mt.Type), p.intern(mt.Status)
+		vm.Maintenance = &mt
+	}
+	if vm.ReservationAffinity != nil {
+		ra := *vm.ReservationAffinity
+		ra.Type, ra.Key = p.intern(ra.Type), p.intern(ra.Key)
+		ra.Values = p.internSlice(ra.Values)
+		vm.ReservationAffinity = &ra
+	}
+}
+
+// internSlice returns a copy of s with pooled strings.
+func (p stringPool) internSlice(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[i] = p.intern(v)
+	}
+	return out
+}
+
+// internMap returns a copy of m with pooled keys and values.
+func (p stringPool) internMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[p.intern(k)] = p.intern(v)
+	}
+	return out
+}
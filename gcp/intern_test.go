@@ -0,0 +1,57 @@
+package gcp
+
+import (
+	"testing"
+	"unsafe"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestInstanceCollector_SharesRepeatedStrings(t *testing.T) {
+	zone := "https://www.googleapis.com/compute/v1/projects/proj/zones/us-central1-a"
+	script := "#!/bin/sh\napt-get install -y nginx\n"
+	newProto := func(name string) *computepb.Instance {
+		return &computepb.Instance{
+			Name:        proto.String(name),
+			Zone:        proto.String(zone),
+			MachineType: proto.String(zone + "/machineTypes/e2-small"),
+			Labels:      map[string]string{"env": "prod"},
+			Tags:        &computepb.Tags{Items: []string{"http-server"}},
+			Metadata: &computepb.Metadata{Items: []*computepb.Items{
+				{Key: proto.String("startup-script"), Value: proto.String(string([]byte(script)))},
+			}},
+		}
+	}
+	page := []*computepb.Instance{newProto("web-1"), newProto("web-2")}
+	labels := page[0].Labels
+
+	col := &instanceCollector{projectID: "proj"}
+	col.add(page)
+
+	if page[0] != nil || page[1] != nil {
+		t.Error("expected the converted API instances to be released")
+	}
+	a, b := col.list.Instances[0], col.list.Instances[1]
+	if a.Zone != "us-central1-a" || a.MachineType != "e2-small" || a.Metadata["startup-script"] != script {
+		t.Fatalf("unexpected instance: %+v", a)
+	}
+	for field, pair := range map[string][2]string{
+		"zone":           {a.Zone, b.Zone},
+		"machine type":   {a.MachineType, b.MachineType},
+		"label":          {a.Labels["env"], b.Labels["env"]},
+		"tag":            {a.Tags[0], b.Tags[0]},
+		"startup script": {a.Metadata["startup-script"], b.Metadata["startup-script"]},
+	} {
+		if unsafe.StringData(pair[0]) != unsafe.StringData(pair[1]) {
+			t.Errorf("expected the %s of both instances to share memory", field)
+		}
+	}
+	if unsafe.StringData(a.Zone) == unsafe.StringData(zone[len(zone)-len(a.Zone):]) {
+		t.Error("expected the zone not to keep the URL it was cut from")
+	}
+	labels["env"] = "dev"
+	if a.Labels["env"] != "prod" {
+		t.Error("expected the labels to be copied from the API instance")
+	}
+}
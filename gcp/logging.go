@@ -0,0 +1,74 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+	logging "google.golang.org/api/logging/v2"
+)
+
+// ErrLoggingDisabled is returned when the Cloud Logging API is not enabled on the project.
+var ErrLoggingDisabled = errors.New("the Cloud Logging API is not enabled for this project")
+
+// LogEntry holds the essential information for a Cloud Logging entry.
+type LogEntry struct {
+	Timestamp string
+	Severity  string
+	Message   string
+}
+
+// FetchLogs retrieves the most recent log entries for a VM instance, newest first.
+func (c *realClient) FetchLogs(ctx context.Context, projectID, instanceID string, limit int) ([]LogEntry, error) {
+	req := &logging.ListLogEntriesRequest{
+		ResourceNames: []string{"projects/" + projectID},
+		Filter:        fmt.Sprintf(`resource.type="gce_instance" AND resource.labels.instance_id="%s"`, instanceID),
+		OrderBy:       "timestamp desc",
+		PageSize:      int64(limit),
+	}
+	resp, err := c.loggingService.Entries.List(req).Context(ctx).Do()
+	if err != nil {
+		if isServiceDisabled(err) {
+			return nil, ErrLoggingDisabled
+		}
+		return nil, fmt.Errorf("failed to list log entries: %w", err)
+	}
+	entries := make([]LogEntry, 0, len(resp.Entries))
+	for _, e := range resp.Entries {
+		entries = append(entries, LogEntry{
+			Timestamp: e.Timestamp,
+			Severity:  e.Severity,
+			Message:   logMessage(e),
+		})
+	}
+	return entries, nil
+}
+
+// logMessage returns the most readable payload of a log entry.
+func logMessage(e *logging.LogEntry) string {
+	switch {
+	case e.TextPayload != "":
+		return e.TextPayload
+	case len(e.JsonPayload) > 0:
+		return string(e.JsonPayload)
+	default:
+		return string(e.ProtoPayload)
+	}
+}
+
+// isServiceDisabled reports whether err indicates that the called API has not
+// been enabled on the project.
+func isServiceDisabled(err error) bool {
+	var gErr *googleapi.Error
+	if !errors.As(err, &gErr) || gErr.Code != 403 {
+		return false
+	}
+	for _, item := range gErr.Errors {
+		if item.Reason == "accessNotConfigured" {
+			return true
+		}
+	}
+	return strings.Contains(gErr.Message, "SERVICE_DISABLED") || strings.Contains(gErr.Body, "SERVICE_DISABLED")
+}
@@ -0,0 +1,116 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+)
+
+// MachineTypeTTL is how long a cached machine type spec is trusted. Specs of
+// a machine type practically never change, so it is long.
+const MachineTypeTTL = 30 * 24 * time.Hour
+
+// MachineTypeSpec is the size of a machine type.
+type MachineTypeSpec struct {
+	CPUs     int `json:"cpus"`
+	MemoryMB int `json:"memory_mb"`
+}
+
+// CachedMachineType is a machine type spec with when it was fetched.
+type CachedMachineType struct {
+	Spec    MachineTypeSpec `json:"spec"`
+	Fetched time.Time       `json:"fetched"`
+}
+
+// MachineTypeGetter fetches the spec of a machine type in a zone.
+type MachineTypeGetter interface {
+	GetMachineType(ctx context.Context, projectID, zone, machineType string) (MachineTypeSpec, error)
+}
+
+// GetMachineType fetches the number of vCPUs and the memory of a machine
+// type, which may be a custom one such as "custom-2-4096".
+func (c *realClient) GetMachineType(ctx context.Context, projectID, zone, machineType string) (MachineTypeSpec, error) {
+	mt, err := c.machineTypesClient.Get(ctx, &computepb.GetMachineTypeRequest{
+		Project:     projectID,
+		Zone:        zone,
+		MachineType: machineType,
+	})
+	if err != nil {
+		return MachineTypeSpec{}, fmt.Errorf("failed to get machine type %s in %s: %w", machineType, zone, err)
+	}
+	return MachineTypeSpec{CPUs: int(mt.GetGuestCpus()), MemoryMB: int(mt.GetMemoryMb())}, nil
+}
+
+// MachineTypeCache remembers machine type specs, keyed by zone and type, so
+// that instances sharing a type cost one API call. Nothing is evicted during
+// a session; entries older than MachineTypeTTL are fetched again. It is safe
+// for concurrent use.
+type MachineTypeCache struct {
+	mu    sync.Mutex
+	specs map[string]CachedMachineType
+	now   func() time.Time
+}
+
+// NewMachineTypeCache returns an empty cache.
+func NewMachineTypeCache() *MachineTypeCache {
+	return &MachineTypeCache{specs: make(map[string]CachedMachineType), now: time.Now}
+}
+
+// machineTypeKey identifies a machine type in the cache. Machine types are
+// the same in every project, so the project is left out.
+func machineTypeKey(zone, machineType string) string {
+	return zone + "/" + machineType
+}
+
+// Lookup returns the cached spec of the machine type, if it is fresh.
+func (c *MachineTypeCache) Lookup(zone, machineType string) (MachineTypeSpec, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.specs[machineTypeKey(zone, machineType)]
+	if !ok || c.now().Sub(e.Fetched) > MachineTypeTTL {
+		return MachineTypeSpec{}, false
+	}
+	return e.Spec, true
+}
+
+// Spec returns the spec of the machine type, fetching it with getter unless
+// it is cached. Failures are not cached.
+func (c *MachineTypeCache) Spec(ctx context.Context, getter MachineTypeGetter, projectID, zone, machineType string) (MachineTypeSpec, error) {
+	if spec, ok := c.Lookup(zone, machineType); ok {
+		return spec, nil
+	}
+	spec, err := getter.GetMachineType(ctx, projectID, zone, machineType)
+	if err != nil {
+		return MachineTypeSpec{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.specs[machineTypeKey(zone, machineType)] = CachedMachineType{Spec: spec, Fetched: c.now()}
+	return spec, nil
+}
+
+// Entries returns a copy of the cached specs, e.g. to persist them.
+func (c *MachineTypeCache) Entries() map[string]CachedMachineType {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make(map[string]CachedMachineType, len(c.specs))
+	for k, e := range c.specs {
+		entries[k] = e
+	}
+	return entries
+}
+
+// Restore adds previously persisted entries that are still fresh, keeping
+// those fetched since.
+func (c *MachineTypeCache) Restore(entries map[string]CachedMachineType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range entries {
+		if _, ok := c.specs[k]; !ok && c.now().Sub(e.Fetched) <= MachineTypeTTL {
+			c.specs[k] = e
+		}
+	}
+}
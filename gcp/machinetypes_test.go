@@ -0,0 +1,115 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+)
+
+// countingGetter returns specs from a table and counts the calls per type.
+type countingGetter struct {
+	specs map[string]MachineTypeSpec
+	calls map[string]int
+}
+
+func (g *countingGetter) GetMachineType(ctx context.Context, projectID, zone, machineType string) (MachineTypeSpec, error) {
+	g.calls[machineType]++
+	spec, ok := g.specs[machineType]
+	if !ok {
+		return MachineTypeSpec{}, errors.New("not found")
+	}
+	return spec, nil
+}
+
+func TestMachineTypeCache_HitAndMiss(t *testing.T) {
+	getter := &countingGetter{
+		specs: map[string]MachineTypeSpec{"e2-small": {CPUs: 2, MemoryMB: 2048}},
+		calls: make(map[string]int),
+	}
+	c := NewMachineTypeCache()
+	ctx := context.Background()
+
+	for range 3 {
+		spec, err := c.Spec(ctx, getter, "p", "z-1", "e2-small")
+		if err != nil {
+			t.Fatalf("Spec() returned an unexpected error: %v", err)
+		}
+		if spec != (MachineTypeSpec{CPUs: 2, MemoryMB: 2048}) {
+			t.Errorf("unexpected spec %+v", spec)
+		}
+	}
+	if getter.calls["e2-small"] != 1 {
+		t.Errorf("expected one fetch for a shared type, got %d", getter.calls["e2-small"])
+	}
+
+	if _, err := c.Spec(ctx, getter, "p", "z-2", "e2-small"); err != nil {
+		t.Fatal(err)
+	}
+	if getter.calls["e2-small"] != 2 {
+		t.Errorf("another zone should be a miss, got %d fetches", getter.calls["e2-small"])
+	}
+
+	for range 2 {
+		if _, err := c.Spec(ctx, getter, "p", "z-1", "n9-huge"); err == nil {
+			t.Error("expected an error for an unknown type")
+		}
+	}
+	if getter.calls["n9-huge"] != 2 {
+		t.Errorf("failures should not be cached, got %d fetches", getter.calls["n9-huge"])
+	}
+}
+
+func TestMachineTypeCache_TTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewMachineTypeCache()
+	c.now = func() time.Time { return now }
+	c.Restore(map[string]CachedMachineType{
+		"z-1/e2-small":  {Spec: MachineTypeSpec{CPUs: 2}, Fetched: now.Add(-time.Hour)},
+		"z-1/e2-medium": {Spec: MachineTypeSpec{CPUs: 2}, Fetched: now.Add(-MachineTypeTTL - time.Hour)},
+	})
+	if _, ok := c.Lookup("z-1", "e2-small"); !ok {
+		t.Error("a fresh persisted entry should be restored")
+	}
+	if _, ok := c.Lookup("z-1", "e2-medium"); ok {
+		t.Error("a stale persisted entry should be dropped")
+	}
+	if len(c.Entries()) != 1 {
+		t.Errorf("unexpected entries %v", c.Entries())
+	}
+
+	now = now.Add(MachineTypeTTL)
+	if _, ok := c.Lookup("z-1", "e2-small"); ok {
+		t.Error("an entry should expire after the TTL")
+	}
+}
+
+func TestGetMachineType_WithMockServer(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/compute/v1/projects/test-project/zones/us-central1-a/machineTypes/custom-2-4096" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `{"name": "custom-2-4096", "guestCpus": 2, "memoryMb": 4096}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	spec, err := client.GetMachineType(ctx, "test-project", "us-central1-a", "custom-2-4096")
+	if err != nil {
+		t.Fatalf("GetMachineType() returned an unexpected error: %v", err)
+	}
+	if spec != (MachineTypeSpec{CPUs: 2, MemoryMB: 4096}) {
+		t.Errorf("unexpected spec %+v", spec)
+	}
+}
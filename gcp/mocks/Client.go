@@ -28,16 +28,16 @@ func (_m *Client) Close() error {
 	return r0
 }
 
-// FetchInstances provides a mock function with given fields: ctx, projectID
-func (_m *Client) FetchInstances(ctx context.Context, projectID string) ([]gcp.Instance, error) {
+// FetchFirewallRules provides a mock function with given fields: ctx, projectID
+func (_m *Client) FetchFirewallRules(ctx context.Context, projectID string) ([]gcp.FirewallRule, error) {
 	ret := _m.Called(ctx, projectID)
 
-	var r0 []gcp.Instance
-	if rf, ok := ret.Get(0).(func(context.Context, string) []gcp.Instance); ok {
+	var r0 []gcp.FirewallRule
+	if rf, ok := ret.Get(0).(func(context.Context, string) []gcp.FirewallRule); ok {
 		r0 = rf(ctx, projectID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]gcp.Instance)
+			r0 = ret.Get(0).([]gcp.FirewallRule)
 		}
 	}
 
@@ -49,4 +49,252 @@ func (_m *Client) FetchInstances(ctx context.Context, projectID string) ([]gcp.I
 	}
 
 	return r0, r1
-}
\ No newline at end of file
+}
+
+// FetchInstances provides a mock function with given fields: ctx, projectID, opts
+func (_m *Client) FetchInstances(ctx context.Context, projectID string, opts gcp.FetchOptions) (gcp.InstanceList, error) {
+	ret := _m.Called(ctx, projectID, opts)
+
+	var r0 gcp.InstanceList
+	if rf, ok := ret.Get(0).(func(context.Context, string, gcp.FetchOptions) gcp.InstanceList); ok {
+		r0 = rf(ctx, projectID, opts)
+	} else {
+		r0 = ret.Get(0).(gcp.InstanceList)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, gcp.FetchOptions) error); ok {
+		r1 = rf(ctx, projectID, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FetchLogs provides a mock function with given fields: ctx, projectID, instanceID, limit
+func (_m *Client) FetchLogs(ctx context.Context, projectID string, instanceID string, limit int) ([]gcp.LogEntry, error) {
+	ret := _m.Called(ctx, projectID, instanceID, limit)
+
+	var r0 []gcp.LogEntry
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) []gcp.LogEntry); ok {
+		r0 = rf(ctx, projectID, instanceID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]gcp.LogEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, projectID, instanceID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInstance provides a mock function with given fields: ctx, projectID, zone, name
+func (_m *Client) GetInstance(ctx context.Context, projectID string, zone string, name string) (gcp.Instance, error) {
+	ret := _m.Called(ctx, projectID, zone, name)
+
+	var r0 gcp.Instance
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) gcp.Instance); ok {
+		r0 = rf(ctx, projectID, zone, name)
+	} else {
+		r0 = ret.Get(0).(gcp.Instance)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, projectID, zone, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInstanceIAM provides a mock function with given fields: ctx, projectID, zone, name
+func (_m *Client) GetInstanceIAM(ctx context.Context, projectID string, zone string, name string) ([]gcp.IAMBinding, error) {
+	ret := _m.Called(ctx, projectID, zone, name)
+
+	var r0 []gcp.IAMBinding
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) []gcp.IAMBinding); ok {
+		r0 = rf(ctx, projectID, zone, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]gcp.IAMBinding)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, projectID, zone, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TestInstancePermissions provides a mock function with given fields: ctx, projectID, zone, name, permissions
+func (_m *Client) TestInstancePermissions(ctx context.Context, projectID string, zone string, name string, permissions []string) ([]string, error) {
+	ret := _m.Called(ctx, projectID, zone, name, permissions)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []string) []string); ok {
+		r0 = rf(ctx, projectID, zone, name, permissions)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, []string) error); ok {
+		r1 = rf(ctx, projectID, zone, name, permissions)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMachineType provides a mock function with given fields: ctx, projectID, zone, machineType
+func (_m *Client) GetMachineType(ctx context.Context, projectID string, zone string, machineType string) (gcp.MachineTypeSpec, error) {
+	ret := _m.Called(ctx, projectID, zone, machineType)
+
+	var r0 gcp.MachineTypeSpec
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) gcp.MachineTypeSpec); ok {
+		r0 = rf(ctx, projectID, zone, machineType)
+	} else {
+		r0 = ret.Get(0).(gcp.MachineTypeSpec)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, projectID, zone, machineType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RegionQuotas provides a mock function with given fields: ctx, projectID, region
+func (_m *Client) RegionQuotas(ctx context.Context, projectID string, region string) ([]gcp.Quota, error) {
+	ret := _m.Called(ctx, projectID, region)
+
+	var r0 []gcp.Quota
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []gcp.Quota); ok {
+		r0 = rf(ctx, projectID, region)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]gcp.Quota)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, projectID, region)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListZones provides a mock function with given fields: ctx, projectID
+func (_m *Client) ListZones(ctx context.Context, projectID string) ([]string, error) {
+	ret := _m.Called(ctx, projectID)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, projectID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, projectID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResumeInstance provides a mock function with given fields: ctx, projectID, zone, name
+func (_m *Client) ResumeInstance(ctx context.Context, projectID string, zone string, name string) error {
+	ret := _m.Called(ctx, projectID, zone, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, projectID, zone, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetMachineType provides a mock function with given fields: ctx, projectID, zone, name, machineType
+func (_m *Client) SetMachineType(ctx context.Context, projectID string, zone string, name string, machineType string) error {
+	ret := _m.Called(ctx, projectID, zone, name, machineType)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) error); ok {
+		r0 = rf(ctx, projectID, zone, name, machineType)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetDeletionProtection provides a mock function with given fields: ctx, projectID, zone, name, enabled
+func (_m *Client) SetDeletionProtection(ctx context.Context, projectID string, zone string, name string, enabled bool) error {
+	ret := _m.Called(ctx, projectID, zone, name, enabled)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, bool) error); ok {
+		r0 = rf(ctx, projectID, zone, name, enabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StartInstance provides a mock function with given fields: ctx, projectID, zone, name
+func (_m *Client) StartInstance(ctx context.Context, projectID string, zone string, name string) error {
+	ret := _m.Called(ctx, projectID, zone, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, projectID, zone, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SuspendInstance provides a mock function with given fields: ctx, projectID, zone, name
+func (_m *Client) SuspendInstance(ctx context.Context, projectID string, zone string, name string) error {
+	ret := _m.Called(ctx, projectID, zone, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, projectID, zone, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
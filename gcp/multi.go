@@ -0,0 +1,93 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// InstanceFetcher lists the instances of a single project.
+type InstanceFetcher interface {
+	FetchInstances(ctx context.Context, projectID string, opts FetchOptions) (InstanceList, error)
+}
+
+// ProjectError records a project whose instances could not be listed.
+type ProjectError struct {
+	ProjectID string
+	Err       error
+}
+
+func (e ProjectError) Error() string { return fmt.Sprintf("%s: %v", e.ProjectID, e.Err) }
+
+func (e ProjectError) Unwrap() error { return e.Err }
+
+// FetchInstancesMulti lists the instances of several projects concurrently
+// and merges them in project order, or nearest to opts.HomeRegion first if
+// set, keeping at most opts.MaxResults across all projects. Projects that
+// fail are reported in ProjectErrors; an error is only returned if every
+// project failed.
+// opts.Progress is called with the total across all projects, and at most
+// opts.Concurrency projects are listed at once.
+func FetchInstancesMulti(ctx context.Context, f InstanceFetcher, projectIDs []string, opts FetchOptions) (InstanceList, error) {
+	lists := make([]InstanceList, len(projectIDs))
+	errs := make([]error, len(projectIDs))
+
+	var mu sync.Mutex
+	loaded := make([]int, len(projectIDs))
+	var g errgroup.Group
+	limit := opts.Concurrency
+	if limit <= 0 {
+		limit = runtime.GOMAXPROCS(0)
+	}
+	g.SetLimit(limit)
+	for i, projectID := range projectIDs {
+		projectOpts := opts
+		if opts.Progress != nil {
+			projectOpts.Progress = func(n int) {
+				mu.Lock()
+				defer mu.Unlock()
+				loaded[i] = n
+				total := 0
+				for _, l := range loaded {
+					total += l
+				}
+				opts.Progress(total)
+			}
+		}
+		g.Go(func() error {
+			lists[i], errs[i] = f.FetchInstances(ctx, projectID, projectOpts)
+			// Failures are collected per project rather than cancelling the others.
+			return nil
+		})
+	}
+	g.Wait()
+
+	var merged InstanceList
+	for i, projectID := range projectIDs {
+		if errs[i] != nil {
+			merged.ProjectErrors = append(merged.ProjectErrors, ProjectError{ProjectID: projectID, Err: errs[i]})
+			continue
+		}
+		merged.Instances = append(merged.Instances, lists[i].Instances...)
+		merged.ZoneErrors = append(merged.ZoneErrors, lists[i].ZoneErrors...)
+		merged.SkippedZoneWarnings += lists[i].SkippedZoneWarnings
+		merged.Truncated = merged.Truncated || lists[i].Truncated
+	}
+	if len(projectIDs) > 0 && len(merged.ProjectErrors) == len(projectIDs) {
+		failed := make([]error, len(merged.ProjectErrors))
+		for i, pe := range merged.ProjectErrors {
+			failed[i] = pe
+		}
+		return InstanceList{}, fmt.Errorf("failed to list instances in every project: %w", errors.Join(failed...))
+	}
+	merged = orderInstances(merged, opts)
+	if opts.MaxResults > 0 && len(merged.Instances) > opts.MaxResults {
+		merged.Instances = merged.Instances[:opts.MaxResults]
+		merged.Truncated = true
+	}
+	return merged, nil
+}
@@ -0,0 +1,165 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFetcher returns canned results per project.
+type fakeFetcher map[string]InstanceList
+
+func (f fakeFetcher) FetchInstances(ctx context.Context, projectID string, opts FetchOptions) (InstanceList, error) {
+	list, ok := f[projectID]
+	if !ok {
+		return InstanceList{}, errors.New("permission denied")
+	}
+	return list, nil
+}
+
+// countingFetcher records how many fetches run at the same time.
+type countingFetcher struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (f *countingFetcher) FetchInstances(ctx context.Context, projectID string, opts FetchOptions) (InstanceList, error) {
+	f.mu.Lock()
+	f.running++
+	f.peak = max(f.peak, f.running)
+	f.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	f.mu.Lock()
+	f.running--
+	f.mu.Unlock()
+	return InstanceList{Instances: []Instance{{ProjectID: projectID}}}, nil
+}
+
+func TestFetchInstancesMulti_Concurrency(t *testing.T) {
+	projects := []string{"p1", "p2", "p3", "p4", "p5", "p6", "p7", "p8"}
+	for _, limit := range []int{1, 3} {
+		f := &countingFetcher{}
+		list, err := FetchInstancesMulti(context.Background(), f, projects, FetchOptions{Concurrency: limit})
+		if err != nil {
+			t.Fatalf("FetchInstancesMulti() returned an unexpected error: %v", err)
+		}
+		if len(list.Instances) != len(projects) {
+			t.Errorf("expected every project to be listed, got %d instances", len(list.Instances))
+		}
+		if f.peak > limit {
+			t.Errorf("with a limit of %d, %d fetches ran at once", limit, f.peak)
+		}
+	}
+}
+
+func TestFetchInstancesMulti_MergesInProjectOrder(t *testing.T) {
+	f := fakeFetcher{
+		"proj-a": {Instances: []Instance{{ProjectID: "proj-a", Name: "a-1"}}},
+		"proj-b": {Instances: []Instance{{ProjectID: "proj-b", Name: "b-1"}, {ProjectID: "proj-b", Name: "b-2"}}, Truncated: true},
+	}
+
+	list, err := FetchInstancesMulti(context.Background(), f, []string{"proj-b", "proj-a"}, FetchOptions{})
+	if err != nil {
+		t.Fatalf("FetchInstancesMulti() returned an unexpected error: %v", err)
+	}
+	want := []Instance{{ProjectID: "proj-b", Name: "b-1"}, {ProjectID: "proj-b", Name: "b-2"}, {ProjectID: "proj-a", Name: "a-1"}}
+	if !reflect.DeepEqual(list.Instances, want) {
+		t.Errorf("expected %v, got %v", want, list.Instances)
+	}
+	if !list.Truncated {
+		t.Error("expected truncation of any project to be reported")
+	}
+}
+
+func TestFetchInstancesMulti_MaxResults(t *testing.T) {
+	f := fakeFetcher{
+		"proj-a": {Instances: []Instance{{ProjectID: "proj-a", Name: "a-1", Zone: "us-central1-a"}, {ProjectID: "proj-a", Name: "a-2", Zone: "europe-west1-b"}}},
+		"proj-b": {Instances: []Instance{{ProjectID: "proj-b", Name: "b-1", Zone: "europe-west2-a"}, {ProjectID: "proj-b", Name: "b-2", Zone: "asia-east1-a"}}},
+	}
+
+	list, err := FetchInstancesMulti(context.Background(), f, []string{"proj-a", "proj-b"}, FetchOptions{MaxResults: 3})
+	if err != nil {
+		t.Fatalf("FetchInstancesMulti() returned an unexpected error: %v", err)
+	}
+	if got := instanceNames(list.Instances); !reflect.DeepEqual(got, []string{"a-1", "a-2", "b-1"}) {
+		t.Errorf("expected the first 3 instances across the projects, got %v", got)
+	}
+	if !list.Truncated {
+		t.Error("expected the cut to be reported as truncation")
+	}
+
+	list, err = FetchInstancesMulti(context.Background(), f, []string{"proj-a", "proj-b"}, FetchOptions{MaxResults: 2, HomeRegion: "europe-west2"})
+	if err != nil {
+		t.Fatalf("FetchInstancesMulti() returned an unexpected error: %v", err)
+	}
+	if got := instanceNames(list.Instances); !reflect.DeepEqual(got, []string{"b-1", "a-2"}) {
+		t.Errorf("expected the 2 instances nearest to the home region across the projects, got %v", got)
+	}
+	if !list.Truncated {
+		t.Error("expected the cut to be reported as truncation")
+	}
+
+	list, err = FetchInstancesMulti(context.Background(), f, []string{"proj-a", "proj-b"}, FetchOptions{MaxResults: 4})
+	if err != nil {
+		t.Fatalf("FetchInstancesMulti() returned an unexpected error: %v", err)
+	}
+	if len(list.Instances) != 4 || list.Truncated {
+		t.Errorf("expected all 4 instances without truncation, got %d (truncated %v)", len(list.Instances), list.Truncated)
+	}
+}
+
+func TestFetchInstancesMulti_PartialFailure(t *testing.T) {
+	f := fakeFetcher{"proj-a": {Instances: []Instance{{ProjectID: "proj-a", Name: "a-1"}}}}
+
+	list, err := FetchInstancesMulti(context.Background(), f, []string{"proj-a", "proj-x"}, FetchOptions{})
+	if err != nil {
+		t.Fatalf("FetchInstancesMulti() returned an unexpected error: %v", err)
+	}
+	if len(list.Instances) != 1 {
+		t.Errorf("expected the healthy project's instances, got %v", list.Instances)
+	}
+	if len(list.ProjectErrors) != 1 || list.ProjectErrors[0].ProjectID != "proj-x" {
+		t.Errorf("expected proj-x to be reported as failed, got %v", list.ProjectErrors)
+	}
+}
+
+func TestFetchInstancesMulti_AllFail(t *testing.T) {
+	_, err := FetchInstancesMulti(context.Background(), fakeFetcher{}, []string{"proj-x", "proj-y"}, FetchOptions{})
+	if err == nil {
+		t.Fatal("expected an error when every project fails")
+	}
+}
+
+// progressFetcher reports every project as having loaded n instances.
+type progressFetcher int
+
+func (n progressFetcher) FetchInstances(ctx context.Context, projectID string, opts FetchOptions) (InstanceList, error) {
+	opts.Progress(int(n))
+	return InstanceList{}, nil
+}
+
+func TestFetchInstancesMulti_ProgressTotals(t *testing.T) {
+	var last int
+	opts := FetchOptions{Progress: func(loaded int) { last = max(last, loaded) }}
+	if _, err := FetchInstancesMulti(context.Background(), progressFetcher(5), []string{"a", "b", "c"}, opts); err != nil {
+		t.Fatalf("FetchInstancesMulti() returned an unexpected error: %v", err)
+	}
+	if last != 15 {
+		t.Errorf("expected progress to reach 15, got %d", last)
+	}
+}
+
+// instanceNames returns the names of vms, in order.
+func instanceNames(vms []Instance) []string {
+	var out []string
+	for _, vm := range vms {
+		out = append(out, vm.Name)
+	}
+	return out
+}
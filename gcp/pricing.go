@@ -0,0 +1,97 @@
+package gcp
+
+import "sort"
+
+// defaultHourlyPrices are rough on-demand prices in USD per hour for common
+// machine types in us-central1. They are meant for ballpark estimates only.
+var defaultHourlyPrices = map[string]float64{
+	"f1-micro":       0.0076,
+	"g1-small":       0.0257,
+	"e2-micro":       0.0084,
+	"e2-small":       0.0168,
+	"e2-medium":      0.0335,
+	"e2-standard-2":  0.0670,
+	"e2-standard-4":  0.1340,
+	"e2-standard-8":  0.2681,
+	"e2-standard-16": 0.5361,
+	"n1-standard-1":  0.0475,
+	"n1-standard-2":  0.0950,
+	"n1-standard-4":  0.1900,
+	"n1-standard-8":  0.3800,
+	"n2-standard-2":  0.0971,
+	"n2-standard-4":  0.1942,
+	"n2-standard-8":  0.3885,
+	"n2d-standard-2": 0.0845,
+	"n2d-standard-4": 0.1690,
+	"c2-standard-4":  0.2088,
+	"c2-standard-8":  0.4176,
+	"t2d-standard-1": 0.0422,
+}
+
+// HourlyPrices returns the bundled price table with overrides applied, e.g.
+// for custom machine types or committed-use discounts.
+func HourlyPrices(overrides map[string]float64) map[string]float64 {
+	prices := make(map[string]float64, len(defaultHourlyPrices)+len(overrides))
+	for machineType, price := range defaultHourlyPrices {
+		prices[machineType] = price
+	}
+	for machineType, price := range overrides {
+		prices[machineType] = price
+	}
+	return prices
+}
+
+// MachineTypeSummary aggregates the instances sharing a machine type.
+type MachineTypeSummary struct {
+	MachineType string
+	Count       int
+	Running     int
+	// HourlyCost is the estimated cost of the running instances. It is only
+	// meaningful when Priced is true.
+	HourlyCost float64
+	Priced     bool
+}
+
+// SummarizeByMachineType groups instances by machine type, ordered by count
+// and then name. Only running instances contribute to the cost estimate.
+func SummarizeByMachineType(vms []Instance, prices map[string]float64) []MachineTypeSummary {
+	byType := make(map[string]*MachineTypeSummary)
+	for _, vm := range vms {
+		s, ok := byType[vm.MachineType]
+		if !ok {
+			_, priced := prices[vm.MachineType]
+			s = &MachineTypeSummary{MachineType: vm.MachineType, Priced: priced}
+			byType[vm.MachineType] = s
+		}
+		s.Count++
+		if vm.Status == "RUNNING" {
+			s.Running++
+			s.HourlyCost += prices[vm.MachineType]
+		}
+	}
+
+	summaries := make([]MachineTypeSummary, 0, len(byType))
+	for _, s := range byType {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Count != summaries[j].Count {
+			return summaries[i].Count > summaries[j].Count
+		}
+		return summaries[i].MachineType < summaries[j].MachineType
+	})
+	return summaries
+}
+
+// TotalHourlyCost sums the estimated cost of the priced summaries and reports
+// how many running instances could not be priced.
+func TotalHourlyCost(summaries []MachineTypeSummary) (total float64, unpriced int) {
+	for _, s := range summaries {
+		if s.Priced {
+			total += s.HourlyCost
+		} else {
+			unpriced += s.Running
+		}
+	}
+	return total, unpriced
+}
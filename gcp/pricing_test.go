@@ -0,0 +1,55 @@
+package gcp
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestHourlyPrices_Overrides(t *testing.T) {
+	prices := HourlyPrices(map[string]float64{"e2-micro": 0.001, "custom-4-8192": 0.2})
+	if prices["e2-micro"] != 0.001 {
+		t.Errorf("expected the override to win, got %v", prices["e2-micro"])
+	}
+	if prices["custom-4-8192"] != 0.2 {
+		t.Errorf("expected the custom type to be added, got %v", prices["custom-4-8192"])
+	}
+	if prices["e2-small"] != defaultHourlyPrices["e2-small"] {
+		t.Errorf("expected untouched defaults to remain, got %v", prices["e2-small"])
+	}
+}
+
+func TestSummarizeByMachineType(t *testing.T) {
+	vms := []Instance{
+		{Name: "a", MachineType: "e2-small", Status: "RUNNING"},
+		{Name: "b", MachineType: "e2-small", Status: "TERMINATED"},
+		{Name: "c", MachineType: "e2-small", Status: "RUNNING"},
+		{Name: "d", MachineType: "n1-standard-1", Status: "RUNNING"},
+		{Name: "e", MachineType: "custom-2-4096", Status: "RUNNING"},
+	}
+	prices := map[string]float64{"e2-small": 0.5, "n1-standard-1": 1}
+
+	got := SummarizeByMachineType(vms, prices)
+	want := []MachineTypeSummary{
+		{MachineType: "e2-small", Count: 3, Running: 2, HourlyCost: 1, Priced: true},
+		{MachineType: "custom-2-4096", Count: 1, Running: 1, Priced: false},
+		{MachineType: "n1-standard-1", Count: 1, Running: 1, HourlyCost: 1, Priced: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SummarizeByMachineType() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	total, unpriced := TotalHourlyCost(got)
+	if math.Abs(total-2) > 1e-9 {
+		t.Errorf("expected a total of 2, got %v", total)
+	}
+	if unpriced != 1 {
+		t.Errorf("expected 1 unpriced running instance, got %d", unpriced)
+	}
+}
+
+func TestSummarizeByMachineType_Empty(t *testing.T) {
+	if got := SummarizeByMachineType(nil, HourlyPrices(nil)); len(got) != 0 {
+		t.Errorf("expected no summaries, got %+v", got)
+	}
+}
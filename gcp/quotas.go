@@ -0,0 +1,67 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+)
+
+// ErrQuotasForbidden is returned when the caller may not view the quotas of
+// a region.
+var ErrQuotasForbidden = errors.New("not allowed to view the quotas of this region")
+
+// Quota is the usage of a regional quota, e.g. of the CPUS metric.
+type Quota struct {
+	Metric string
+	Usage  float64
+	Limit  float64
+}
+
+// commonQuotas are the quota metrics that most often keep instances from
+// starting. They are reported even when unused.
+var commonQuotas = []string{"CPUS", "PREEMPTIBLE_CPUS", "INSTANCES", "IN_USE_ADDRESSES"}
+
+// relevantQuota reports whether q limits starting instances: a common
+// metric, or the CPUs of a machine family or GPUs that the project may use.
+func relevantQuota(q Quota) bool {
+	if slices.Contains(commonQuotas, q.Metric) {
+		return true
+	}
+	return (strings.HasSuffix(q.Metric, "_CPUS") || strings.HasSuffix(q.Metric, "_GPUS")) && (q.Limit > 0 || q.Usage > 0)
+}
+
+// RegionQuotas returns the CPU, GPU and instance quotas of a region, common
+// metrics first. Quotas of machine families and GPUs the project cannot use
+// are left out.
+func (c *realClient) RegionQuotas(ctx context.Context, projectID, region string) ([]Quota, error) {
+	r, err := c.regionsClient.Get(ctx, &computepb.GetRegionRequest{Project: projectID, Region: region})
+	if err != nil {
+		if isForbidden(err) {
+			return nil, ErrQuotasForbidden
+		}
+		return nil, fmt.Errorf("failed to get region %s: %w", region, err)
+	}
+	var quotas []Quota
+	for _, q := range r.GetQuotas() {
+		quota := Quota{Metric: q.GetMetric(), Usage: q.GetUsage(), Limit: q.GetLimit()}
+		if relevantQuota(quota) {
+			quotas = append(quotas, quota)
+		}
+	}
+	slices.SortStableFunc(quotas, func(a, b Quota) int {
+		return quotaRank(a.Metric) - quotaRank(b.Metric)
+	})
+	return quotas, nil
+}
+
+// quotaRank orders the common metrics first, in the order of commonQuotas.
+func quotaRank(metric string) int {
+	if i := slices.Index(commonQuotas, metric); i >= 0 {
+		return i
+	}
+	return len(commonQuotas)
+}
@@ -0,0 +1,70 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestRegionQuotas_WithMockServer(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/compute/v1/projects/test-project/regions/us-central1" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		fmt.Fprintln(w, `{"name": "us-central1", "quotas": [
+			{"metric": "N2_CPUS", "limit": 24, "usage": 8},
+			{"metric": "INSTANCES", "limit": 6000, "usage": 3},
+			{"metric": "C2_CPUS", "limit": 0, "usage": 0},
+			{"metric": "CPUS", "limit": 24, "usage": 12},
+			{"metric": "NVIDIA_T4_GPUS", "limit": 4},
+			{"metric": "NVIDIA_A100_GPUS"},
+			{"metric": "SNAPSHOTS", "limit": 5000, "usage": 10}
+		]}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	quotas, err := client.RegionQuotas(ctx, "test-project", "us-central1")
+	if err != nil {
+		t.Fatalf("RegionQuotas() returned an unexpected error: %v", err)
+	}
+	want := []Quota{
+		{Metric: "CPUS", Usage: 12, Limit: 24},
+		{Metric: "INSTANCES", Usage: 3, Limit: 6000},
+		{Metric: "N2_CPUS", Usage: 8, Limit: 24},
+		{Metric: "NVIDIA_T4_GPUS", Limit: 4},
+	}
+	if !reflect.DeepEqual(quotas, want) {
+		t.Errorf("expected %+v, got %+v", want, quotas)
+	}
+}
+
+func TestRegionQuotas_Forbidden(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintln(w, `{"error": {"code": 403, "message": "Required 'compute.regions.get' permission"}}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	if _, err := client.RegionQuotas(ctx, "test-project", "us-central1"); !errors.Is(err, ErrQuotasForbidden) {
+		t.Fatalf("expected ErrQuotasForbidden, got %v", err)
+	}
+}
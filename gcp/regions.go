@@ -0,0 +1,249 @@
+package gcp
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Region describes where a Compute Engine region is located.
+type Region struct {
+	Name     string
+	Location string
+	Lat, Lon float64
+}
+
+// regions is a static table of Compute Engine regions. It is data only, so
+// new regions can be added without touching any logic.
+var regions = []Region{
+	{"africa-south1", "Johannesburg, South Africa", -26.20, 28.05},
+	{"asia-east1", "Changhua County, Taiwan", 24.05, 120.52},
+	{"asia-east2", "Hong Kong", 22.32, 114.17},
+	{"asia-northeast1", "Tokyo, Japan", 35.68, 139.69},
+	{"asia-northeast2", "Osaka, Japan", 34.69, 135.50},
+	{"asia-northeast3", "Seoul, South Korea", 37.57, 126.98},
+	{"asia-south1", "Mumbai, India", 19.08, 72.88},
+	{"asia-south2", "Delhi, India", 28.70, 77.10},
+	{"asia-southeast1", "Jurong West, Singapore", 1.35, 103.82},
+	{"asia-southeast2", "Jakarta, Indonesia", -6.21, 106.85},
+	{"australia-southeast1", "Sydney, Australia", -33.87, 151.21},
+	{"australia-southeast2", "Melbourne, Australia", -37.81, 144.96},
+	{"europe-central2", "Warsaw, Poland", 52.23, 21.01},
+	{"europe-north1", "Hamina, Finland", 60.57, 27.20},
+	{"europe-southwest1", "Madrid, Spain", 40.42, -3.70},
+	{"europe-west1", "St. Ghislain, Belgium", 50.45, 3.82},
+	{"europe-west2", "London, England", 51.51, -0.13},
+	{"europe-west3", "Frankfurt, Germany", 50.11, 8.68},
+	{"europe-west4", "Eemshaven, Netherlands", 53.44, 6.83},
+	{"europe-west6", "Zurich, Switzerland", 47.38, 8.54},
+	{"europe-west8", "Milan, Italy", 45.46, 9.19},
+	{"europe-west9", "Paris, France", 48.86, 2.35},
+	{"europe-west10", "Berlin, Germany", 52.52, 13.40},
+	{"europe-west12", "Turin, Italy", 45.07, 7.69},
+	{"me-central1", "Doha, Qatar", 25.29, 51.53},
+	{"me-central2", "Dammam, Saudi Arabia", 26.43, 50.10},
+	{"me-west1", "Tel Aviv, Israel", 32.09, 34.78},
+	{"northamerica-northeast1", "Montréal, Québec", 45.50, -73.57},
+	{"northamerica-northeast2", "Toronto, Ontario", 43.65, -79.38},
+	{"southamerica-east1", "Osasco, São Paulo, Brazil", -23.53, -46.79},
+	{"southamerica-west1", "Santiago, Chile", -33.45, -70.67},
+	{"us-central1", "Council Bluffs, Iowa", 41.26, -95.86},
+	{"us-east1", "Moncks Corner, South Carolina", 33.20, -80.01},
+	{"us-east4", "Ashburn, Virginia", 39.04, -77.49},
+	{"us-east5", "Columbus, Ohio", 39.96, -83.00},
+	{"us-south1", "Dallas, Texas", 32.78, -96.80},
+	{"us-west1", "The Dalles, Oregon", 45.59, -121.18},
+	{"us-west2", "Los Angeles, California", 34.05, -118.24},
+	{"us-west3", "Salt Lake City, Utah", 40.76, -111.89},
+	{"us-west4", "Las Vegas, Nevada", 36.17, -115.14},
+}
+
+// regionZones lists the zone suffixes of the regions whose zones are not
+// the usual a, b and c.
+var regionZones = map[string]string{
+	"europe-west1": "bcd",
+	"us-central1":  "abcf",
+	"us-east1":     "bcd",
+}
+
+// Regions returns the known regions.
+func Regions() []Region {
+	return slices.Clone(regions)
+}
+
+// RegionZones returns the zones of a known region, e.g. "us-east1-b",
+// "us-east1-c" and "us-east1-d" for "us-east1".
+func RegionZones(region string) []string {
+	suffixes, ok := regionZones[region]
+	if !ok {
+		suffixes = "abc"
+	}
+	zones := make([]string, len(suffixes))
+	for i, suffix := range suffixes {
+		zones[i] = region + "-" + string(suffix)
+	}
+	return zones
+}
+
+// LookupRegion returns the region with the given name.
+func LookupRegion(name string) (Region, bool) {
+	for _, r := range regions {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Region{}, false
+}
+
+// ZoneRegion returns the region a zone belongs to, e.g. "us-central1" for
+// "us-central1-a".
+func ZoneRegion(zone string) string {
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		return zone[:i]
+	}
+	return zone
+}
+
+// Short codes for the parts of a region name, used to abbreviate zones.
+var (
+	geoCodes = map[string]string{
+		"africa":       "af",
+		"asia":         "as",
+		"australia":    "au",
+		"europe":       "eu",
+		"me":           "me",
+		"northamerica": "na",
+		"southamerica": "sa",
+		"us":           "us",
+	}
+	directionCodes = map[string]string{
+		"central":   "c",
+		"east":      "e",
+		"north":     "n",
+		"northeast": "ne",
+		"northwest": "nw",
+		"south":     "s",
+		"southeast": "se",
+		"southwest": "sw",
+		"west":      "w",
+	}
+)
+
+// AbbreviateZone shortens a zone in a known region, e.g. "us-central1-a" to
+// "usc1-a". Other zones are returned unchanged. ExpandZone reverses it.
+func AbbreviateZone(zone string) string {
+	region := ZoneRegion(zone)
+	if _, ok := LookupRegion(region); !ok {
+		return zone
+	}
+	geo, rest, ok := strings.Cut(region, "-")
+	if !ok {
+		return zone
+	}
+	i := strings.IndexAny(rest, "0123456789")
+	if i <= 0 {
+		return zone
+	}
+	g, gok := geoCodes[geo]
+	d, dok := directionCodes[rest[:i]]
+	if !gok || !dok {
+		return zone
+	}
+	return g + d + rest[i:] + zone[len(region):]
+}
+
+// ExpandZone returns the full name of a zone abbreviated by AbbreviateZone.
+// Anything else is returned unchanged.
+func ExpandZone(abbr string) string {
+	short := ZoneRegion(abbr)
+	if len(short) < 3 {
+		return abbr
+	}
+	i := strings.IndexAny(short, "0123456789")
+	if i <= 2 {
+		return abbr
+	}
+	geo, ok := codeName(geoCodes, short[:2])
+	if !ok {
+		return abbr
+	}
+	dir, ok := codeName(directionCodes, short[2:i])
+	if !ok {
+		return abbr
+	}
+	region := geo + "-" + dir + short[i:]
+	if _, ok := LookupRegion(region); !ok {
+		return abbr
+	}
+	return region + abbr[len(short):]
+}
+
+// codeName returns the name a short code stands for.
+func codeName(codes map[string]string, code string) (string, bool) {
+	for name, c := range codes {
+		if c == code {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// OrderZones returns the zones sorted by distance from homeRegion, nearest
+// first. Zones in unknown regions come last; ties are broken by name. The
+// input is not modified.
+func OrderZones(zones []string, homeRegion string) []string {
+	ordered := append([]string(nil), zones...)
+	home, ok := LookupRegion(homeRegion)
+	if !ok {
+		sort.Strings(ordered)
+		return ordered
+	}
+	dist := make(map[string]float64, len(ordered))
+	for _, z := range ordered {
+		if r, ok := LookupRegion(ZoneRegion(z)); ok {
+			dist[z] = distanceKm(home, r)
+		} else {
+			dist[z] = math.Inf(1)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		di, dj := dist[ordered[i]], dist[ordered[j]]
+		if di != dj {
+			return di < dj
+		}
+		return ordered[i] < ordered[j]
+	})
+	return ordered
+}
+
+// SortByProximity orders instances so that those in zones nearest to
+// homeRegion come first, keeping the existing order within a zone.
+func SortByProximity(vms []Instance, homeRegion string) {
+	var zones []string
+	seen := make(map[string]bool)
+	for _, vm := range vms {
+		if !seen[vm.Zone] {
+			seen[vm.Zone] = true
+			zones = append(zones, vm.Zone)
+		}
+	}
+	rank := make(map[string]int, len(zones))
+	for i, z := range OrderZones(zones, homeRegion) {
+		rank[z] = i
+	}
+	sort.SliceStable(vms, func(i, j int) bool {
+		return rank[vms[i].Zone] < rank[vms[j].Zone]
+	})
+}
+
+// distanceKm returns the great-circle distance between two regions.
+func distanceKm(a, b Region) float64 {
+	const earthRadiusKm = 6371
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(b.Lat - a.Lat)
+	dLon := toRad(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,107 @@
+package gcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestZoneRegion(t *testing.T) {
+	if got := ZoneRegion("us-central1-a"); got != "us-central1" {
+		t.Errorf("ZoneRegion() = %q, want us-central1", got)
+	}
+	if got := ZoneRegion("local"); got != "local" {
+		t.Errorf("ZoneRegion() = %q, want the input for malformed zones", got)
+	}
+}
+
+func TestRegionZones(t *testing.T) {
+	if got, want := RegionZones("us-east1"), []string{"us-east1-b", "us-east1-c", "us-east1-d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RegionZones() = %v, want %v", got, want)
+	}
+	if got, want := RegionZones("asia-east1"), []string{"asia-east1-a", "asia-east1-b", "asia-east1-c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RegionZones() = %v, want %v", got, want)
+	}
+	for region := range regionZones {
+		if _, ok := LookupRegion(region); !ok {
+			t.Errorf("zones are listed for %s, which is not in the region table", region)
+		}
+	}
+}
+
+func TestOrderZones(t *testing.T) {
+	zones := []string{"us-central1-a", "asia-east1-b", "mars-north1-a", "europe-west3-a", "europe-west1-c", "europe-west1-b"}
+
+	got := OrderZones(zones, "europe-west1")
+	want := []string{"europe-west1-b", "europe-west1-c", "europe-west3-a", "us-central1-a", "asia-east1-b", "mars-north1-a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OrderZones() = %v, want %v", got, want)
+	}
+	if zones[0] != "us-central1-a" {
+		t.Error("OrderZones() must not modify its input")
+	}
+}
+
+func TestOrderZones_UnknownHome(t *testing.T) {
+	got := OrderZones([]string{"us-east1-b", "asia-east1-a"}, "")
+	want := []string{"asia-east1-a", "us-east1-b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OrderZones() = %v, want alphabetical order %v", got, want)
+	}
+}
+
+func TestSortByProximity(t *testing.T) {
+	vms := []Instance{
+		{Name: "tokyo", Zone: "asia-northeast1-a"},
+		{Name: "iowa-1", Zone: "us-central1-a"},
+		{Name: "oregon", Zone: "us-west1-b"},
+		{Name: "iowa-2", Zone: "us-central1-a"},
+	}
+	SortByProximity(vms, "us-west1")
+
+	var names []string
+	for _, vm := range vms {
+		names = append(names, vm.Name)
+	}
+	want := []string{"oregon", "iowa-1", "iowa-2", "tokyo"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("SortByProximity() order = %v, want %v", names, want)
+	}
+}
+
+func TestAbbreviateZone(t *testing.T) {
+	tests := map[string]string{
+		"us-central1-a":             "usc1-a",
+		"europe-west1-b":            "euw1-b",
+		"asia-northeast3-c":         "asne3-c",
+		"northamerica-northeast2-a": "nane2-a",
+		"mars-central1-a":           "mars-central1-a",
+		"us-central9-a":             "us-central9-a",
+		"":                          "",
+	}
+	for zone, want := range tests {
+		if got := AbbreviateZone(zone); got != want {
+			t.Errorf("AbbreviateZone(%q) = %q, want %q", zone, got, want)
+		}
+	}
+}
+
+func TestAbbreviateZone_RoundTrips(t *testing.T) {
+	seen := make(map[string]string)
+	for _, r := range regions {
+		zone := r.Name + "-a"
+		abbr := AbbreviateZone(zone)
+		if abbr == zone {
+			t.Errorf("%s was not abbreviated", zone)
+		}
+		if other, dup := seen[abbr]; dup {
+			t.Errorf("%s and %s both abbreviate to %s", other, zone, abbr)
+		}
+		seen[abbr] = zone
+		if got := ExpandZone(abbr); got != zone {
+			t.Errorf("ExpandZone(%q) = %q, want %q", abbr, got, zone)
+		}
+	}
+	if got := ExpandZone("us-central1-a"); got != "us-central1-a" {
+		t.Errorf("ExpandZone should leave full zones unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,76 @@
+package gcp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Statuses are the states an instance can be in, in lifecycle order.
+var Statuses = []string{
+	"PROVISIONING", "STAGING", "RUNNING", "STOPPING", "STOPPED",
+	"SUSPENDING", "SUSPENDED", "REPAIRING", "TERMINATED", "DEPROVISIONING",
+}
+
+// StatusClass groups the states of an instance by what can be done with it.
+type StatusClass int
+
+const (
+	// StatusUnknown is a state this version does not know about.
+	StatusUnknown StatusClass = iota
+	// StatusRunning can be connected to.
+	StatusRunning
+	// StatusStopped is at rest and can be started or resumed.
+	StatusStopped
+	// StatusTransitional is on its way between states, such as while it is
+	// being created or repaired, and should be waited for before acting on
+	// it.
+	StatusTransitional
+)
+
+// ClassifyStatus returns the class of an instance status.
+func ClassifyStatus(status string) StatusClass {
+	switch status {
+	case "RUNNING":
+		return StatusRunning
+	case "TERMINATED", "STOPPED", "SUSPENDED":
+		return StatusStopped
+	case "PROVISIONING", "STAGING", "STOPPING", "SUSPENDING", "REPAIRING", "DEPROVISIONING":
+		return StatusTransitional
+	default:
+		return StatusUnknown
+	}
+}
+
+// ParseStatuses parses a comma-separated list of statuses, such as
+// "RUNNING,STAGING", in any case. An empty list returns nil, selecting every
+// status.
+func ParseStatuses(s string) ([]string, error) {
+	var statuses []string
+	for _, status := range strings.Split(s, ",") {
+		status = strings.ToUpper(strings.TrimSpace(status))
+		if status == "" {
+			continue
+		}
+		if !slices.Contains(Statuses, status) {
+			return nil, fmt.Errorf("unknown status %q, must be one of %s", status, strings.Join(Statuses, ", "))
+		}
+		if !slices.Contains(statuses, status) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// statusFilter returns the list filter matching the instances in any of
+// statuses, or "" to match every instance.
+func statusFilter(statuses []string) string {
+	if len(statuses) == 1 {
+		return fmt.Sprintf("status = %q", statuses[0])
+	}
+	terms := make([]string, len(statuses))
+	for i, s := range statuses {
+		terms[i] = fmt.Sprintf("(status = %q)", s)
+	}
+	return strings.Join(terms, " OR ")
+}
@@ -0,0 +1,98 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestParseStatuses(t *testing.T) {
+	statuses, err := ParseStatuses("running, Staging,RUNNING")
+	if err != nil {
+		t.Fatalf("ParseStatuses() returned an unexpected error: %v", err)
+	}
+	if want := []string{"RUNNING", "STAGING"}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("expected %v, got %v", want, statuses)
+	}
+	if statuses, err := ParseStatuses(""); err != nil || statuses != nil {
+		t.Errorf("an empty list should select every status, got %v, %v", statuses, err)
+	}
+	if _, err := ParseStatuses("RUNNING,ASLEEP"); err == nil {
+		t.Error("expected an error for an unknown status")
+	}
+}
+
+func TestClassifyStatus(t *testing.T) {
+	want := map[string]StatusClass{
+		"PROVISIONING":   StatusTransitional,
+		"STAGING":        StatusTransitional,
+		"RUNNING":        StatusRunning,
+		"STOPPING":       StatusTransitional,
+		"STOPPED":        StatusStopped,
+		"SUSPENDING":     StatusTransitional,
+		"SUSPENDED":      StatusStopped,
+		"REPAIRING":      StatusTransitional,
+		"TERMINATED":     StatusStopped,
+		"DEPROVISIONING": StatusTransitional,
+	}
+	if len(want) != len(Statuses) {
+		t.Fatalf("expected every one of %v to be classified", Statuses)
+	}
+	for _, status := range Statuses {
+		if got := ClassifyStatus(status); got != want[status] {
+			t.Errorf("ClassifyStatus(%q) = %d, expected %d", status, got, want[status])
+		}
+	}
+	for _, status := range []string{"", "running", "HIBERNATING"} {
+		if got := ClassifyStatus(status); got != StatusUnknown {
+			t.Errorf("ClassifyStatus(%q) = %d, expected it to be unknown", status, got)
+		}
+	}
+}
+
+func TestStatusFilter(t *testing.T) {
+	for _, s := range Statuses {
+		if got, want := statusFilter([]string{s}), fmt.Sprintf(`status = "%s"`, s); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+	if got, want := statusFilter([]string{"RUNNING", "STAGING"}), `(status = "RUNNING") OR (status = "STAGING")`; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got := statusFilter(nil); got != "" {
+		t.Errorf("expected no filter without statuses, got %q", got)
+	}
+}
+
+func TestFetchInstances_Statuses(t *testing.T) {
+	var filters []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filters = append(filters, r.URL.Query().Get("filter"))
+		fmt.Fprintln(w, `{}`)
+	}))
+	defer mockServer.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, option.WithEndpoint(mockServer.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+
+	for _, opts := range []FetchOptions{
+		{Statuses: []string{"RUNNING"}},
+		{Statuses: []string{"RUNNING"}, Zones: []string{"us-central1-a"}},
+		{},
+	} {
+		if _, err := client.FetchInstances(ctx, "test-project", opts); err != nil {
+			t.Fatalf("FetchInstances() returned an unexpected error: %v", err)
+		}
+	}
+	if want := []string{`status = "RUNNING"`, `status = "RUNNING"`, ""}; !reflect.DeepEqual(filters, want) {
+		t.Errorf("expected filters %q, got %q", want, filters)
+	}
+}
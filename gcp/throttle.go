@@ -0,0 +1,108 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// maxRateLimitRetries is how many times a rate-limited listing is started
+// over before its error is returned.
+const maxRateLimitRetries = 5
+
+// maxBackoff caps how long a rate-limited listing waits before retrying.
+const maxBackoff = time.Minute
+
+// throttle pauses every API listing of a client while the API is rate
+// limiting it, so that concurrent fetches back off together instead of each
+// hammering the API on its own. It is safe for concurrent use.
+type throttle struct {
+	// base is the first backoff when the API does not say how long to wait.
+	// It doubles with every retry.
+	base time.Duration
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+// wait blocks until any backoff in progress has passed or ctx is done.
+func (t *throttle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	d := time.Until(t.until)
+	t.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backoff pauses calls after the attempt-th retry of a rate-limited call,
+// for retryAfter if the API asked for it, and returns how long it pauses.
+func (t *throttle) backoff(retryAfter time.Duration, attempt int) time.Duration {
+	d := retryAfter
+	if d <= 0 {
+		d = min(t.base<<attempt, maxBackoff)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(d); until.After(t.until) {
+		t.until = until
+	}
+	return d
+}
+
+// do runs call, starting it over after backing off while the API rate
+// limits it. throttled, if set, is told how long each backoff lasts.
+func (t *throttle) do(ctx context.Context, throttled func(wait time.Duration), call func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(ctx); err != nil {
+			return err
+		}
+		err := call()
+		retryAfter, limited := rateLimited(err)
+		if !limited || attempt == maxRateLimitRetries {
+			return err
+		}
+		d := t.backoff(retryAfter, attempt)
+		if throttled != nil {
+			throttled(d)
+		}
+	}
+}
+
+// rateLimited reports whether err is a rate-limit (HTTP 429) response from
+// the API, along with the wait its Retry-After header asks for, if any.
+func rateLimited(err error) (time.Duration, bool) {
+	var gErr *googleapi.Error
+	if !errors.As(err, &gErr) || gErr.Code != http.StatusTooManyRequests {
+		return 0, false
+	}
+	return parseRetryAfter(gErr.Header.Get("Retry-After"), time.Now()), true
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or as
+// an HTTP date. It returns zero if the header is missing or invalid.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,118 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"-3", 0},
+		{"Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second},
+		{"Wed, 01 May 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// rateLimitedServer answers the first limited requests with 429 and then
+// with a single instance.
+func rateLimitedServer(t *testing.T, limited int32) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= limited {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprintln(w, `{"error": {"code": 429, "message": "Quota exceeded"}}`)
+			return
+		}
+		if r.URL.Path == "/compute/v1/projects/test-project/zones/us-central1-a/instances" {
+			fmt.Fprintln(w, `{"items": [{"name": "instance-1", "zone": "zones/us-central1-a"}]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"items": {"zones/us-central1-a": {"instances": [{"name": "instance-1", "zone": "zones/us-central1-a"}]}}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newThrottledClient(t *testing.T, url string) Client {
+	client, err := NewClient(context.Background(), option.WithEndpoint(url), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create client for test: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	client.(*realClient).throttle.base = time.Millisecond
+	return client
+}
+
+func TestFetchInstances_BacksOffWhenRateLimited(t *testing.T) {
+	for _, zones := range [][]string{nil, {"us-central1-a"}} {
+		server, calls := rateLimitedServer(t, 2)
+		client := newThrottledClient(t, server.URL)
+
+		var waits []time.Duration
+		opts := FetchOptions{Zones: zones, Throttled: func(wait time.Duration) { waits = append(waits, wait) }}
+		list, err := client.FetchInstances(context.Background(), "test-project", opts)
+		if err != nil {
+			t.Fatalf("FetchInstances(zones=%v) returned an unexpected error: %v", zones, err)
+		}
+		if len(list.Instances) != 1 || len(list.ZoneErrors) != 0 {
+			t.Errorf("expected the listing to succeed after backing off, got %+v", list)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("expected 3 requests, got %d", calls.Load())
+		}
+		if len(waits) != 2 || waits[0] != time.Millisecond || waits[1] != 2*time.Millisecond {
+			t.Errorf("expected exponential backoff to be reported, got %v", waits)
+		}
+	}
+}
+
+func TestFetchInstances_GivesUpWhenStillRateLimited(t *testing.T) {
+	server, calls := rateLimitedServer(t, 100)
+	client := newThrottledClient(t, server.URL)
+
+	if _, err := client.FetchInstances(context.Background(), "test-project", FetchOptions{}); err == nil {
+		t.Fatal("expected an error once the retries were exhausted")
+	}
+	if got := calls.Load(); got != maxRateLimitRetries+1 {
+		t.Errorf("expected %d requests, got %d", maxRateLimitRetries+1, got)
+	}
+}
+
+func TestThrottle_SharedBackoff(t *testing.T) {
+	th := &throttle{base: time.Second}
+	th.backoff(50*time.Millisecond, 0)
+
+	start := time.Now()
+	if err := th.wait(context.Background()); err != nil {
+		t.Fatalf("wait() returned an unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected other callers to wait out the backoff, waited %v", elapsed)
+	}
+
+	th.backoff(time.Hour, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := th.wait(ctx); err == nil {
+		t.Error("expected wait() to stop when the context is done")
+	}
+}
@@ -1,37 +1,380 @@
 // Package main is the entry point for the gcp-rider application.
+//
+// Besides its flags, gcp-rider reads these environment variables:
+//
+//	GCP_PROJECT_ID       project to show when no projects are configured
+//	GCP_RIDER_CONFIG     path of the config file
+//	GCP_RIDER_CACHE_DIR  directory for cached state such as pins
+//	GCP_RIDER_ENDPOINT   URL of a fake Compute and Logging server to use
+//	                     instead of Google Cloud, without credentials; meant
+//	                     for local development, e.g. http://localhost:8080
+//	GCP_SSH_USER         user to log in as over SSH; when unset, it is the
+//	                     local user if the instance's ssh-keys metadata has
+//	                     keys for it, or else guessed from the image, which
+//	                     is a heuristic, and left to gcloud or ssh when there
+//	                     is no good guess
+//	TMUX                 set by tmux; SSH sessions to several marked instances
+//	                     then open in tmux windows of their own
+//
+// With -output, the instances are printed instead of starting the interface,
+// and the exit code tells scripts how it went:
+//
+//	0  every instance was listed, even if there are none
+//	1  some or all instances could not be listed
+//	2  no credentials, or the credentials were rejected
+//	3  invalid flags, config or environment
+//
+// With -output json, each instance is an object with snake_case keys such
+// as "project_id" and "machine_type". Metadata is left out, as it may hold
+// secrets.
+//
+// The interactive mode exits with 1 on any failure.
 package main
 
 import (
+	"bufio"
 	"context"
+	"flag"
 	"fmt"
+	"gcp-rider/cache"
+	"gcp-rider/config"
 	"gcp-rider/gcp"
+	"gcp-rider/metrics"
 	"gcp-rider/tui"
+	"io"
 	"log"
+	"net"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
+	"golang.org/x/oauth2/google"
 )
 
 func main() {
+	colorMode := flag.String("color", "auto", "when to use colors: auto, always or never")
+	maxResults := flag.Int("max-results", 0, "stop after loading this many instances (0 loads all)")
+	projectsFile := flag.String("projects-file", "", "file listing project IDs to show together, one per line")
+	homeRegion := flag.String("home-region", "", "list instances in zones nearest to this region first, e.g. europe-west1")
+	verbose := flag.Bool("verbose", false, "show raw API errors")
+	quiet := flag.Bool("quiet", false, "show just the instance rows, without the header, key help and status lines")
+	dumpAPI := flag.String("dump-api", "", "with -verbose, write the raw API responses of each instance listing to JSON files in this directory, e.g. for bug reports")
+	dumpRedact := flag.Bool("dump-redact", true, "replace metadata values, which may hold secrets, in the -dump-api files")
+	pickZones := flag.Bool("pick-zones", false, "pick the zones to load at startup instead of loading every zone")
+	output := flag.String("output", "", "print the instances as text or json and exit instead of starting the interface")
+	tableStyle := flag.String("table-style", "plain", "table style of -output text: plain, markdown or borders")
+	concurrency := flag.Int("concurrency", runtime.GOMAXPROCS(0), "how many projects to list at once; lower it if listing hits API quotas")
+	fields := flag.String("fields", "all", "with -output, the optional instance fields to populate, comma-separated: network, image, metadata, labels and details; leaving some out speeds up listing huge fleets")
+	sshTo := flag.String("ssh", "", "connect to this instance over SSH as soon as it is found and quit afterwards; NAME, or NAME.ZONE or NAME.ZONE.PROJECT when the name is ambiguous")
+	strict := flag.Bool("strict", false, "fail when any zone cannot be listed instead of showing the instances of the others")
+	skipZoneWarnings := flag.Bool("skip-zone-warnings", false, "do not report zones the listing warns about, for speed")
+	sortBy := flag.String("sort", "", "sort the instances; age lists the newest first")
+	status := flag.String("status", "", "list only the instances in these states, comma-separated, e.g. RUNNING; filtered by the API, which speeds up listing huge fleets")
+	sshArgs := flag.String("ssh-args", "", "extra arguments for gcloud compute ssh, e.g. \"-- -A\" (overrides ssh_args in the config)")
+	sshTransport := flag.String("ssh-transport", "", "how to open SSH sessions: gcloud for gcloud compute ssh, or ssh for plain ssh to the external IP, or through ssh_jump_host (overrides ssh_transport in the config)")
+	metricsFile := flag.String("metrics-file", "", "write metrics of every instance listing to this file in the Prometheus text format, e.g. for the node exporter's textfile collector")
+	metricsPort := flag.Int("metrics-port", 0, "serve metrics of the instance listings in the Prometheus text format on this port, at /metrics, while the interface runs")
+	metricsHost := flag.String("metrics-host", "127.0.0.1", "address to serve -metrics-port on; 0.0.0.0 serves the metrics on every interface")
+	flag.Parse()
+
+	if *output != "" && !slices.Contains(outputFormats, *output) {
+		fmt.Fprintf(os.Stderr, "Error: invalid -output value %q: must be %s.\n", *output, strings.Join(outputFormats, " or "))
+		os.Exit(exitUsage)
+	}
+	if !slices.Contains(tableStyles, *tableStyle) {
+		fmt.Fprintf(os.Stderr, "Error: invalid -table-style value %q: must be %s.\n", *tableStyle, strings.Join(tableStyles, ", "))
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	if *sortBy != "" && !slices.Contains(sortOrders, *sortBy) {
+		fmt.Fprintf(os.Stderr, "Error: invalid -sort value %q: must be %s.\n", *sortBy, strings.Join(sortOrders, ", "))
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	if *sshTransport != "" && !slices.Contains(config.SSHTransports, *sshTransport) {
+		fmt.Fprintf(os.Stderr, "Error: invalid -ssh-transport value %q: must be %s.\n", *sshTransport, strings.Join(config.SSHTransports, " or "))
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	if *maxResults < 0 {
+		fmt.Fprintln(os.Stderr, "Error: -max-results must not be negative.")
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	if *sshTo != "" && *output != "" {
+		fmt.Fprintln(os.Stderr, "Error: -ssh cannot be combined with -output.")
+		os.Exit(exitUsage)
+	}
+	if *metricsPort != 0 && *output != "" {
+		fmt.Fprintln(os.Stderr, "Error: -metrics-port cannot be combined with -output; use -metrics-file.")
+		os.Exit(exitUsage)
+	}
+	if *metricsPort < 0 || *metricsPort > 65535 {
+		fmt.Fprintln(os.Stderr, "Error: -metrics-port must be between 1 and 65535.")
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	if *dumpAPI != "" && !*verbose {
+		fmt.Fprintln(os.Stderr, "Error: -dump-api requires -verbose.")
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	if *concurrency < 1 {
+		fmt.Fprintln(os.Stderr, "Error: -concurrency must be at least 1.")
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	if _, ok := gcp.LookupRegion(*homeRegion); *homeRegion != "" && !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown -home-region %q.\n", *homeRegion)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	fetchFields, err := gcp.ParseFields(*fields)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -fields value: %v.\n", err)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	if fetchFields != nil && *output == "" {
+		fmt.Fprintln(os.Stderr, "Error: -fields requires -output; the interface shows every field.")
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	statuses, err := gcp.ParseStatuses(*status)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -status value: %v.\n", err)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+
+	cfgPath, err := config.Path()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to locate config: %v.\n", err)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v.\n", err)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+
 	projectID := os.Getenv("GCP_PROJECT_ID")
+	projects := []string{projectID}
+	switch {
+	case *projectsFile != "":
+		projects, err = readProjectsFile(*projectsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(*output, exitUsage))
+		}
+		projectID = projects[0]
+	case len(cfg.Projects) > 0:
+		projects = cfg.Projects
+		projectID = projects[0]
+	}
 	if projectID == "" {
-		fmt.Println("Error: GCP_PROJECT_ID environment variable not set.")
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Error: GCP_PROJECT_ID environment variable not set.")
+		os.Exit(exitCode(*output, exitUsage))
+	}
+
+	keys, err := tui.NewKeyMap(cfg.Keys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid key bindings in %s: %v.\n", cfgPath, err)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+
+	color, err := useColor(*colorMode, isatty.IsTerminal(os.Stdout.Fd()), os.Getenv("NO_COLOR") != "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+	theme, err := tui.NewTheme(cfg.Theme, color)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid theme in %s: %v.\n", cfgPath, err)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+
+	spin, err := tui.NewSpinner(cfg.Spinner)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid spinner in %s: %v.\n", cfgPath, err)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+
+	if err := tui.CheckQueries(cfg.Queries); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid saved queries in %s: %v.\n", cfgPath, err)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+
+	cacheDir, err := cache.Dir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to locate cache: %v.\n", err)
+		os.Exit(exitCode(*output, exitUsage))
+	}
+
+	clientOpts := gcp.EndpointOptions(os.Getenv(gcp.EndpointEnv))
+	if clientOpts == nil {
+		if err := checkCredentials(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(*output, exitAuthError))
+		}
 	}
 
 	// Create the real GCP client.
-	gcpClient, err := gcp.NewClient(context.Background())
+	gcpClient, err := gcp.NewClient(context.Background(), clientOpts...)
 	if err != nil {
-		log.Fatalf("Failed to create GCP client: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to create GCP client: %v.\n", err)
+		os.Exit(exitCode(*output, fetchExitCode(err)))
 	}
 	defer gcpClient.Close()
 
+	fetchOpts := gcp.FetchOptions{
+		MaxResults:       *maxResults,
+		HomeRegion:       *homeRegion,
+		Concurrency:      *concurrency,
+		Fields:           fetchFields,
+		SkipZoneWarnings: *skipZoneWarnings,
+		Strict:           *strict,
+		Statuses:         statuses,
+	}
+	if *dumpAPI != "" {
+		fetchOpts.Dump = &gcp.Dumper{Dir: *dumpAPI, Redact: *dumpRedact}
+	}
+	var recorder *metrics.Recorder
+	var observe func(gcp.InstanceList, error, time.Duration)
+	if *metricsFile != "" || *metricsPort != 0 {
+		recorder = &metrics.Recorder{Path: *metricsFile}
+		observe = func(list gcp.InstanceList, err error, took time.Duration) {
+			recorder.Record(metrics.NewFetch(list, err, took))
+		}
+	}
+	if *output != "" {
+		code := writeInstances(context.Background(), gcpClient, projects, fetchOpts, outputOptions{format: *output, tableStyle: *tableStyle, labelColumns: cfg.LabelColumns, sort: *sortBy, observe: observe}, os.Stdout, os.Stderr)
+		if recorder != nil && recorder.Err() != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v.\n", recorder.Err())
+		}
+		gcpClient.Close()
+		os.Exit(code)
+	}
+
+	stopMetrics := func() {}
+	if *metricsPort != 0 {
+		ln, err := net.Listen("tcp", net.JoinHostPort(*metricsHost, strconv.Itoa(*metricsPort)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: cannot serve metrics: %v.\n", err)
+			gcpClient.Close()
+			os.Exit(exitCode(*output, exitUsage))
+		}
+		stopMetrics = metrics.Serve(ln, recorder)
+	}
+
 	// Create the TUI model, injecting the GCP client as a dependency.
-	tuiModel := tui.NewModel(gcpClient, projectID)
+	opts := []tui.Option{
+		tui.WithKeyMap(keys),
+		tui.WithTheme(theme),
+		tui.WithSpinner(spin),
+		tui.WithPrices(gcp.HourlyPrices(cfg.Prices)),
+		tui.WithCacheDir(cacheDir),
+		tui.WithFetchOptions(fetchOpts),
+		tui.WithProjects(projects),
+		tui.WithVerbose(*verbose),
+		tui.WithQuiet(*quiet),
+		tui.WithTmux(os.Getenv("TMUX") != ""),
+		tui.WithConfig(cfgPath, cfg, color),
+		tui.WithZonePicker(*pickZones),
+	}
+	if observe != nil {
+		opts = append(opts, tui.WithFetchObserver(observe))
+	}
+	if *sshArgs != "" {
+		opts = append(opts, tui.WithSSHArgs(strings.Fields(*sshArgs)))
+	}
+	if *sshTransport != "" {
+		opts = append(opts, tui.WithSSHTransport(*sshTransport))
+	}
+	if *sortBy != "" {
+		opts = append(opts, tui.WithSort(*sortBy))
+	}
+	if *sshTo != "" {
+		opts = append(opts, tui.WithSSHTarget(*sshTo))
+	}
+	if clientOpts == nil {
+		opts = append(opts, tui.WithIdentity(gcp.CallerIdentity))
+	}
+	if user := os.Getenv("GCP_SSH_USER"); user != "" {
+		opts = append(opts, tui.WithSSHUser(user))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		opts = append(opts, tui.WithSSHConfigPath(filepath.Join(home, ".ssh", "config")))
+	}
+	tuiModel := tui.NewModel(gcpClient, projectID, opts...)
 
 	// Start the Bubble Tea program.
 	p := tea.NewProgram(tuiModel)
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	stopMetrics()
+	if err != nil {
 		log.Fatalf("Alas, there's been an error: %v", err)
 	}
+	if recorder != nil && recorder.Err() != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v.\n", recorder.Err())
+	}
+	if err := final.(tui.Model).ExitErr(); err != nil {
+		gcpClient.Close()
+		fmt.Fprintf(os.Stderr, "Error: %v.\n", err)
+		os.Exit(1)
+	}
+}
+
+// useColor decides whether to render colors for the given -color mode. In
+// auto mode colors are used only on a terminal and when NO_COLOR is unset.
+func useColor(mode string, isTTY, noColor bool) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		return isTTY && !noColor, nil
+	default:
+		return false, fmt.Errorf("invalid -color value %q: must be auto, always or never", mode)
+	}
+}
+
+// checkCredentials fails early with instructions when no Application Default
+// Credentials are available, instead of letting the first request fail.
+func checkCredentials(ctx context.Context) error {
+	if _, err := google.FindDefaultCredentials(ctx, "https://www.googleapis.com/auth/cloud-platform"); err != nil {
+		return fmt.Errorf("no Google Cloud credentials found (%v).\nLog in with:\n  gcloud auth application-default login", err)
+	}
+	return nil
+}
+
+// readProjectsFile reads the project IDs listed in path.
+func readProjectsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open projects file: %w", err)
+	}
+	defer f.Close()
+	projects, err := parseProjects(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read projects file: %w", err)
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("projects file %s lists no projects", path)
+	}
+	return projects, nil
+}
+
+// parseProjects parses a newline-delimited list of project IDs, skipping
+// blank lines and # comments and dropping duplicates.
+func parseProjects(r io.Reader) ([]string, error) {
+	var projects []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		projects = append(projects, line)
+	}
+	return projects, scanner.Err()
 }
@@ -1,4 +1,249 @@
 package main
 
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/auth"
+	"google.golang.org/api/googleapi"
+)
+
 // Unit testing for fetchInstances is complex due to the nature of the GCP client library.
-// A full integration test against a real GCP project would be the best way to test this functionality.
\ No newline at end of file
+// A full integration test against a real GCP project would be the best way to test this functionality.
+
+func TestUseColor(t *testing.T) {
+	tests := []struct {
+		mode    string
+		isTTY   bool
+		noColor bool
+		want    bool
+	}{
+		{"auto", true, false, true},
+		{"auto", false, false, false},
+		{"auto", true, true, false},
+		{"always", false, true, true},
+		{"never", true, false, false},
+	}
+	for _, tt := range tests {
+		got, err := useColor(tt.mode, tt.isTTY, tt.noColor)
+		if err != nil {
+			t.Fatalf("useColor(%q) returned an unexpected error: %v", tt.mode, err)
+		}
+		if got != tt.want {
+			t.Errorf("useColor(%q, tty=%v, NO_COLOR=%v) = %v, want %v", tt.mode, tt.isTTY, tt.noColor, got, tt.want)
+		}
+	}
+
+	if _, err := useColor("sometimes", true, false); err == nil {
+		t.Error("expected an error for an invalid mode")
+	}
+}
+
+func TestParseProjects(t *testing.T) {
+	input := `
+# production
+prod-eu
+  prod-us   # trailing comment
+
+staging
+prod-eu
+	staging
+`
+	got, err := parseProjects(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseProjects() returned an unexpected error: %v", err)
+	}
+	want := []string{"prod-eu", "prod-us", "staging"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProjects() = %v, want %v", got, want)
+	}
+}
+
+func TestParseProjects_Empty(t *testing.T) {
+	got, err := parseProjects(strings.NewReader("# nothing here\n\n"))
+	if err != nil {
+		t.Fatalf("parseProjects() returned an unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected no projects, got %v", got)
+	}
+}
+
+func TestCheckCredentials(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", filepath.Join(dir, "missing.json"))
+	err := checkCredentials(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "gcloud auth application-default login") {
+		t.Fatalf("expected an error with the login command, got %v", err)
+	}
+
+	path := filepath.Join(dir, "credentials.json")
+	creds := `{"type": "authorized_user", "client_id": "id", "client_secret": "secret", "refresh_token": "token"}`
+	if err := os.WriteFile(path, []byte(creds), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+	if err := checkCredentials(context.Background()); err != nil {
+		t.Fatalf("checkCredentials() returned an unexpected error: %v", err)
+	}
+}
+
+func TestWriteInstances_JSON(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "web-1", Zone: "us-central1-a", Status: "RUNNING"})
+	var out, errOut bytes.Buffer
+	code := writeInstances(context.Background(), client, []string{"proj"}, gcp.FetchOptions{}, outputOptions{format: "json"}, &out, &errOut)
+	if code != exitOK {
+		t.Fatalf("writeInstances() = %d, want %d (stderr: %s)", code, exitOK, errOut.String())
+	}
+	var vms []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &vms); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if len(vms) != 1 || vms[0]["name"] != "web-1" || vms[0]["project_id"] != "proj" {
+		t.Errorf("unexpected instances: %+v", vms)
+	}
+}
+
+func TestWriteInstances_JSONKeys(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{
+		Name: "web-1", Zone: "us-central1-a", Status: "RUNNING",
+		Metadata:    map[string]string{"startup-script": "export TOKEN=secret"},
+		Maintenance: &gcp.Maintenance{Type: "SCHEDULED"},
+	})
+	var out, errOut bytes.Buffer
+	if code := writeInstances(context.Background(), client, []string{"proj"}, gcp.FetchOptions{}, outputOptions{format: "json"}, &out, &errOut); code != exitOK {
+		t.Fatalf("writeInstances() = %d, want %d (stderr: %s)", code, exitOK, errOut.String())
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Errorf("metadata should be left out of the output:\n%s", out.String())
+	}
+	var vms []map[string]json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &vms); err != nil || len(vms) != 1 {
+		t.Fatalf("expected one JSON object, got %v:\n%s", err, out.String())
+	}
+	want := []string{
+		"automatic_restart", "boot_license", "confidential_vm", "created_at", "deletion_protection",
+		"external_ip", "gpus", "hostname", "id", "image", "internal_ip", "labels", "last_start_at",
+		"last_stop_at", "machine_type", "maintenance", "name", "network", "on_host_maintenance",
+		"preemptible", "project_id", "provisioning_model", "reservation_affinity", "resource_policies",
+		"shielded_vm", "status", "subnetwork", "tags", "zone",
+	}
+	if got := slices.Sorted(maps.Keys(vms[0])); !slices.Equal(got, want) {
+		t.Errorf("expected keys %v, got %v", want, got)
+	}
+	var maintenance map[string]json.RawMessage
+	if err := json.Unmarshal(vms[0]["maintenance"], &maintenance); err != nil {
+		t.Fatalf("maintenance is not an object: %v", err)
+	}
+	if got, want := slices.Sorted(maps.Keys(maintenance)), []string{"can_reschedule", "status", "type", "window_end", "window_start"}; !slices.Equal(got, want) {
+		t.Errorf("expected maintenance keys %v, got %v", want, got)
+	}
+}
+
+func TestWriteInstances_Empty(t *testing.T) {
+	var out, errOut bytes.Buffer
+	code := writeInstances(context.Background(), gcptest.NewFakeClient(), []string{"proj"}, gcp.FetchOptions{}, outputOptions{format: "json"}, &out, &errOut)
+	if code != exitOK {
+		t.Fatalf("an empty list should succeed, got exit code %d", code)
+	}
+	if got := strings.TrimSpace(out.String()); got != "[]" {
+		t.Errorf("expected an empty JSON array, got %q", got)
+	}
+}
+
+func TestWriteInstances_Text(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "web-1", Zone: "us-central1-a", Status: "RUNNING", MachineType: "e2-small"})
+	var out, errOut bytes.Buffer
+	if code := writeInstances(context.Background(), client, []string{"proj"}, gcp.FetchOptions{}, outputOptions{format: "text", tableStyle: "plain"}, &out, &errOut); code != exitOK {
+		t.Fatalf("writeInstances() = %d, want %d", code, exitOK)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "PROJECT") || strings.Join(strings.Fields(lines[1]), " ") != "proj us-central1-a web-1 RUNNING e2-small" {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestWriteInstances_LabelColumns(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "web-1", Zone: "us-central1-a", Status: "RUNNING", MachineType: "e2-small", Labels: map[string]string{"team": "shop"}},
+		gcp.Instance{Name: "web-2", Zone: "us-central1-a", Status: "RUNNING", MachineType: "e2-small"},
+	)
+	var out, errOut bytes.Buffer
+	opts := outputOptions{format: "text", tableStyle: "plain", labelColumns: []string{"team"}}
+	if code := writeInstances(context.Background(), client, []string{"proj"}, gcp.FetchOptions{}, opts, &out, &errOut); code != exitOK {
+		t.Fatalf("writeInstances() = %d, want %d", code, exitOK)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "TEAM") || !strings.HasSuffix(lines[1], "shop") || !strings.HasSuffix(lines[2], "-") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestWriteInstances_SortAge(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "old", Zone: "us-central1-a", CreatedAt: "2023-01-01T00:00:00Z"},
+		gcp.Instance{Name: "unknown", Zone: "us-central1-a"},
+		gcp.Instance{Name: "new", Zone: "us-central1-a", CreatedAt: "2024-06-01T00:00:00Z"},
+	)
+	var out, errOut bytes.Buffer
+	opts := outputOptions{format: "text", tableStyle: "plain", sort: "age"}
+	if code := writeInstances(context.Background(), client, []string{"proj"}, gcp.FetchOptions{}, opts, &out, &errOut); code != exitOK {
+		t.Fatalf("writeInstances() = %d, want %d", code, exitOK)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var got []string
+	for _, line := range lines[1:] {
+		got = append(got, strings.Fields(line)[2])
+	}
+	if want := []string{"new", "old", "unknown"}; !slices.Equal(got, want) {
+		t.Errorf("expected instances in order %v, got %v:\n%s", want, got, out.String())
+	}
+}
+
+func TestWriteInstances_ExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"api error", &googleapi.Error{Code: http.StatusInternalServerError}, exitAPIError},
+		{"unauthorized", &googleapi.Error{Code: http.StatusUnauthorized}, exitAuthError},
+		{"forbidden", &googleapi.Error{Code: http.StatusForbidden}, exitAuthError},
+		{"token refresh", &auth.Error{Err: errors.New("invalid_grant")}, exitAuthError},
+		{"compute disabled", &gcp.ComputeDisabledError{ProjectID: "proj", Err: &googleapi.Error{Code: http.StatusForbidden}}, exitAPIError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := gcptest.NewFakeClient()
+			client.SetError("FetchInstances", tt.err)
+			var out, errOut bytes.Buffer
+			if got := writeInstances(context.Background(), client, []string{"proj"}, gcp.FetchOptions{}, outputOptions{format: "json"}, &out, &errOut); got != tt.want {
+				t.Errorf("writeInstances() = %d, want %d", got, tt.want)
+			}
+			if !strings.HasPrefix(errOut.String(), "Error: ") {
+				t.Errorf("expected the error on stderr, got %q", errOut.String())
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if got := exitCode("", exitUsage); got != 1 {
+		t.Errorf("the interactive mode should exit with 1, got %d", got)
+	}
+	if got := exitCode("json", exitUsage); got != exitUsage {
+		t.Errorf("exitCode() = %d, want %d", got, exitUsage)
+	}
+}
@@ -0,0 +1,163 @@
+// Package metrics exposes how the listings of instances went in the
+// Prometheus text format, for tooling that embeds gcp-rider.
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gcp-rider/gcp"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fetch is the outcome of one listing of the instances.
+type Fetch struct {
+	Duration  time.Duration
+	Instances []gcp.Instance
+	// Errors counts the projects and zones that could not be listed, or is
+	// 1 when nothing could be.
+	Errors int
+}
+
+// NewFetch returns the outcome of a listing that returned list and err
+// after taking d.
+func NewFetch(list gcp.InstanceList, err error, d time.Duration) Fetch {
+	f := Fetch{Duration: d, Instances: list.Instances, Errors: len(list.ProjectErrors) + len(list.ZoneErrors) + list.SkippedZoneWarnings}
+	if err != nil {
+		f.Errors++
+	}
+	return f
+}
+
+// Write writes the metrics of f to w in the Prometheus text format. Every
+// known status is counted, even without instances, so that its series does
+// not disappear between listings.
+func Write(w io.Writer, f Fetch) error {
+	counts := make(map[string]int, len(gcp.Statuses))
+	for _, s := range gcp.Statuses {
+		counts[s] = 0
+	}
+	for _, vm := range f.Instances {
+		counts[vm.Status]++
+	}
+	statuses := slices.Clone(gcp.Statuses)
+	var unknown []string
+	for s := range counts {
+		if !slices.Contains(statuses, s) {
+			unknown = append(unknown, s)
+		}
+	}
+	sort.Strings(unknown)
+	statuses = append(statuses, unknown...)
+
+	var b bytes.Buffer
+	gauge(&b, "gcp_rider_fetch_duration_seconds", "How long the last listing of the instances took.")
+	fmt.Fprintf(&b, "gcp_rider_fetch_duration_seconds %g\n", f.Duration.Seconds())
+	gauge(&b, "gcp_rider_instances", "How many instances the last listing found.")
+	fmt.Fprintf(&b, "gcp_rider_instances %d\n", len(f.Instances))
+	gauge(&b, "gcp_rider_instances_by_status", "How many instances the last listing found in each state.")
+	for _, s := range statuses {
+		fmt.Fprintf(&b, "gcp_rider_instances_by_status{status=%q} %d\n", s, counts[s])
+	}
+	gauge(&b, "gcp_rider_fetch_errors", "How many projects or zones the last listing could not list.")
+	fmt.Fprintf(&b, "gcp_rider_fetch_errors %d\n", f.Errors)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// gauge writes the help and type lines of a gauge.
+func gauge(b *bytes.Buffer, name, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// Recorder keeps the metrics of the latest listing, writing them to a file
+// and serving them over HTTP as asked. It is safe for concurrent use.
+type Recorder struct {
+	// Path is the file the metrics are written to after every listing, e.g.
+	// for the textfile collector of the node exporter. None is written
+	// when it is empty.
+	Path string
+
+	mu     sync.Mutex
+	latest *Fetch
+	err    error
+}
+
+// Record keeps f as the latest listing and writes it to Path.
+func (r *Recorder) Record(f Fetch) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latest = &f
+	if r.Path != "" {
+		r.err = writeFile(r.Path, f)
+	}
+}
+
+// Err returns why the metrics of the latest listing could not be written to
+// Path, or nil.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// writeFile writes the metrics of f to path, replacing the file at once so
+// that readers never see it half written.
+func writeFile(path string, f Fetch) error {
+	var b bytes.Buffer
+	if err := Write(&b, f); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP serves the metrics of the latest listing, or 503 Service
+// Unavailable until the first one is done.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r.mu.Lock()
+	latest := r.latest
+	r.mu.Unlock()
+	if latest == nil {
+		http.Error(w, "no listing done yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	Write(w, *latest)
+}
+
+// Serve serves the metrics of r on /metrics from ln until the returned
+// function is called, which shuts the server down.
+func Serve(ln net.Listener, r *Recorder) (shutdown func()) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", r)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}
+}
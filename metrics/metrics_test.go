@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"errors"
+	"gcp-rider/gcp"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleFetch() Fetch {
+	return NewFetch(gcp.InstanceList{
+		Instances: []gcp.Instance{
+			{Name: "web-1", Status: "RUNNING"},
+			{Name: "web-2", Status: "RUNNING"},
+			{Name: "db-1", Status: "TERMINATED"},
+			{Name: "odd-1", Status: "HIBERNATING"},
+		},
+		ZoneErrors: []gcp.ZoneError{{ProjectID: "proj", Zone: "us-east1-b", Err: errors.New("quota exceeded")}},
+	}, nil, 1500*time.Millisecond)
+}
+
+const sampleMetrics = `# HELP gcp_rider_fetch_duration_seconds How long the last listing of the instances took.
+# TYPE gcp_rider_fetch_duration_seconds gauge
+gcp_rider_fetch_duration_seconds 1.5
+# HELP gcp_rider_instances How many instances the last listing found.
+# TYPE gcp_rider_instances gauge
+gcp_rider_instances 4
+# HELP gcp_rider_instances_by_status How many instances the last listing found in each state.
+# TYPE gcp_rider_instances_by_status gauge
+gcp_rider_instances_by_status{status="PROVISIONING"} 0
+gcp_rider_instances_by_status{status="STAGING"} 0
+gcp_rider_instances_by_status{status="RUNNING"} 2
+gcp_rider_instances_by_status{status="STOPPING"} 0
+gcp_rider_instances_by_status{status="STOPPED"} 0
+gcp_rider_instances_by_status{status="SUSPENDING"} 0
+gcp_rider_instances_by_status{status="SUSPENDED"} 0
+gcp_rider_instances_by_status{status="REPAIRING"} 0
+gcp_rider_instances_by_status{status="TERMINATED"} 1
+gcp_rider_instances_by_status{status="DEPROVISIONING"} 0
+gcp_rider_instances_by_status{status="HIBERNATING"} 1
+# HELP gcp_rider_fetch_errors How many projects or zones the last listing could not list.
+# TYPE gcp_rider_fetch_errors gauge
+gcp_rider_fetch_errors 1
+`
+
+func TestWrite(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, sampleFetch()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if got := b.String(); got != sampleMetrics {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, sampleMetrics)
+	}
+}
+
+func TestNewFetch_Errors(t *testing.T) {
+	f := NewFetch(gcp.InstanceList{
+		ProjectErrors: []gcp.ProjectError{{ProjectID: "a", Err: errors.New("denied")}},
+	}, errors.New("every project failed"), time.Second)
+	if f.Errors != 2 {
+		t.Errorf("Errors = %d, want 2", f.Errors)
+	}
+}
+
+func TestRecorder_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gcp-rider.prom")
+	r := &Recorder{Path: path}
+	r.Record(sampleFetch())
+	if err := r.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != sampleMetrics {
+		t.Errorf("file =\n%s\nwant\n%s", data, sampleMetrics)
+	}
+
+	r = &Recorder{Path: filepath.Join(t.TempDir(), "missing", "gcp-rider.prom")}
+	r.Record(sampleFetch())
+	if r.Err() == nil {
+		t.Error("Err() = nil, want an error for a missing directory")
+	}
+}
+
+func TestRecorder_ServeHTTP(t *testing.T) {
+	r := &Recorder{}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status before a listing = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	r.Record(sampleFetch())
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Body.String(); got != sampleMetrics {
+		t.Errorf("body =\n%s\nwant\n%s", got, sampleMetrics)
+	}
+}
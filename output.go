@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/auth"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// Exit codes of the non-interactive mode, so that scripts running with
+// -output can tell failures apart. They are documented in the package comment.
+const (
+	exitOK        = 0
+	exitAPIError  = 1
+	exitAuthError = 2
+	exitUsage     = 3
+)
+
+// exitCode returns code when running non-interactively with -output, and 1
+// otherwise.
+func exitCode(output string, code int) int {
+	if output == "" {
+		return 1
+	}
+	return code
+}
+
+// outputFormats are the values accepted by -output.
+var outputFormats = []string{"text", "json"}
+
+// sortOrders are the values accepted by -sort.
+var sortOrders = []string{"age"}
+
+// outputOptions selects how the instances are printed.
+type outputOptions struct {
+	// format is one of outputFormats.
+	format string
+	// tableStyle is one of tableStyles, used by the text format.
+	tableStyle string
+	// labelColumns are label keys added as columns by the text format.
+	labelColumns []string
+	// sort is one of sortOrders, or empty to keep the listing order.
+	sort string
+	// observe, when set, is told how the listing went, e.g. for metrics.
+	observe func(list gcp.InstanceList, err error, took time.Duration)
+}
+
+// writeInstances lists the instances of projects to w as out selects. Fetch
+// failures are reported to errw, and the returned exit code tells whether
+// the list is complete. Projects or zones that could not be listed still
+// print the instances that could.
+func writeInstances(ctx context.Context, client gcp.InstanceFetcher, projects []string, opts gcp.FetchOptions, out outputOptions, w, errw io.Writer) int {
+	start := time.Now()
+	list, err := gcp.FetchInstancesMulti(ctx, client, projects, opts)
+	if out.observe != nil {
+		out.observe(list, err, time.Since(start))
+	}
+	if err != nil {
+		fmt.Fprintf(errw, "Error: %v\n", err)
+		return fetchExitCode(err)
+	}
+
+	if out.sort == "age" {
+		slices.SortStableFunc(list.Instances, gcp.CompareNewest)
+	}
+	if err := printInstances(w, list.Instances, out); err != nil {
+		fmt.Fprintf(errw, "Error: %v\n", err)
+		return exitAPIError
+	}
+
+	code := exitOK
+	for _, pe := range list.ProjectErrors {
+		fmt.Fprintf(errw, "Error: could not load %s: %v\n", pe.ProjectID, pe.Err)
+		code = max(code, fetchExitCode(pe.Err))
+	}
+	for _, ze := range list.ZoneErrors {
+		fmt.Fprintf(errw, "Error: could not load zone %s of %s: %v\n", ze.Zone, ze.ProjectID, ze.Err)
+		code = max(code, exitAPIError)
+	}
+	if n := list.SkippedZoneWarnings; n > 0 {
+		fmt.Fprintf(errw, "Error: could not load %d zones; run without -skip-zone-warnings to see which\n", n)
+		code = max(code, exitAPIError)
+	}
+	if list.Truncated {
+		fmt.Fprintf(errw, "Warning: list truncated at %d instances\n", len(list.Instances))
+	}
+	return code
+}
+
+// jsonInstance is an instance as printed by -output json. Its keys are part
+// of the interface scripts rely on, so they are spelled out here rather than
+// following gcp.Instance. Metadata is left out, as startup scripts and other
+// entries may hold secrets.
+type jsonInstance struct {
+	ID                  string                   `json:"id"`
+	ProjectID           string                   `json:"project_id"`
+	Name                string                   `json:"name"`
+	Zone                string                   `json:"zone"`
+	Status              string                   `json:"status"`
+	MachineType         string                   `json:"machine_type"`
+	Hostname            string                   `json:"hostname"`
+	Network             string                   `json:"network"`
+	Subnetwork          string                   `json:"subnetwork"`
+	ExternalIP          string                   `json:"external_ip"`
+	InternalIP          string                   `json:"internal_ip"`
+	Image               string                   `json:"image"`
+	BootLicense         string                   `json:"boot_license"`
+	Tags                []string                 `json:"tags"`
+	ResourcePolicies    []string                 `json:"resource_policies"`
+	Labels              map[string]string        `json:"labels"`
+	GPUs                int                      `json:"gpus"`
+	Preemptible         bool                     `json:"preemptible"`
+	DeletionProtection  bool                     `json:"deletion_protection"`
+	ShieldedVM          bool                     `json:"shielded_vm"`
+	ConfidentialVM      bool                     `json:"confidential_vm"`
+	CreatedAt           string                   `json:"created_at"`
+	LastStartAt         string                   `json:"last_start_at"`
+	LastStopAt          string                   `json:"last_stop_at"`
+	AutomaticRestart    bool                     `json:"automatic_restart"`
+	OnHostMaintenance   string                   `json:"on_host_maintenance"`
+	ProvisioningModel   string                   `json:"provisioning_model"`
+	Maintenance         *jsonMaintenance         `json:"maintenance"`
+	ReservationAffinity *jsonReservationAffinity `json:"reservation_affinity"`
+}
+
+// jsonMaintenance is the announced host maintenance of a jsonInstance.
+type jsonMaintenance struct {
+	Type          string `json:"type"`
+	Status        string `json:"status"`
+	WindowStart   string `json:"window_start"`
+	WindowEnd     string `json:"window_end"`
+	CanReschedule bool   `json:"can_reschedule"`
+}
+
+// jsonReservationAffinity is the reservation affinity of a jsonInstance.
+type jsonReservationAffinity struct {
+	Type   string   `json:"type"`
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
+// newJSONInstance returns vm as printed by -output json.
+func newJSONInstance(vm gcp.Instance) jsonInstance {
+	out := jsonInstance{
+		ID:                 vm.ID,
+		ProjectID:          vm.ProjectID,
+		Name:               vm.Name,
+		Zone:               vm.Zone,
+		Status:             vm.Status,
+		MachineType:        vm.MachineType,
+		Hostname:           vm.Hostname,
+		Network:            vm.Network,
+		Subnetwork:         vm.Subnetwork,
+		ExternalIP:         vm.ExternalIP,
+		InternalIP:         vm.InternalIP,
+		Image:              vm.Image,
+		BootLicense:        vm.BootLicense,
+		Tags:               vm.Tags,
+		ResourcePolicies:   vm.ResourcePolicies,
+		Labels:             vm.Labels,
+		GPUs:               vm.GPUs,
+		Preemptible:        vm.Preemptible,
+		DeletionProtection: vm.DeletionProtection,
+		ShieldedVM:         vm.ShieldedVM,
+		ConfidentialVM:     vm.ConfidentialVM,
+		CreatedAt:          vm.CreatedAt,
+		LastStartAt:        vm.LastStartAt,
+		LastStopAt:         vm.LastStopAt,
+		AutomaticRestart:   vm.AutomaticRestart,
+		OnHostMaintenance:  vm.OnHostMaintenance,
+		ProvisioningModel:  vm.ProvisioningModel,
+	}
+	if mt := vm.Maintenance; mt != nil {
+		out.Maintenance = &jsonMaintenance{Type: mt.Type, Status: mt.Status, WindowStart: mt.WindowStart, WindowEnd: mt.WindowEnd, CanReschedule: mt.CanReschedule}
+	}
+	if ra := vm.ReservationAffinity; ra != nil {
+		out.ReservationAffinity = &jsonReservationAffinity{Type: ra.Type, Key: ra.Key, Values: ra.Values}
+	}
+	return out
+}
+
+// printInstances writes vms to w as a table or a JSON array.
+func printInstances(w io.Writer, vms []gcp.Instance, out outputOptions) error {
+	switch out.format {
+	case "json":
+		list := make([]jsonInstance, len(vms))
+		for i, vm := range vms {
+			list[i] = newJSONInstance(vm)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	case "text":
+		header := []string{"PROJECT", "ZONE", "NAME", "STATUS", "MACHINE TYPE"}
+		for _, k := range out.labelColumns {
+			header = append(header, strings.ToUpper(k))
+		}
+		rows := make([][]string, len(vms))
+		for i, vm := range vms {
+			rows[i] = []string{vm.ProjectID, vm.Zone, vm.Name, vm.Status, vm.MachineType}
+			for _, k := range out.labelColumns {
+				rows[i] = append(rows[i], labelOrDash(vm, k))
+			}
+		}
+		return renderTable(w, out.tableStyle, header, rows)
+	default:
+		return fmt.Errorf("unknown output format %q", out.format)
+	}
+}
+
+// labelOrDash returns the value of the label key of vm, or "-" if it has no
+// such label.
+func labelOrDash(vm gcp.Instance, key string) string {
+	if v, ok := vm.Labels[key]; ok && v != "" {
+		return v
+	}
+	return "-"
+}
+
+// fetchExitCode returns the exit code for a failed fetch: exitAuthError when
+// the credentials were rejected, could not be refreshed or lack the
+// permission, exitAPIError otherwise. A disabled Compute API is not a
+// matter of credentials, even though it is reported as forbidden.
+func fetchExitCode(err error) int {
+	var disabled *gcp.ComputeDisabledError
+	if errors.As(err, &disabled) {
+		return exitAPIError
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && (apiErr.Code == http.StatusUnauthorized || apiErr.Code == http.StatusForbidden) {
+		return exitAuthError
+	}
+	var authErr *auth.Error
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &authErr) || errors.As(err, &retrieveErr) {
+		return exitAuthError
+	}
+	return exitAPIError
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// tableStyles are the values accepted by -table-style.
+var tableStyles = []string{"plain", "markdown", "borders"}
+
+// renderTable writes a table with the given header and rows in style:
+// "plain" aligns columns with spaces, "markdown" writes a Markdown table and
+// "borders" draws box borders around the cells.
+func renderTable(w io.Writer, style string, header []string, rows [][]string) error {
+	switch style {
+	case "plain":
+		return renderPlain(w, header, rows)
+	case "markdown":
+		return renderMarkdown(w, header, rows)
+	case "borders":
+		return renderBorders(w, header, rows)
+	default:
+		return fmt.Errorf("unknown table style %q", style)
+	}
+}
+
+func renderPlain(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func renderMarkdown(w io.Writer, header []string, rows [][]string) error {
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			b.WriteString(" " + strings.ReplaceAll(c, "|", `\|`) + " |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(header)
+	b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func renderBorders(w io.Writer, header []string, rows [][]string) error {
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, c := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(c))
+		}
+	}
+	line := func(left, mid, right string) string {
+		parts := make([]string, len(widths))
+		for i, width := range widths {
+			parts[i] = strings.Repeat("─", width+2)
+		}
+		return left + strings.Join(parts, mid) + right + "\n"
+	}
+	row := func(cells []string) string {
+		parts := make([]string, len(cells))
+		for i, c := range cells {
+			parts[i] = " " + c + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c)) + " "
+		}
+		return "│" + strings.Join(parts, "│") + "│\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(line("┌", "┬", "┐"))
+	b.WriteString(row(header))
+	b.WriteString(line("├", "┼", "┤"))
+	for _, r := range rows {
+		b.WriteString(row(r))
+	}
+	b.WriteString(line("└", "┴", "┘"))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
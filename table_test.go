@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var (
+	testHeader = []string{"NAME", "STATUS"}
+	testRows   = [][]string{{"web-1", "RUNNING"}, {"db|primary", "TERMINATED"}}
+)
+
+func TestRenderTable(t *testing.T) {
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{"plain", `
+NAME        STATUS
+web-1       RUNNING
+db|primary  TERMINATED
+`},
+		{"markdown", `
+| NAME | STATUS |
+| --- | --- |
+| web-1 | RUNNING |
+| db\|primary | TERMINATED |
+`},
+		{"borders", `
+┌────────────┬────────────┐
+│ NAME       │ STATUS     │
+├────────────┼────────────┤
+│ web-1      │ RUNNING    │
+│ db|primary │ TERMINATED │
+└────────────┴────────────┘
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			var b strings.Builder
+			if err := renderTable(&b, tt.style, testHeader, testRows); err != nil {
+				t.Fatalf("renderTable() returned an unexpected error: %v", err)
+			}
+			if want := strings.TrimPrefix(tt.want, "\n"); b.String() != want {
+				t.Errorf("renderTable(%q) =\n%s\nwant\n%s", tt.style, b.String(), want)
+			}
+		})
+	}
+}
+
+func TestRenderTable_UnknownStyle(t *testing.T) {
+	if err := renderTable(&strings.Builder{}, "fancy", testHeader, testRows); err == nil {
+		t.Error("expected an error for an unknown style")
+	}
+}
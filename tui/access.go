@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"slices"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Permissions that let the caller log in to an instance: through OS Login,
+// or by adding a key to the instance metadata as gcloud does.
+const (
+	permOSLogin      = "compute.instances.osLogin"
+	permOSAdminLogin = "compute.instances.osAdminLogin"
+	permSetMetadata  = "compute.instances.setMetadata"
+)
+
+// sshPermissions are the permissions tested to tell whether the caller can
+// SSH to an instance.
+var sshPermissions = []string{permOSLogin, permOSAdminLogin, permSetMetadata}
+
+// accessWorkers bounds how many instances have their permissions tested at
+// once.
+const accessWorkers = 8
+
+// canLikelySSH guesses whether the caller can SSH to vm as user, given the
+// permissions they hold on it. It is a heuristic: with OS Login enabled it
+// needs an OS Login permission; otherwise it needs to be allowed to add a
+// key to the instance metadata, or a key for user already being there.
+// Project-wide keys, IAP tunnel access and firewall rules are not checked.
+func canLikelySSH(vm gcp.Instance, user string, granted []string) bool {
+	if strings.EqualFold(vm.Metadata["enable-oslogin"], "true") {
+		return slices.Contains(granted, permOSLogin) || slices.Contains(granted, permOSAdminLogin)
+	}
+	return slices.Contains(granted, permSetMetadata) || hasSSHKey(vm.Metadata["ssh-keys"], user)
+}
+
+// hasSSHKey reports whether the ssh-keys metadata holds a key for user.
+func hasSSHKey(keys, user string) bool {
+	if user == "" {
+		return false
+	}
+	for _, line := range strings.Split(keys, "\n") {
+		if u, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && u == user {
+			return true
+		}
+	}
+	return false
+}
+
+// accessMsg carries the permissions the caller holds on each instance, keyed
+// by noteKey. Instances whose permissions could not be tested are missing.
+type accessMsg struct {
+	granted map[string][]string
+	failed  int
+}
+
+// fetchAccessCmd returns a command that tests the SSH permissions of the
+// caller on every loaded instance.
+func (m Model) fetchAccessCmd() tea.Cmd {
+	vms := make([]gcp.Instance, len(m.vms))
+	keys := make([]string, len(m.vms))
+	projects := make([]string, len(m.vms))
+	for i, vm := range m.vms {
+		vms[i], keys[i], projects[i] = m.located(vm), m.noteKey(vm), m.projectOf(vm)
+	}
+	ctx, client := m.ctx, m.gcpClient
+	return func() tea.Msg {
+		msg := accessMsg{granted: make(map[string][]string, len(vms))}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, accessWorkers)
+		for i, vm := range vms {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				granted, err := client.TestInstancePermissions(ctx, projects[i], vm.Zone, vm.Name, sshPermissions)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					msg.failed++
+					return
+				}
+				msg.granted[keys[i]] = granted
+			}()
+		}
+		wg.Wait()
+		return msg
+	}
+}
+
+// toggleAccessible shows only the instances the caller can likely SSH to, or
+// all of them again. Permissions are tested afresh each time the filter is
+// turned on.
+func (m Model) toggleAccessible() (tea.Model, tea.Cmd) {
+	if m.accessibleOnly {
+		current, ok := m.selected()
+		m.accessibleOnly = false
+		m.cursor = 0
+		if ok {
+			m.moveCursorTo(current)
+		}
+		return m, nil
+	}
+	m.message = ""
+	return m, m.startLoading("Checking SSH access...", m.fetchAccessCmd())
+}
+
+// showAccessible applies the tested permissions and shows only the instances
+// the caller can likely SSH to.
+func (m Model) showAccessible(msg accessMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	current, ok := m.selected()
+	m.granted = msg.granted
+	m.listVersion++
+	m.accessibleOnly = true
+	m.cursor = 0
+	if ok {
+		m.moveCursorTo(current)
+	}
+	if msg.failed > 0 {
+		m.message = fmt.Sprintf("Could not check access to %d %s; unchecked instances stay shown.", msg.failed, plural(msg.failed, "instance", "instances"))
+	}
+	return m, nil
+}
+
+// accessible reports whether the caller can likely SSH to vm. Instances
+// whose permissions are unknown, such as those loaded since the check, are
+// assumed accessible so that nothing is hidden by mistake.
+func (m Model) accessible(vm gcp.Instance) bool {
+	granted, ok := m.granted[m.noteKey(vm)]
+	return !ok || canLikelySSH(vm, m.sshUser(vm), granted)
+}
+
+// accessibleView renders how many instances are hidden while only the
+// accessible ones are shown.
+func (m Model) accessibleView() string {
+	if !m.accessibleOnly {
+		return ""
+	}
+	var hidden int
+	for _, vm := range m.vms {
+		if !m.accessible(vm) {
+			hidden++
+		}
+	}
+	return fmt.Sprintf("Hiding %d %s you likely cannot SSH to, press %s to show all\n", hidden, plural(hidden, "instance", "instances"), m.keys.first(actionAccessible))
+}
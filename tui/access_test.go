@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanLikelySSH(t *testing.T) {
+	osLogin := map[string]string{"enable-oslogin": "TRUE"}
+	tests := []struct {
+		name    string
+		vm      gcp.Instance
+		granted []string
+		want    bool
+	}{
+		{"os login granted", gcp.Instance{Metadata: osLogin}, []string{permOSLogin}, true},
+		{"os admin login granted", gcp.Instance{Metadata: osLogin}, []string{permOSAdminLogin}, true},
+		{"os login ignores metadata keys", gcp.Instance{Metadata: osLogin}, []string{permSetMetadata}, false},
+		{"can add a key", gcp.Instance{}, []string{permSetMetadata}, true},
+		{"key already there", gcp.Instance{Metadata: map[string]string{"ssh-keys": "bob:ssh-rsa A\nops:ssh-ed25519 B"}}, nil, true},
+		{"nothing granted", gcp.Instance{Metadata: map[string]string{"ssh-keys": "bob:ssh-rsa A"}}, []string{permOSLogin}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, canLikelySSH(tt.vm, "ops", tt.granted))
+		})
+	}
+}
+
+func TestUpdate_ToggleAccessible(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-3", Zone: "z-1", Status: "RUNNING"},
+	)
+	client.SetPermissions("test-project", "z-1", "vm-2", "compute.instances.get")
+	m := loadedModel(t, client)
+	m.cursor = 2
+
+	m, cmd := keyPress(t, m, "u")
+	require.True(t, m.loading)
+	for _, msg := range batchMsgs(cmd) {
+		model, _ := m.Update(msg)
+		m = model.(Model)
+	}
+	require.Equal(t, []string{"vm-1", "vm-3"}, names(m.visible()))
+	require.Equal(t, "vm-3", m.visible()[m.cursor].Name, "the cursor should stay on the selected instance")
+	require.Contains(t, m.View(), "Hiding 1 instance you likely cannot SSH to, press u to show all")
+
+	m, _ = keyPress(t, m, "u")
+	require.Len(t, m.visible(), 3)
+}
+
+func TestUpdate_AccessibleKeepsUnchecked(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+	client.SetError("TestInstancePermissions", errors.New("boom"))
+	m := loadedModel(t, client)
+
+	m, cmd := keyPress(t, m, "u")
+	for _, msg := range batchMsgs(cmd) {
+		model, _ := m.Update(msg)
+		m = model.(Model)
+	}
+	require.Len(t, m.visible(), 1)
+	require.Equal(t, "Could not check access to 1 instance; unchecked instances stay shown.", m.message)
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+// keyPress sends a single key to m and returns the updated model.
+func keyPress(t *testing.T, m Model, key string) (Model, tea.Cmd) {
+	t.Helper()
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return model.(Model), cmd
+}
+
+// loadedModel returns a model whose list has been fetched from client.
+func loadedModel(t *testing.T, client gcpClient) Model {
+	t.Helper()
+	m := NewModel(client, "test-project")
+	model, _ := m.Update(m.fetchVmsCmd())
+	return model.(Model)
+}
+
+func TestUpdate_SuspendConfirmed(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+	m := loadedModel(t, client)
+
+	m, cmd := keyPress(t, m, "S")
+	require.Nil(t, cmd, "suspend must wait for confirmation")
+	require.Contains(t, m.View(), "Suspend vm-1? (y/n)")
+
+	_, cmd = keyPress(t, m, "y")
+	require.Equal(t, actionDoneMsg{"Suspended vm-1."}, cmd())
+	vm, _ := client.Instance("test-project", "z-1", "vm-1")
+	require.Equal(t, "SUSPENDED", vm.Status)
+}
+
+func TestUpdate_SuspendRequiresRunning(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"}}
+	m.loading = false
+
+	m, _ = keyPress(t, m, "S")
+	require.Nil(t, m.confirm)
+	require.Equal(t, "vm-1 is TERMINATED; only running instances can be suspended.", m.message)
+
+	m.vms[0].Status = "PROVISIONING"
+	m, _ = keyPress(t, m, "S")
+	require.Nil(t, m.confirm)
+	require.Equal(t, "vm-1 is PROVISIONING; wait until it is RUNNING to suspend it.", m.message)
+}
+
+func TestUpdate_ResumeConfirmed(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "SUSPENDED"})
+	m := loadedModel(t, client)
+
+	m, _ = keyPress(t, m, "R")
+	_, cmd := keyPress(t, m, "y")
+	require.Equal(t, actionDoneMsg{"Resumed vm-1."}, cmd())
+	vm, _ := client.Instance("test-project", "z-1", "vm-1")
+	require.Equal(t, "RUNNING", vm.Status)
+}
+
+func TestUpdate_SuspendUnsupportedSurfacesError(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+	client.SetError("SuspendInstance", errors.New("failed to suspend instance: googleapi: Error 400: Suspend is not supported for machine type e2-micro"))
+	m := loadedModel(t, client)
+
+	m, _ = keyPress(t, m, "S")
+	_, cmd := keyPress(t, m, "y")
+	model, _ := m.Update(cmd())
+	require.Contains(t, model.(Model).View(), "Suspend is not supported for machine type e2-micro")
+}
+
+func TestUpdate_StartZoneExhaustedShowsHint(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"})
+	raw := errors.New("failed to start instance: googleapi: Error 503: SERVICE UNAVAILABLE: ZONE_RESOURCE_POOL_EXHAUSTED")
+	client.SetError("StartInstance", &gcp.ZoneExhaustedError{Zone: "z-1", Instance: "vm-1", Err: raw})
+	m := loadedModel(t, client)
+
+	m, _ = keyPress(t, m, "enter")
+	m, cmd := keyPress(t, m, "y")
+	require.Contains(t, m.View(), "Starting vm-1...")
+	msg := batchMsgs(cmd)[0]
+	model, _ := m.Update(msg)
+	m = model.(Model)
+	view := m.View()
+	require.Contains(t, view, "Error: zone z-1 has no capacity for vm-1 right now; try again later or move it to another zone.")
+	require.NotContains(t, view, "Error 503", "the raw error is only shown in verbose mode")
+
+	m.verbose = true
+	model, _ = m.Update(msg)
+	require.Contains(t, model.(Model).View(), "Details: failed to start instance: googleapi: Error 503")
+}
+
+func TestUpdate_ActionErrorShowsBanner(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}}
+	m.loading = true
+
+	model, _ := m.Update(actionErrMsg{err: errors.New("failed to start instance: quota exceeded")})
+	m = model.(Model)
+	require.Nil(t, m.err, "action errors must not replace the list")
+	require.False(t, m.loading)
+	view := m.View()
+	require.Contains(t, view, "[vm-1]")
+	require.Contains(t, view, "Error: failed to start instance: quota exceeded")
+
+	m, _ = keyPress(t, m, "j")
+	require.NotContains(t, m.View(), "quota exceeded", "any key should dismiss the banner")
+}
@@ -0,0 +1,190 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bulkAction is an action over the marked instances, awaiting confirmation.
+type bulkAction struct {
+	// verb, doing and done name the action, e.g. "Suspend", "Suspending"
+	// and "Suspended".
+	verb, doing, done string
+	// from is the status an instance must have for the action to apply.
+	from string
+	run  instanceAction
+	// targets are the marked instances the action applies to, and skipped
+	// the marked instances in another state.
+	targets []gcp.Instance
+	skipped []gcp.Instance
+	// warning names the targets marked do not disturb, if any.
+	warning string
+}
+
+// instanceKey identifies an instance across projects.
+func (m Model) instanceKey(vm gcp.Instance) string {
+	return m.projectOf(vm) + "/" + vm.Zone + "/" + vm.Name
+}
+
+// toggleMark marks or unmarks vm for a bulk action and moves to the next
+// instance, so that several can be marked in a row.
+func (m Model) toggleMark(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	marked := make(map[string]bool, len(m.marked)+1)
+	for k := range m.marked {
+		marked[k] = true
+	}
+	if key := m.instanceKey(vm); marked[key] {
+		delete(marked, key)
+	} else {
+		marked[key] = true
+	}
+	m.marked = marked
+	if m.cursor < len(m.visible())-1 {
+		m.cursor++
+	}
+	return m, nil
+}
+
+// markedInstances returns the marked instances, in list order.
+func (m Model) markedInstances() []gcp.Instance {
+	var vms []gcp.Instance
+	for _, vm := range m.vms {
+		if m.marked[m.instanceKey(vm)] {
+			vms = append(vms, vm)
+		}
+	}
+	return vms
+}
+
+// planBulk splits vms into those the action applies to, being in the from
+// status, and those it skips.
+func planBulk(vms []gcp.Instance, action bulkAction) bulkAction {
+	for _, vm := range vms {
+		if vm.Status == action.from {
+			action.targets = append(action.targets, vm)
+		} else {
+			action.skipped = append(action.skipped, vm)
+		}
+	}
+	return action
+}
+
+// askBulk shows the summary of action over the marked instances and waits
+// for confirmation.
+func (m Model) askBulk(action bulkAction) (tea.Model, tea.Cmd) {
+	action = planBulk(m.markedInstances(), action)
+	if len(action.targets) == 0 {
+		m.message = fmt.Sprintf("None of the %d marked instances is %s.", len(action.skipped), action.from)
+		return m, nil
+	}
+	if !m.allowAction(action.doing, action.targets...) {
+		return m, nil
+	}
+	action.warning = m.dndWarning(action.targets...)
+	m.bulk = &action
+	m.message = ""
+	m.mode = modeBulkConfirm
+	return m, nil
+}
+
+// bulkAnswer returns the key that runs the pending bulk action: y, or
+// dndOverride when some of the targets are marked do not disturb.
+func (m Model) bulkAnswer() string {
+	if m.bulk.warning != "" {
+		return dndOverride
+	}
+	return "y"
+}
+
+// updateBulkConfirm runs the bulk action if the user presses its answer, and
+// cancels it on any other key.
+func (m Model) updateBulkConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	answer := m.bulkAnswer()
+	action := m.bulk
+	m.bulk = nil
+	m.mode = modeList
+	if msg.String() != answer {
+		m.message = "Cancelled."
+		if answer == dndOverride && msg.String() == "y" {
+			m.message = fmt.Sprintf("Cancelled; press %s to override do not disturb.", dndOverride)
+		}
+		return m, nil
+	}
+	m.marked = nil
+	return m, m.startLoading(fmt.Sprintf("%s %d instances...", action.doing, len(action.targets)), m.bulkCmd(*action))
+}
+
+// bulkCmd returns a command that runs action against every target and
+// reports how many succeeded and which failed.
+func (m Model) bulkCmd(action bulkAction) tea.Cmd {
+	projects := make([]string, len(action.targets))
+	zones := make([]string, len(action.targets))
+	for i, vm := range action.targets {
+		projects[i], zones[i] = m.projectOf(vm), m.located(vm).Zone
+	}
+	ctx := m.ctx
+	return func() tea.Msg {
+		msg := bulkDoneMsg{verb: action.verb, done: action.done}
+		for i, vm := range action.targets {
+			if err := action.run(ctx, projects[i], zones[i], vm.Name); err != nil {
+				msg.failed = append(msg.failed, fmt.Errorf("%s: %w", vm.Name, err))
+				continue
+			}
+			msg.count++
+			msg.succeeded = append(msg.succeeded, vm.Name)
+		}
+		return msg
+	}
+}
+
+// finishBulk shows the outcome of a bulk action, with the failures in the
+// banner. The list is refreshed unless every target failed.
+func (m Model) finishBulk(msg bulkDoneMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.recordBulk(msg)
+	if len(msg.failed) > 0 {
+		m.banner = fmt.Sprintf("Error: %v", errors.Join(msg.failed...))
+	}
+	fade := m.showOutcome(msg.summary())
+	if msg.count == 0 {
+		return m, fade
+	}
+	return m, tea.Batch(fade, m.refresh())
+}
+
+// bulkView renders the summary of the pending bulk action.
+func (m Model) bulkView() string {
+	a := m.bulk
+	var b strings.Builder
+	if a.warning != "" {
+		b.WriteString(m.theme.Transitional.Render(strings.TrimSpace(a.warning)) + "\n\n")
+	}
+	b.WriteString(fmt.Sprintf("%s %d instances?\n\n", a.verb, len(a.targets)))
+	for _, vm := range a.targets {
+		b.WriteString(fmt.Sprintf("  %s %s\n", vm.Name, m.theme.Muted.Render(m.projectOf(vm)+"/"+vm.Zone)))
+	}
+	if len(a.skipped) > 0 {
+		b.WriteString(fmt.Sprintf("\nSkipped, not %s (%d):\n", a.from, len(a.skipped)))
+		for _, vm := range a.skipped {
+			b.WriteString(fmt.Sprintf("  %s %s\n", vm.Name, m.theme.status(vm.Status)))
+		}
+	}
+	b.WriteString("\n" + m.hintView())
+	return b.String()
+}
+
+// markMarker returns the marker shown for marked instances while any are
+// marked.
+func (m Model) markMarker(vm gcp.Instance) string {
+	if len(m.marked) == 0 {
+		return ""
+	}
+	if m.marked[m.instanceKey(vm)] {
+		return "+"
+	}
+	return " "
+}
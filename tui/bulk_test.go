@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+// batchMsgs runs cmd and, if it is a batch, every command in it, returning
+// the messages they produce. Spinner ticks are skipped.
+func batchMsgs(cmd tea.Cmd) []tea.Msg {
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok {
+		return []tea.Msg{cmd()}
+	}
+	var msgs []tea.Msg
+	for _, c := range batch {
+		if c == nil {
+			continue
+		}
+		if msg := c(); !isTick(msg) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+func isTick(msg tea.Msg) bool {
+	_, ok := msg.(spinner.TickMsg)
+	return ok
+}
+
+func TestPlanBulk(t *testing.T) {
+	vms := []gcp.Instance{
+		{Name: "web-1", Status: "RUNNING"},
+		{Name: "db-1", Status: "TERMINATED"},
+		{Name: "web-2", Status: "RUNNING"},
+	}
+	plan := planBulk(vms, bulkAction{verb: "Suspend", from: "RUNNING"})
+	require.Equal(t, []string{"web-1", "web-2"}, names(plan.targets))
+	require.Equal(t, []string{"db-1"}, names(plan.skipped))
+}
+
+func TestUpdate_BulkSuspend(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "web-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "db-1", Zone: "z-1", Status: "TERMINATED"},
+		gcp.Instance{Name: "web-2", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "web-3", Zone: "z-1", Status: "RUNNING"},
+	)
+	m := loadedModel(t, client)
+
+	// Mark the first three; marking moves the cursor down.
+	for range 3 {
+		m, _ = keyPress(t, m, " ")
+	}
+	require.Contains(t, m.View(), " + [web-2] RUNNING")
+	require.Contains(t, m.View(), ">  [web-3] RUNNING")
+
+	m, _ = keyPress(t, m, "S")
+	require.Equal(t, modeBulkConfirm, m.mode)
+	view := m.View()
+	require.Contains(t, view, "Suspend 2 instances?")
+	require.Contains(t, view, "  web-1 test-project/z-1\n  web-2 test-project/z-1\n")
+	require.Contains(t, view, "Skipped, not RUNNING (1):\n  db-1 TERMINATED")
+	require.NotContains(t, view, "web-3")
+	require.Contains(t, view, "Press y to suspend 2 instances")
+
+	m, cmd := keyPress(t, m, "y")
+	require.Equal(t, modeList, m.mode)
+	require.Empty(t, m.marked)
+	require.True(t, m.loading)
+	require.Contains(t, batchMsgs(cmd), tea.Msg(bulkDoneMsg{verb: "Suspend", done: "Suspended", count: 2, succeeded: []string{"web-1", "web-2"}}))
+	for name, status := range map[string]string{"web-1": "SUSPENDED", "web-2": "SUSPENDED", "web-3": "RUNNING"} {
+		vm, _ := client.Instance("test-project", "z-1", name)
+		require.Equal(t, status, vm.Status, name)
+	}
+}
+
+func TestUpdate_BulkCancelled(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "web-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "web-2", Zone: "z-1", Status: "RUNNING"},
+	)
+	m := loadedModel(t, client)
+	m, _ = keyPress(t, m, " ")
+	m, _ = keyPress(t, m, "S")
+
+	m, cmd := keyPress(t, m, "n")
+	require.Nil(t, cmd)
+	require.Equal(t, "Cancelled.", m.message)
+	require.Len(t, m.marked, 1, "the marks should be kept to try again")
+	vm, _ := client.Instance("test-project", "z-1", "web-1")
+	require.Equal(t, "RUNNING", vm.Status)
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.Empty(t, model.(Model).marked, "esc should clear the marks")
+}
+
+func TestUpdate_BulkNothingApplies(t *testing.T) {
+	m := loadedModel(t, gcptest.NewFakeClient(gcp.Instance{Name: "web-1", Zone: "z-1", Status: "RUNNING"}))
+	m, _ = keyPress(t, m, " ")
+	m, _ = keyPress(t, m, "R")
+	require.Equal(t, modeList, m.mode)
+	require.Equal(t, "None of the 1 marked instances is SUSPENDED.", m.message)
+}
@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dndOverride is the key that runs an action on instances marked do not
+// disturb. Pressing y there cancels it like any other key, so that the
+// usual answer cannot disturb them.
+const dndOverride = "!"
+
+// confirmation is a pending yes/no question. Pressing y, or dndOverride when
+// override is set, runs cmd, showing loading meanwhile if set, any other key
+// dismisses it.
+type confirmation struct {
+	prompt   string
+	cmd      tea.Cmd
+	loading  string
+	override bool
+}
+
+// answer returns the key that runs the confirmed action.
+func (c confirmation) answer() string {
+	if c.override {
+		return dndOverride
+	}
+	return "y"
+}
+
+// view renders the question with the keys that answer it.
+func (c confirmation) view() string {
+	if c.override {
+		return fmt.Sprintf("%s (%s to override, any other key to cancel)", c.prompt, dndOverride)
+	}
+	return c.prompt + " (y/n)"
+}
+
+// guard names those of vms marked do not disturb before the prompt of c and
+// makes it take dndOverride to run, if there are any. It reports whether
+// there were.
+func (m Model) guard(c *confirmation, vms ...gcp.Instance) bool {
+	warning := m.dndWarning(vms...)
+	if warning == "" {
+		return false
+	}
+	c.prompt = warning + c.prompt
+	c.override = true
+	return true
+}
+
+// allowAction reports whether doing, e.g. "Suspending", may change vms. Every
+// action that changes instances goes through it before running, by way of
+// askAction, runAction or askBulk: it is refused while locked on the project
+// of one of them, saying how to unlock it.
+func (m *Model) allowAction(doing string, vms ...gcp.Instance) bool {
+	if p := m.lockedProject(vms...); p != "" {
+		m.message = fmt.Sprintf("%s is locked on %s (press %s to unlock).", doing, p, keyName(m.keys.first(actionUnlock)))
+		return false
+	}
+	return true
+}
+
+// askAction asks c, an action doing something to vms, unless allowAction
+// refuses it. When some of vms are marked do not disturb, they are named
+// first and only dndOverride runs it.
+func (m *Model) askAction(doing string, c confirmation, vms ...gcp.Instance) {
+	if m.allowAction(doing, vms...) {
+		m.guard(&c, vms...)
+		m.confirm = &c
+	}
+}
+
+// runAction returns the command of c, an action doing something to vms,
+// unless allowAction refuses it. When some of vms are marked do not
+// disturb, it asks c first instead, naming them, and only dndOverride runs
+// it.
+func (m *Model) runAction(doing string, c confirmation, vms ...gcp.Instance) tea.Cmd {
+	if !m.allowAction(doing, vms...) {
+		return nil
+	}
+	if m.guard(&c, vms...) {
+		m.confirm = &c
+		return nil
+	}
+	if c.loading != "" {
+		return m.startLoading(c.loading, c.cmd)
+	}
+	return c.cmd
+}
+
+// updateConfirm answers the pending confirmation with the pressed key.
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.confirm
+	m.confirm = nil
+	if msg.String() == c.answer() {
+		if c.loading != "" {
+			return m, m.startLoading(c.loading, c.cmd)
+		}
+		return m, c.cmd
+	}
+	m.message = "Cancelled."
+	if c.override && msg.String() == "y" {
+		m.message = fmt.Sprintf("Cancelled; press %s to override do not disturb.", dndOverride)
+	}
+	return m.quitLaunch()
+}
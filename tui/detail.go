@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"sort"
+	"strings"
+)
+
+// maxInlineValue is how many characters of a label or metadata value are
+// shown while it is collapsed.
+const maxInlineValue = 60
+
+// detailField is a label or metadata entry shown in the detail view.
+type detailField struct {
+	// id identifies the field across renders, e.g. "metadata/startup-script".
+	id    string
+	key   string
+	value string
+}
+
+// long reports whether the value does not fit on one line, so that it is
+// collapsed until expanded.
+func (f detailField) long() bool {
+	return len([]rune(f.value)) > maxInlineValue || strings.Contains(f.value, "\n")
+}
+
+// detailFields returns the labels and then the metadata of vm, each sorted
+// by key.
+func detailFields(vm gcp.Instance) (labels, metadata []detailField) {
+	return sortedFields("label", vm.Labels), sortedFields("metadata", vm.Metadata)
+}
+
+func sortedFields(kind string, entries map[string]string) []detailField {
+	fields := make([]detailField, 0, len(entries))
+	for k, v := range entries {
+		fields = append(fields, detailField{id: kind + "/" + k, key: k, value: v})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+	return fields
+}
+
+// longFields returns the ids of the fields of vm that can be expanded, in
+// display order.
+func longFields(vm gcp.Instance) []string {
+	labels, metadata := detailFields(vm)
+	var ids []string
+	for _, f := range append(labels, metadata...) {
+		if f.long() {
+			ids = append(ids, f.id)
+		}
+	}
+	return ids
+}
+
+// focusedField returns the id of the long field that e expands, if any.
+func (m Model) focusedField(vm gcp.Instance) string {
+	ids := longFields(vm)
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[min(m.detailFocus, len(ids)-1)]
+}
+
+// moveFieldFocus moves the focus to the next or previous long field.
+func (m *Model) moveFieldFocus(vm gcp.Instance, delta int) {
+	n := len(longFields(vm))
+	if n == 0 {
+		return
+	}
+	m.detailFocus = ((min(m.detailFocus, n-1)+delta)%n + n) % n
+}
+
+// toggleField expands or collapses the focused field.
+func (m *Model) toggleField(vm gcp.Instance) {
+	id := m.focusedField(vm)
+	if id == "" {
+		return
+	}
+	expanded := make(map[string]bool, len(m.expanded)+1)
+	for k, v := range m.expanded {
+		expanded[k] = v
+	}
+	expanded[id] = !expanded[id]
+	m.expanded = expanded
+}
+
+// fieldsView renders a section of labels or metadata. Long values show a
+// single truncated line until they are expanded, and are wrapped to the
+// terminal width when they are or when long lines are wrapped.
+func (m Model) fieldsView(title string, fields []detailField, focused string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n" + title + ":\n")
+	for _, f := range fields {
+		marker := "  "
+		if f.id == focused {
+			marker = "> "
+		}
+		if !f.long() {
+			b.WriteString(fmt.Sprintf("%s%s: %s\n", marker, f.key, f.value))
+			continue
+		}
+		lines := strings.Split(f.value, "\n")
+		if !m.expanded[f.id] && !m.wrap {
+			first := []rune(lines[0])
+			if len(first) > maxInlineValue {
+				first = first[:maxInlineValue]
+			}
+			hint := fmt.Sprintf("(%d lines, e to expand)", len(lines))
+			if len(lines) == 1 {
+				hint = fmt.Sprintf("(%d characters, e to expand)", len([]rune(f.value)))
+			}
+			b.WriteString(fmt.Sprintf("%s%s: %s… %s\n", marker, f.key, string(first), m.theme.Muted.Render(hint)))
+			continue
+		}
+		if m.wrap {
+			b.WriteString(fmt.Sprintf("%s%s:\n", marker, f.key))
+		} else {
+			b.WriteString(fmt.Sprintf("%s%s: %s\n", marker, f.key, m.theme.Muted.Render("(e to collapse)")))
+		}
+		width := m.width - 4
+		if width < 20 {
+			width = 76
+		}
+		for _, line := range lines {
+			for _, part := range wrapLine(line, width) {
+				b.WriteString("    " + part + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// wrapLine breaks line into pieces of at most width characters.
+func wrapLine(line string, width int) []string {
+	runes := []rune(line)
+	if len(runes) <= width {
+		return []string{line}
+	}
+	var parts []string
+	for len(runes) > width {
+		parts = append(parts, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(parts, string(runes))
+}
+
+// detailViewportSize returns the size of the scrollable part of the detail
+// view for a terminal size, leaving room for the lines below it.
+func detailViewportSize(width, height int) (int, int) {
+	return width, max(height-5, 1)
+}
+
+// reservationView describes which reservations an instance consumes: any,
+// none, or the specific ones it names.
+func reservationView(ra *gcp.ReservationAffinity) string {
+	if ra == nil {
+		return "-"
+	}
+	switch ra.Type {
+	case "ANY_RESERVATION":
+		return "any"
+	case "NO_RESERVATION":
+		return "none"
+	case "SPECIFIC_RESERVATION":
+		if ra.Key == "compute.googleapis.com/reservation-name" {
+			return "specific: " + orDash(strings.Join(ra.Values, ", "))
+		}
+		return fmt.Sprintf("specific: %s = %s", ra.Key, orDash(strings.Join(ra.Values, ", ")))
+	}
+	return orDash(ra.Type)
+}
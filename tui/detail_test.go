@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapLine(t *testing.T) {
+	require.Equal(t, []string{"short"}, wrapLine("short", 10))
+	require.Equal(t, []string{"abcd", "efgh", "ij"}, wrapLine("abcdefghij", 4))
+	require.Equal(t, []string{""}, wrapLine("", 4))
+}
+
+func detailModel(vm gcp.Instance) Model {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{vm}
+	m.mode = modeDetail
+	return m
+}
+
+func TestReservationView(t *testing.T) {
+	require.Equal(t, "-", reservationView(nil))
+	require.Equal(t, "any", reservationView(&gcp.ReservationAffinity{Type: "ANY_RESERVATION"}))
+	require.Equal(t, "none", reservationView(&gcp.ReservationAffinity{Type: "NO_RESERVATION"}))
+	require.Equal(t, "specific: res-1, res-2", reservationView(&gcp.ReservationAffinity{
+		Type: "SPECIFIC_RESERVATION", Key: "compute.googleapis.com/reservation-name", Values: []string{"res-1", "res-2"},
+	}))
+	require.Equal(t, "specific: team = ml", reservationView(&gcp.ReservationAffinity{
+		Type: "SPECIFIC_RESERVATION", Key: "team", Values: []string{"ml"},
+	}))
+}
+
+func TestDetail_LongValuesCollapsed(t *testing.T) {
+	script := "#!/bin/bash\n" + strings.Repeat("echo configuring\n", 50)
+	m := detailModel(gcp.Instance{
+		Name:     "vm-1",
+		Labels:   map[string]string{"env": "prod", "owner": strings.Repeat("x", 100)},
+		Metadata: map[string]string{"startup-script": script},
+	})
+
+	view := m.View()
+	require.Contains(t, view, "  env: prod\n")
+	require.Contains(t, view, "> owner: "+strings.Repeat("x", maxInlineValue)+"… (100 characters, e to expand)")
+	require.Contains(t, view, "  startup-script: #!/bin/bash… (52 lines, e to expand)")
+	require.NotContains(t, view, "echo configuring")
+	require.Contains(t, view, "tab and e to expand long values")
+
+	// Expanding one field leaves the others collapsed.
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = model.(Model)
+	m, _ = keyPress(t, m, "e")
+	view = m.View()
+	require.Contains(t, view, "> startup-script: (e to collapse)")
+	require.Contains(t, view, "    echo configuring\n")
+	require.Contains(t, view, "… (100 characters, e to expand)")
+
+	m, _ = keyPress(t, m, "e")
+	require.NotContains(t, m.View(), "echo configuring")
+}
+
+func TestDetail_ExpandedValuesWrap(t *testing.T) {
+	m := detailModel(gcp.Instance{Name: "vm-1", Labels: map[string]string{"owner": strings.Repeat("x", 100)}})
+	m.width = 44
+
+	m, _ = keyPress(t, m, "e")
+	require.Contains(t, m.View(), "    "+strings.Repeat("x", 40)+"\n")
+}
+
+func TestDetail_Scrolls(t *testing.T) {
+	metadata := make(map[string]string)
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		metadata["key-"+k] = "value"
+	}
+	m := detailModel(gcp.Instance{Name: "vm-1", Metadata: metadata})
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 12})
+	m = model.(Model)
+
+	view := m.View()
+	require.True(t, strings.HasPrefix(view, "vm-1"), view)
+	require.NotContains(t, view, "key-j")
+	require.Contains(t, view, "esc to go back", "the help should stay visible")
+
+	for range 40 {
+		m, _ = keyPress(t, m, "j")
+	}
+	view = m.View()
+	require.Contains(t, view, "key-j")
+	require.False(t, strings.HasPrefix(view, "vm-1"), "the name should have scrolled away")
+}
@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/cache"
+	"gcp-rider/gcp"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dndName is the file next to the config holding the instances marked do
+// not disturb. Unlike deletion protection, the mark is local and only
+// guards against actions taken from here: each of them asks first, naming
+// the marked instances, and only runs once dndOverride is pressed.
+const dndName = "do-not-disturb.json"
+
+// dndMsg carries the do-not-disturb marks loaded from the config directory.
+type dndMsg struct {
+	keys []string
+	err  error
+}
+
+// stateDir returns the directory the state of the user is kept in, such as
+// the do-not-disturb marks, the notes and the recent SSH targets: that of
+// the config file, so that clearing the cache does not lose it, or "" when
+// there is none.
+func (m Model) stateDir() string {
+	if m.configPath == "" {
+		return ""
+	}
+	return filepath.Dir(m.configPath)
+}
+
+// loadState reads the state saved as name in the state directory into v.
+// State that earlier versions kept in the cache directory is read from there
+// until it is saved again.
+func (m Model) loadState(name string, v any) error {
+	ok, err := cache.Load(m.stateDir(), name, v)
+	if err != nil || ok || m.cacheDir == "" {
+		return err
+	}
+	_, err = cache.Load(m.cacheDir, name, v)
+	return err
+}
+
+// loadDNDCmd returns a command that reads the do-not-disturb marks.
+func (m Model) loadDNDCmd() tea.Msg {
+	var keys []string
+	_, err := cache.Load(m.stateDir(), dndName, &keys)
+	return dndMsg{keys: keys, err: err}
+}
+
+// handleDND applies the do-not-disturb marks loaded at startup.
+func (m Model) handleDND(msg dndMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Could not load the do-not-disturb marks: %v", msg.err)
+		return m, nil
+	}
+	m.dnd = make(map[string]bool, len(msg.keys))
+	for _, k := range msg.keys {
+		m.dnd[k] = true
+	}
+	return m, nil
+}
+
+// toggleDND marks vm do not disturb, or clears the mark, and persists the
+// result.
+func (m Model) toggleDND(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	dnd := maps.Clone(m.dnd)
+	if dnd == nil {
+		dnd = make(map[string]bool)
+	}
+	if key := m.noteKey(vm); dnd[key] {
+		delete(dnd, key)
+		m.message = fmt.Sprintf("%s may be disturbed again.", vm.Name)
+	} else {
+		dnd[key] = true
+		m.message = fmt.Sprintf("Marked %s do not disturb.", vm.Name)
+	}
+	m.dnd = dnd
+	dir := m.stateDir()
+	if dir == "" {
+		return m, nil
+	}
+	keys := slices.Sorted(maps.Keys(dnd))
+	return m, func() tea.Msg {
+		if err := cache.Save(dir, dndName, keys); err != nil {
+			return actionErrMsg{err: fmt.Errorf("could not save the do-not-disturb marks: %w", err)}
+		}
+		return nil
+	}
+}
+
+// dndWarning names those of vms marked do not disturb, for the
+// confirmation of an action on them to show first, or returns "".
+func (m Model) dndWarning(vms ...gcp.Instance) string {
+	var names []string
+	for _, vm := range vms {
+		if m.dnd[m.noteKey(vm)] {
+			names = append(names, vm.Name)
+		}
+	}
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0] + " is marked do not disturb. "
+	}
+	return strings.Join(names, ", ") + " are marked do not disturb. "
+}
+
+// dndColumn renders a reminder after the name of vm in the list when it is
+// marked do not disturb.
+func (m Model) dndColumn(vm gcp.Instance) string {
+	if !m.dnd[m.noteKey(vm)] {
+		return ""
+	}
+	return " " + m.theme.Transitional.Render("⊘ do not disturb")
+}
@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"gcp-rider/cache"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func dndModel(t *testing.T, vms ...gcp.Instance) Model {
+	t.Helper()
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = vms
+	m.dnd = map[string]bool{"test-project/vm-1": true}
+	return m
+}
+
+func TestUpdate_ToggleDND(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	m := NewModel(new(mocks.Client), "test-project", WithConfig(path, config.Config{}, false))
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1"}, {Name: "vm-2", ProjectID: "other"}}
+	m.cursor = 1
+
+	m, cmd := keyPress(t, m, "D")
+	require.Equal(t, "Marked vm-2 do not disturb.", m.message)
+	require.Nil(t, cmd())
+	require.Contains(t, m.View(), "> [vm-2]  ⊘ do not disturb")
+
+	var saved []string
+	ok, err := cache.Load(dir, dndName, &saved)
+	require.NoError(t, err)
+	require.True(t, ok, "the marks should be kept next to the config")
+	require.Equal(t, []string{"other/vm-2"}, saved)
+
+	m2 := NewModel(new(mocks.Client), "test-project", WithConfig(path, config.Config{}, false))
+	model, _ := m2.Update(m2.loadDNDCmd())
+	require.True(t, model.(Model).dnd["other/vm-2"], "the marks should survive a new session")
+
+	m, _ = keyPress(t, m, "D")
+	require.Equal(t, "vm-2 may be disturbed again.", m.message)
+	require.Empty(t, m.dnd)
+}
+
+func TestUpdate_DNDAsksFirst(t *testing.T) {
+	m := dndModel(t, gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+
+	m, _ = keyPress(t, m, "S")
+	require.NotNil(t, m.confirm)
+	require.Equal(t, "vm-1 is marked do not disturb. Suspend vm-1?", m.confirm.prompt)
+}
+
+func TestUpdate_DNDRefusesYes(t *testing.T) {
+	m := dndModel(t, gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+
+	m, _ = keyPress(t, m, "S")
+	m, cmd := keyPress(t, m, "y")
+	require.Nil(t, cmd, "y should not run an action on an instance marked do not disturb")
+	require.Nil(t, m.confirm)
+	require.False(t, m.loading)
+	require.Equal(t, "Cancelled; press ! to override do not disturb.", m.message)
+
+	m, _ = keyPress(t, m, "S")
+	m, cmd = keyPress(t, m, "!")
+	require.NotNil(t, cmd)
+}
+
+func TestUpdate_DNDStartBeforeSSH(t *testing.T) {
+	m := dndModel(t, gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"})
+
+	m, _ = keyPress(t, m, "enter")
+	require.NotNil(t, m.confirm)
+	require.Equal(t, "vm-1 is marked do not disturb. vm-1 is TERMINATED; start it first?", m.confirm.prompt)
+}
+
+func TestUpdate_DNDBulk(t *testing.T) {
+	m := dndModel(t,
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "SUSPENDED"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "SUSPENDED"},
+	)
+	m.marked = map[string]bool{"test-project/z-1/vm-1": true, "test-project/z-1/vm-2": true}
+
+	m, _ = keyPress(t, m, "R")
+	require.Equal(t, modeBulkConfirm, m.mode)
+	require.Contains(t, m.View(), "vm-1 is marked do not disturb.\n\nResume 2 instances?")
+	require.Contains(t, m.View(), "! to override do not disturb and resume 2 instances")
+
+	m, cmd := keyPress(t, m, "y")
+	require.Nil(t, cmd, "y should not run a bulk action on an instance marked do not disturb")
+	require.Equal(t, modeList, m.mode)
+	require.Len(t, m.marked, 2, "the marks should be kept for another try")
+}
+
+func TestUpdate_DNDDetailActions(t *testing.T) {
+	m := dndModel(t, gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "TERMINATED", DeletionProtection: true})
+	m.mode = modeDetail
+
+	m, cmd := keyPress(t, m, "d")
+	require.Nil(t, cmd, "nothing should change before confirming")
+	require.Contains(t, m.View(), "vm-1 is marked do not disturb. Turn deletion protection off for vm-1? (! to override, any other key to cancel)")
+	m, cmd = keyPress(t, m, "n")
+	require.Nil(t, cmd)
+	require.Equal(t, "Cancelled.", m.message)
+
+	m, _ = keyPress(t, m, "m")
+	require.Equal(t, modeMachineType, m.mode)
+	m, _ = keyPress(t, m, "e2-small")
+	m, cmd = keyPress(t, m, "enter")
+	require.Nil(t, cmd)
+	require.Equal(t, modeDetail, m.mode)
+	require.Equal(t, "vm-1 is marked do not disturb. Change vm-1 to e2-small?", m.confirm.prompt)
+	m, cmd = keyPress(t, m, "!")
+	require.NotNil(t, cmd)
+	require.True(t, m.loading)
+	require.Equal(t, "Changing machine type...", m.loadingText)
+}
+
+func TestUpdate_DNDLeavesOthersAlone(t *testing.T) {
+	m := dndModel(t, gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "RUNNING"})
+
+	m, _ = keyPress(t, m, "S")
+	require.NotNil(t, m.confirm, "instances not marked should not be guarded")
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"gcp-rider/gcp"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestEndToEnd_FakeEndpoint drives the model with a real client talking to a
+// fake Compute server, as when running with GCP_RIDER_ENDPOINT set.
+func TestEndToEnd_FakeEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/compute/v1/projects/dev-project/aggregated/instances" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `{
+			"items": {
+				"zones/us-central1-a": {
+					"instances": [
+						{"name": "web-1", "status": "RUNNING", "zone": "zones/us-central1-a", "machineType": "zones/us-central1-a/machineTypes/e2-small"}
+					]
+				},
+				"zones/europe-west1-b": {
+					"instances": [
+						{"name": "db-1", "status": "TERMINATED", "zone": "zones/europe-west1-b", "machineType": "zones/europe-west1-b/machineTypes/n2-standard-4"}
+					]
+				}
+			}
+		}`)
+	}))
+	defer server.Close()
+
+	client, err := gcp.NewClient(context.Background(), gcp.EndpointOptions(server.URL)...)
+	require.NoError(t, err)
+	defer client.Close()
+
+	m := NewModel(client, "dev-project")
+	msg := m.streamVmsCmd(m.fetchSeq)()
+	for {
+		progress, ok := msg.(fetchProgressMsg)
+		if !ok {
+			break
+		}
+		msg = waitForFetch(progress.ch)()
+	}
+	model, _ := m.Update(msg)
+	m = model.(Model)
+
+	require.NoError(t, m.err)
+	view := m.View()
+	require.Contains(t, view, "[web-1] RUNNING")
+	require.Contains(t, view, "[db-1] TERMINATED")
+}
@@ -0,0 +1,440 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// While typing a filter over at least filterDebounceMin instances, the filter
+// is applied once typing pauses for filterDebounce rather than on every key,
+// so that typing stays responsive on large lists.
+const (
+	filterDebounce    = 80 * time.Millisecond
+	filterDebounceMin = 500
+)
+
+// filterTickMsg applies the typed filter if no key was typed since the tick
+// with the same sequence number was scheduled.
+type filterTickMsg struct {
+	seq int
+}
+
+// filterTerm is one whitespace-separated part of a filter query. Terms of the
+// form "network:NAME" or "subnet:NAME" match the network of an instance,
+// "project:ID" its project, "status:STATUS" its status and "label:KEY=VALUE"
+// its labels; any other term matches its name.
+type filterTerm struct {
+	field string
+	value string
+}
+
+// filterFields maps the prefixes accepted in a filter query to the instance
+// field they match.
+var filterFields = map[string]func(gcp.Instance) string{
+	"network": func(vm gcp.Instance) string { return vm.Network },
+	"subnet":  func(vm gcp.Instance) string { return vm.Subnetwork },
+	"project": func(vm gcp.Instance) string { return vm.ProjectID },
+	"status":  func(vm gcp.Instance) string { return vm.Status },
+	"label":   labelPairs,
+}
+
+// labelPairs returns the labels of vm as space-separated "key=value" pairs,
+// so that a label term matches any one of them.
+func labelPairs(vm gcp.Instance) string {
+	pairs := make([]string, 0, len(vm.Labels))
+	for _, k := range sortedKeys(vm.Labels) {
+		pairs = append(pairs, k+"="+vm.Labels[k])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// parseFilter splits a filter query into terms.
+func parseFilter(query string) []filterTerm {
+	var terms []filterTerm
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		field, value, ok := strings.Cut(word, ":")
+		if ok {
+			if _, known := filterFields[field]; known {
+				terms = append(terms, filterTerm{field: field, value: value})
+				continue
+			}
+		}
+		terms = append(terms, filterTerm{value: word})
+	}
+	return terms
+}
+
+// matchesFilter reports whether vm matches every term, ignoring case.
+func matchesFilter(vm gcp.Instance, terms []filterTerm) bool {
+	for _, t := range terms {
+		got := vm.Name
+		if t.field != "" {
+			got = filterFields[t.field](vm)
+		}
+		if !strings.Contains(strings.ToLower(got), t.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// visibleKey is what the instances shown depend on, so that they are only
+// filtered and sorted again once one of them changes. The instances, the pins
+// and the granted permissions are told apart by listVersion, which every
+// change to them bumps.
+type visibleKey struct {
+	listVersion    int
+	filter         string
+	filterRegex    bool
+	filterRE       *regexp.Regexp
+	hideStopped    bool
+	externalOnly   bool
+	accessibleOnly bool
+	query          *config.SavedQuery
+	sortColumn     string
+	sortDesc       bool
+}
+
+// visibleCache holds the instances shown for the key they were worked out
+// for.
+type visibleCache struct {
+	key visibleKey
+	vms []gcp.Instance
+}
+
+// visibleKey returns the key of the instances shown as the model stands.
+func (m Model) visibleKey() visibleKey {
+	return visibleKey{
+		listVersion:    m.listVersion,
+		filter:         m.filter,
+		filterRegex:    m.filterRegex,
+		filterRE:       m.filterRE,
+		hideStopped:    m.hideStopped,
+		externalOnly:   m.externalOnly,
+		accessibleOnly: m.accessibleOnly,
+		query:          m.query,
+		sortColumn:     m.sortColumn,
+		sortDesc:       m.sortDesc,
+	}
+}
+
+// cacheVisible keeps the instances shown, so that the messages that leave
+// the list as it is do not filter it again.
+func (m *Model) cacheVisible() {
+	if key := m.visibleKey(); m.shown == nil || m.shown.key != key {
+		m.shown = &visibleCache{key: key, vms: m.visible()}
+	}
+}
+
+// visible returns the instances matching the current filter and saved query,
+// in list order, leaving out stopped instances while they are hidden and
+// instances without an external IP while only exposed ones are shown.
+func (m Model) visible() []gcp.Instance {
+	filtered := m.filter != "" && (!m.filterRegex || m.filterRE != nil)
+	if !filtered && !m.hideStopped && !m.externalOnly && !m.accessibleOnly && m.query == nil && m.sortColumn == "" {
+		return m.vms
+	}
+	if m.shown != nil && m.shown.key == m.visibleKey() {
+		return m.shown.vms
+	}
+	match := func(vm gcp.Instance) bool { return true }
+	switch {
+	case filtered && m.filterRegex:
+		match = func(vm gcp.Instance) bool { return m.filterRE.MatchString(vm.Name) }
+	case filtered:
+		terms := parseFilter(m.filter)
+		match = func(vm gcp.Instance) bool { return matchesFilter(vm, terms) }
+	}
+	var vms []gcp.Instance
+	for _, vm := range m.vms {
+		if m.hideStopped && isStopped(vm) {
+			continue
+		}
+		if m.externalOnly && vm.ExternalIP == "" {
+			continue
+		}
+		if m.accessibleOnly && !m.accessible(vm) {
+			continue
+		}
+		if match(vm) && m.inQueryProject(vm) {
+			vms = append(vms, vm)
+		}
+	}
+	m.sortVisible(vms)
+	return vms
+}
+
+// isStopped reports whether vm is stopped or suspended, as opposed to live or
+// changing state.
+func isStopped(vm gcp.Instance) bool {
+	return gcp.ClassifyStatus(vm.Status) == gcp.StatusStopped
+}
+
+// toggleStopped hides or shows stopped instances, keeping the cursor on the
+// selected instance when it is still shown.
+func (m Model) toggleStopped() (tea.Model, tea.Cmd) {
+	current, ok := m.selected()
+	m.hideStopped = !m.hideStopped
+	m.cursor = 0
+	if ok {
+		m.moveCursorTo(current)
+	}
+	return m, nil
+}
+
+// hiddenView renders how many stopped instances are hidden, if any.
+func (m Model) hiddenView() string {
+	if !m.hideStopped {
+		return ""
+	}
+	var hidden int
+	for _, vm := range m.vms {
+		if isStopped(vm) {
+			hidden++
+		}
+	}
+	if hidden == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d stopped hidden, press %s to show them\n", hidden, m.keys.first(actionStopped))
+}
+
+// toggleExternal shows only the instances with an external IP, or all of them
+// again, keeping the cursor on the selected instance when it is still shown.
+func (m Model) toggleExternal() (tea.Model, tea.Cmd) {
+	current, ok := m.selected()
+	m.externalOnly = !m.externalOnly
+	m.cursor = 0
+	if ok {
+		m.moveCursorTo(current)
+	}
+	return m, nil
+}
+
+// externalView renders how many instances have an external IP while only
+// those are shown.
+func (m Model) externalView() string {
+	if !m.externalOnly {
+		return ""
+	}
+	var exposed int
+	for _, vm := range m.vms {
+		if vm.ExternalIP != "" {
+			exposed++
+		}
+	}
+	key := m.keys.first(actionExternal)
+	if exposed == 1 {
+		return fmt.Sprintf("1 instance has an external IP, press %s to show all\n", key)
+	}
+	return fmt.Sprintf("%d instances have external IPs, press %s to show all\n", exposed, key)
+}
+
+// setQuery changes the filter query. In regex mode the query is compiled; an
+// invalid pattern keeps the last valid one applied and is reported by
+// filterView.
+func (m *Model) setQuery(query string) {
+	m.filter = query
+	m.filterErr = nil
+	if !m.filterRegex {
+		m.filterRE = nil
+		return
+	}
+	if query == "" {
+		m.filterRE = nil
+		return
+	}
+	re, err := regexp.Compile(query)
+	if err != nil {
+		m.filterErr = err
+		return
+	}
+	m.filterRE = re
+}
+
+// selected returns the instance under the cursor, if any.
+func (m Model) selected() (gcp.Instance, bool) {
+	vms := m.visible()
+	if m.cursor >= len(vms) {
+		return gcp.Instance{}, false
+	}
+	return vms[m.cursor], true
+}
+
+// setFilter changes the filter, keeping the cursor on the selected instance
+// when it is still shown.
+func (m *Model) setFilter(query string) {
+	current, ok := m.selected()
+	m.setQuery(query)
+	m.cursor = 0
+	if ok {
+		m.moveCursorTo(current)
+	}
+}
+
+// moveCursorTo puts the cursor on vm if it is shown.
+func (m *Model) moveCursorTo(vm gcp.Instance) {
+	for i, v := range m.visible() {
+		if v.Name == vm.Name && v.Zone == vm.Zone && v.ProjectID == vm.ProjectID {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+// openFilter starts editing the filter.
+func (m Model) openFilter() (tea.Model, tea.Cmd) {
+	m.filterAnchor, _ = m.selected()
+	m.mode = modeFilter
+	m.message = ""
+	m.filterInput.SetValue(m.filter)
+	m.filterInput.CursorEnd()
+	return m, m.filterInput.Focus()
+}
+
+// updateFilter handles key presses while the filter is being edited. The list
+// is filtered as the user types, debounced on large lists, with the cursor
+// kept on the instance selected when editing started whenever it matches;
+// enter keeps the filter, esc clears it, tab accepts the completion and
+// ctrl+t switches between substring and regex matching.
+func (m Model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	// Any pending debounced update is superseded by what happens here.
+	m.filterSeq++
+	switch msg.String() {
+	case "esc":
+		m.rememberSearch(m.filterInput.Value())
+		m.setQuery("")
+		m.mode = modeList
+		m.filterInput.Blur()
+	case "enter":
+		m.rememberSearch(m.filterInput.Value())
+		m.setQuery(m.filterInput.Value())
+		m.mode = modeList
+		m.filterInput.Blur()
+	case "ctrl+t":
+		m.filterRegex = !m.filterRegex
+		m.setQuery(m.filterInput.Value())
+	case "tab":
+		// Complete from the instances matching what was typed, even if
+		// the debounced update has not applied it yet.
+		m.setQuery(m.filterInput.Value())
+		if word, prefix := m.completion(); prefix != "" {
+			query := m.filterInput.Value()
+			m.filterInput.SetValue(query[:len(query)-len(word)] + prefix)
+			m.filterInput.CursorEnd()
+			m.setQuery(m.filterInput.Value())
+		}
+	default:
+		m.filterInput, cmd = m.filterInput.Update(msg)
+		if len(m.vms) >= filterDebounceMin {
+			seq := m.filterSeq
+			tick := tea.Tick(filterDebounce, func(time.Time) tea.Msg { return filterTickMsg{seq: seq} })
+			return m, tea.Batch(cmd, tick)
+		}
+		m.setQuery(m.filterInput.Value())
+	}
+	m.cursor = 0
+	m.moveCursorTo(m.filterAnchor)
+	return m, cmd
+}
+
+// applyTypedFilter applies the filter being typed once typing has paused.
+// Ticks superseded by a later key, or arriving after editing ended, are
+// ignored.
+func (m Model) applyTypedFilter(msg filterTickMsg) (tea.Model, tea.Cmd) {
+	if msg.seq != m.filterSeq || m.mode != modeFilter {
+		return m, nil
+	}
+	m.setQuery(m.filterInput.Value())
+	m.cursor = 0
+	m.moveCursorTo(m.filterAnchor)
+	return m, nil
+}
+
+// names returns the names of vms, in order.
+func names(vms []gcp.Instance) []string {
+	var out []string
+	for _, vm := range vms {
+		out = append(out, vm.Name)
+	}
+	return out
+}
+
+// commonPrefix returns the longest prefix shared by all of names, or "" if
+// there are none.
+func commonPrefix(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	prefix := names[0]
+	for _, name := range names[1:] {
+		n := 0
+		for n < len(prefix) && n < len(name) && prefix[n] == name[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return prefix
+}
+
+// completion returns the last word of the filter being typed and the name
+// prefix it completes to: the longest common prefix of the matching
+// instances. The prefix is empty if they share none longer than the word.
+func (m Model) completion() (word, prefix string) {
+	query := m.filterInput.Value()
+	if m.filterRegex || query == "" || strings.HasSuffix(query, " ") {
+		return "", ""
+	}
+	word = query[strings.LastIndex(query, " ")+1:]
+	if terms := parseFilter(word); len(terms) != 1 || terms[0].field != "" {
+		return word, ""
+	}
+	prefix = commonPrefix(names(m.visible()))
+	if len(prefix) <= len(word) || !strings.EqualFold(prefix[:len(word)], word) {
+		return word, ""
+	}
+	return word, prefix
+}
+
+// completionView renders the rest of the completion after the query, greyed
+// out.
+func (m Model) completionView() string {
+	word, prefix := m.completion()
+	if prefix == "" {
+		return ""
+	}
+	return m.muted(prefix[len(word):])
+}
+
+// filterView renders the filter line below the list, if a filter is being
+// edited or applied.
+func (m Model) filterView() string {
+	label := "Filter"
+	if m.filterRegex {
+		label = "Regex"
+	}
+	var invalid string
+	if m.filterErr != nil {
+		invalid = fmt.Sprintf(" invalid pattern: %v", m.filterErr)
+	}
+	if m.mode == modeFilter {
+		return label + ": " + m.filterInput.View() + m.completionView() + invalid + "\n"
+	}
+	if m.filter != "" {
+		var unsearched string
+		if n := len(m.projectErrors); n == 1 {
+			unsearched = ", 1 project not searched"
+		} else if n > 1 {
+			unsearched = fmt.Sprintf(", %d projects not searched", n)
+		}
+		return fmt.Sprintf("%s: %s (%d of %d shown%s, esc to clear)%s\n", label, m.filter, len(m.visible()), len(m.vms), unsearched, invalid)
+	}
+	return ""
+}
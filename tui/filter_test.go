@@ -0,0 +1,332 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesFilter(t *testing.T) {
+	vm := gcp.Instance{Name: "web-1", ProjectID: "shop-prod", Network: "prod-vpc", Subnetwork: "frontend", Status: "RUNNING", Labels: map[string]string{"env": "prod", "team": "web"}}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"web", true},
+		{"WEB", true},
+		{"db", false},
+		{"network:prod", true},
+		{"network:staging", false},
+		{"subnet:frontend", true},
+		{"subnet:backend", false},
+		{"web subnet:front", true},
+		{"web subnet:back", false},
+		{"zone:us", false},
+		{"project:shop", true},
+		{"project:SHOP-PROD web", true},
+		{"project:billing", false},
+		{"project:billing web", false},
+		{"status:running", true},
+		{"status:terminated", false},
+		{"label:env=prod", true},
+		{"label:team=web status:running", true},
+		{"label:env=dev", false},
+		{"label:owner", false},
+	}
+	for _, tt := range tests {
+		if got := matchesFilter(vm, parseFilter(tt.query)); got != tt.want {
+			t.Errorf("matchesFilter(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFilter_AcrossProjects(t *testing.T) {
+	m := NewModel(new(mocks.Client), "shop-prod", WithProjects([]string{"shop-prod", "shop-dev", "billing"}))
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{
+		Instances: []gcp.Instance{
+			{Name: "vm-foo", ProjectID: "shop-prod"},
+			{Name: "vm-bar", ProjectID: "shop-prod"},
+			{Name: "vm-foo", ProjectID: "shop-dev"},
+		},
+		ProjectErrors: []gcp.ProjectError{{ProjectID: "billing", Err: errors.New("permission denied")}},
+	}})
+	m = model.(Model)
+
+	m.setFilter("foo")
+	require.Equal(t, []string{"vm-foo", "vm-foo"}, names(m.visible()), "matches from every loaded project should be shown")
+	view := m.View()
+	require.Contains(t, view, "shop-prod")
+	require.Contains(t, view, "shop-dev")
+	require.Contains(t, view, "(2 of 3 shown, 1 project not searched, esc to clear)")
+	require.Contains(t, view, "Could not load billing")
+
+	m.setFilter("project:dev")
+	require.Len(t, m.visible(), 1)
+	require.Equal(t, "shop-dev", m.visible()[0].ProjectID)
+}
+
+func TestUpdate_Filter(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{
+		{Name: "web-1", Subnetwork: "frontend"},
+		{Name: "db-1", Subnetwork: "backend"},
+		{Name: "web-2", Subnetwork: "frontend"},
+	}
+	m.cursor = 2
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	m = model.(Model)
+	require.Equal(t, modeFilter, m.mode)
+	for _, r := range "subnet:front" {
+		model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = model.(Model)
+	}
+	vm, ok := m.selected()
+	require.True(t, ok)
+	require.Equal(t, "web-2", vm.Name, "the cursor should stay on the selected instance")
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.Equal(t, modeList, m.mode)
+	view := m.View()
+	require.NotContains(t, view, "db-1")
+	require.Contains(t, view, "Filter: subnet:front (2 of 3 shown, esc to clear)")
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	require.Empty(t, m.filter)
+	require.Contains(t, m.View(), "db-1")
+}
+
+func TestUpdate_FilterDebounced(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	for i := range filterDebounceMin {
+		m.vms = append(m.vms, gcp.Instance{Name: fmt.Sprintf("web-%d", i)})
+	}
+	m.vms = append(m.vms, gcp.Instance{Name: "db-1"})
+
+	m, _ = keyPress(t, m, "/")
+	// The ticks scheduled for each key, as they would arrive after the delay.
+	var ticks []tea.Msg
+	for _, r := range "db" {
+		var cmd tea.Cmd
+		m, cmd = keyPress(t, m, string(r))
+		require.NotNil(t, cmd)
+		ticks = append(ticks, filterTickMsg{seq: m.filterSeq})
+	}
+	require.Empty(t, m.filter, "the filter should wait for typing to pause")
+
+	model, _ := m.Update(ticks[0])
+	m = model.(Model)
+	require.Empty(t, m.filter, "a stale tick should be ignored")
+
+	model, _ = m.Update(ticks[1])
+	m = model.(Model)
+	require.Equal(t, "db", m.filter)
+	require.Equal(t, []string{"db-1"}, names(m.visible()))
+
+	m, _ = keyPress(t, m, "x")
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.Equal(t, "dbx", m.filter, "enter should apply the pending filter")
+}
+
+func TestUpdate_VisibleCached(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "web-1"}, {Name: "db-1"}, {Name: "web-2"}}
+
+	m, _ = keyPress(t, m, "/")
+	m, _ = keyPress(t, m, "web")
+	m, _ = keyPress(t, m, "enter")
+	require.NotNil(t, m.shown)
+	require.Equal(t, []string{"web-1", "web-2"}, names(m.shown.vms))
+	shown := m.shown
+
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = model.(Model)
+	require.Same(t, shown, m.shown, "a message that leaves the list alone should not filter it again")
+
+	model, _ = m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "web-3"}, {Name: "db-1"}}}, seq: m.fetchSeq})
+	m = model.(Model)
+	require.Equal(t, []string{"web-3"}, names(m.visible()), "a new list should be filtered again")
+
+	m, _ = keyPress(t, m, "/")
+	for range "web" {
+		model, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+		m = model.(Model)
+	}
+	m, _ = keyPress(t, m, "db")
+	m, _ = keyPress(t, m, "enter")
+	require.Equal(t, []string{"db-1"}, names(m.visible()), "a new filter should be applied")
+
+	model, _ = m.Update(instanceMsg{key: m.instanceKey(gcp.Instance{Name: "db-1"}), vm: gcp.Instance{Name: "db-1", Status: "TERMINATED"}})
+	m = model.(Model)
+	require.Equal(t, "TERMINATED", m.visible()[0].Status, "a refreshed instance should be shown")
+
+	m.setFilter("b")
+	model, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = model.(Model)
+	require.Equal(t, []string{"web-3", "db-1"}, names(m.visible()))
+	model, _ = m.togglePin(gcp.Instance{Name: "db-1"})
+	m = model.(Model)
+	require.Equal(t, []string{"db-1", "web-3"}, names(m.visible()), "a new pin should be sorted on top")
+}
+
+func TestUpdate_RegexFilter(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "web-1"}, {Name: "web-api"}, {Name: "db-web-2"}}
+
+	m, _ = keyPress(t, m, "/")
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = model.(Model)
+	require.True(t, m.filterRegex)
+	for _, r := range `^web-\d+` {
+		m, _ = keyPress(t, m, string(r))
+	}
+	require.NoError(t, m.filterErr)
+	require.NotNil(t, m.filterRE)
+	require.Equal(t, []string{"web-1"}, names(m.visible()))
+	require.Contains(t, m.View(), "Regex: ")
+
+	// An unfinished pattern is reported and the last valid one stays applied.
+	m, _ = keyPress(t, m, "(")
+	require.Error(t, m.filterErr)
+	require.Equal(t, []string{"web-1"}, names(m.visible()))
+	require.Contains(t, m.View(), "invalid pattern")
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	require.NoError(t, m.filterErr)
+	require.Len(t, m.visible(), 3)
+}
+
+func TestUpdate_ToggleStopped(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{
+		{Name: "web-1", Status: "RUNNING"},
+		{Name: "old-1", Status: "TERMINATED"},
+		{Name: "web-2", Status: "RUNNING"},
+		{Name: "idle-1", Status: "SUSPENDED"},
+	}
+	m.cursor = 2
+
+	m, _ = keyPress(t, m, "t")
+	require.Equal(t, []string{"web-1", "web-2"}, names(m.visible()))
+	require.Equal(t, 1, m.cursor, "the cursor should stay on web-2")
+	require.Contains(t, m.View(), "2 stopped hidden, press t to show them")
+
+	// The filter applies on top of hiding.
+	m.setFilter("1")
+	require.Equal(t, []string{"web-1"}, names(m.visible()))
+	m.setFilter("")
+
+	m, _ = keyPress(t, m, "t")
+	require.Len(t, m.visible(), 4)
+	vm, _ := m.selected()
+	require.Equal(t, "web-1", vm.Name)
+	require.NotContains(t, m.View(), "stopped hidden")
+}
+
+func TestUpdate_ToggleExternal(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{
+		{Name: "web-1", ExternalIP: "34.1.2.3"},
+		{Name: "db-1"},
+		{Name: "web-2", ExternalIP: "34.1.2.4"},
+		{Name: "db-2"},
+	}
+	m.cursor = 3
+
+	m, _ = keyPress(t, m, "e")
+	require.Equal(t, []string{"web-1", "web-2"}, names(m.visible()))
+	require.Equal(t, 0, m.cursor, "the cursor should be back in bounds")
+	require.Contains(t, m.View(), "2 instances have external IPs, press e to show all")
+
+	m.cursor = 1
+	m.setFilter("1")
+	require.Equal(t, []string{"web-1"}, names(m.visible()))
+	require.Equal(t, 0, m.cursor)
+	require.Contains(t, m.View(), "2 instances have external IPs")
+	m.setFilter("")
+
+	m, _ = keyPress(t, m, "e")
+	require.Len(t, m.visible(), 4)
+	vm, _ := m.selected()
+	require.Equal(t, "web-1", vm.Name)
+	require.NotContains(t, m.View(), "external IP")
+}
+
+func TestWithConfig_HideStopped(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project", WithConfig("", config.Config{HideStopped: true}, false))
+	m.vms = []gcp.Instance{{Name: "old-1", Status: "TERMINATED"}}
+	require.Empty(t, m.visible())
+	_, ok := m.selected()
+	require.False(t, ok)
+}
+
+func TestCommonPrefix(t *testing.T) {
+	require.Equal(t, "", commonPrefix(nil), "no matches")
+	require.Equal(t, "web-prod-1", commonPrefix([]string{"web-prod-1"}), "a single match completes to its name")
+	require.Equal(t, "web-prod-", commonPrefix([]string{"web-prod-1", "web-prod-2", "web-prod-10"}))
+	require.Equal(t, "web-", commonPrefix([]string{"web-prod-1", "web-dev"}))
+	require.Equal(t, "", commonPrefix([]string{"web-1", "api-1"}))
+	require.Equal(t, "web", commonPrefix([]string{"web", "web-1"}))
+}
+
+func TestUpdate_FilterCompletion(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "web-prod-1"}, {Name: "web-prod-2"}, {Name: "api-prod-1"}, {Name: "web-dev-1"}}
+
+	m, _ = keyPress(t, m, "/")
+	for _, r := range "WEB-P" {
+		m, _ = keyPress(t, m, string(r))
+	}
+	_, prefix := m.completion()
+	require.Equal(t, "web-prod-", prefix)
+	require.Contains(t, m.View(), "rod-\n", "the completion should be shown after the query")
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = model.(Model)
+	require.Equal(t, "web-prod-", m.filterInput.Value())
+	require.Equal(t, "web-prod-", m.filter)
+	_, prefix = m.completion()
+	require.Empty(t, prefix, "nothing is left to complete")
+
+	m, _ = keyPress(t, m, "2")
+	require.Equal(t, []string{"web-prod-2"}, names(m.visible()))
+	_, prefix = m.completion()
+	require.Empty(t, prefix, "the single match is already typed in full")
+
+	for _, query := range []string{"prod", "web ", "network:de", "zzz"} {
+		m.filterInput.SetValue(query)
+		m.setQuery(query)
+		_, prefix = m.completion()
+		require.Empty(t, prefix, query)
+	}
+
+	m.filterInput.SetValue("api web-pr")
+	m.setQuery("api web-pr")
+	_, prefix = m.completion()
+	require.Empty(t, prefix, "no instance matches both terms")
+	m.filterInput.SetValue("prod web-p")
+	m.setQuery("prod web-p")
+	word, prefix := m.completion()
+	require.Equal(t, "web-p", word)
+	require.Equal(t, "web-prod-", prefix, "the last term is completed")
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	require.Equal(t, "prod web-prod-", model.(Model).filterInput.Value())
+}
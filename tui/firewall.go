@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// firewallMsg carries the firewall rules fetched for an instance.
+type firewallMsg struct {
+	vm    gcp.Instance
+	rules []gcp.FirewallRule
+	err   error
+}
+
+// exposure holds the firewall rules that apply to an instance.
+type exposure struct {
+	vm    gcp.Instance
+	rules []gcp.FirewallRule
+}
+
+// fetchFirewallCmd returns a command that fetches the firewall rules of the
+// instance's project.
+func (m Model) fetchFirewallCmd(vm gcp.Instance) tea.Cmd {
+	projectID := m.projectOf(vm)
+	return func() tea.Msg {
+		rules, err := m.gcpClient.FetchFirewallRules(m.ctx, projectID)
+		return firewallMsg{vm: vm, rules: rules, err: err}
+	}
+}
+
+// showFirewall keeps the rules that apply to the instance for the detail
+// view, or explains why they could not be loaded.
+func (m Model) showFirewall(msg firewallMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if errors.Is(msg.err, gcp.ErrFirewallsForbidden) {
+		m.message = fmt.Sprintf("You are not allowed to list the firewall rules of %s, so the exposure of %s is unknown.", m.projectOf(msg.vm), msg.vm.Name)
+		return m, nil
+	}
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Failed to load firewall rules: %v", msg.err)
+		return m, nil
+	}
+	m.exposure = &exposure{vm: msg.vm, rules: gcp.RulesFor(msg.vm, msg.rules)}
+	return m, nil
+}
+
+// firewallView renders the open ports of vm, if they have been loaded.
+func (m Model) firewallView(vm gcp.Instance) string {
+	e := m.exposure
+	if e == nil || e.vm.Name != vm.Name || e.vm.Zone != vm.Zone || e.vm.ProjectID != vm.ProjectID {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nFirewall (ingress allowed by tag match):\n")
+	if len(e.rules) == 0 {
+		b.WriteString("  No rules allow incoming traffic.\n")
+	}
+	for _, r := range e.rules {
+		allowed := make([]string, len(r.Allowed))
+		for i, a := range r.Allowed {
+			allowed[i] = a.String()
+		}
+		b.WriteString(fmt.Sprintf("  %s: %s from %s", r.Name, strings.Join(allowed, " "), strings.Join(r.SourceRanges, ", ")))
+		if r.OpenToInternet() {
+			b.WriteString(" " + m.theme.Stopped.Render("(open to the internet)"))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetail_FirewallExposure(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "web-1", Zone: "z-1", Status: "RUNNING", Network: "prod", Tags: []string{"ssh"}})
+	client.AddFirewallRules(
+		gcp.FirewallRule{Name: "allow-ssh", Network: "prod", TargetTags: []string{"ssh"}, SourceRanges: []string{"0.0.0.0/0"}, Allowed: []gcp.FirewallAllow{{Protocol: "tcp", Ports: []string{"22"}}}},
+		gcp.FirewallRule{Name: "allow-internal", Network: "prod", SourceRanges: []string{"10.0.0.0/8"}, Allowed: []gcp.FirewallAllow{{Protocol: "icmp"}}},
+		gcp.FirewallRule{Name: "allow-db", Network: "prod", TargetTags: []string{"db"}, SourceRanges: []string{"10.0.0.0/8"}, Allowed: []gcp.FirewallAllow{{Protocol: "tcp", Ports: []string{"5432"}}}},
+	)
+	m := loadedModel(t, client)
+
+	m, _ = keyPress(t, m, "i")
+	m, _ = keyPress(t, m, "f")
+	require.True(t, m.loading)
+	model, _ := m.Update(m.fetchFirewallCmd(m.vms[0])())
+	view := model.(Model).View()
+	require.Contains(t, view, "allow-ssh: tcp:22 from 0.0.0.0/0 (open to the internet)")
+	require.Contains(t, view, "allow-internal: icmp from 10.0.0.0/8\n")
+	require.NotContains(t, view, "allow-db")
+}
+
+func TestDetail_FirewallForbidden(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "web-1", Zone: "z-1", Status: "RUNNING"})
+	client.SetError("FetchFirewallRules", gcp.ErrFirewallsForbidden)
+	m := loadedModel(t, client)
+
+	m, _ = keyPress(t, m, "i")
+	model, _ := m.Update(m.fetchFirewallCmd(m.vms[0])())
+	m = model.(Model)
+	require.Equal(t, modeDetail, m.mode)
+	require.Contains(t, m.View(), "You are not allowed to list the firewall rules of test-project")
+}
@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"strings"
+)
+
+// instanceFlags returns short markers for notable traits of vm: attached
+// GPUs, Spot or preemptible scheduling, Shielded and Confidential VM, and
+// deletion protection. With fancy set, deletion protection is shown as a lock
+// emoji rather than "LOCK".
+func instanceFlags(vm gcp.Instance, fancy bool) string {
+	var flags []string
+	switch {
+	case vm.GPUs == 1:
+		flags = append(flags, "GPU")
+	case vm.GPUs > 1:
+		flags = append(flags, fmt.Sprintf("%dxGPU", vm.GPUs))
+	}
+	switch {
+	case vm.ProvisioningModel == "SPOT":
+		flags = append(flags, "SPOT")
+	case vm.Preemptible:
+		flags = append(flags, "PREEMPT")
+	}
+	if vm.ShieldedVM {
+		flags = append(flags, "SHIELDED")
+	}
+	if vm.ConfidentialVM {
+		flags = append(flags, "CVM")
+	}
+	if vm.DeletionProtection {
+		if fancy {
+			flags = append(flags, "🔒")
+		} else {
+			flags = append(flags, "LOCK")
+		}
+	}
+	return strings.Join(flags, " ")
+}
@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		vm    gcp.Instance
+		fancy bool
+		want  string
+	}{
+		{"none", gcp.Instance{}, true, ""},
+		{"one gpu", gcp.Instance{GPUs: 1}, false, "GPU"},
+		{"several gpus", gcp.Instance{GPUs: 4}, false, "4xGPU"},
+		{"spot", gcp.Instance{ProvisioningModel: "SPOT"}, false, "SPOT"},
+		{"preemptible", gcp.Instance{Preemptible: true}, false, "PREEMPT"},
+		{"spot wins over preemptible", gcp.Instance{ProvisioningModel: "SPOT", Preemptible: true}, false, "SPOT"},
+		{"protected plain", gcp.Instance{DeletionProtection: true}, false, "LOCK"},
+		{"protected fancy", gcp.Instance{DeletionProtection: true}, true, "🔒"},
+		{"shielded and confidential", gcp.Instance{ShieldedVM: true, ConfidentialVM: true}, false, "SHIELDED CVM"},
+		{"all", gcp.Instance{GPUs: 1, ProvisioningModel: "SPOT", DeletionProtection: true}, true, "GPU SPOT 🔒"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, instanceFlags(tt.vm, tt.fancy))
+		})
+	}
+}
+
+func TestView_FlagsColumn(t *testing.T) {
+	m := NewModel(new(mocks.Client), "")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING", GPUs: 1, DeletionProtection: true}}
+	m.loading = false
+	require.Contains(t, m.View(), "> [vm-1] RUNNING GPU LOCK\n")
+}
@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"gcp-rider/gcp"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commandRunner runs a non-interactive command until it exits or ctx is
+// done, and returns its combined output.
+type commandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// runCommand is the commandRunner used outside of tests.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// gcloudDefaultsArgs returns the gcloud invocations that make the instance's
+// project and zone the active gcloud defaults.
+func gcloudDefaultsArgs(vm gcp.Instance, projectID string) [][]string {
+	return [][]string{
+		{"config", "set", "project", projectID},
+		{"config", "set", "compute/zone", vm.Zone},
+	}
+}
+
+// gcloudDefaultsMsg reports the outcome of setting the gcloud defaults.
+type gcloudDefaultsMsg struct {
+	vm  gcp.Instance
+	err error
+}
+
+// setGcloudDefaultsCmd returns a command that points gcloud at the instance's
+// project and zone.
+func (m Model) setGcloudDefaultsCmd(vm gcp.Instance) tea.Cmd {
+	run := m.run
+	projectID := m.projectOf(vm)
+	vm = m.located(vm)
+	return func() tea.Msg {
+		for _, args := range gcloudDefaultsArgs(vm, projectID) {
+			if out, err := run(context.Background(), "gcloud", args...); err != nil {
+				return gcloudDefaultsMsg{vm: vm, err: fmt.Errorf("gcloud %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))}
+			}
+		}
+		return gcloudDefaultsMsg{vm: vm}
+	}
+}
+
+// handleGcloudDefaults reports the outcome in the status line.
+func (m Model) handleGcloudDefaults(msg gcloudDefaultsMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Failed to set gcloud defaults: %v", msg.err)
+		return m, nil
+	}
+	m.message = fmt.Sprintf("gcloud now defaults to project %s, zone %s.", m.projectOf(msg.vm), msg.vm.Zone)
+	return m, nil
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGcloudDefaultsArgs(t *testing.T) {
+	vm := gcp.Instance{Name: "vm-1", Zone: "europe-west1-b"}
+	require.Equal(t, [][]string{
+		{"config", "set", "project", "test-project"},
+		{"config", "set", "compute/zone", "europe-west1-b"},
+	}, gcloudDefaultsArgs(vm, "test-project"))
+}
+
+func TestUpdate_SetGcloudDefaults(t *testing.T) {
+	var calls []string
+	m := NewModel(new(mocks.Client), "test-project")
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		return nil, nil
+	}
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1"}}
+	m.loading = false
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")})
+	require.NotNil(t, cmd)
+	model, _ := m.Update(cmd())
+	m = model.(Model)
+
+	require.Equal(t, []string{"gcloud config set project test-project", "gcloud config set compute/zone z-1"}, calls)
+	require.Equal(t, "gcloud now defaults to project test-project, zone z-1.", m.message)
+}
+
+func TestUpdate_SetGcloudDefaultsProjectZone(t *testing.T) {
+	var calls []string
+	cfg := config.Config{ProjectZones: map[string]string{"shop-prod": "europe-west1-b"}}
+	m := NewModel(new(mocks.Client), "test-project", WithConfig("", cfg, false))
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		return nil, nil
+	}
+
+	model, _ := m.Update(m.setGcloudDefaultsCmd(gcp.Instance{Name: "vm-1", ProjectID: "shop-prod"})())
+	require.Equal(t, "gcloud config set compute/zone europe-west1-b", calls[1], "the project's default zone should stand in for an unknown zone")
+	require.Equal(t, "gcloud now defaults to project shop-prod, zone europe-west1-b.", model.(Model).message)
+
+	calls = nil
+	m.setGcloudDefaultsCmd(gcp.Instance{Name: "vm-2", ProjectID: "shop-prod", Zone: "europe-west1-c"})()
+	require.Equal(t, "gcloud config set compute/zone europe-west1-c", calls[1], "the instance's own zone comes first")
+}
+
+func TestUpdate_SetGcloudDefaultsFailure(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte("ERROR: not logged in"), errors.New("exit status 1")
+	}
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1"}}
+	m.loading = false
+
+	model, _ := m.Update(m.setGcloudDefaultsCmd(m.vms[0])())
+	m = model.(Model)
+
+	require.Contains(t, m.message, "Failed to set gcloud defaults")
+	require.Contains(t, m.message, "ERROR: not logged in")
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// keyName returns how key is written in help text.
+func keyName(key string) string {
+	if key == " " {
+		return "space"
+	}
+	return key
+}
+
+// keyHint returns the keys that matter in the current mode, as one line of
+// help shown at the bottom of the screen.
+func (m Model) keyHint() string {
+	key := func(action string) string { return keyName(m.keys.first(action)) }
+	switch m.mode {
+	case modeFilter:
+		matching := "regex"
+		if m.filterRegex {
+			matching = "substring"
+		}
+		return "enter to keep the filter, esc to clear it, tab to complete, ctrl+t for " + matching + " matching"
+	case modeNote:
+		return "enter to save (empty to remove), esc to cancel"
+	case modeDetail:
+		vm, _ := m.selected()
+		hint := fmt.Sprintf("%s to change machine type, %s to check firewall exposure, %s to view IAM access, %s to view region quotas, %s to turn deletion protection %s",
+			key(actionMachineType), key(actionFirewall), key(actionIAM), key(actionQuotas), key(actionDeletionProtection), onOff(!vm.DeletionProtection))
+		if m.locked() {
+			hint = fmt.Sprintf("%s to check firewall exposure, %s to view IAM access, %s to view region quotas, %s and %s locked (press %s to unlock)",
+				key(actionFirewall), key(actionIAM), key(actionQuotas), key(actionMachineType), key(actionDeletionProtection), key(actionUnlock))
+		}
+		if len(longFields(vm)) > 0 && !m.wrap {
+			hint += fmt.Sprintf(", tab and %s to expand long values", key(actionExpand))
+		}
+		wrap := "wrap long lines"
+		if m.wrap {
+			wrap = "cut long lines short"
+		}
+		return fmt.Sprintf("%s, %s to copy as Terraform, %s to %s, %s to view logs, esc to go back", hint, key(actionTerraform), key(actionWrap), wrap, key(actionLogs))
+	case modeMachineType:
+		return "enter to apply, esc to cancel"
+	case modeLogs:
+		return "↑/↓ to scroll, esc to go back"
+	case modeZones:
+		return "space to select, enter to load the selected zones, esc to load all zones"
+	case modeRecent:
+		return "enter to connect, esc to go back"
+	case modeQueries:
+		return "enter to apply, esc to go back"
+	case modeHistory:
+		return "↑/↓ to scroll, esc to go back"
+	case modeUnlock:
+		return "enter to unlock, esc to cancel"
+	case modeRegions:
+		if m.regionSearching {
+			return "enter to keep the search, esc to clear it"
+		}
+		return "↑/↓ to scroll, / to search, esc to go back"
+	case modeTree:
+		return "↑/↓ to move, ←/→ to collapse or expand, enter to select the instance, esc to go back"
+	case modeBulkConfirm:
+		if m.bulk.warning != "" {
+			return fmt.Sprintf("%s to override do not disturb and %s %d instances, any other key to cancel", dndOverride, strings.ToLower(m.bulk.verb), len(m.bulk.targets))
+		}
+		return fmt.Sprintf("y to %s %d instances, any other key to cancel", strings.ToLower(m.bulk.verb), len(m.bulk.targets))
+	}
+	if len(m.marked) > 0 && m.locked() {
+		return fmt.Sprintf("%s and %s locked (press %s to unlock), %s to SSH into the %d marked, %s to mark more, esc to clear the marks, %s to quit",
+			key(actionSuspend), key(actionResume), key(actionUnlock), key(actionSSH), len(m.marked), key(actionMark), key(actionQuit))
+	}
+	if len(m.marked) > 0 {
+		return fmt.Sprintf("%s to suspend, %s to resume or %s to SSH into the %d marked, %s to mark more, esc to clear the marks, %s to quit",
+			key(actionSuspend), key(actionResume), key(actionSSH), len(m.marked), key(actionMark), key(actionQuit))
+	}
+	if m.locked() {
+		return fmt.Sprintf("%s to connect, %s for details, %s to filter, %s to mark, %s and %s locked (press %s to unlock), %s to quit",
+			key(actionSSH), key(actionDetail), key(actionFilter), key(actionMark), key(actionSuspend), key(actionResume), key(actionUnlock), key(actionQuit))
+	}
+	return fmt.Sprintf("%s to connect, %s for details, %s to filter, %s to mark, %s to quit",
+		key(actionSSH), key(actionDetail), key(actionFilter), key(actionMark), key(actionQuit))
+}
+
+// hintView renders the key hint of the current mode. It wraps rather than
+// being cut short on narrow terminals, so that no key goes missing. Quiet
+// mode shows none.
+func (m Model) hintView() string {
+	if m.quiet {
+		return ""
+	}
+	hint := "Press " + m.keyHint() + "."
+	if m.width > 0 {
+		hint = ansi.Wrap(hint, m.width, "")
+	}
+	return hint + "\n"
+}
@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyHint_PerMode(t *testing.T) {
+	tests := []struct {
+		mode mode
+		want string
+	}{
+		{modeList, "enter to connect, i for details, / to filter, space to mark, q to quit"},
+		{modeFilter, "enter to keep the filter, esc to clear it, tab to complete, ctrl+t for regex matching"},
+		{modeNote, "enter to save (empty to remove), esc to cancel"},
+		{modeDetail, "m to change machine type, f to check firewall exposure, a to view IAM access, Q to view region quotas, d to turn deletion protection on, T to copy as Terraform, w to wrap long lines, l to view logs, esc to go back"},
+		{modeMachineType, "enter to apply, esc to cancel"},
+		{modeLogs, "↑/↓ to scroll, esc to go back"},
+		{modeZones, "space to select, enter to load the selected zones, esc to load all zones"},
+		{modeRecent, "enter to connect, esc to go back"},
+		{modeQueries, "enter to apply, esc to go back"},
+	}
+	for _, tt := range tests {
+		m := NewModel(new(mocks.Client), "test-project")
+		m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}}
+		m.mode = tt.mode
+		require.Equal(t, tt.want, m.keyHint(), "mode %d", tt.mode)
+	}
+}
+
+func TestKeyHint_FollowsState(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}, {Name: "vm-2", Zone: "z-1", Status: "RUNNING"}}
+
+	m.marked = map[string]bool{m.instanceKey(m.vms[0]): true, m.instanceKey(m.vms[1]): true}
+	require.Equal(t, "S to suspend, R to resume or enter to SSH into the 2 marked, space to mark more, esc to clear the marks, q to quit", m.keyHint())
+
+	m.mode = modeBulkConfirm
+	m.bulk = &bulkAction{verb: "Suspend", targets: m.vms}
+	require.Equal(t, "y to suspend 2 instances, any other key to cancel", m.keyHint())
+
+	m.mode = modeFilter
+	m.filterRegex = true
+	require.Contains(t, m.keyHint(), "ctrl+t for substring matching")
+}
+
+func TestKeyHint_UsesRemappedKeys(t *testing.T) {
+	keys, err := NewKeyMap(map[string][]string{actionQuit: {"ctrl+q"}, actionSSH: {"s"}})
+	require.NoError(t, err)
+	m := NewModel(new(mocks.Client), "test-project", WithKeyMap(keys))
+	require.Equal(t, "s to connect, i for details, / to filter, space to mark, ctrl+q to quit", m.keyHint())
+}
+
+func TestView_HintWrapsOnNarrowTerminals(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}}
+	m.loading = false
+	m.width = 40
+
+	view := m.View()
+	require.Contains(t, view, "Press enter to connect, i for details, /")
+	require.Contains(t, view, "q to quit.", "no key should be cut off")
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyEntry is an action taken during the session, such as "Stopped
+// vm-1.", kept to recall what was done. Nothing is persisted.
+type historyEntry struct {
+	at     time.Time
+	text   string
+	failed bool
+}
+
+// record adds text to the history of the session, at the current time.
+func (m *Model) record(text string, failed bool) {
+	// Clip so that earlier copies of the model keep their own history.
+	m.history = append(slices.Clip(m.history), historyEntry{at: m.now(), text: text, failed: failed})
+}
+
+// recordBulk adds the outcome of a bulk action to the history, one entry
+// per instance.
+func (m *Model) recordBulk(msg bulkDoneMsg) {
+	for _, name := range msg.succeeded {
+		m.record(fmt.Sprintf("%s %s.", msg.done, name), false)
+	}
+	for _, err := range msg.failed {
+		m.record(fmt.Sprintf("Could not %s %v", strings.ToLower(msg.verb), err), true)
+	}
+}
+
+// openHistory shows the actions taken during the session.
+func (m Model) openHistory() (tea.Model, tea.Cmd) {
+	if len(m.history) == 0 {
+		m.message = "No actions taken yet this session."
+		return m, nil
+	}
+	m.message = ""
+	m.mode = modeHistory
+	m.historyBack = 0
+	return m, nil
+}
+
+// historyRowsHeight returns how many entries of the history fit on the
+// screen, or -1 while the terminal size is unknown.
+func (m Model) historyRowsHeight() int {
+	if m.height <= 0 {
+		return -1
+	}
+	// Leave room for the title, the hint and the blank lines around.
+	return max(1, m.height-strings.Count(m.hintView(), "\n")-4)
+}
+
+// shownHistory returns the entries of the history shown: those that fit
+// the screen, ending historyBack entries before the latest.
+func (m Model) shownHistory() []historyEntry {
+	end := len(m.history) - min(m.historyBack, len(m.history))
+	entries := m.history[:end]
+	if height := m.historyRowsHeight(); height >= 0 {
+		entries = entries[max(0, end-height):]
+	}
+	return entries
+}
+
+// updateHistory handles key presses in the session history.
+func (m Model) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m.quit()
+	case "up", "k":
+		if height := m.historyRowsHeight(); height >= 0 && len(m.history)-m.historyBack > height {
+			m.historyBack++
+		}
+	case "down", "j":
+		m.historyBack = max(0, m.historyBack-1)
+	case "esc", m.keys.first(actionHistory):
+		m.mode = modeList
+	}
+	return m, nil
+}
+
+// historyView renders the actions taken during the session, oldest first.
+// The most recent ones are shown until it is scrolled back when they do not
+// fit the terminal.
+func (m Model) historyView() string {
+	var b strings.Builder
+	b.WriteString("Actions this session:\n\n")
+	for _, e := range m.shownHistory() {
+		text := e.text
+		if e.failed {
+			text = m.theme.Stopped.Render(text)
+		}
+		b.WriteString(m.fitLine(fmt.Sprintf("  %s %s", m.muted(e.at.Format("15:04:05")), text), 0) + "\n")
+	}
+	b.WriteString("\n" + m.hintView())
+	return b.String()
+}
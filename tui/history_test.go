@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"gcp-rider/gcp/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	at := time.Date(2025, 3, 10, 12, 4, 0, 0, time.UTC)
+	m.now = func() time.Time { return at }
+
+	m.record("Stopped vm-x.", false)
+	before := m
+	m.record("Could not start vm-y: quota exceeded", true)
+
+	require.Equal(t, []historyEntry{
+		{at: at, text: "Stopped vm-x."},
+		{at: at, text: "Could not start vm-y: quota exceeded", failed: true},
+	}, m.history)
+	require.Len(t, before.history, 1, "earlier copies of the model should keep their history")
+}
+
+func TestRecordBulk(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.recordBulk(bulkDoneMsg{
+		verb: "Suspend", done: "Suspended", count: 2,
+		succeeded: []string{"web-1", "web-2"},
+		failed:    []error{errors.New("db-1: quota exceeded")},
+	})
+
+	var texts []string
+	for _, e := range m.history {
+		texts = append(texts, e.text)
+	}
+	require.Equal(t, []string{"Suspended web-1.", "Suspended web-2.", "Could not suspend db-1: quota exceeded"}, texts)
+	require.True(t, m.history[2].failed)
+}
+
+func TestUpdate_History(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+	m := loadedModel(t, client)
+	m.now = func() time.Time { return time.Date(2025, 3, 10, 12, 4, 5, 0, time.Local) }
+
+	m, _ = keyPress(t, m, "H")
+	require.Equal(t, modeList, m.mode)
+	require.Equal(t, "No actions taken yet this session.", m.message)
+
+	model, _ := m.Update(actionDoneMsg{"Suspended vm-1."})
+	m = model.(Model)
+	model, _ = m.Update(actionErrMsg{err: errors.New("quota exceeded"), action: "start vm-1"})
+	m = model.(Model)
+	model, _ = m.Update(actionErrMsg{err: errors.New("disk full")})
+	m = model.(Model)
+	m.loading = false
+	require.Len(t, m.history, 2, "failures that are not actions against an instance are not recorded")
+
+	m, _ = keyPress(t, m, "H")
+	require.Equal(t, modeHistory, m.mode)
+	view := m.View()
+	require.Contains(t, view, "Actions this session:")
+	require.Contains(t, view, "  12:04:05 Suspended vm-1.\n  12:04:05 Could not start vm-1: quota exceeded\n")
+	require.Contains(t, view, "Press ↑/↓ to scroll, esc to go back.")
+
+	m, _ = keyPress(t, m, "esc")
+	require.Equal(t, modeList, m.mode)
+}
+
+func TestView_HistoryShowsLatest(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	for _, text := range []string{"Started a.", "Started b.", "Started c."} {
+		m.record(text, false)
+	}
+	m.height = 7
+	m.mode = modeHistory
+
+	view := m.View()
+	require.NotContains(t, view, "Started a.")
+	require.Contains(t, view, "Started b.")
+	require.Contains(t, view, "Started c.")
+}
+
+func TestUpdate_HistoryScrolls(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	for _, text := range []string{"Started a.", "Started b.", "Started c.", "Started d."} {
+		m.record(text, false)
+	}
+	m.height = 7
+
+	m, _ = keyPress(t, m, "H")
+	require.NotContains(t, m.View(), "Started b.")
+	require.Contains(t, m.View(), "Started d.")
+
+	for range 3 {
+		m, _ = keyPress(t, m, "k")
+	}
+	view := m.View()
+	require.Contains(t, view, "Started a.\n", "earlier actions should be reachable")
+	require.Contains(t, view, "Started b.\n")
+	require.NotContains(t, view, "Started c.")
+
+	m, _ = keyPress(t, m, "j")
+	require.Contains(t, m.View(), "Started c.\n")
+	require.NotContains(t, m.View(), "Started a.")
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// iamMsg carries the IAM bindings fetched for an instance.
+type iamMsg struct {
+	vm       gcp.Instance
+	bindings []gcp.IAMBinding
+	err      error
+}
+
+// access holds the IAM bindings of an instance.
+type access struct {
+	vm       gcp.Instance
+	bindings []gcp.IAMBinding
+}
+
+// fetchIAMCmd returns a command that fetches the IAM policy of the instance.
+func (m Model) fetchIAMCmd(vm gcp.Instance) tea.Cmd {
+	projectID, zone := m.projectOf(vm), m.located(vm).Zone
+	return func() tea.Msg {
+		bindings, err := m.gcpClient.GetInstanceIAM(m.ctx, projectID, zone, vm.Name)
+		return iamMsg{vm: vm, bindings: bindings, err: err}
+	}
+}
+
+// showIAM keeps the IAM bindings of the instance for the detail view, or
+// explains why they could not be loaded.
+func (m Model) showIAM(msg iamMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if errors.Is(msg.err, gcp.ErrIAMForbidden) {
+		m.message = fmt.Sprintf("You are not allowed to read the IAM policy of %s.", msg.vm.Name)
+		return m, nil
+	}
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Failed to load IAM policy: %v", msg.err)
+		return m, nil
+	}
+	m.access = &access{vm: msg.vm, bindings: msg.bindings}
+	return m, nil
+}
+
+// iamView renders the IAM bindings of vm, if they have been loaded.
+func (m Model) iamView(vm gcp.Instance) string {
+	a := m.access
+	if a == nil || a.vm.Name != vm.Name || a.vm.Zone != vm.Zone || a.vm.ProjectID != vm.ProjectID {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nIAM (granted on the instance itself):\n")
+	if len(a.bindings) == 0 {
+		b.WriteString("  No instance-level bindings; access comes from the project and above.\n")
+	}
+	for _, binding := range a.bindings {
+		b.WriteString(fmt.Sprintf("  %s: %s", binding.Role, strings.Join(binding.Members, ", ")))
+		if binding.Condition != "" {
+			b.WriteString(" " + m.theme.Muted.Render("(if "+binding.Condition+")"))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetail_IAM(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "web-1", Zone: "z-1", Status: "RUNNING"})
+	client.SetIAM("test-project", "z-1", "web-1",
+		gcp.IAMBinding{Role: "roles/compute.osAdminLogin", Members: []string{"user:alice@example.com", "group:ops@example.com"}},
+		gcp.IAMBinding{Role: "roles/compute.instanceAdmin.v1", Members: []string{"user:bob@example.com"}, Condition: "business hours"},
+	)
+	m := loadedModel(t, client)
+
+	m, _ = keyPress(t, m, "i")
+	m, _ = keyPress(t, m, "a")
+	require.True(t, m.loading)
+	model, _ := m.Update(m.fetchIAMCmd(m.vms[0])())
+	view := model.(Model).View()
+	require.Contains(t, view, "roles/compute.osAdminLogin: user:alice@example.com, group:ops@example.com\n")
+	require.Contains(t, view, "roles/compute.instanceAdmin.v1: user:bob@example.com (if business hours)")
+}
+
+func TestDetail_IAMEmptyPolicy(t *testing.T) {
+	m := loadedModel(t, gcptest.NewFakeClient(gcp.Instance{Name: "web-1", Zone: "z-1", Status: "RUNNING"}))
+
+	m, _ = keyPress(t, m, "i")
+	model, _ := m.Update(m.fetchIAMCmd(m.vms[0])())
+	require.Contains(t, model.(Model).View(), "No instance-level bindings")
+}
+
+func TestDetail_IAMForbidden(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "web-1", Zone: "z-1", Status: "RUNNING"})
+	client.SetError("GetInstanceIAM", gcp.ErrIAMForbidden)
+	m := loadedModel(t, client)
+
+	m, _ = keyPress(t, m, "i")
+	model, _ := m.Update(m.fetchIAMCmd(m.vms[0])())
+	m = model.(Model)
+	require.Equal(t, modeDetail, m.mode)
+	require.Contains(t, m.View(), "You are not allowed to read the IAM policy of web-1.")
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"gcp-rider/config"
+	"path"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// identityTimeout bounds how long looking up the caller's account may take.
+const identityTimeout = 10 * time.Second
+
+// identityMsg carries the account the credentials act as.
+type identityMsg struct {
+	identity string
+	err      error
+}
+
+// loadIdentityCmd looks up the account the credentials act as.
+func (m Model) loadIdentityCmd() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), identityTimeout)
+	defer cancel()
+	identity, err := m.whoami(ctx)
+	return identityMsg{identity: identity, err: err}
+}
+
+// handleIdentity keeps the account for the environment banner.
+func (m Model) handleIdentity(msg identityMsg) (tea.Model, tea.Cmd) {
+	m.identity = msg.identity
+	if msg.err != nil {
+		m.identity = "unknown account"
+		if m.verbose {
+			m.identity += fmt.Sprintf(" (%v)", msg.err)
+		}
+	}
+	return m, nil
+}
+
+// isProd reports whether projectID matches the production patterns of the
+// config.
+func (m Model) isProd(projectID string) bool {
+	patterns := m.cfg.ProdProjects
+	if patterns == nil {
+		patterns = config.DefaultProdProjects
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, projectID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// envView renders the account and projects being acted on, highlighted when
+// a project is production so that it is not mistaken for another one. It is
+// empty when the account is not looked up.
+func (m Model) envView() string {
+	if m.whoami == nil {
+		return ""
+	}
+	identity := m.identity
+	if identity == "" {
+		identity = "..."
+	}
+	projects := []string{m.projectID}
+	if m.multiProject() {
+		projects = m.projects
+	}
+	prod := false
+	for _, p := range projects {
+		prod = prod || m.isProd(p)
+	}
+	line := fmt.Sprintf("Account: %s  Project: %s", identity, strings.Join(projects, ", "))
+	if prod {
+		return m.theme.Stopped.Render(line+"  [PROD]") + "\n"
+	}
+	return m.muted(line) + "\n"
+}
@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsProd(t *testing.T) {
+	m := NewModel(gcptest.NewFakeClient(), "shop-prod")
+	require.True(t, m.isProd("shop-prod"), "the default patterns should match")
+	require.False(t, m.isProd("shop-dev"))
+
+	m = NewModel(gcptest.NewFakeClient(), "shop-prod", WithConfig("", config.Config{ProdProjects: []string{"billing", "live-*"}}, false))
+	require.True(t, m.isProd("live-eu"))
+	require.True(t, m.isProd("billing"))
+	require.False(t, m.isProd("shop-prod"))
+
+	m = NewModel(gcptest.NewFakeClient(), "shop-prod", WithConfig("", config.Config{ProdProjects: []string{}}, false))
+	require.False(t, m.isProd("shop-prod"), "an empty list should highlight nothing")
+}
+
+func TestView_Environment(t *testing.T) {
+	whoami := func(context.Context) (string, error) { return "alice@example.com", nil }
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+	m := NewModel(client, "shop-prod", WithIdentity(whoami))
+	model, _ := m.Update(m.fetchVmsCmd())
+	m = model.(Model)
+	require.Contains(t, m.View(), "Account: ...  Project: shop-prod  [PROD]\nGCP VMs:")
+
+	model, _ = m.Update(m.loadIdentityCmd())
+	m = model.(Model)
+	require.Contains(t, m.View(), "Account: alice@example.com  Project: shop-prod  [PROD]\n")
+
+	m = NewModel(client, "shop-dev", WithIdentity(func(context.Context) (string, error) { return "", errors.New("offline") }))
+	model, _ = m.Update(m.loadIdentityCmd())
+	m = model.(Model)
+	m.loading = false
+	require.Contains(t, m.View(), "Account: unknown account  Project: shop-dev\n")
+}
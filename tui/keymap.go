@@ -0,0 +1,203 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Actions that can be remapped through the config file.
+const (
+	actionQuit         = "quit"
+	actionUp           = "up"
+	actionDown         = "down"
+	actionSSH          = "ssh"
+	actionDetail       = "detail"
+	actionLogs         = "logs"
+	actionDense        = "dense"
+	actionRefreshOne   = "refresh-one"
+	actionSummary      = "summary"
+	actionGcloud       = "gcloud"
+	actionSuspend      = "suspend"
+	actionResume       = "resume"
+	actionPin          = "pin"
+	actionFilter       = "filter"
+	actionReload       = "reload"
+	actionZones        = "zones"
+	actionReconnect    = "reconnect"
+	actionRetrySSH     = "retry-ssh"
+	actionRecent       = "recent"
+	actionStopped      = "stopped"
+	actionNote         = "note"
+	actionMark         = "mark"
+	actionQueries      = "queries"
+	actionExternal     = "external"
+	actionCopySSH      = "copy-ssh"
+	actionAppendSSH    = "append-ssh"
+	actionAccessible   = "accessible"
+	actionMouse        = "mouse"
+	actionWrap         = "wrap"
+	actionNewest       = "newest"
+	actionTree         = "tree"
+	actionNextMatch    = "next-match"
+	actionPrevMatch    = "prev-match"
+	actionHistory      = "history"
+	actionDoNotDisturb = "do-not-disturb"
+	actionRegions      = "regions"
+	actionUnlock       = "unlock"
+
+	// Actions of the detail view.
+	actionMachineType        = "machine-type"
+	actionFirewall           = "firewall"
+	actionIAM                = "iam"
+	actionQuotas             = "quotas"
+	actionExpand             = "expand"
+	actionDeletionProtection = "deletion-protection"
+	actionTerraform          = "terraform"
+)
+
+// defaultKeys are the bindings used when the config does not override them.
+var defaultKeys = map[string][]string{
+	actionQuit:         {"q"},
+	actionUp:           {"up", "k"},
+	actionDown:         {"down", "j"},
+	actionSSH:          {"enter"},
+	actionDetail:       {"i"},
+	actionLogs:         {"l"},
+	actionDense:        {"v"},
+	actionRefreshOne:   {"f"},
+	actionSummary:      {"c"},
+	actionGcloud:       {"g"},
+	actionSuspend:      {"S"},
+	actionResume:       {"R"},
+	actionPin:          {"p"},
+	actionFilter:       {"/"},
+	actionReload:       {"ctrl+r"},
+	actionZones:        {"z"},
+	actionReconnect:    {"."},
+	actionRetrySSH:     {"r"},
+	actionRecent:       {"h"},
+	actionStopped:      {"t"},
+	actionNote:         {"a"},
+	actionMark:         {" "},
+	actionQueries:      {"o"},
+	actionExternal:     {"e"},
+	actionCopySSH:      {"y"},
+	actionAppendSSH:    {"Y"},
+	actionAccessible:   {"u"},
+	actionMouse:        {"m"},
+	actionWrap:         {"w"},
+	actionNewest:       {"A"},
+	actionTree:         {"T"},
+	actionNextMatch:    {"n"},
+	actionPrevMatch:    {"N"},
+	actionHistory:      {"H"},
+	actionDoNotDisturb: {"D"},
+	actionRegions:      {"Z"},
+	actionUnlock:       {"U"},
+
+	actionMachineType:        {"m"},
+	actionFirewall:           {"f"},
+	actionIAM:                {"a"},
+	actionQuotas:             {"Q"},
+	actionExpand:             {"e"},
+	actionDeletionProtection: {"d"},
+	actionTerraform:          {"T"},
+}
+
+// detailActions are the actions of the detail view alone. Their keys only
+// have to differ from those of the other actions of the detail view, so
+// they may reuse keys of the list.
+var detailActions = []string{
+	actionMachineType, actionFirewall, actionIAM, actionQuotas,
+	actionExpand, actionDeletionProtection, actionTerraform,
+}
+
+// detailScope are the actions the detail view responds to: its own and
+// those of the list it keeps.
+var detailScope = append([]string{actionQuit, actionDetail, actionLogs, actionWrap}, detailActions...)
+
+// detailReserved are the keys the detail view handles itself, which cannot
+// be bound to its actions.
+var detailReserved = []string{"esc", "backspace", "tab", "shift+tab"}
+
+// KeyMap maps the list view's actions to the keys that trigger them.
+type KeyMap struct {
+	bindings map[string][]string
+}
+
+// DefaultKeyMap returns the built-in key bindings.
+func DefaultKeyMap() KeyMap {
+	km, _ := NewKeyMap(nil)
+	return km
+}
+
+// NewKeyMap applies overrides on top of the default bindings. It fails if an
+// override names an unknown action, if two actions of the same view share a
+// key, or if a key the detail view handles itself is bound to its actions.
+func NewKeyMap(overrides map[string][]string) (KeyMap, error) {
+	bindings := make(map[string][]string, len(defaultKeys))
+	for action, keys := range defaultKeys {
+		bindings[action] = keys
+	}
+	for action, keys := range overrides {
+		if _, ok := defaultKeys[action]; !ok {
+			return KeyMap{}, fmt.Errorf("unknown key binding action %q", action)
+		}
+		if len(keys) == 0 {
+			return KeyMap{}, fmt.Errorf("no keys bound to action %q", action)
+		}
+		bindings[action] = keys
+	}
+
+	var listScope []string
+	for action := range bindings {
+		if !slices.Contains(detailActions, action) {
+			listScope = append(listScope, action)
+		}
+	}
+	if err := checkConflicts(bindings, listScope); err != nil {
+		return KeyMap{}, err
+	}
+	if err := checkConflicts(bindings, detailScope); err != nil {
+		return KeyMap{}, err
+	}
+	for _, action := range detailScope {
+		for _, key := range bindings[action] {
+			if slices.Contains(detailReserved, key) {
+				return KeyMap{}, fmt.Errorf("key %q of %q is reserved by the detail view", key, action)
+			}
+		}
+	}
+	return KeyMap{bindings: bindings}, nil
+}
+
+// checkConflicts reports two of actions sharing a key.
+func checkConflicts(bindings map[string][]string, actions []string) error {
+	// Check actions in a stable order so the reported conflict is deterministic.
+	actions = slices.Clone(actions)
+	sort.Strings(actions)
+	owners := make(map[string]string)
+	for _, action := range actions {
+		for _, key := range bindings[action] {
+			if owner, ok := owners[key]; ok && owner != action {
+				return fmt.Errorf("key %q is bound to both %q and %q", key, owner, action)
+			}
+			owners[key] = action
+		}
+	}
+	return nil
+}
+
+// matches reports whether key triggers action.
+func (km KeyMap) matches(action, key string) bool {
+	return slices.Contains(km.bindings[action], key)
+}
+
+// first returns the primary key bound to action, for use in help text.
+func (km KeyMap) first(action string) string {
+	if keys := km.bindings[action]; len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
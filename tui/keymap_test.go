@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyMap_Defaults(t *testing.T) {
+	km, err := NewKeyMap(nil)
+	require.NoError(t, err)
+	require.True(t, km.matches(actionQuit, "q"))
+	require.True(t, km.matches(actionDown, "j"))
+	require.True(t, km.matches(actionSSH, "enter"))
+}
+
+func TestNewKeyMap_Override(t *testing.T) {
+	km, err := NewKeyMap(map[string][]string{actionSSH: {"s"}})
+	require.NoError(t, err)
+	require.True(t, km.matches(actionSSH, "s"))
+	require.False(t, km.matches(actionSSH, "enter"), "overrides replace the default keys")
+	require.True(t, km.matches(actionQuit, "q"), "other actions keep their defaults")
+}
+
+func TestNewKeyMap_Conflict(t *testing.T) {
+	_, err := NewKeyMap(map[string][]string{actionSSH: {"q"}})
+	require.ErrorContains(t, err, `key "q" is bound to both`)
+}
+
+func TestNewKeyMap_DetailScope(t *testing.T) {
+	_, err := NewKeyMap(nil)
+	require.NoError(t, err, "detail actions may reuse keys of the list")
+
+	_, err = NewKeyMap(map[string][]string{actionTerraform: {"l"}})
+	require.ErrorContains(t, err, `key "l" is bound to both "logs" and "terraform"`)
+
+	_, err = NewKeyMap(map[string][]string{actionQuotas: {"tab"}})
+	require.ErrorContains(t, err, `key "tab" of "quotas" is reserved by the detail view`)
+}
+
+func TestUpdateDetail_UsesKeyMap(t *testing.T) {
+	km, err := NewKeyMap(map[string][]string{actionQuit: {"x", "ctrl+q"}, actionTerraform: {"t"}})
+	require.NoError(t, err)
+
+	m := NewModel(new(mocks.Client), "", WithKeyMap(km))
+	m.vms = []gcp.Instance{{Name: "vm-1"}}
+	m.loading = false
+	m.mode = modeDetail
+	require.Contains(t, m.View(), "t to copy as Terraform")
+
+	_, cmd := keyPress(t, m, "q")
+	require.Nil(t, cmd, "the default quit key should no longer quit from the details")
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlQ})
+	require.NotNil(t, cmd, "secondary keys should work in the details too")
+	require.Equal(t, tea.QuitMsg{}, cmd())
+}
+
+func TestNewKeyMap_UnknownAction(t *testing.T) {
+	_, err := NewKeyMap(map[string][]string{"launch": {"x"}})
+	require.ErrorContains(t, err, `unknown key binding action "launch"`)
+}
+
+func TestNewKeyMap_EmptyBinding(t *testing.T) {
+	_, err := NewKeyMap(map[string][]string{actionQuit: {}})
+	require.Error(t, err)
+}
+
+func TestUpdate_UsesKeyMap(t *testing.T) {
+	km, err := NewKeyMap(map[string][]string{actionDown: {"x"}})
+	require.NoError(t, err)
+
+	m := NewModel(new(mocks.Client), "", WithKeyMap(km))
+	m.vms = []gcp.Instance{{Name: "vm-1"}, {Name: "vm-2"}}
+	m.loading = false
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = model.(Model)
+	require.Equal(t, 0, m.cursor, "the default key should no longer move the cursor")
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = model.(Model)
+	require.Equal(t, 1, m.cursor, "the remapped key should move the cursor")
+}
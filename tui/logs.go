@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// logLimit is the number of recent log entries fetched for an instance.
+const logLimit = 100
+
+// logsMsg is sent when the log entries for an instance have been fetched.
+type logsMsg struct {
+	vm      gcp.Instance
+	entries []gcp.LogEntry
+	err     error
+}
+
+// fetchLogsCmd returns a command that fetches the recent logs of an instance.
+func (m Model) fetchLogsCmd(vm gcp.Instance) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := m.gcpClient.FetchLogs(m.ctx, m.projectOf(vm), vm.ID, logLimit)
+		return logsMsg{vm: vm, entries: entries, err: err}
+	}
+}
+
+// openLogs starts fetching the logs of the instance under the cursor.
+func (m Model) openLogs() (tea.Model, tea.Cmd) {
+	m.prevMode = m.mode
+	m.message = ""
+	vm, _ := m.selected()
+	return m, m.startLoading("Loading logs...", m.fetchLogsCmd(vm))
+}
+
+// showLogs handles fetched log entries, falling back to the previous screen
+// with a message if they could not be loaded.
+func (m Model) showLogs(msg logsMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if errors.Is(msg.err, gcp.ErrLoggingDisabled) {
+		m.message = fmt.Sprintf("Cloud Logging is not enabled. Enable it with: gcloud services enable logging.googleapis.com --project %s", m.projectOf(msg.vm))
+		return m, nil
+	}
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Failed to load logs: %v", msg.err)
+		return m, nil
+	}
+	m.mode = modeLogs
+	m.logs.SetContent(formatLogs(msg.entries))
+	m.logs.GotoBottom()
+	return m, nil
+}
+
+// updateLogs handles key presses while the log viewer is shown.
+func (m Model) updateLogs(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		m.mode = m.prevMode
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.logs, cmd = m.logs.Update(msg)
+	return m, cmd
+}
+
+// formatLogs renders log entries oldest first, like a tail.
+func formatLogs(entries []gcp.LogEntry) string {
+	if len(entries) == 0 {
+		return "No log entries found."
+	}
+	var b strings.Builder
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		b.WriteString(fmt.Sprintf("%s %-8s %s\n", e.Timestamp, e.Severity, e.Message))
+	}
+	return b.String()
+}
+
+// logsViewportSize returns the viewport dimensions for a terminal size,
+// leaving room for the title and the help line.
+func logsViewportSize(width, height int) (int, int) {
+	return width, max(height-4, 1)
+}
+
+// logsView renders the log viewer.
+func (m Model) logsView() string {
+	vm, _ := m.selected()
+	return fmt.Sprintf("Logs for %s:\n\n%s\n", vm.Name, m.logs.View()) + m.hintView()
+}
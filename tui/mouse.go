@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// toggleMouse turns mouse support on or off. It is off unless the config
+// turns it on, as some terminals do not report mouse events well.
+func (m Model) toggleMouse() (tea.Model, tea.Cmd) {
+	m.mouse = !m.mouse
+	if m.mouse {
+		m.message = "Mouse on: click a row to select it, scroll to move."
+		return m, tea.EnableMouseCellMotion
+	}
+	m.message = "Mouse off."
+	return m, tea.DisableMouse
+}
+
+// rowsTop returns the line of the view where the first instance is shown.
+func (m Model) rowsTop() int {
+	header, _ := m.listChrome()
+	return strings.Count(header, "\n")
+}
+
+// updateMouse selects the clicked instance and moves the cursor with the
+// wheel in the list, and scrolls the detail and log views.
+func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch m.mode {
+	case modeDetail:
+		vm, _ := m.selected()
+		m.detail.SetContent(m.fitLines(m.detailBody(vm), 16))
+		m.detail, cmd = m.detail.Update(msg)
+		return m, cmd
+	case modeLogs:
+		m.logs, cmd = m.logs.Update(msg)
+		return m, cmd
+	case modeList:
+	default:
+		return m, nil
+	}
+	if m.confirm != nil {
+		return m, nil
+	}
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case msg.Button == tea.MouseButtonWheelDown:
+		if m.cursor < len(m.visible())-1 {
+			m.cursor++
+		}
+	case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress:
+		if row, ok := m.rowAt(msg.Y - m.rowsTop()); ok {
+			m.cursor = row
+		}
+	}
+	return m, nil
+}
+
+// rowAt returns the row shown on the given line of the list, counting from
+// the first row shown, which takes scrolling and wrapped rows into account.
+func (m Model) rowAt(line int) (int, bool) {
+	if line < 0 {
+		return 0, false
+	}
+	vms := m.visible()
+	for i := m.offset; i < len(vms); i++ {
+		line -= m.rowLines(i, vms[i])
+		if line < 0 {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate_MouseSelectsRow(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-3", Zone: "z-1", Status: "RUNNING"},
+	)
+	m := loadedModel(t, client)
+
+	m, cmd := keyPress(t, m, "m")
+	require.True(t, m.mouse)
+	require.NotNil(t, cmd)
+
+	// The header and a blank line come before the rows.
+	click := func(y int) {
+		model, _ := m.Update(tea.MouseMsg{Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+		m = model.(Model)
+	}
+	click(3)
+	require.Equal(t, 1, m.cursor)
+	require.Contains(t, m.View(), "> [vm-2]")
+	click(1)
+	require.Equal(t, 1, m.cursor, "clicks outside the rows should be ignored")
+
+	m.dense = true
+	click(2)
+	require.Equal(t, 2, m.cursor)
+
+	model, _ := m.Update(tea.MouseMsg{Button: tea.MouseButtonWheelUp, Action: tea.MouseActionPress})
+	m = model.(Model)
+	require.Equal(t, 1, m.cursor)
+	model, _ = m.Update(tea.MouseMsg{Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress})
+	m = model.(Model)
+	require.Equal(t, 2, m.cursor)
+
+	m, _ = keyPress(t, m, "m")
+	require.False(t, m.mouse)
+}
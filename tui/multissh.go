@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"gcp-rider/gcp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tmuxWindowArgs returns the tmux arguments that run argv in a new window
+// named after the instance, in the background so that the list stays in
+// front.
+func tmuxWindowArgs(name string, argv []string) []string {
+	return append([]string{"new-window", "-d", "-n", name}, argv...)
+}
+
+// tmuxSessionsMsg reports which SSH sessions were opened in tmux windows.
+type tmuxSessionsMsg struct {
+	opened []string
+	failed []error
+}
+
+// sshMarked connects to the running marked instances: each in a tmux window
+// of its own when running inside tmux, or else one after the other, the
+// next session starting when the previous one ends.
+func (m Model) sshMarked() (tea.Model, tea.Cmd) {
+	var targets []gcp.Instance
+	skipped := 0
+	for _, vm := range m.markedInstances() {
+		if gcp.ClassifyStatus(vm.Status) == gcp.StatusRunning {
+			targets = append(targets, vm)
+		} else {
+			skipped++
+		}
+	}
+	if len(targets) == 0 {
+		m.message = fmt.Sprintf("None of the %d marked instances is RUNNING.", skipped)
+		return m, nil
+	}
+	m.marked = nil
+	if !m.tmux {
+		m.sshQueue = targets[1:]
+		return m.sshQueued(targets[0])
+	}
+	var remember tea.Cmd
+	argvs := make([][]string, len(targets))
+	for i, vm := range targets {
+		argv, err := m.sshCommand(vm)
+		if err != nil {
+			m.message = fmt.Sprintf("Cannot SSH to %s: %v.", vm.Name, err)
+			return m, nil
+		}
+		argvs[i] = argv
+		remember = m.rememberSSH(vm)
+	}
+	m.message = fmt.Sprintf("Opening %d SSH sessions in tmux...", len(targets))
+	if skipped > 0 {
+		m.message = fmt.Sprintf("Opening %d SSH sessions in tmux, skipping %d not running...", len(targets), skipped)
+	}
+	ctx, run, hook := m.ctx, m.run, m.cfg.PreSSHHook
+	projects := make([]string, len(targets))
+	for i, vm := range targets {
+		projects[i], targets[i] = m.projectOf(vm), m.located(vm)
+	}
+	return m, tea.Batch(remember, func() tea.Msg {
+		var msg tmuxSessionsMsg
+		for i, vm := range targets {
+			out, err := openTmuxWindow(ctx, run, hook, vm, projects[i], argvs[i])
+			if err != nil {
+				if out != "" {
+					err = fmt.Errorf("%w: %s", err, out)
+				}
+				msg.failed = append(msg.failed, fmt.Errorf("%s: %w", vm.Name, err))
+				continue
+			}
+			msg.opened = append(msg.opened, vm.Name)
+		}
+		return msg
+	})
+}
+
+// openTmuxWindow runs the pre-SSH hook for vm and opens a tmux window
+// running argv, giving up after sshConnectTimeout or once ctx is cancelled,
+// as connect does. It returns the output of whichever failed.
+func openTmuxWindow(ctx context.Context, run commandRunner, hook string, vm gcp.Instance, projectID string, argv []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, sshConnectTimeout)
+	defer cancel()
+	if out, err := runPreSSHHook(ctx, run, hook, vm, projectID); err != nil {
+		return out, err
+	}
+	b, err := run(ctx, "tmux", tmuxWindowArgs(vm.Name, argv)...)
+	return strings.TrimSpace(string(b)), err
+}
+
+// handleTmuxSessions reports which sessions were opened.
+func (m Model) handleTmuxSessions(msg tmuxSessionsMsg) (tea.Model, tea.Cmd) {
+	var parts []string
+	if len(msg.opened) > 0 {
+		parts = append(parts, fmt.Sprintf("Opened SSH to %s in tmux windows.", strings.Join(msg.opened, ", ")))
+	}
+	for _, err := range msg.failed {
+		parts = append(parts, fmt.Sprintf("Could not open SSH to %v.", err))
+	}
+	m.message = strings.Join(parts, " ")
+	return m, nil
+}
+
+// connectQueued connects to the next instance waiting for an SSH session
+// after the previous one ended, if any.
+func (m Model) connectQueued() (tea.Model, tea.Cmd, bool) {
+	if len(m.sshQueue) == 0 {
+		return m, nil, false
+	}
+	next := m.sshQueue[0]
+	m.sshQueue = m.sshQueue[1:]
+	model, cmd := m.sshQueued(next)
+	return model, cmd, true
+}
+
+// sshQueued connects to vm, dropping the instances queued after it when no
+// connection is made, e.g. as vm is no longer running.
+func (m Model) sshQueued(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	model, cmd := m.ssh(vm)
+	if next := model.(Model); next.connecting == nil {
+		next.sshQueue = nil
+		return next, cmd
+	}
+	return model, cmd
+}
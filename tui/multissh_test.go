@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func markedModel(t *testing.T) Model {
+	t.Helper()
+	m := NewModel(new(mocks.Client), "test-project")
+	m.vms = []gcp.Instance{
+		{Name: "web-1", Zone: "z-1", Status: "RUNNING"},
+		{Name: "web-2", Zone: "z-1", Status: "RUNNING"},
+		{Name: "db-1", Zone: "z-2", Status: "TERMINATED"},
+	}
+	m.loading = false
+	m.master = func(ctx context.Context, argv []string, socket string) (func(), string, error) {
+		return func() {}, "", nil
+	}
+	for range m.vms {
+		m, _ = keyPress(t, m, " ")
+	}
+	return m
+}
+
+func TestTmuxWindowArgs(t *testing.T) {
+	require.Equal(t,
+		[]string{"new-window", "-d", "-n", "vm-1", "gcloud", "compute", "ssh", "vm-1", "--zone", "z-1", "--project", "p"},
+		tmuxWindowArgs("vm-1", []string{"gcloud", "compute", "ssh", "vm-1", "--zone", "z-1", "--project", "p"}))
+}
+
+func TestUpdate_SSHMarkedTmux(t *testing.T) {
+	m := markedModel(t)
+	m.tmux = true
+	var ran [][]string
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		ran = append(ran, append([]string{name}, args...))
+		if args[3] == "web-2" {
+			return []byte("no server running\n"), errors.New("exit status 1")
+		}
+		return nil, nil
+	}
+
+	m, cmd := keyPress(t, m, "enter")
+	require.Nil(t, m.connecting)
+	require.Empty(t, m.marked)
+	require.Equal(t, "Opening 2 SSH sessions in tmux, skipping 1 not running...", m.message)
+
+	msg := cmd()
+	require.Equal(t, [][]string{
+		{"tmux", "new-window", "-d", "-n", "web-1", "gcloud", "compute", "ssh", "web-1", "--zone", "z-1", "--project", "test-project"},
+		{"tmux", "new-window", "-d", "-n", "web-2", "gcloud", "compute", "ssh", "web-2", "--zone", "z-1", "--project", "test-project"},
+	}, ran)
+
+	model, _ := m.Update(msg)
+	require.Equal(t, "Opened SSH to web-1 in tmux windows. Could not open SSH to web-2: exit status 1: no server running.", model.(Model).message)
+}
+
+func TestUpdate_SSHMarkedOneAfterAnother(t *testing.T) {
+	m := markedModel(t)
+
+	m, _ = keyPress(t, m, "enter")
+	require.NotNil(t, m.connecting)
+	require.Equal(t, "web-1", m.connecting.vm.Name)
+	require.Empty(t, m.marked)
+
+	model, _ := m.Update(sshDoneMsg{vm: m.connecting.vm})
+	m = model.(Model)
+	require.NotNil(t, m.connecting, "the next session should start when the previous one ends")
+	require.Equal(t, "web-2", m.connecting.vm.Name)
+
+	m, _ = keyPress(t, m, "esc")
+	require.Empty(t, m.sshQueue, "cancelling should drop the instances still queued")
+}
+
+func TestUpdate_SSHMarkedNoneRunning(t *testing.T) {
+	m := markedModel(t)
+	m.marked = map[string]bool{m.instanceKey(m.vms[2]): true}
+
+	m, cmd := keyPress(t, m, "enter")
+	require.Nil(t, cmd)
+	require.Nil(t, m.connecting)
+	require.Equal(t, "None of the 1 marked instances is RUNNING.", m.message)
+}
+
+func TestUpdate_SSHMarkedTmuxQuitCancelsHook(t *testing.T) {
+	m := markedModel(t)
+	m.tmux = true
+	m.cfg.PreSSHHook = "sleep 600"
+	var ran []string
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		ran = append(ran, name)
+		// The hook hangs until the session is cancelled.
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	m, cmd := keyPress(t, m, "enter")
+	m.quit()
+	model, _ := m.Update(cmd())
+	require.Equal(t, []string{"sh", "sh"}, ran, "tmux should not run after the hook was cancelled")
+	require.Contains(t, model.(Model).message, "Could not open SSH to web-1: pre-SSH hook failed: context canceled.")
+}
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/cache"
+	"gcp-rider/gcp"
+	"maps"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// notesName is the file next to the config holding the notes attached to
+// instances.
+const notesName = "notes.json"
+
+// notesMsg carries the notes loaded from the config directory.
+type notesMsg struct {
+	notes map[string]string
+	err   error
+}
+
+// noteKey identifies an instance in the notes, which span projects.
+func (m Model) noteKey(vm gcp.Instance) string {
+	return m.projectOf(vm) + "/" + vm.Name
+}
+
+// loadNotesCmd returns a command that reads the notes.
+func (m Model) loadNotesCmd() tea.Msg {
+	var notes map[string]string
+	err := m.loadState(notesName, &notes)
+	return notesMsg{notes: notes, err: err}
+}
+
+// handleNotes applies the notes loaded at startup.
+func (m Model) handleNotes(msg notesMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Could not load notes: %v", msg.err)
+		return m, nil
+	}
+	m.notes = msg.notes
+	return m, nil
+}
+
+// openNote starts editing the note of vm. The note is attached to vm when
+// it is saved, even if the list changed in the meantime.
+func (m Model) openNote(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	m.mode = modeNote
+	m.message = ""
+	m.noteTarget = vm
+	m.input.SetValue(m.notes[m.noteKey(vm)])
+	m.input.Placeholder = "e.g. flaky, do not delete"
+	m.input.CursorEnd()
+	return m, m.input.Focus()
+}
+
+// updateNote handles key presses while a note is being edited. An empty
+// note removes it.
+func (m Model) updateNote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		m.mode = modeList
+		m.input.Blur()
+		return m.setNote(m.noteTarget, strings.TrimSpace(m.input.Value()))
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// setNote attaches note to vm, or removes its note if note is empty, and
+// persists the result.
+func (m Model) setNote(vm gcp.Instance, note string) (tea.Model, tea.Cmd) {
+	notes := maps.Clone(m.notes)
+	if notes == nil {
+		notes = make(map[string]string)
+	}
+	if note == "" {
+		delete(notes, m.noteKey(vm))
+		m.message = fmt.Sprintf("Removed the note on %s.", vm.Name)
+	} else {
+		notes[m.noteKey(vm)] = note
+		m.message = fmt.Sprintf("Saved the note on %s.", vm.Name)
+	}
+	m.notes = notes
+	dir := m.stateDir()
+	if dir == "" {
+		return m, nil
+	}
+	return m, func() tea.Msg {
+		if err := cache.Save(dir, notesName, notes); err != nil {
+			return actionErrMsg{err: fmt.Errorf("could not save notes: %w", err)}
+		}
+		return nil
+	}
+}
+
+// noteView renders the note editor below the list.
+func (m Model) noteView() string {
+	return fmt.Sprintf("Note on %s: %s\n", m.noteTarget.Name, m.input.View()) + m.hintView()
+}
+
+// noteColumn renders the note of vm after its name in the list, if it has
+// one.
+func (m Model) noteColumn(vm gcp.Instance) string {
+	note, ok := m.notes[m.noteKey(vm)]
+	if !ok {
+		return ""
+	}
+	return " " + m.theme.Muted.Render("✎ "+note)
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"gcp-rider/cache"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate_EditNote(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	m := NewModel(new(mocks.Client), "test-project", WithConfig(path, config.Config{}, false), WithCacheDir(t.TempDir()))
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1"}, {Name: "vm-2", ProjectID: "other"}}
+	m.cursor = 1
+
+	m, _ = keyPress(t, m, "a")
+	require.Equal(t, modeNote, m.mode)
+	require.Contains(t, m.View(), "Note on vm-2:")
+	m, _ = keyPress(t, m, "flaky, do not delete")
+	model, cmd := m.updateNote(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.Equal(t, modeList, m.mode)
+	require.Equal(t, "Saved the note on vm-2.", m.message)
+	require.Nil(t, cmd())
+	require.Contains(t, m.View(), "> [vm-2]  ✎ flaky, do not delete")
+
+	var saved map[string]string
+	ok, err := cache.Load(dir, notesName, &saved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"other/vm-2": "flaky, do not delete"}, saved)
+
+	// Notes survive a new session and a refresh.
+	m2 := NewModel(new(mocks.Client), "test-project", WithConfig(path, config.Config{}, false))
+	model, _ = m2.Update(m2.loadNotesCmd())
+	model, _ = model.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-2", ProjectID: "other"}}}})
+	require.Contains(t, model.View(), "✎ flaky, do not delete")
+
+	// An empty note removes it.
+	m, _ = keyPress(t, m, "a")
+	m.input.SetValue("")
+	model, _ = m.updateNote(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.Equal(t, "Removed the note on vm-2.", m.message)
+	require.Empty(t, m.notes)
+}
+
+func TestUpdate_EditNoteAfterRefresh(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1"}, {Name: "vm-2"}}
+	m.cursor = 1
+
+	m, _ = keyPress(t, m, "a")
+	m, _ = keyPress(t, m, "flaky")
+	// A refresh while typing no longer lists vm-2, moving the cursor off it.
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-0"}, {Name: "vm-1"}}}, seq: m.fetchSeq})
+	m = model.(Model)
+	require.NotEqual(t, "vm-2", m.visible()[m.cursor].Name)
+	require.Contains(t, m.View(), "Note on vm-2:")
+	model, _ = m.updateNote(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.Equal(t, map[string]string{"test-project/vm-2": "flaky"}, m.notes, "the note should go to the instance it was opened on")
+}
+
+func TestUpdate_EditNoteCancelled(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1"}}
+
+	m, _ = keyPress(t, m, "a")
+	m, _ = keyPress(t, m, "temp")
+	model, _ := m.updateNote(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	require.Equal(t, modeList, m.mode)
+	require.Empty(t, m.notes)
+}
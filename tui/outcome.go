@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How long the outcome of an action is shown in the footer, and how long it
+// then stays faded before it is cleared.
+const (
+	outcomeShown = 4 * time.Second
+	outcomeFade  = time.Second
+)
+
+// outcome is the summary of the last completed action, shown as the footer
+// message until it fades out.
+type outcome struct {
+	text  string
+	seq   int
+	faded bool
+}
+
+// outcomeTickMsg advances the fade of the outcome with the given sequence
+// number: first to faded, then to cleared.
+type outcomeTickMsg struct {
+	seq  int
+	fade bool
+}
+
+// bulkDoneMsg is sent when a bulk action has run against every target.
+type bulkDoneMsg struct {
+	verb, done string
+	count      int
+	// succeeded names the instances the action succeeded on.
+	succeeded []string
+	failed    []error
+}
+
+// summary renders the outcome of a bulk action, e.g. "Suspended 3 instances
+// (1 failed)."
+func (msg bulkDoneMsg) summary() string {
+	s := fmt.Sprintf("%s %d %s", msg.done, msg.count, plural(msg.count, "instance", "instances"))
+	if n := len(msg.failed); n > 0 {
+		s += fmt.Sprintf(" (%d failed)", n)
+	}
+	return s + "."
+}
+
+// plural returns one if n is 1 and many otherwise.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// showOutcome shows text as the footer message and returns the command that
+// fades it out. A later outcome replaces it and restarts the fade.
+func (m *Model) showOutcome(text string) tea.Cmd {
+	seq := 1
+	if m.outcome != nil {
+		seq = m.outcome.seq + 1
+	}
+	m.outcome = &outcome{text: text, seq: seq}
+	m.message = text
+	return tea.Tick(outcomeShown, func(time.Time) tea.Msg { return outcomeTickMsg{seq: seq, fade: true} })
+}
+
+// advanceOutcome fades or clears the outcome the tick is for. Ticks of a
+// replaced outcome are ignored, and the footer is left alone if something
+// else has been shown there since.
+func (m Model) advanceOutcome(msg outcomeTickMsg) (tea.Model, tea.Cmd) {
+	if m.outcome == nil || m.outcome.seq != msg.seq {
+		return m, nil
+	}
+	if msg.fade {
+		o := *m.outcome
+		o.faded = true
+		m.outcome = &o
+		return m, tea.Tick(outcomeFade, func(time.Time) tea.Msg { return outcomeTickMsg{seq: msg.seq} })
+	}
+	if m.message == m.outcome.text {
+		m.message = ""
+	}
+	m.outcome = nil
+	return m, nil
+}
+
+// messageView renders the footer message, dimmed while an outcome fades.
+func (m Model) messageView() string {
+	if m.outcome != nil && m.outcome.faded && m.message == m.outcome.text {
+		return m.theme.Muted.Render(m.message)
+	}
+	return m.message
+}
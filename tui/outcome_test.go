@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkDoneMsg_Summary(t *testing.T) {
+	require.Equal(t, "Suspended 3 instances.", bulkDoneMsg{done: "Suspended", count: 3}.summary())
+	require.Equal(t, "Resumed 1 instance (2 failed).", bulkDoneMsg{done: "Resumed", count: 1, failed: []error{errors.New("a"), errors.New("b")}}.summary())
+}
+
+func TestUpdate_OutcomeFades(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1"}}
+
+	model, cmd := m.Update(actionDoneMsg{"Suspended vm-1."})
+	m = model.(Model)
+	require.NotNil(t, cmd)
+	require.Equal(t, "Suspended vm-1.", m.message)
+	m.loading = false
+
+	model, cmd = m.Update(outcomeTickMsg{seq: 1, fade: true})
+	m = model.(Model)
+	require.NotNil(t, cmd, "the faded outcome should be cleared later")
+	require.True(t, m.outcome.faded)
+	require.Contains(t, m.View(), "Suspended vm-1.")
+
+	model, _ = m.Update(outcomeTickMsg{seq: 1})
+	m = model.(Model)
+	require.Empty(t, m.message)
+	require.Nil(t, m.outcome)
+}
+
+func TestUpdate_OutcomeReplaced(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+
+	model, _ := m.Update(actionDoneMsg{"Suspended vm-1."})
+	model, _ = model.(Model).Update(actionDoneMsg{"Suspended vm-2."})
+	m = model.(Model)
+
+	model, cmd := m.Update(outcomeTickMsg{seq: 1, fade: true})
+	m = model.(Model)
+	require.Nil(t, cmd, "ticks of a replaced outcome should be ignored")
+	require.False(t, m.outcome.faded)
+
+	// A message shown since the outcome is left in place.
+	m.message = "No recent SSH targets yet."
+	model, _ = m.Update(outcomeTickMsg{seq: 2})
+	require.Equal(t, "No recent SSH targets yet.", model.(Model).message)
+}
+
+func TestUpdate_BulkPartialFailure(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+
+	model, cmd := m.Update(bulkDoneMsg{done: "Stopped", count: 3, failed: []error{errors.New("db-1: quota exceeded")}})
+	m = model.(Model)
+	require.NotNil(t, cmd)
+	require.Equal(t, "Stopped 3 instances (1 failed).", m.message)
+	require.Equal(t, "Error: db-1: quota exceeded", m.banner)
+
+	m = NewModel(new(mocks.Client), "test-project")
+	m.loading = true
+	model, _ = m.Update(bulkDoneMsg{done: "Stopped", failed: []error{errors.New("db-1: quota exceeded")}})
+	m = model.(Model)
+	require.False(t, m.loading, "nothing changed, so the list should not be refreshed")
+	require.Equal(t, "Stopped 0 instances (1 failed).", m.message)
+}
@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/cache"
+	"gcp-rider/gcp"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pinsName is the cache entry holding the names of pinned instances.
+const pinsName = "pins.json"
+
+// pinsMsg carries the pins loaded from the cache directory.
+type pinsMsg struct {
+	names []string
+	err   error
+}
+
+// pinsSavedMsg is sent once the pins have been written to the cache directory.
+type pinsSavedMsg struct{ err error }
+
+// loadPinsCmd returns a command that reads the pinned instance names.
+func (m Model) loadPinsCmd() tea.Msg {
+	var names []string
+	_, err := cache.Load(m.cacheDir, pinsName, &names)
+	return pinsMsg{names: names, err: err}
+}
+
+// handlePins applies the pins loaded at startup.
+func (m Model) handlePins(msg pinsMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Could not load pinned instances: %v", msg.err)
+		return m, nil
+	}
+	m.pinned = make(map[string]bool, len(msg.names))
+	for _, name := range msg.names {
+		m.pinned[name] = true
+	}
+	m.listVersion++
+	m.sortPinned()
+	return m, nil
+}
+
+// togglePin pins or unpins vm and persists the result.
+func (m Model) togglePin(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	pinned := make(map[string]bool, len(m.pinned)+1)
+	for name := range m.pinned {
+		pinned[name] = true
+	}
+	if pinned[vm.Name] {
+		delete(pinned, vm.Name)
+		m.message = fmt.Sprintf("Unpinned %s.", vm.Name)
+	} else {
+		pinned[vm.Name] = true
+		m.message = fmt.Sprintf("Pinned %s.", vm.Name)
+	}
+	m.pinned = pinned
+	m.listVersion++
+	m.sortPinned()
+	if m.cacheDir == "" {
+		return m, nil
+	}
+	return m, m.savePinsCmd()
+}
+
+// savePinsCmd returns a command that persists the pinned instance names.
+func (m Model) savePinsCmd() tea.Cmd {
+	dir := m.cacheDir
+	names := make([]string, 0, len(m.pinned))
+	for name := range m.pinned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return func() tea.Msg {
+		return pinsSavedMsg{cache.Save(dir, pinsName, names)}
+	}
+}
+
+// handlePinsSaved reports a failure to persist the pins.
+func (m Model) handlePinsSaved(msg pinsSavedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Could not save pinned instances: %v", msg.err)
+	}
+	return m, nil
+}
+
+// sortPinned moves pinned instances to the top of the list, keeping the
+// order within pinned and unpinned instances, and keeps the cursor on the
+// same instance.
+func (m *Model) sortPinned() {
+	current, ok := m.selected()
+	vms := make([]gcp.Instance, len(m.vms))
+	copy(vms, m.vms)
+	sort.SliceStable(vms, func(i, j int) bool {
+		return m.pinned[vms[i].Name] && !m.pinned[vms[j].Name]
+	})
+	m.vms = vms
+	m.listVersion++
+	if ok {
+		m.moveCursorTo(current)
+	}
+}
+
+// pinMarker returns the marker shown for pinned instances.
+func (m Model) pinMarker(vm gcp.Instance) string {
+	if m.pinned[vm.Name] {
+		return "*"
+	}
+	return " "
+}
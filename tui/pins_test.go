@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"gcp-rider/cache"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate_TogglePin(t *testing.T) {
+	dir := t.TempDir()
+	m := NewModel(new(mocks.Client), "test-project", WithCacheDir(dir))
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	m.cursor = 2
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m = model.(Model)
+	require.Equal(t, []string{"c", "a", "b"}, names(m.vms))
+	require.Equal(t, 0, m.cursor, "the cursor should follow the pinned instance")
+	require.Contains(t, m.View(), ">*[c]")
+	model, _ = m.Update(cmd())
+	m = model.(Model)
+	require.Equal(t, "Pinned c.", m.message)
+
+	var saved []string
+	ok, err := cache.Load(dir, pinsName, &saved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"c"}, saved)
+
+	model, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m = model.(Model)
+	m.Update(cmd())
+	require.Equal(t, "Unpinned c.", m.message)
+	ok, err = cache.Load(dir, pinsName, &saved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, saved)
+}
+
+func TestUpdate_PinsSurviveRefreshAndRestart(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, cache.Save(dir, pinsName, []string{"d", "b"}))
+
+	m := NewModel(new(mocks.Client), "test-project", WithCacheDir(dir))
+	model, _ := m.Update(m.loadPinsCmd())
+	m = model.(Model)
+
+	model, _ = m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}}})
+	m = model.(Model)
+	require.Equal(t, []string{"b", "d", "a", "c"}, names(m.vms), "pinned instances keep their fetched order")
+}
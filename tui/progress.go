@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fetchProgressMsg reports how many instances a fetch has loaded so far.
+// Reading the next message from ch continues the fetch.
+type fetchProgressMsg struct {
+	seq    int
+	loaded int
+	ch     <-chan tea.Msg
+}
+
+// fetchThrottledMsg reports that a fetch is backing off because the API is
+// rate limiting it. Reading the next message from ch continues the fetch.
+type fetchThrottledMsg struct {
+	seq  int
+	wait time.Duration
+	ch   <-chan tea.Msg
+}
+
+// refresh starts fetching the list again, with a fresh progress counter.
+func (m *Model) refresh() tea.Cmd {
+	m.fetchSeq++
+	return m.startLoading("Loading VMs...", m.streamVmsCmd(m.fetchSeq))
+}
+
+// streamVmsCmd returns a command that fetches the VMs, reporting progress
+// with fetchProgressMsg and backoffs with fetchThrottledMsg before the final
+// vmsMsg or errMsg. Once the fetch is over the channel is closed, so that
+// quitting while a message is still queued leaves no goroutine blocked on it.
+func (m Model) streamVmsCmd(seq int) tea.Cmd {
+	return func() tea.Msg {
+		ch := make(chan tea.Msg, 1)
+		opts := m.fetchOpts
+		opts.Progress = func(loaded int) {
+			// Drop updates while the previous one is still queued; the
+			// counter only goes up, so the next one supersedes it.
+			select {
+			case ch <- fetchProgressMsg{seq: seq, loaded: loaded, ch: ch}:
+			default:
+			}
+		}
+		opts.Throttled = func(wait time.Duration) {
+			select {
+			case ch <- fetchThrottledMsg{seq: seq, wait: wait, ch: ch}:
+			default:
+			}
+		}
+		go func() {
+			defer close(ch)
+			msg := m.fetchVms(opts, seq)
+			// Nobody reads the result after quitting, and a queued progress
+			// update may fill the channel.
+			select {
+			case ch <- msg:
+			case <-m.ctx.Done():
+			}
+		}()
+		return <-ch
+	}
+}
+
+// waitForFetch returns a command that reads the next message of a fetch, or
+// nothing once the fetch is over.
+func waitForFetch(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg { return <-ch }
+}
+
+// handleFetchProgress shows the running count of the current fetch and
+// keeps reading from it.
+func (m Model) handleFetchProgress(msg fetchProgressMsg) (tea.Model, tea.Cmd) {
+	if msg.seq == m.fetchSeq && m.loading {
+		m.loadingText = fmt.Sprintf("Loaded %d instances...", msg.loaded)
+	}
+	return m, waitForFetch(msg.ch)
+}
+
+// handleFetchThrottled shows that the current fetch is backing off until
+// the next progress update, and keeps reading from it.
+func (m Model) handleFetchThrottled(msg fetchThrottledMsg) (tea.Model, tea.Cmd) {
+	if msg.seq == m.fetchSeq && m.loading {
+		m.loadingText = fmt.Sprintf("Rate limited, backing off for %s...", msg.wait.Round(time.Second))
+	}
+	return m, waitForFetch(msg.ch)
+}
@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"gcp-rider/gcp/mocks"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate_FetchProgress(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-1", Zone: "z-1"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1"},
+		gcp.Instance{Name: "vm-3", Zone: "z-1"},
+	)
+	m := NewModel(client, "test-project")
+	m.loading = false
+	m.refresh()
+
+	msg := m.streamVmsCmd(m.fetchSeq)()
+	progress, ok := msg.(fetchProgressMsg)
+	require.True(t, ok, "expected progress before the list, got %T", msg)
+	require.Equal(t, 1, progress.loaded)
+
+	model, cmd := m.Update(msg)
+	m = model.(Model)
+	require.True(t, m.loading)
+	require.Contains(t, m.View(), "Loaded 1 instances...")
+
+	for {
+		model, cmd = m.Update(cmd())
+		m = model.(Model)
+		if !m.loading {
+			break
+		}
+	}
+	require.Len(t, m.vms, 3)
+	require.NotContains(t, m.View(), "Loaded")
+
+	// A new refresh starts counting from scratch.
+	m.refresh()
+	require.Equal(t, "Loading VMs...", m.loadingText)
+}
+
+func TestUpdate_StaleFetchProgressIgnored(t *testing.T) {
+	m := NewModel(gcptest.NewFakeClient(), "test-project")
+	m.refresh()
+
+	model, _ := m.Update(fetchProgressMsg{seq: m.fetchSeq - 1, loaded: 42, ch: make(chan tea.Msg)})
+	require.Equal(t, "Loading VMs...", model.(Model).loadingText)
+}
+
+func TestUpdate_StaleFetchResult(t *testing.T) {
+	m := NewModel(gcptest.NewFakeClient(), "test-project")
+	m.refresh()
+	m.refresh()
+
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-old"}}}, seq: m.fetchSeq - 1})
+	m = model.(Model)
+	require.True(t, m.loading, "the result of an earlier fetch should be dropped")
+	require.Empty(t, m.vms)
+
+	model, _ = m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-new"}}}, seq: m.fetchSeq})
+	m = model.(Model)
+	model, _ = m.Update(errMsg{err: errors.New("timeout"), seq: m.fetchSeq - 1})
+	m = model.(Model)
+	require.NoError(t, m.err, "a late failure of an earlier fetch should not replace the list")
+	require.Equal(t, "vm-new", m.vms[0].Name)
+}
+
+func TestUpdate_FetchThrottled(t *testing.T) {
+	m := NewModel(gcptest.NewFakeClient(), "test-project")
+	m.refresh()
+
+	ch := make(chan tea.Msg, 1)
+	ch <- vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-1"}}}, seq: m.fetchSeq}
+	model, cmd := m.Update(fetchThrottledMsg{seq: m.fetchSeq, wait: 4 * time.Second, ch: ch})
+	m = model.(Model)
+	require.Contains(t, m.View(), "Rate limited, backing off for 4s...")
+
+	model, _ = m.Update(cmd())
+	m = model.(Model)
+	require.False(t, m.loading, "the fetch should continue after the backoff")
+	require.Len(t, m.vms, 1)
+}
+
+func TestQuit_CancelsStreamingFetch(t *testing.T) {
+	client := new(mocks.Client)
+	stopped := make(chan struct{})
+	client.On("FetchInstances", mock.Anything, "test-project", mock.Anything).
+		Return(gcp.InstanceList{}, context.Canceled).
+		Run(func(args mock.Arguments) {
+			ctx, opts := args.Get(0).(context.Context), args.Get(2).(gcp.FetchOptions)
+			defer close(stopped)
+			// Keep loading pages until cancelled.
+			for loaded := 1; ctx.Err() == nil; loaded++ {
+				opts.Progress(loaded)
+				time.Sleep(time.Millisecond)
+			}
+		})
+	m := NewModel(client, "test-project")
+	m.refresh()
+
+	msg := m.streamVmsCmd(m.fetchSeq)()
+	progress, ok := msg.(fetchProgressMsg)
+	require.True(t, ok, "expected progress before the list, got %T", msg)
+
+	_, cmd := m.quit()
+	require.NotNil(t, cmd)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the fetch kept going after quitting")
+	}
+
+	// The producer closes the channel rather than blocking on the final
+	// message, whatever progress update was still queued.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range progress.ch {
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the fetch goroutine is still blocked")
+	}
+	require.Nil(t, waitForFetch(progress.ch)())
+}
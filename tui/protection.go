@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// onOff returns "on" or "off".
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// setDeletionProtectionCmd returns a command that turns deletion protection
+// of the given instance on or off.
+func (m Model) setDeletionProtectionCmd(vm gcp.Instance, enabled bool) tea.Cmd {
+	projectID, zone := m.projectOf(vm), m.located(vm).Zone
+	return func() tea.Msg {
+		if err := m.gcpClient.SetDeletionProtection(m.ctx, projectID, zone, vm.Name, enabled); err != nil {
+			return actionErrMsg{err: err, action: fmt.Sprintf("turn deletion protection %s for %s", onOff(enabled), vm.Name)}
+		}
+		return actionDoneMsg{fmt.Sprintf("Turned deletion protection %s for %s.", onOff(enabled), vm.Name)}
+	}
+}
+
+// toggleDeletionProtection flips deletion protection of the selected
+// instance, asking first before turning it off. The list is reloaded once
+// the change is done, so the flag shows the new state.
+func (m Model) toggleDeletionProtection() (tea.Model, tea.Cmd) {
+	vm, ok := m.selected()
+	if !ok {
+		return m, nil
+	}
+	m.message = ""
+	enabled := !vm.DeletionProtection
+	doing := fmt.Sprintf("Turning deletion protection %s", onOff(enabled))
+	c := confirmation{
+		prompt:  fmt.Sprintf("Turn deletion protection %s for %s?", onOff(enabled), vm.Name),
+		cmd:     m.setDeletionProtectionCmd(vm, enabled),
+		loading: doing + "...",
+	}
+	if !enabled {
+		m.askAction(doing, c, vm)
+		return m, nil
+	}
+	return m, m.runAction(doing, c, vm)
+}
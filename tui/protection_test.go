@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate_DeletionProtectionRoundTrip(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+	m := loadedModel(t, client)
+	m, _ = keyPress(t, m, "i")
+	require.Contains(t, m.View(), "Deletion protection: no")
+
+	for _, want := range []bool{true, false} {
+		var cmd tea.Cmd
+		m, cmd = keyPress(t, m, "d")
+		if !want {
+			require.Nil(t, cmd, "turning deletion protection off should ask first")
+			require.Contains(t, m.View(), "Turn deletion protection off for vm-1? (y/n)")
+			m, cmd = keyPress(t, m, "y")
+		}
+		require.True(t, m.loading)
+		msgs := batchMsgs(cmd)
+		require.Equal(t, []tea.Msg{actionDoneMsg{"Turned deletion protection " + onOff(want) + " for vm-1."}}, msgs)
+		vm, _ := client.Instance("test-project", "z-1", "vm-1")
+		require.Equal(t, want, vm.DeletionProtection)
+
+		model, _ := m.Update(msgs[0])
+		model, _ = model.(Model).Update(model.(Model).fetchVmsCmd())
+		m = model.(Model)
+		require.Equal(t, want, m.vms[0].DeletionProtection, "the reloaded list should show the new flag")
+		require.Contains(t, m.View(), "Deletion protection: "+yesNo(want))
+	}
+}
+
+func TestUpdate_DeletionProtectionError(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING", DeletionProtection: true})
+	client.SetError("SetDeletionProtection", errors.New("failed to set deletion protection: permission denied"))
+	m := loadedModel(t, client)
+	m, _ = keyPress(t, m, "i")
+
+	m, _ = keyPress(t, m, "d")
+	m, cmd := keyPress(t, m, "y")
+	model, _ := m.Update(batchMsgs(cmd)[0])
+	m = model.(Model)
+	require.False(t, m.loading)
+	require.Contains(t, m.View(), "Error: failed to set deletion protection: permission denied")
+	vm, _ := client.Instance("test-project", "z-1", "vm-1")
+	require.True(t, vm.DeletionProtection)
+}
@@ -0,0 +1,320 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sortOrders maps the sort orders a saved query can name to how they compare
+// two instances. Every list column has one, so the list can be sorted by any
+// column shown.
+var sortOrders = map[string]func(a, b gcp.Instance) int{
+	"name":         func(a, b gcp.Instance) int { return cmp.Compare(a.Name, b.Name) },
+	"zone":         func(a, b gcp.Instance) int { return cmp.Compare(a.Zone, b.Zone) },
+	"status":       func(a, b gcp.Instance) int { return cmp.Compare(a.Status, b.Status) },
+	"machine-type": func(a, b gcp.Instance) int { return cmp.Compare(a.MachineType, b.MachineType) },
+	"network":      func(a, b gcp.Instance) int { return cmp.Compare(a.Network, b.Network) },
+	"external-ip":  func(a, b gcp.Instance) int { return cmp.Compare(a.ExternalIP, b.ExternalIP) },
+	"image":        func(a, b gcp.Instance) int { return cmp.Compare(a.Image, b.Image) },
+	"project":      func(a, b gcp.Instance) int { return cmp.Compare(a.ProjectID, b.ProjectID) },
+	"flags": func(a, b gcp.Instance) int {
+		return cmp.Compare(instanceFlags(a, false), instanceFlags(b, false))
+	},
+	"created": gcp.CompareCreated,
+	"uptime":  compareUptime,
+}
+
+// descSortOrders holds the sort orders whose descending order is not simply
+// the reverse of sortOrders, e.g. instances without a creation time stay last
+// when sorting newest first.
+var descSortOrders = map[string]func(a, b gcp.Instance) int{
+	"created": gcp.CompareNewest,
+}
+
+// compareUptime orders running instances from the most recently started to
+// the longest running. Instances that are not running come last.
+func compareUptime(a, b gcp.Instance) int {
+	ta, errA := gcp.ParseTimestamp(a.LastStartAt)
+	tb, errB := gcp.ParseTimestamp(b.LastStartAt)
+	upA, upB := a.Status == "RUNNING" && errA == nil, b.Status == "RUNNING" && errB == nil
+	switch {
+	case !upA && !upB:
+		return 0
+	case !upA:
+		return 1
+	case !upB:
+		return -1
+	}
+	return tb.Compare(ta)
+}
+
+// listColumns maps the columns a saved query can name to how they render an
+// instance.
+var listColumns = map[string]func(m Model, vm gcp.Instance) string{
+	"zone":         func(m Model, vm gcp.Instance) string { return m.zone(vm.Zone) },
+	"status":       func(m Model, vm gcp.Instance) string { return m.theme.status(vm.Status) },
+	"machine-type": func(m Model, vm gcp.Instance) string { return vm.MachineType },
+	"network":      func(m Model, vm gcp.Instance) string { return vm.Network },
+	"external-ip":  func(m Model, vm gcp.Instance) string { return orDash(vm.ExternalIP) },
+	"image":        func(m Model, vm gcp.Instance) string { return vm.Image },
+	"created":      func(m Model, vm gcp.Instance) string { return relativeTime(vm.CreatedAt, m.now()) },
+	"uptime":       func(m Model, vm gcp.Instance) string { return uptime(vm, m.now()) },
+	"flags":        func(m Model, vm gcp.Instance) string { return m.muted(instanceFlags(vm, m.color)) },
+	"project":      func(m Model, vm gcp.Instance) string { return m.muted(m.projectOf(vm)) },
+}
+
+// labelColumnPrefix starts the name of a column showing a label, e.g.
+// "label:team".
+const labelColumnPrefix = "label:"
+
+// labelColumn returns the name of the column showing the label key.
+func labelColumn(key string) string {
+	return labelColumnPrefix + key
+}
+
+// listColumn returns how the named column renders an instance: one of
+// listColumns, or a label column showing "-" for instances without the label.
+func listColumn(name string) (func(m Model, vm gcp.Instance) string, bool) {
+	if key, ok := strings.CutPrefix(name, labelColumnPrefix); ok && key != "" {
+		return func(m Model, vm gcp.Instance) string { return orDash(vm.Labels[key]) }, true
+	}
+	render, ok := listColumns[name]
+	return render, ok
+}
+
+// sortOrder returns how the named sort order compares two instances: one of
+// sortOrders, or by a label for label columns.
+func sortOrder(name string) (func(a, b gcp.Instance) int, bool) {
+	if key, ok := strings.CutPrefix(name, labelColumnPrefix); ok && key != "" {
+		return func(a, b gcp.Instance) int { return cmp.Compare(a.Labels[key], b.Labels[key]) }, true
+	}
+	order, ok := sortOrders[name]
+	return order, ok
+}
+
+// checkSort reports an error unless the list can be sorted by name.
+func checkSort(name string) error {
+	if _, ok := sortOrder(name); !ok {
+		return fmt.Errorf("unknown sort %q, must be one of %s or %sKEY", name, strings.Join(sortedKeys(sortOrders), ", "), labelColumnPrefix)
+	}
+	return nil
+}
+
+// CheckQueries reports saved queries that name an unknown sort order or
+// column.
+func CheckQueries(queries []config.SavedQuery) error {
+	for _, q := range queries {
+		if q.Sort != "" {
+			if err := checkSort(q.Sort); err != nil {
+				return fmt.Errorf("saved query %q: %w", q.Name, err)
+			}
+		}
+		for _, c := range q.Columns {
+			if _, ok := listColumn(c); !ok {
+				return fmt.Errorf("saved query %q: unknown column %q, must be one of %s or %sKEY", q.Name, c, strings.Join(sortedKeys(listColumns), ", "), labelColumnPrefix)
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// muted renders s in the muted style, or nothing if it is empty.
+func (m Model) muted(s string) string {
+	if s == "" {
+		return ""
+	}
+	return m.theme.Muted.Render(s)
+}
+
+// columns returns the columns shown after the name: those of the saved query
+// in use, or the defaults of the list or dense view followed by the label
+// columns of the config.
+func (m Model) columns(dense bool) []string {
+	if m.query != nil && len(m.query.Columns) > 0 {
+		return m.query.Columns
+	}
+	cols := []string{"status", "flags"}
+	if dense {
+		cols = []string{"zone", "status", "flags"}
+	}
+	if m.multiProject() {
+		cols = append(cols, "project")
+	}
+	for _, k := range m.cfg.LabelColumns {
+		cols = append(cols, labelColumn(k))
+	}
+	return cols
+}
+
+// columnsView renders the given columns of vm, each with a leading space. The
+// flags column is left out when vm has none.
+func (m Model) columnsView(vm gcp.Instance, cols []string) string {
+	var b strings.Builder
+	for _, c := range cols {
+		render, _ := listColumn(c)
+		v := render(m, vm)
+		if c == "flags" && v == "" {
+			continue
+		}
+		b.WriteString(" " + v)
+	}
+	return b.String()
+}
+
+// sortVisible orders vms by the column picked with the number keys, or else
+// by the sort of the saved query in use, keeping pinned instances on top. vms
+// is sorted in place.
+func (m Model) sortVisible(vms []gcp.Instance) {
+	var order func(a, b gcp.Instance) int
+	switch {
+	case m.sortColumn != "":
+		order, _ = sortOrder(m.sortColumn)
+		if m.sortDesc {
+			if desc, ok := descSortOrders[m.sortColumn]; ok {
+				order = desc
+			} else {
+				asc := order
+				order = func(a, b gcp.Instance) int { return asc(b, a) }
+			}
+		}
+	case m.query != nil && m.query.Sort != "":
+		order, _ = sortOrder(m.query.Sort)
+	default:
+		return
+	}
+	slices.SortStableFunc(vms, func(a, b gcp.Instance) int {
+		if pa, pb := m.pinned[a.Name], m.pinned[b.Name]; pa != pb {
+			if pa {
+				return -1
+			}
+			return 1
+		}
+		return order(a, b)
+	})
+}
+
+// inQueryProject reports whether vm belongs to the project of the saved
+// query in use, if it names one.
+func (m Model) inQueryProject(vm gcp.Instance) bool {
+	return m.query == nil || m.query.Project == "" || m.projectOf(vm) == m.query.Project
+}
+
+// openQueries shows the saved queries from the config.
+func (m Model) openQueries() (tea.Model, tea.Cmd) {
+	if len(m.cfg.Queries) == 0 {
+		m.message = fmt.Sprintf("No saved queries in %s.", m.configPath)
+		return m, nil
+	}
+	m.message = ""
+	m.queryCursor = 0
+	for i, q := range m.cfg.Queries {
+		if m.query != nil && q.Name == m.query.Name {
+			m.queryCursor = i + 1
+		}
+	}
+	m.mode = modeQueries
+	return m, nil
+}
+
+// updateQueries handles key presses in the saved query picker. The first
+// entry clears the query in use.
+func (m Model) updateQueries(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m.quit()
+	case "up", "k":
+		if m.queryCursor > 0 {
+			m.queryCursor--
+		}
+	case "down", "j":
+		if m.queryCursor < len(m.cfg.Queries) {
+			m.queryCursor++
+		}
+	case "esc":
+		m.mode = modeList
+	case "enter":
+		m.mode = modeList
+		if m.queryCursor == 0 {
+			m.applyQuery(nil)
+		} else {
+			m.applyQuery(&m.cfg.Queries[m.queryCursor-1])
+		}
+	}
+	return m, nil
+}
+
+// applyQuery switches to q, replacing the filter with its own, or back to
+// the plain list if q is nil. The cursor stays on the selected instance when
+// it is still shown.
+func (m *Model) applyQuery(q *config.SavedQuery) {
+	current, ok := m.selected()
+	m.query = q
+	m.filterRegex = false
+	m.setQuery("")
+	m.message = "Showing all instances."
+	if q != nil {
+		m.setQuery(q.Filter)
+		m.message = fmt.Sprintf("Showing saved query %s.", q.Name)
+	}
+	m.cursor = 0
+	if ok {
+		m.moveCursorTo(current)
+	}
+}
+
+// queriesView renders the saved query picker.
+func (m Model) queriesView() string {
+	var b strings.Builder
+	b.WriteString("Saved queries:\n\n")
+	entry := func(i int, name, detail string) {
+		marker := " "
+		if i == m.queryCursor {
+			marker = ">"
+		}
+		b.WriteString(fmt.Sprintf("%s %s", marker, name))
+		if detail != "" {
+			b.WriteString(" " + m.muted(detail))
+		}
+		b.WriteString("\n")
+	}
+	entry(0, "All instances", "")
+	for i, q := range m.cfg.Queries {
+		entry(i+1, q.Name, queryDetail(q))
+	}
+	b.WriteString("\n" + m.hintView())
+	return b.String()
+}
+
+// queryDetail summarizes what a saved query does.
+func queryDetail(q config.SavedQuery) string {
+	var parts []string
+	if q.Filter != "" {
+		parts = append(parts, "filter: "+q.Filter)
+	}
+	if q.Sort != "" {
+		parts = append(parts, "sort: "+q.Sort)
+	}
+	if len(q.Columns) > 0 {
+		parts = append(parts, "columns: "+strings.Join(q.Columns, ", "))
+	}
+	if q.Project != "" {
+		parts = append(parts, "project: "+q.Project)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckQueries(t *testing.T) {
+	require.NoError(t, CheckQueries([]config.SavedQuery{{Name: "all"}, {Name: "prod", Sort: "created", Columns: []string{"zone", "machine-type", "uptime"}}}))
+	require.ErrorContains(t, CheckQueries([]config.SavedQuery{{Name: "prod", Sort: "size"}}), `saved query "prod": unknown sort "size"`)
+	require.ErrorContains(t, CheckQueries([]config.SavedQuery{{Name: "prod", Columns: []string{"zone", "ip"}}}), `saved query "prod": unknown column "ip"`)
+	require.NoError(t, CheckQueries([]config.SavedQuery{{Name: "teams", Sort: "label:team", Columns: []string{"label:team"}}}))
+	require.ErrorContains(t, CheckQueries([]config.SavedQuery{{Name: "teams", Columns: []string{"label:"}}}), `unknown column "label:"`)
+}
+
+func TestView_LabelColumns(t *testing.T) {
+	cfg := config.Config{LabelColumns: []string{"team", "owner"}}
+	m := NewModel(new(mocks.Client), "test-project", WithConfig("", cfg, false))
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{
+		{Name: "web-1", Status: "RUNNING", Labels: map[string]string{"team": "shop", "owner": "ana"}},
+		{Name: "db-1", Status: "RUNNING", Labels: map[string]string{"team": "data"}},
+	}}})
+	m = model.(Model)
+	view := m.View()
+	require.Contains(t, view, "> [web-1] RUNNING shop ana\n")
+	require.Contains(t, view, "  [db-1] RUNNING data -\n", "missing labels should show a dash")
+
+	m, _ = keyPress(t, m, "4")
+	require.Equal(t, []string{"db-1", "web-1"}, names(m.visible()), "label columns should sort like the others")
+}
+
+func queriesModel(t *testing.T) Model {
+	t.Helper()
+	cfg := config.Config{Queries: []config.SavedQuery{
+		{Name: "prod-running", Filter: "status:running label:env=prod", Sort: "name", Columns: []string{"zone", "machine-type"}},
+		{Name: "dev", Filter: "label:env=dev", Project: "shop-dev"},
+	}}
+	m := NewModel(new(mocks.Client), "shop-prod", WithProjects([]string{"shop-prod", "shop-dev"}), WithConfig("", cfg, false))
+	prod := map[string]string{"env": "prod"}
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{
+		{Name: "web-2", ProjectID: "shop-prod", Zone: "z-1", Status: "RUNNING", MachineType: "e2-small", Labels: prod},
+		{Name: "db-1", ProjectID: "shop-prod", Zone: "z-2", Status: "RUNNING", MachineType: "n2-standard-4", Labels: prod},
+		{Name: "web-1", ProjectID: "shop-prod", Zone: "z-1", Status: "TERMINATED", MachineType: "e2-small", Labels: prod},
+		{Name: "web-3", ProjectID: "shop-dev", Zone: "z-1", Status: "RUNNING", Labels: map[string]string{"env": "dev"}},
+		{Name: "web-4", ProjectID: "shop-prod", Zone: "z-1", Status: "RUNNING", Labels: map[string]string{"env": "dev"}},
+	}}})
+	return model.(Model)
+}
+
+func TestUpdate_SavedQuery(t *testing.T) {
+	m := queriesModel(t)
+
+	m, _ = keyPress(t, m, "o")
+	require.Equal(t, modeQueries, m.mode)
+	view := m.View()
+	require.Contains(t, view, "> All instances\n")
+	require.Contains(t, view, "  prod-running filter: status:running label:env=prod; sort: name; columns: zone, machine-type\n")
+	require.Contains(t, view, "  dev filter: label:env=dev; project: shop-dev\n")
+
+	m, _ = keyPress(t, m, "j")
+	m, _ = keyPress(t, m, "enter")
+	require.Equal(t, modeList, m.mode)
+	require.Equal(t, []string{"db-1", "web-2"}, names(m.visible()))
+	view = m.View()
+	require.Contains(t, view, "GCP VMs: (query: prod-running)")
+	require.Contains(t, view, "  [db-1] z-2 n2-standard-4\n> [web-2] z-1 e2-small\n", "the cursor should stay on the selected instance")
+	require.Contains(t, view, "Showing saved query prod-running.")
+
+	m, _ = keyPress(t, m, "o")
+	require.Contains(t, m.View(), "> prod-running", "the picker should open on the query in use")
+
+	m, _ = keyPress(t, m, "j")
+	m, _ = keyPress(t, m, "enter")
+	require.Equal(t, []string{"web-3"}, names(m.visible()), "only the project of the query should be shown")
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	require.Nil(t, m.query)
+	require.Empty(t, m.filter)
+	require.Len(t, m.visible(), 5)
+}
+
+func TestUpdate_SavedQueryKeepsPinsOnTop(t *testing.T) {
+	m := queriesModel(t)
+	m.pinned = map[string]bool{"web-2": true}
+	m.applyQuery(&m.cfg.Queries[0])
+	require.Equal(t, []string{"web-2", "db-1"}, names(m.visible()))
+}
+
+func TestUpdate_NoSavedQueries(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project", WithConfig("/home/me/config.json", config.Config{}, false))
+	m.loading = false
+
+	m, _ = keyPress(t, m, "o")
+	require.Equal(t, modeList, m.mode)
+	require.Equal(t, "No saved queries in /home/me/config.json.", m.message)
+}
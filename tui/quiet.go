@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quietFooterView renders what quiet mode shows below the instance rows:
+// only what a key press is waiting on or has just done, such as the filter
+// being typed, a question, or the outcome of an action. A list that could
+// not be loaded whole is still owned up to on one line.
+func (m Model) quietFooterView() string {
+	var b strings.Builder
+	b.WriteString(m.quietErrorsView())
+	if m.mode == modeFilter {
+		b.WriteString(m.filterView())
+	}
+	if m.mode == modeNote {
+		b.WriteString(m.noteView())
+	}
+	b.WriteString(m.selectionNoticeView())
+	if m.confirm != nil {
+		b.WriteString(m.confirm.view() + "\n")
+	}
+	if m.message != "" {
+		b.WriteString(m.messageView() + "\n")
+	}
+	if m.banner != "" {
+		b.WriteString(m.banner + "\n")
+	}
+	return b.String()
+}
+
+// quietErrorsView counts the projects and zones the last listing could not
+// load on one line, or returns "" if it loaded them all.
+func (m Model) quietErrorsView() string {
+	var failed []string
+	if n := len(m.projectErrors); n > 0 {
+		failed = append(failed, fmt.Sprintf("%d %s", n, plural(n, "project", "projects")))
+	}
+	if n := len(m.zoneErrors) + m.skippedZones; n > 0 {
+		failed = append(failed, fmt.Sprintf("%d %s", n, plural(n, "zone", "zones")))
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Could not load %s.\n", strings.Join(failed, " and "))
+}
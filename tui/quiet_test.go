@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestView_Quiet(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "TERMINATED"},
+	)
+	m := NewModel(client, "test-project", WithQuiet(true))
+	model, _ := m.Update(m.fetchVmsCmd())
+	m = model.(Model)
+
+	require.Equal(t, "> [vm-1] RUNNING\n  [vm-2] TERMINATED\n", m.View())
+
+	// Keys work the same, and what they wait on is still shown.
+	m, _ = keyPress(t, m, "down")
+	require.Equal(t, "  [vm-1] RUNNING\n> [vm-2] TERMINATED\n", m.View())
+	m, _ = keyPress(t, m, "/")
+	require.Contains(t, m.View(), "Filter: ")
+	require.NotContains(t, m.View(), "Press")
+	m, _ = keyPress(t, m, "esc")
+	m, _ = keyPress(t, m, "i")
+	require.Equal(t, modeDetail, m.mode)
+	require.NotContains(t, m.View(), "Press")
+}
+
+func TestView_QuietCountsErrors(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project", WithQuiet(true))
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{
+		Instances:     []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}},
+		ProjectErrors: []gcp.ProjectError{{ProjectID: "other", Err: errors.New("denied")}},
+		ZoneErrors: []gcp.ZoneError{
+			{ProjectID: "test-project", Zone: "z-2", Err: errors.New("unavailable")},
+			{ProjectID: "test-project", Zone: "z-3", Err: errors.New("unavailable")},
+		},
+	}})
+	m = model.(Model)
+	require.Equal(t, "> [vm-1] RUNNING\nCould not load 1 project and 2 zones.\n", m.View())
+}
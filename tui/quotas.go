@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// quotasMsg carries the quotas fetched for the region of an instance.
+type quotasMsg struct {
+	projectID, region string
+	quotas            []gcp.Quota
+	err               error
+}
+
+// regionQuotas holds the quotas last loaded for a region.
+type regionQuotas struct {
+	projectID, region string
+	quotas            []gcp.Quota
+}
+
+// fetchQuotasCmd returns a command that fetches the quotas of the region of
+// vm.
+func (m Model) fetchQuotasCmd(vm gcp.Instance) tea.Cmd {
+	projectID, region := m.projectOf(vm), gcp.ZoneRegion(vm.Zone)
+	return func() tea.Msg {
+		quotas, err := m.gcpClient.RegionQuotas(m.ctx, projectID, region)
+		return quotasMsg{projectID: projectID, region: region, quotas: quotas, err: err}
+	}
+}
+
+// showQuotas keeps the quotas for the detail view, or explains why they
+// could not be loaded.
+func (m Model) showQuotas(msg quotasMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if errors.Is(msg.err, gcp.ErrQuotasForbidden) {
+		m.message = fmt.Sprintf("You are not allowed to view the quotas of %s in %s.", msg.region, msg.projectID)
+		return m, nil
+	}
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Failed to load quotas: %v", msg.err)
+		return m, nil
+	}
+	m.quotas = &regionQuotas{projectID: msg.projectID, region: msg.region, quotas: msg.quotas}
+	return m, nil
+}
+
+// quotasView renders the quotas of the region of vm, if they have been
+// loaded. Quotas nearly used up are highlighted.
+func (m Model) quotasView(vm gcp.Instance) string {
+	q := m.quotas
+	if q == nil || q.projectID != m.projectOf(vm) || q.region != gcp.ZoneRegion(vm.Zone) {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\nQuotas of %s:\n", q.region))
+	if len(q.quotas) == 0 {
+		b.WriteString("  No CPU or GPU quotas reported.\n")
+	}
+	width := 0
+	for _, quota := range q.quotas {
+		width = max(width, len(quota.Metric))
+	}
+	for _, quota := range q.quotas {
+		usage := fmt.Sprintf("%g of %g used", quota.Usage, quota.Limit)
+		switch {
+		case quota.Usage >= quota.Limit:
+			usage = m.theme.Stopped.Render(usage + " (none left)")
+		case quota.Usage >= 0.8*quota.Limit:
+			usage = m.theme.Transitional.Render(usage + " (nearly used up)")
+		}
+		b.WriteString(fmt.Sprintf("  %-*s %s\n", width+1, quota.Metric+":", usage))
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetail_Quotas(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "web-1", Zone: "us-central1-a", Status: "RUNNING"},
+		gcp.Instance{Name: "web-2", Zone: "europe-west1-b", Status: "RUNNING"},
+	)
+	client.SetQuotas("us-central1",
+		gcp.Quota{Metric: "CPUS", Usage: 12, Limit: 24},
+		gcp.Quota{Metric: "INSTANCES", Usage: 5, Limit: 6},
+		gcp.Quota{Metric: "NVIDIA_T4_GPUS", Usage: 4, Limit: 4},
+	)
+	m := loadedModel(t, client)
+
+	m, _ = keyPress(t, m, "i")
+	m, cmd := keyPress(t, m, "Q")
+	require.True(t, m.loading)
+	var quotas quotasMsg
+	for _, msg := range batchMsgs(cmd) {
+		if q, ok := msg.(quotasMsg); ok {
+			quotas = q
+		}
+	}
+	model, _ := m.Update(quotas)
+	m = model.(Model)
+	view := m.View()
+	require.Contains(t, view, "Quotas of us-central1:\n")
+	require.Contains(t, view, "  CPUS:           12 of 24 used\n")
+	require.Contains(t, view, "  INSTANCES:      5 of 6 used (nearly used up)\n")
+	require.Contains(t, view, "  NVIDIA_T4_GPUS: 4 of 4 used (none left)\n")
+
+	// The quotas of one region are not shown for instances in another.
+	m, _ = keyPress(t, m, "esc")
+	m, _ = keyPress(t, m, "down")
+	m, _ = keyPress(t, m, "i")
+	require.NotContains(t, m.View(), "Quotas of")
+}
+
+func TestDetail_QuotasUnavailable(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "web-1", Zone: "us-central1-a", Status: "RUNNING"})
+	m := loadedModel(t, client)
+	m, _ = keyPress(t, m, "i")
+
+	model, _ := m.Update(m.fetchQuotasCmd(m.vms[0])())
+	require.Contains(t, model.(Model).View(), "Quotas of us-central1:\n  No CPU or GPU quotas reported.\n")
+
+	client.SetError("RegionQuotas", gcp.ErrQuotasForbidden)
+	model, _ = m.Update(m.fetchQuotasCmd(m.vms[0])())
+	require.Contains(t, model.(Model).View(), "You are not allowed to view the quotas of us-central1 in test-project.")
+
+	client.SetError("RegionQuotas", errors.New("backend unavailable"))
+	model, _ = m.Update(m.fetchQuotasCmd(m.vms[0])())
+	require.Contains(t, model.(Model).View(), "Failed to load quotas: backend unavailable")
+}
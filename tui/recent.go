@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/cache"
+	"gcp-rider/gcp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// recentName is the file next to the config holding the most recent SSH
+// targets.
+const recentName = "recent.json"
+
+// maxRecent is how many SSH targets are remembered.
+const maxRecent = 10
+
+// recentTarget is an instance that was connected to over SSH. It records
+// enough to connect again from any project.
+type recentTarget struct {
+	Project string `json:"project"`
+	Zone    string `json:"zone"`
+	Name    string `json:"name"`
+}
+
+// recentMsg carries the SSH targets loaded from the config directory.
+type recentMsg struct {
+	targets []recentTarget
+	err     error
+}
+
+// pushRecent returns recent with t moved or added to the front, dropping the
+// oldest targets beyond limit. recent is not modified.
+func pushRecent(recent []recentTarget, t recentTarget, limit int) []recentTarget {
+	out := make([]recentTarget, 0, min(len(recent)+1, limit))
+	out = append(out, t)
+	for _, r := range recent {
+		if len(out) == limit {
+			break
+		}
+		if r != t {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// loadRecentCmd returns a command that reads the recent SSH targets.
+func (m Model) loadRecentCmd() tea.Msg {
+	var targets []recentTarget
+	err := m.loadState(recentName, &targets)
+	return recentMsg{targets: targets, err: err}
+}
+
+// handleRecent applies the recent SSH targets loaded at startup.
+func (m Model) handleRecent(msg recentMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Could not load recent SSH targets: %v", msg.err)
+		return m, nil
+	}
+	m.recent = msg.targets
+	return m, nil
+}
+
+// rememberSSH records vm as the most recent SSH target and returns a command
+// that persists the list.
+func (m *Model) rememberSSH(vm gcp.Instance) tea.Cmd {
+	m.lastSSH = &vm
+	m.recent = pushRecent(m.recent, recentTarget{Project: m.projectOf(vm), Zone: m.located(vm).Zone, Name: vm.Name}, maxRecent)
+	dir, targets := m.stateDir(), m.recent
+	if dir == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := cache.Save(dir, recentName, targets); err != nil {
+			return actionErrMsg{err: fmt.Errorf("could not remember the SSH target: %w", err)}
+		}
+		return nil
+	}
+}
+
+// openRecent shows the recent SSH targets.
+func (m Model) openRecent() (tea.Model, tea.Cmd) {
+	if len(m.recent) == 0 {
+		m.message = "No recent SSH targets yet."
+		return m, nil
+	}
+	m.message = ""
+	m.recentCursor = 0
+	m.mode = modeRecent
+	return m, nil
+}
+
+// updateRecent handles key presses in the recent SSH targets view.
+func (m Model) updateRecent(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m.quit()
+	case "up", "k":
+		if m.recentCursor > 0 {
+			m.recentCursor--
+		}
+	case "down", "j":
+		if m.recentCursor < len(m.recent)-1 {
+			m.recentCursor++
+		}
+	case "esc":
+		m.mode = modeList
+	case "enter":
+		m.mode = modeList
+		return m.connectRecent(m.recent[m.recentCursor])
+	}
+	return m, nil
+}
+
+// connectRecent connects to t, using its current state when it is in the
+// list. Targets from projects that are not loaded are connected to directly,
+// still by way of connect and so of the pre-SSH hook.
+func (m Model) connectRecent(t recentTarget) (tea.Model, tea.Cmd) {
+	for _, vm := range m.vms {
+		if vm.Name == t.Name && vm.Zone == t.Zone && m.projectOf(vm) == t.Project {
+			return m.ssh(vm)
+		}
+	}
+	vm := gcp.Instance{ProjectID: t.Project, Zone: t.Zone, Name: t.Name}
+	remember := m.rememberSSH(vm)
+	model, cmd := m.connect(vm)
+	return model, tea.Batch(cmd, remember)
+}
+
+// recentView renders the recent SSH targets, most recent first.
+func (m Model) recentView() string {
+	var b strings.Builder
+	b.WriteString("Recent SSH targets:\n\n")
+	for i, t := range m.recent {
+		marker := " "
+		if i == m.recentCursor {
+			marker = ">"
+		}
+		b.WriteString(fmt.Sprintf("%s %s %s\n", marker, t.Name, m.theme.Muted.Render(t.Project+"/"+t.Zone)))
+	}
+	b.WriteString("\n" + m.hintView())
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"gcp-rider/cache"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushRecent(t *testing.T) {
+	a := recentTarget{Project: "p", Zone: "z", Name: "a"}
+	b := recentTarget{Project: "p", Zone: "z", Name: "b"}
+	c := recentTarget{Project: "p", Zone: "z", Name: "c"}
+	otherA := recentTarget{Project: "q", Zone: "z", Name: "a"}
+
+	tests := []struct {
+		name   string
+		recent []recentTarget
+		target recentTarget
+		limit  int
+		want   []recentTarget
+	}{
+		{"empty", nil, a, 3, []recentTarget{a}},
+		{"new target goes first", []recentTarget{a, b}, c, 3, []recentTarget{c, a, b}},
+		{"known target moves to the front", []recentTarget{a, b, c}, c, 3, []recentTarget{c, a, b}},
+		{"oldest is dropped beyond the limit", []recentTarget{a, b}, c, 2, []recentTarget{c, a}},
+		{"same name in another project is distinct", []recentTarget{a}, otherA, 3, []recentTarget{otherA, a}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := append([]recentTarget(nil), tt.recent...)
+			require.Equal(t, tt.want, pushRecent(tt.recent, tt.target, tt.limit))
+			require.Equal(t, before, tt.recent, "the input should not be modified")
+		})
+	}
+}
+
+func TestRememberSSH_Persists(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	m := NewModel(new(mocks.Client), "test-project", WithConfig(path, config.Config{}, false), WithCacheDir(t.TempDir()))
+	cmd := m.rememberSSH(gcp.Instance{Name: "vm-1", Zone: "z-1", ProjectID: "other"})
+	require.NotNil(t, cmd)
+	require.Nil(t, cmd())
+
+	var saved []recentTarget
+	ok, err := cache.Load(dir, recentName, &saved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []recentTarget{{Project: "other", Zone: "z-1", Name: "vm-1"}}, saved)
+
+	model, _ := NewModel(new(mocks.Client), "test-project", WithConfig(path, config.Config{}, false)).Update(m.loadRecentCmd())
+	require.Equal(t, saved, model.(Model).recent)
+}
+
+func TestLoadRecent_FromEarlierCacheDir(t *testing.T) {
+	cacheDir := t.TempDir()
+	legacy := []recentTarget{{Project: "other", Zone: "z-1", Name: "vm-1"}}
+	require.NoError(t, cache.Save(cacheDir, recentName, legacy))
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	m := NewModel(new(mocks.Client), "test-project", WithConfig(path, config.Config{}, false), WithCacheDir(cacheDir))
+	model, _ := m.Update(m.loadRecentCmd())
+	require.Equal(t, legacy, model.(Model).recent, "targets kept in the cache directory should still be read")
+
+	m = model.(Model)
+	require.Nil(t, m.rememberSSH(gcp.Instance{Name: "vm-2", Zone: "z-1"})())
+	var saved []recentTarget
+	ok, err := cache.Load(filepath.Dir(path), recentName, &saved)
+	require.NoError(t, err)
+	require.True(t, ok, "the targets should be saved next to the config")
+	require.Len(t, saved, 2)
+}
+
+func TestUpdate_Recent(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"}}
+
+	m, _ = keyPress(t, m, "h")
+	require.Equal(t, "No recent SSH targets yet.", m.message)
+
+	m.recent = []recentTarget{
+		{Project: "elsewhere", Zone: "z-9", Name: "far"},
+		{Project: "test-project", Zone: "z-1", Name: "vm-1"},
+	}
+	m, _ = keyPress(t, m, "h")
+	require.Equal(t, modeRecent, m.mode)
+	require.Contains(t, m.View(), "> far")
+
+	// Targets in the list are connected to with their current state.
+	m, _ = keyPress(t, m, "j")
+	m, cmd := keyPress(t, m, "enter")
+	require.Equal(t, modeList, m.mode)
+	require.Nil(t, cmd)
+	require.NotNil(t, m.confirm, "a stopped instance should be offered to start")
+	m.confirm = nil
+
+	// Targets from other projects are connected to directly.
+	m, _ = keyPress(t, m, "h")
+	m, cmd = keyPress(t, m, "enter")
+	require.NotNil(t, cmd)
+	require.Equal(t, "elsewhere", m.lastSSH.ProjectID)
+	require.Equal(t, "far", m.recent[0].Name)
+}
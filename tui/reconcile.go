@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"slices"
+)
+
+// reconcileSelection keeps the cursor on the instance selected before the
+// list was reloaded, wherever it is now listed. before are the instances
+// shown until then; if prev is gone, the cursor moves to the nearest of
+// them still shown, those below it first. If it was deleted or moved to
+// another zone meanwhile, a notice says so, since the cursor is no longer
+// where the user left it. An instance is only taken for deleted when its
+// zone was listed completely; otherwise it may just have been left out.
+func (m *Model) reconcileSelection(prev gcp.Instance, before []gcp.Instance) {
+	for _, vm := range m.vms {
+		if vm.Name != prev.Name || m.projectOf(vm) != m.projectOf(prev) {
+			continue
+		}
+		if vm.Zone != prev.Zone {
+			m.selectionNotice = fmt.Sprintf("%s moved from %s to %s since the last refresh.", prev.Name, prev.Zone, vm.Zone)
+		}
+		m.moveCursorTo(vm)
+		return
+	}
+	if !m.listedZone(prev) {
+		m.message = fmt.Sprintf("%s is not in this listing; the cursor moved to another instance.", prev.Name)
+		m.moveToNeighbour(prev, before)
+		return
+	}
+	m.selectionNotice = fmt.Sprintf("%s no longer exists; the cursor moved to another instance.", prev.Name)
+	m.moveToNeighbour(prev, before)
+	if _, ok := m.selected(); !ok {
+		m.selectionNotice = fmt.Sprintf("%s no longer exists.", prev.Name)
+	}
+}
+
+// listedZone reports whether the last fetch listed every instance in the
+// project and zone of vm, so that its absence means it was deleted. It did
+// not when the listing was cut short, failed there, or was limited to some
+// zones or states.
+func (m Model) listedZone(vm gcp.Instance) bool {
+	if m.truncated || len(m.fetchOpts.Statuses) > 0 || m.skippedZones > 0 {
+		return false
+	}
+	if len(m.fetchOpts.Zones) > 0 && !slices.Contains(m.fetchOpts.Zones, vm.Zone) {
+		return false
+	}
+	project := m.projectOf(vm)
+	for _, pe := range m.projectErrors {
+		if pe.ProjectID == project {
+			return false
+		}
+	}
+	for _, ze := range m.zoneErrors {
+		if ze.Zone == vm.Zone && (ze.ProjectID == project || ze.ProjectID == "") {
+			return false
+		}
+	}
+	return true
+}
+
+// moveToNeighbour moves the cursor to the instance next to prev in before
+// that is still shown: the first one below it, or else the nearest above.
+func (m *Model) moveToNeighbour(prev gcp.Instance, before []gcp.Instance) {
+	shown := make(map[string]bool)
+	for _, vm := range m.visible() {
+		shown[m.instanceKey(vm)] = true
+	}
+	i := slices.IndexFunc(before, func(vm gcp.Instance) bool { return m.instanceKey(vm) == m.instanceKey(prev) })
+	if i < 0 {
+		return
+	}
+	for _, vm := range before[i+1:] {
+		if shown[m.instanceKey(vm)] {
+			m.moveCursorTo(vm)
+			return
+		}
+	}
+	for j := i - 1; j >= 0; j-- {
+		if shown[m.instanceKey(before[j])] {
+			m.moveCursorTo(before[j])
+			return
+		}
+	}
+}
+
+// guardsSelection reports whether key acts on the selected instance in a
+// way that must wait until a selection notice has been read.
+func (m Model) guardsSelection(key string) bool {
+	for _, action := range []string{actionSSH, actionSuspend, actionResume, actionGcloud} {
+		if m.keys.matches(action, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectionNoticeView renders the selection notice, if any.
+func (m Model) selectionNoticeView() string {
+	if m.selectionNotice == "" {
+		return ""
+	}
+	return m.theme.Transitional.Render(m.selectionNotice+" Check the selection, then press esc to dismiss this.") + "\n"
+}
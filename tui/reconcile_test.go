@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate_SelectionDeletedElsewhere(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+	)
+	m := loadedModel(t, client)
+	m.cursor = 1
+
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}}}})
+	m = model.(Model)
+	require.Contains(t, m.View(), "vm-2 no longer exists; the cursor moved to another instance.")
+
+	m, cmd := keyPress(t, m, "enter")
+	require.Nil(t, cmd, "SSH should wait until the notice is dismissed")
+	require.Contains(t, m.View(), "The selection changed; press esc once you have checked it.")
+
+	m, _ = keyPress(t, m, "esc")
+	require.NotContains(t, m.View(), "no longer exists")
+}
+
+func TestUpdate_SelectionNotListed(t *testing.T) {
+	listed := []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}}
+	for name, tt := range map[string]struct {
+		opts gcp.FetchOptions
+		msg  vmsMsg
+	}{
+		"zone error":    {msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: listed, ZoneErrors: []gcp.ZoneError{{ProjectID: "test-project", Zone: "z-1", Err: errors.New("unavailable")}}}}},
+		"project error": {msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: listed, ProjectErrors: []gcp.ProjectError{{ProjectID: "test-project", Err: errors.New("denied")}}}}},
+		"truncated":     {msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: listed, Truncated: true}}},
+		"status filter": {opts: gcp.FetchOptions{Statuses: []string{"RUNNING"}}, msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: listed}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := NewModel(gcptest.NewFakeClient(), "test-project", WithFetchOptions(tt.opts))
+			m.loading = false
+			m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}, {Name: "vm-2", Zone: "z-1", Status: "RUNNING"}}
+			m.cursor = 1
+
+			model, _ := m.Update(tt.msg)
+			m = model.(Model)
+			require.Empty(t, m.selectionNotice, "an instance that was not listed must not be reported deleted")
+			require.Equal(t, "vm-2 is not in this listing; the cursor moved to another instance.", m.message)
+
+			_, cmd := keyPress(t, m, "enter")
+			require.NotNil(t, cmd, "actions should not wait for a notice")
+		})
+	}
+}
+
+func TestUpdate_SelectionMovedZone(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+	)
+	m := loadedModel(t, client)
+	m.cursor = 1
+
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{
+		{Name: "vm-2", Zone: "z-2", Status: "RUNNING", ProjectID: "test-project"},
+		{Name: "vm-1", Zone: "z-1", Status: "RUNNING", ProjectID: "test-project"},
+	}}})
+	m = model.(Model)
+	vm, ok := m.selected()
+	require.True(t, ok)
+	require.Equal(t, "z-2", vm.Zone, "the cursor should follow the moved instance")
+	require.Contains(t, m.View(), "vm-2 moved from z-1 to z-2 since the last refresh.")
+}
+
+func TestUpdate_SelectionUnchanged(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+	)
+	m := loadedModel(t, client)
+	m.cursor = 1
+
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{
+		{Name: "vm-0", Zone: "z-1", Status: "RUNNING", ProjectID: "test-project"},
+		{Name: "vm-1", Zone: "z-1", Status: "RUNNING", ProjectID: "test-project"},
+		{Name: "vm-2", Zone: "z-1", Status: "RUNNING", ProjectID: "test-project"},
+	}}})
+	m = model.(Model)
+	vm, _ := m.selected()
+	require.Equal(t, "vm-2", vm.Name)
+	require.Empty(t, m.selectionNotice)
+}
@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// regionMatches reports whether query, in any case, is part of the name,
+// location or one of the zones of r.
+func regionMatches(r gcp.Region, query string) bool {
+	query = strings.ToLower(query)
+	if strings.Contains(r.Name, query) || strings.Contains(strings.ToLower(r.Location), query) {
+		return true
+	}
+	for _, zone := range gcp.RegionZones(r.Name) {
+		if strings.Contains(zone, query) {
+			return true
+		}
+	}
+	return false
+}
+
+// referenceRegions returns the regions of the reference matching the
+// search.
+func (m Model) referenceRegions() []gcp.Region {
+	var regions []gcp.Region
+	for _, r := range gcp.Regions() {
+		if regionMatches(r, m.regionQuery) {
+			regions = append(regions, r)
+		}
+	}
+	return regions
+}
+
+// openRegions shows the reference of regions and zones with where they are.
+func (m Model) openRegions() (tea.Model, tea.Cmd) {
+	m.message = ""
+	m.mode = modeRegions
+	m.regionQuery = ""
+	m.regionOffset = 0
+	return m, nil
+}
+
+// regionRowsHeight returns how many regions fit on the screen, or -1 while
+// the terminal size is unknown.
+func (m Model) regionRowsHeight() int {
+	if m.height <= 0 {
+		return -1
+	}
+	// Leave room for the title, the search, the hint and the blank lines
+	// around.
+	return max(1, m.height-6)
+}
+
+// updateRegions handles key presses in the region reference: / searches it
+// like the filter does the list, and up and down scroll it.
+func (m Model) updateRegions(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.regionSearching {
+		switch msg.String() {
+		case "esc":
+			m.regionQuery = ""
+			fallthrough
+		case "enter":
+			m.regionSearching = false
+			m.input.Blur()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		m.regionQuery = strings.TrimSpace(m.input.Value())
+		m.regionOffset = 0
+		return m, cmd
+	}
+	switch msg.String() {
+	case "q":
+		return m.quit()
+	case "/":
+		m.regionSearching = true
+		m.input.SetValue(m.regionQuery)
+		m.input.Placeholder = "region, place or zone"
+		m.input.CursorEnd()
+		return m, m.input.Focus()
+	case "up", "k":
+		m.regionOffset = max(0, m.regionOffset-1)
+	case "down", "j":
+		if height := m.regionRowsHeight(); height >= 0 && m.regionOffset+height < len(m.referenceRegions()) {
+			m.regionOffset++
+		}
+	case "esc", m.keys.first(actionRegions):
+		if m.regionQuery != "" && msg.String() == "esc" {
+			m.regionQuery = ""
+			m.regionOffset = 0
+			return m, nil
+		}
+		m.mode = modeList
+	}
+	return m, nil
+}
+
+// regionsView renders the regions matching the search with their location
+// and zones, marking the region of the selected instance.
+func (m Model) regionsView() string {
+	var b strings.Builder
+	regions := m.referenceRegions()
+	title := "Regions and zones:"
+	if m.regionQuery != "" {
+		title = fmt.Sprintf("Regions and zones matching %q:", m.regionQuery)
+	}
+	b.WriteString(title + "\n\n")
+	nameWidth, locationWidth := 0, 0
+	for _, r := range regions {
+		nameWidth, locationWidth = max(nameWidth, len(r.Name)), max(locationWidth, len([]rune(r.Location)))
+	}
+	current := ""
+	if vm, ok := m.selected(); ok {
+		current = gcp.ZoneRegion(m.located(vm).Zone)
+	}
+	shown := regions[min(m.regionOffset, len(regions)):]
+	if height := m.regionRowsHeight(); height >= 0 && len(shown) > height {
+		shown = shown[:height]
+	}
+	for _, r := range shown {
+		marker := " "
+		if r.Name == current {
+			marker = ">"
+		}
+		var suffixes []string
+		for _, zone := range gcp.RegionZones(r.Name) {
+			suffixes = append(suffixes, strings.TrimPrefix(zone, r.Name+"-"))
+		}
+		location := r.Location + strings.Repeat(" ", locationWidth-len([]rune(r.Location)))
+		line := fmt.Sprintf("%s %-*s  %s  %s", marker, nameWidth, r.Name, location, m.muted("zones "+strings.Join(suffixes, ", ")))
+		b.WriteString(m.fitLine(line, 4) + "\n")
+	}
+	if len(regions) == 0 {
+		b.WriteString("  No region matches.\n")
+	}
+	b.WriteString("\n")
+	if m.regionSearching {
+		b.WriteString("Search: " + m.input.View() + "\n")
+	}
+	b.WriteString(m.hintView())
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionMatches(t *testing.T) {
+	r, ok := gcp.LookupRegion("europe-west1")
+	require.True(t, ok)
+	require.True(t, regionMatches(r, "europe-west1"))
+	require.True(t, regionMatches(r, "belgium"), "the location should match in any case")
+	require.True(t, regionMatches(r, "west1-d"), "the zones should match")
+	require.False(t, regionMatches(r, "west1-a"), "europe-west1 has no zone a")
+}
+
+func TestUpdate_Regions(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "europe-west1-b"}}
+
+	m, _ = keyPress(t, m, "Z")
+	require.Equal(t, modeRegions, m.mode)
+	view := m.View()
+	require.Contains(t, view, "Regions and zones:")
+	require.Contains(t, view, "> europe-west1             St. Ghislain, Belgium          zones b, c, d\n")
+	require.Contains(t, view, "  us-central1              Council Bluffs, Iowa           zones a, b, c, f\n")
+
+	m, _ = keyPress(t, m, "/")
+	require.True(t, m.regionSearching)
+	m, _ = keyPress(t, m, "japan")
+	require.Equal(t, "japan", m.regionQuery)
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.False(t, m.regionSearching)
+	view = m.View()
+	require.Contains(t, view, `Regions and zones matching "japan":`)
+	require.Contains(t, view, "asia-northeast1  Tokyo, Japan")
+	require.Contains(t, view, "asia-northeast2  Osaka, Japan")
+	require.NotContains(t, view, "europe-west1")
+
+	// esc clears the search first, then goes back to the list.
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	require.Equal(t, modeRegions, m.mode)
+	require.Empty(t, m.regionQuery)
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.Equal(t, modeList, model.(Model).mode)
+}
+
+func TestUpdate_RegionsScroll(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.height = 10
+	m, _ = keyPress(t, m, "Z")
+	require.Contains(t, m.View(), "africa-south1")
+
+	m, _ = keyPress(t, m, "j")
+	view := m.View()
+	require.NotContains(t, view, "africa-south1")
+	require.Contains(t, view, "asia-east1")
+
+	for range 100 {
+		m, _ = keyPress(t, m, "j")
+	}
+	require.Contains(t, m.View(), "us-west4", "scrolling should stop at the last region")
+	m, _ = keyPress(t, m, "k")
+	require.Equal(t, len(gcp.Regions())-4-1, m.regionOffset)
+}
@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// configMsg carries a reloaded and validated config.
+type configMsg struct {
+	cfg     config.Config
+	keys    KeyMap
+	theme   Theme
+	spinner spinner.Spinner
+	err     error
+}
+
+// reloadConfigCmd returns a command that reads and validates the config file.
+func (m Model) reloadConfigCmd() tea.Cmd {
+	path, color := m.configPath, m.color
+	return func() tea.Msg {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return configMsg{err: err}
+		}
+		keys, err := NewKeyMap(cfg.Keys)
+		if err != nil {
+			return configMsg{err: fmt.Errorf("invalid key bindings: %w", err)}
+		}
+		theme, err := NewTheme(cfg.Theme, color)
+		if err != nil {
+			return configMsg{err: fmt.Errorf("invalid theme: %w", err)}
+		}
+		s, err := NewSpinner(cfg.Spinner)
+		if err != nil {
+			return configMsg{err: fmt.Errorf("invalid spinner: %w", err)}
+		}
+		if err := CheckQueries(cfg.Queries); err != nil {
+			return configMsg{err: fmt.Errorf("invalid saved queries: %w", err)}
+		}
+		return configMsg{cfg: cfg, keys: keys, theme: theme, spinner: s}
+	}
+}
+
+// reloadConfig starts reloading the config file, if there is one.
+func (m Model) reloadConfig() (tea.Model, tea.Cmd) {
+	if m.configPath == "" {
+		m.message = "No config file to reload."
+		return m, nil
+	}
+	return m, m.reloadConfigCmd()
+}
+
+// applyConfig switches to a reloaded config, keeping the current one if the
+// new one is invalid. The list is fetched again if the projects changed.
+func (m Model) applyConfig(msg configMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.banner = fmt.Sprintf("Error: could not reload %s: %v", m.configPath, msg.err)
+		return m, nil
+	}
+	prev := m.cfg
+	m.cfg = msg.cfg
+	m.keys = msg.keys
+	m.theme = msg.theme
+	m.spinner.Spinner = msg.spinner
+	m.prices = gcp.HourlyPrices(msg.cfg.Prices)
+	m.message = fmt.Sprintf("Reloaded %s.", m.configPath)
+	if slices.Equal(prev.Projects, msg.cfg.Projects) || len(msg.cfg.Projects) == 0 {
+		return m, nil
+	}
+	m.projects = msg.cfg.Projects
+	m.projectID = m.projects[0]
+	m.cursor = 0
+	return m, m.refresh()
+}
@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+// reloadWith writes data as the config file and reloads it with ctrl+r.
+func reloadWith(t *testing.T, m Model, data string) (Model, tea.Cmd) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(m.configPath, []byte(data), 0o600))
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	require.NotNil(t, cmd)
+	model, cmd = model.(Model).Update(cmd())
+	return model.(Model), cmd
+}
+
+func TestUpdate_ReloadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+	m := NewModel(client, "test-project", WithConfig(path, config.Config{}, false))
+	m.loading = false
+
+	m, cmd := reloadWith(t, m, `{"keys": {"quit": ["x"]}}`)
+	require.Nil(t, cmd, "the list should not be fetched again when the projects are unchanged")
+	require.True(t, m.keys.matches(actionQuit, "x"))
+	require.Contains(t, m.View(), "Reloaded "+path+".")
+
+	m, _ = reloadWith(t, m, `{"keys": {"quit": ["j"]}}`)
+	require.True(t, m.keys.matches(actionQuit, "x"), "an invalid config must keep the previous one")
+	require.Contains(t, m.View(), "could not reload")
+}
+
+func TestUpdate_ReloadConfigProjects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	client := gcptest.NewFakeClient(
+		gcp.Instance{ProjectID: "proj-a", Name: "a-1", Zone: "z-1"},
+		gcp.Instance{ProjectID: "proj-b", Name: "b-1", Zone: "z-1"},
+	)
+	m := NewModel(client, "proj-a", WithConfig(path, config.Config{}, false))
+	m.loading = false
+
+	m, cmd := reloadWith(t, m, `{"projects": ["proj-a", "proj-b"]}`)
+	require.NotNil(t, cmd)
+	require.True(t, m.loading, "changing the projects should fetch the list again")
+	require.Equal(t, []string{"proj-a", "proj-b"}, m.projects)
+	model, _ := m.Update(m.fetchVmsCmd())
+	require.Equal(t, []string{"a-1", "b-1"}, names(model.(Model).vms))
+}
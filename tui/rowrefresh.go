@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// instanceMsg carries a single instance fetched again, replacing the one
+// with key in the list.
+type instanceMsg struct {
+	key string
+	vm  gcp.Instance
+	err error
+}
+
+// refreshInstance fetches vm again without reloading the whole list. Its row
+// shows that it is being refreshed until the result arrives.
+func (m Model) refreshInstance(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	key := m.instanceKey(vm)
+	if m.refreshing[key] {
+		return m, nil
+	}
+	refreshing := make(map[string]bool, len(m.refreshing)+1)
+	for k := range m.refreshing {
+		refreshing[k] = true
+	}
+	refreshing[key] = true
+	m.refreshing = refreshing
+	m.message = ""
+	ctx, projectID, zone, client := m.ctx, m.projectOf(vm), m.located(vm).Zone, m.gcpClient
+	return m, func() tea.Msg {
+		fresh, err := client.GetInstance(ctx, projectID, zone, vm.Name)
+		return instanceMsg{key: key, vm: fresh, err: err}
+	}
+}
+
+// handleInstance puts the refreshed instance in place of the listed one, or
+// drops it from the list if it has been deleted.
+func (m Model) handleInstance(msg instanceMsg) (tea.Model, tea.Cmd) {
+	refreshing := make(map[string]bool, len(m.refreshing))
+	for k := range m.refreshing {
+		if k != msg.key {
+			refreshing[k] = true
+		}
+	}
+	m.refreshing = refreshing
+
+	i := -1
+	for j, vm := range m.vms {
+		if m.instanceKey(vm) == msg.key {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		// The list was reloaded without it in the meantime.
+		return m, nil
+	}
+	name := m.vms[i].Name
+	switch {
+	case errors.Is(msg.err, gcp.ErrInstanceNotFound):
+		current, ok := m.selected()
+		m.vms = append(m.vms[:i:i], m.vms[i+1:]...)
+		m.listVersion++
+		if m.marked[msg.key] {
+			marked := make(map[string]bool, len(m.marked))
+			for k := range m.marked {
+				if k != msg.key {
+					marked[k] = true
+				}
+			}
+			m.marked = marked
+		}
+		if ok && m.instanceKey(current) != msg.key {
+			m.cursor = 0
+			m.moveCursorTo(current)
+		}
+		// Removing the selected instance selects the next one.
+		m.cursor = min(m.cursor, max(len(m.visible())-1, 0))
+		m.message = fmt.Sprintf("%s no longer exists; removed it from the list.", name)
+	case msg.err != nil:
+		m.banner = fmt.Sprintf("Error: could not refresh %s: %v", name, msg.err)
+	default:
+		vms := append([]gcp.Instance(nil), m.vms...)
+		vms[i] = msg.vm
+		m.vms = vms
+		m.listVersion++
+		m.message = fmt.Sprintf("Refreshed %s.", name)
+	}
+	return m, nil
+}
+
+// refreshingColumn marks the row of an instance being refreshed.
+func (m Model) refreshingColumn(vm gcp.Instance) string {
+	if !m.refreshing[m.instanceKey(vm)] {
+		return ""
+	}
+	return " " + m.muted("refreshing...")
+}
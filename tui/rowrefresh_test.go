@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate_RefreshInstance(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+	)
+	m := loadedModel(t, client)
+	m.cursor = 1
+	require.NoError(t, client.SetStatus("test-project", "z-1", "vm-2", "TERMINATED"))
+
+	m, cmd := keyPress(t, m, "f")
+	require.Contains(t, m.View(), "> [vm-2] RUNNING refreshing...\n")
+
+	model, _ := m.Update(cmd())
+	m = model.(Model)
+	view := m.View()
+	require.Contains(t, view, "> [vm-2] TERMINATED\n")
+	require.Contains(t, view, "Refreshed vm-2.")
+	require.Empty(t, m.refreshing)
+}
+
+func TestUpdate_RefreshDeletedInstance(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+	)
+	m := loadedModel(t, client)
+	m.cursor = 1
+	client.SetError("GetInstance", gcp.ErrInstanceNotFound)
+
+	m, cmd := keyPress(t, m, "f")
+	model, _ := m.Update(cmd())
+	m = model.(Model)
+	require.Equal(t, []string{"vm-1"}, names(m.vms))
+	require.Equal(t, 0, m.cursor)
+	require.Contains(t, m.View(), "vm-2 no longer exists; removed it from the list.")
+}
+
+func TestUpdate_RefreshInstanceFails(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+	m := loadedModel(t, client)
+	client.SetError("GetInstance", errors.New("boom"))
+
+	m, cmd := keyPress(t, m, "f")
+	model, _ := m.Update(cmd())
+	m = model.(Model)
+	require.Len(t, m.vms, 1)
+	require.Contains(t, m.View(), "Error: could not refresh vm-1: boom")
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"maps"
+	"path"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sensitive reports whether projectID matches the sensitive patterns of the
+// config.
+func (m Model) sensitive(projectID string) bool {
+	for _, pattern := range m.cfg.SensitiveProjects {
+		if ok, _ := path.Match(pattern, projectID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lockedProject returns the project of the first of vms whose actions are
+// locked, being sensitive and not unlocked yet, or "" if there is none.
+func (m Model) lockedProject(vms ...gcp.Instance) string {
+	for _, vm := range vms {
+		if p := m.projectOf(vm); m.sensitive(p) && !m.unlocked[p] {
+			return p
+		}
+	}
+	return ""
+}
+
+// actionTargets returns the instances the list actions apply to: the marked
+// ones if any, or else the selected one.
+func (m Model) actionTargets() []gcp.Instance {
+	if len(m.marked) > 0 {
+		return m.markedInstances()
+	}
+	if vm, ok := m.selected(); ok {
+		return []gcp.Instance{vm}
+	}
+	return nil
+}
+
+// locked reports whether the actions that change the instances they would
+// apply to are locked.
+func (m Model) locked() bool {
+	return m.lockedProject(m.actionTargets()...) != ""
+}
+
+// openUnlock asks for the ID of the locked project of the instances the
+// actions would apply to, to unlock them.
+func (m Model) openUnlock() (tea.Model, tea.Cmd) {
+	project := m.lockedProject(m.actionTargets()...)
+	if project == "" {
+		m.message = "Actions are not locked."
+		return m, nil
+	}
+	m.message = ""
+	m.mode = modeUnlock
+	m.unlocking = project
+	m.input.SetValue("")
+	m.input.Placeholder = ""
+	return m, m.input.Focus()
+}
+
+// updateUnlock handles key presses while the project ID is typed. The
+// actions on the project are unlocked for the rest of the session only if
+// it is typed exactly.
+func (m Model) updateUnlock(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		m.mode = modeList
+		m.input.Blur()
+		project := m.unlocking
+		if strings.TrimSpace(m.input.Value()) != project {
+			m.message = fmt.Sprintf("That is not %s; actions stay locked.", project)
+			return m, nil
+		}
+		unlocked := maps.Clone(m.unlocked)
+		if unlocked == nil {
+			unlocked = make(map[string]bool)
+		}
+		unlocked[project] = true
+		m.unlocked = unlocked
+		m.message = fmt.Sprintf("Unlocked actions on %s for this session.", project)
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// unlockView renders the prompt for the ID of the locked project.
+func (m Model) unlockView() string {
+	var b strings.Builder
+	b.WriteString(m.envView())
+	b.WriteString(fmt.Sprintf("%s is sensitive: suspending, resuming and changing its instances is locked.\n\n", m.unlocking))
+	b.WriteString("Type the project ID to unlock them for this session: " + m.input.View() + "\n")
+	b.WriteString("\n" + m.hintView())
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func lockedModel(t *testing.T, projectID string, vms ...gcp.Instance) Model {
+	t.Helper()
+	m := NewModel(new(mocks.Client), projectID, WithConfig("", config.Config{SensitiveProjects: []string{"*-prod"}}, false))
+	m.loading = false
+	m.vms = vms
+	return m
+}
+
+func TestLockedProject(t *testing.T) {
+	m := lockedModel(t, "shop-dev")
+	prod := gcp.Instance{Name: "vm-1", ProjectID: "shop-prod"}
+	dev := gcp.Instance{Name: "vm-2", ProjectID: "shop-dev"}
+	require.True(t, m.sensitive("shop-prod"))
+	require.False(t, m.sensitive("shop-dev"))
+	require.Equal(t, "shop-prod", m.lockedProject(dev, prod))
+	require.Empty(t, m.lockedProject(dev), "other projects shown should not be locked")
+
+	m.unlocked = map[string]bool{"shop-prod": true}
+	require.Empty(t, m.lockedProject(prod))
+
+	m = NewModel(new(mocks.Client), "shop-prod")
+	require.Empty(t, m.lockedProject(gcp.Instance{Name: "vm-1"}), "nothing should be locked without sensitive patterns")
+}
+
+func TestUpdate_LockedActions(t *testing.T) {
+	m := lockedModel(t, "shop-prod", gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+	require.Contains(t, m.View(), "S and R locked (press U to unlock)")
+
+	m, cmd := keyPress(t, m, "S")
+	require.Nil(t, cmd)
+	require.Nil(t, m.confirm, "suspending should be locked")
+	require.Equal(t, "Suspending is locked on shop-prod (press U to unlock).", m.message)
+
+	m, _ = keyPress(t, m, "i")
+	m, _ = keyPress(t, m, "d")
+	require.Equal(t, "Turning deletion protection on is locked on shop-prod (press U to unlock).", m.message)
+	require.Contains(t, m.View(), "m and d locked (press U to unlock)")
+}
+
+func TestUpdate_LockedActionPaths(t *testing.T) {
+	m := lockedModel(t, "shop-prod",
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "SUSPENDED"},
+	)
+	m, cmd := keyPress(t, m, "enter")
+	require.Nil(t, cmd)
+	require.Nil(t, m.confirm, "starting before SSH should be locked")
+	require.Equal(t, "Starting is locked on shop-prod (press U to unlock).", m.message)
+
+	m, _ = keyPress(t, m, " ")
+	m, _ = keyPress(t, m, " ")
+	m, _ = keyPress(t, m, "R")
+	require.Nil(t, m.bulk, "resuming the marked instances should be locked")
+	require.Equal(t, "Resuming is locked on shop-prod (press U to unlock).", m.message)
+
+	m, _ = keyPress(t, m, "esc")
+	m.cursor = 0
+	m, _ = keyPress(t, m, "i")
+	m, _ = keyPress(t, m, "m")
+	require.Equal(t, modeDetail, m.mode, "the machine type should be locked")
+	require.Equal(t, "Changing the machine type is locked on shop-prod (press U to unlock).", m.message)
+}
+
+func TestUpdate_Unlock(t *testing.T) {
+	m := lockedModel(t, "shop-prod", gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+
+	m, _ = keyPress(t, m, "U")
+	require.Equal(t, modeUnlock, m.mode)
+	require.Contains(t, m.View(), "Type the project ID to unlock them for this session:")
+	m, _ = keyPress(t, m, "shop-pro")
+	m, _ = keyPress(t, m, "enter")
+	require.Equal(t, modeList, m.mode)
+	require.Equal(t, "That is not shop-prod; actions stay locked.", m.message)
+	require.True(t, m.locked())
+
+	m, _ = keyPress(t, m, "U")
+	require.Empty(t, m.input.Value(), "the prompt should start empty every time")
+	m, _ = keyPress(t, m, "shop-prod")
+	m, _ = keyPress(t, m, "enter")
+	require.Equal(t, "Unlocked actions on shop-prod for this session.", m.message)
+	require.Equal(t, map[string]bool{"shop-prod": true}, m.unlocked)
+	require.False(t, m.locked())
+
+	m, _ = keyPress(t, m, "S")
+	require.NotNil(t, m.confirm, "suspending should ask for confirmation once unlocked")
+
+	m = lockedModel(t, "shop-dev")
+	m, _ = keyPress(t, m, "U")
+	require.Equal(t, modeList, m.mode)
+	require.Equal(t, "Actions are not locked.", m.message)
+}
+
+func TestUpdate_UnlockPerProject(t *testing.T) {
+	m := NewModel(new(mocks.Client), "shop-a-prod", WithProjects([]string{"shop-a-prod", "shop-b-prod"}),
+		WithConfig("", config.Config{SensitiveProjects: []string{"*-prod"}}, false))
+	m.loading = false
+	m.vms = []gcp.Instance{
+		{Name: "vm-a", Zone: "z-1", Status: "RUNNING", ProjectID: "shop-a-prod"},
+		{Name: "vm-b", Zone: "z-1", Status: "RUNNING", ProjectID: "shop-b-prod"},
+	}
+
+	m, _ = keyPress(t, m, "U")
+	require.Contains(t, m.View(), "shop-a-prod is sensitive")
+	m, _ = keyPress(t, m, "shop-a-prod")
+	m, _ = keyPress(t, m, "enter")
+	require.Equal(t, "Unlocked actions on shop-a-prod for this session.", m.message)
+
+	m, _ = keyPress(t, m, "S")
+	require.NotNil(t, m.confirm)
+	m, _ = keyPress(t, m, "n")
+
+	m, _ = keyPress(t, m, "j")
+	m, _ = keyPress(t, m, "S")
+	require.Nil(t, m.confirm, "unlocking a project should not unlock the others")
+	require.Equal(t, "Suspending is locked on shop-b-prod (press U to unlock).", m.message)
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"strings"
+)
+
+// rowsHeight returns how many lines the instance rows can take between
+// header and footer, or -1 while the terminal size is unknown. At least one
+// row is always shown.
+func (m Model) rowsHeight(header, footer string) int {
+	if m.height <= 0 {
+		return -1
+	}
+	return max(1, m.height-strings.Count(header, "\n")-strings.Count(footer, "\n"))
+}
+
+// rowLines returns how many lines the i-th row takes: one, unless it is
+// wrapped.
+func (m Model) rowLines(i int, vm gcp.Instance) int {
+	if !m.wrap || m.width <= 0 {
+		return 1
+	}
+	return strings.Count(m.rowView(i, vm), "\n") + 1
+}
+
+// fits reports whether the rows from i to j take no more than height lines.
+func (m Model) fits(vms []gcp.Instance, i, j, height int) bool {
+	if !m.wrap || m.width <= 0 {
+		return j-i+1 <= height
+	}
+	for ; i <= j; i++ {
+		height -= m.rowLines(i, vms[i])
+		if height < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// rowsView renders the rows that fit in height lines from the first row
+// shown, or every row if height is -1.
+func (m Model) rowsView(height int) string {
+	var b strings.Builder
+	vms := m.visible()
+	for i := min(m.offset, len(vms)); i < len(vms); i++ {
+		row := m.rowView(i, vms[i])
+		if height >= 0 {
+			height -= strings.Count(row, "\n") + 1
+			if height < 0 && i > m.offset {
+				break
+			}
+		}
+		b.WriteString(row + "\n")
+	}
+	return b.String()
+}
+
+// showsRows reports whether the current mode shows the instance rows.
+func (m Model) showsRows() bool {
+	return m.mode == modeList || m.mode == modeFilter || m.mode == modeNote
+}
+
+// scrollToCursor scrolls the list as little as possible for the cursor row
+// to be shown, leaving no blank lines below the last row when the list is
+// scrolled.
+func (m *Model) scrollToCursor() {
+	header, footer := m.listChrome()
+	height := m.rowsHeight(header, footer)
+	vms := m.visible()
+	if height < 0 || len(vms) == 0 {
+		m.offset = 0
+		return
+	}
+	m.offset = max(0, min(m.offset, m.cursor, len(vms)-1))
+	for m.offset < m.cursor && !m.fits(vms, m.offset, m.cursor, height) {
+		m.offset++
+	}
+	for m.offset > 0 && m.fits(vms, m.offset-1, len(vms)-1, height) {
+		m.offset--
+	}
+}
+
+// keepScreenRow scrolls the list so that the cursor is shown row rows below
+// the first row shown, as it was before the list changed. scrollToCursor
+// then keeps it within the screen where that is not possible.
+func (m *Model) keepScreenRow(row int) {
+	m.offset = max(0, m.cursor-row)
+}
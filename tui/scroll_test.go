@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+// scrollModel returns a model listing vm-0 to vm-(n-1) on a terminal with
+// room for three rows.
+func scrollModel(t *testing.T, n int) Model {
+	t.Helper()
+	m := NewModel(new(mocks.Client), "test-project")
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 7})
+	model, _ = model.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: numberedVMs(n)}})
+	return model.(Model)
+}
+
+// numberedVMs returns the running instances vm-0 to vm-(n-1).
+func numberedVMs(n int) []gcp.Instance {
+	vms := make([]gcp.Instance, n)
+	for i := range vms {
+		vms[i] = gcp.Instance{Name: fmt.Sprintf("vm-%d", i), Zone: "z-1", Status: "RUNNING"}
+	}
+	return vms
+}
+
+func TestUpdate_ScrollsToCursor(t *testing.T) {
+	m := scrollModel(t, 10)
+	require.Contains(t, m.View(), "> [vm-0] RUNNING\n  [vm-1] RUNNING\n  [vm-2] RUNNING\n\n")
+
+	for range 4 {
+		m, _ = keyPress(t, m, "down")
+	}
+	require.Equal(t, 2, m.offset)
+	require.Contains(t, m.View(), "  [vm-2] RUNNING\n  [vm-3] RUNNING\n> [vm-4] RUNNING\n\n")
+	require.NotContains(t, m.View(), "vm-1]")
+
+	m, _ = keyPress(t, m, "up")
+	m, _ = keyPress(t, m, "up")
+	m, _ = keyPress(t, m, "up")
+	require.Equal(t, 1, m.offset, "the list should scroll as little as possible")
+
+	// Clicks count from the first row shown.
+	model, _ := m.Update(tea.MouseMsg{Y: m.rowsTop() + 2, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	require.Equal(t, 3, model.(Model).cursor)
+}
+
+func TestUpdate_RefreshKeepsScreenRow(t *testing.T) {
+	m := scrollModel(t, 10)
+	for range 6 {
+		m, _ = keyPress(t, m, "down")
+	}
+	require.Equal(t, 4, m.offset)
+	require.Equal(t, 6, m.cursor)
+
+	// vm-6 moves up to the fourth row: it stays on the last line shown.
+	vms := numberedVMs(10)
+	moved := vms[6]
+	vms = slices.Insert(slices.Delete(vms, 6, 7), 3, moved)
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: vms}})
+	m = model.(Model)
+	require.Equal(t, 3, m.cursor)
+	require.Equal(t, 1, m.offset)
+	require.Contains(t, m.View(), "  [vm-2] RUNNING\n> [vm-6] RUNNING\n")
+	require.Empty(t, m.selectionNotice)
+
+	// Where the same screen line cannot be kept, the cursor stays in view.
+	slices.Reverse(vms)
+	model, _ = m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: vms}})
+	m = model.(Model)
+	require.Equal(t, 6, m.cursor)
+	require.Equal(t, 4, m.offset)
+
+	vms = numberedVMs(10)
+	model, _ = m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: slices.Concat(vms[:1], vms[6:])}})
+	m = model.(Model)
+	require.Equal(t, 1, m.cursor)
+	require.Equal(t, 0, m.offset)
+}
+
+func TestUpdate_RefreshAfterRemoval(t *testing.T) {
+	m := scrollModel(t, 10)
+	for range 6 {
+		m, _ = keyPress(t, m, "down")
+	}
+	m, _ = keyPress(t, m, "up")
+	m, _ = keyPress(t, m, "up")
+	require.Equal(t, 4, m.offset)
+	require.Equal(t, 4, m.cursor)
+
+	// vm-4 is deleted: the cursor moves to the instance below it, on the
+	// same line.
+	vms := numberedVMs(10)
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: slices.Delete(slices.Clone(vms), 4, 5)}})
+	m = model.(Model)
+	selected, _ := m.selected()
+	require.Equal(t, "vm-5", selected.Name)
+	require.Equal(t, 4, m.offset)
+	require.Contains(t, m.selectionNotice, "vm-4 no longer exists")
+
+	// With everything below it gone too, the cursor moves to the nearest
+	// instance above.
+	model, _ = m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: vms[:3]}})
+	m = model.(Model)
+	selected, _ = m.selected()
+	require.Equal(t, "vm-2", selected.Name)
+}
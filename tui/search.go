@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"regexp"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// rememberSearch keeps query as the search term that the next and previous
+// match keys jump between, once the filter it was typed in is gone. An
+// empty query or an invalid pattern keeps the previous term.
+func (m *Model) rememberSearch(query string) {
+	if query == "" {
+		return
+	}
+	if !m.filterRegex {
+		m.search, m.searchRE = query, nil
+		return
+	}
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return
+	}
+	m.search, m.searchRE = query, re
+}
+
+// matchesSearch reports whether vm matches the search term, the way the
+// filter it was typed in matched.
+func (m Model) matchesSearch(vm gcp.Instance) bool {
+	if m.searchRE != nil {
+		return m.searchRE.MatchString(vm.Name)
+	}
+	return matchesFilter(vm, parseFilter(m.search))
+}
+
+// jumpToMatch moves the cursor to the next instance matching the search term
+// in the direction of delta, wrapping around the list. Instances that do not
+// match stay shown.
+func (m Model) jumpToMatch(delta int) (tea.Model, tea.Cmd) {
+	if m.search == "" {
+		m.message = fmt.Sprintf("No search term yet; type one with %s.", m.keys.first(actionFilter))
+		return m, nil
+	}
+	vms := m.visible()
+	for step := 1; step <= len(vms); step++ {
+		i := ((m.cursor+step*delta)%len(vms) + len(vms)) % len(vms)
+		if !m.matchesSearch(vms[i]) {
+			continue
+		}
+		m.message = ""
+		switch {
+		case delta > 0 && i <= m.cursor:
+			m.message = fmt.Sprintf("Search for %s wrapped to the top.", m.search)
+		case delta < 0 && i >= m.cursor:
+			m.message = fmt.Sprintf("Search for %s wrapped to the bottom.", m.search)
+		}
+		m.cursor = i
+		return m, nil
+	}
+	m.message = fmt.Sprintf("No instances match %s.", m.search)
+	return m, nil
+}
@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+// searchModel returns a model listing a mix of web and db instances.
+func searchModel(t *testing.T) Model {
+	t.Helper()
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{
+		{Name: "web-1", Zone: "z-1"},
+		{Name: "db-1", Zone: "z-1"},
+		{Name: "web-2", Zone: "z-1"},
+		{Name: "db-2", Zone: "z-1"},
+		{Name: "web-3", Zone: "z-1"},
+	}
+	return m
+}
+
+// typeSearch types query in the filter and dismisses it with esc.
+func typeSearch(t *testing.T, m Model, query string) Model {
+	t.Helper()
+	m, _ = keyPress(t, m, "/")
+	for _, r := range query {
+		m, _ = keyPress(t, m, string(r))
+	}
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	return model.(Model)
+}
+
+func TestUpdate_JumpToMatch(t *testing.T) {
+	m := typeSearch(t, searchModel(t), "db")
+	require.Len(t, m.visible(), 5, "dismissing the filter shows every instance again")
+	require.Equal(t, 0, m.cursor)
+
+	m, _ = keyPress(t, m, "n")
+	require.Equal(t, "db-1", m.visible()[m.cursor].Name)
+	require.Empty(t, m.message)
+	m, _ = keyPress(t, m, "n")
+	require.Equal(t, "db-2", m.visible()[m.cursor].Name)
+	m, _ = keyPress(t, m, "n")
+	require.Equal(t, "db-1", m.visible()[m.cursor].Name)
+	require.Equal(t, "Search for db wrapped to the top.", m.message)
+
+	m, _ = keyPress(t, m, "N")
+	require.Equal(t, "db-2", m.visible()[m.cursor].Name)
+	require.Equal(t, "Search for db wrapped to the bottom.", m.message)
+	m, _ = keyPress(t, m, "N")
+	require.Equal(t, "db-1", m.visible()[m.cursor].Name)
+	require.Empty(t, m.message)
+}
+
+func TestUpdate_JumpToMatch_KeptFilter(t *testing.T) {
+	m := searchModel(t)
+	m, _ = keyPress(t, m, "/")
+	for _, r := range "web" {
+		m, _ = keyPress(t, m, string(r))
+	}
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.Len(t, m.visible(), 3)
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	require.Len(t, m.visible(), 5, "esc clears the kept filter")
+	m, _ = keyPress(t, m, "n")
+	require.Equal(t, "web-2", m.visible()[m.cursor].Name, "the term survives clearing the filter")
+}
+
+func TestUpdate_JumpToMatch_Regex(t *testing.T) {
+	m := searchModel(t)
+	m.filterRegex = true
+	m = typeSearch(t, m, "-[23]$")
+	m, _ = keyPress(t, m, "n")
+	require.Equal(t, "web-2", m.visible()[m.cursor].Name)
+	m, _ = keyPress(t, m, "n")
+	require.Equal(t, "db-2", m.visible()[m.cursor].Name)
+}
+
+func TestUpdate_JumpToMatch_NoMatch(t *testing.T) {
+	m := searchModel(t)
+	m, _ = keyPress(t, m, "n")
+	require.Equal(t, "No search term yet; type one with /.", m.message)
+
+	m = typeSearch(t, m, "cache")
+	m.cursor = 2
+	m, _ = keyPress(t, m, "n")
+	require.Equal(t, 2, m.cursor, "the cursor stays put without a match")
+	require.Equal(t, "No instances match cache.", m.message)
+}
@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/cache"
+	"gcp-rider/gcp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// snapshotMsg is sent once the fetched list has been saved as the latest
+// snapshot, carrying the changes since the previous one when requested.
+type snapshotMsg struct {
+	diff *gcp.InstanceDiff
+	err  error
+}
+
+// snapshotName is the cache entry holding the last fetched list of a project,
+// or of a "+"-joined set of projects.
+func snapshotName(projects string) string {
+	return "snapshot-" + projects + ".json"
+}
+
+// saveSnapshotCmd returns a command that persists vms as the project's latest
+// snapshot. If compare is true, the previous snapshot is diffed against vms
+// first; no diff is reported when there was no previous snapshot.
+func (m Model) saveSnapshotCmd(vms []gcp.Instance, compare bool) tea.Cmd {
+	dir, name := m.cacheDir, snapshotName(strings.Join(m.projects, "+"))
+	return func() tea.Msg {
+		var msg snapshotMsg
+		if compare {
+			var prev []gcp.Instance
+			ok, err := cache.Load(dir, name, &prev)
+			if err != nil {
+				return snapshotMsg{err: err}
+			}
+			if ok {
+				d := gcp.DiffInstances(prev, vms)
+				msg.diff = &d
+			}
+		}
+		msg.err = cache.Save(dir, name, vms)
+		return msg
+	}
+}
+
+// listedAll reports whether the last fetch listed every instance of the
+// projects shown, in full. Only such a list is compared with the snapshot
+// and saved as the next one, since instances left out of a partial list
+// would be reported as removed and dropped from the snapshot.
+func (m Model) listedAll() bool {
+	return !m.truncated && len(m.projectErrors) == 0 && len(m.zoneErrors) == 0 &&
+		len(m.fetchOpts.Zones) == 0 && len(m.fetchOpts.Statuses) == 0 &&
+		m.skippedZones == 0 && m.fetchOpts.Fields == nil
+}
+
+// handleSnapshot shows the changes since the last run, if any.
+func (m Model) handleSnapshot(msg snapshotMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Could not update the instance snapshot: %v", msg.err)
+	}
+	if msg.diff != nil && !msg.diff.Empty() {
+		m.changes = msg.diff
+	}
+	return m, nil
+}
+
+// changesView renders the differences from the previous session.
+func changesView(d gcp.InstanceDiff) string {
+	var b strings.Builder
+	b.WriteString("Changes since last run (esc to dismiss):\n")
+	for _, vm := range d.Added {
+		b.WriteString(fmt.Sprintf("  + %s (%s)\n", vm.Name, vm.Zone))
+	}
+	for _, vm := range d.Removed {
+		b.WriteString(fmt.Sprintf("  - %s (%s)\n", vm.Name, vm.Zone))
+	}
+	for _, c := range d.Changed {
+		b.WriteString(fmt.Sprintf("  ~ %s (%s): %s -> %s\n", c.Instance.Name, c.Instance.Zone, c.OldStatus, c.Instance.Status))
+	}
+	return b.String()
+}
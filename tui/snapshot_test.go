@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/cache"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate_SnapshotFirstRun(t *testing.T) {
+	dir := t.TempDir()
+	m := NewModel(new(mocks.Client), "test-project", WithCacheDir(dir))
+	vms := []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}}
+
+	model, cmd := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: vms}})
+	m = model.(Model)
+	require.NotNil(t, cmd, "expected the snapshot to be saved")
+	model, _ = m.Update(cmd())
+	m = model.(Model)
+	require.Nil(t, m.changes, "no changes should be reported without a previous snapshot")
+
+	var saved []gcp.Instance
+	ok, err := cache.Load(dir, snapshotName("test-project"), &saved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, vms, saved)
+}
+
+func TestUpdate_SnapshotChanges(t *testing.T) {
+	dir := t.TempDir()
+	prev := []gcp.Instance{
+		{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"},
+		{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+	}
+	require.NoError(t, cache.Save(dir, snapshotName("test-project"), prev))
+
+	m := NewModel(new(mocks.Client), "test-project", WithCacheDir(dir))
+	model, cmd := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}, {Name: "vm-3", Zone: "z-1", Status: "RUNNING"}}}})
+	m = model.(Model)
+	model, _ = m.Update(cmd())
+	m = model.(Model)
+
+	view := m.View()
+	require.Contains(t, view, "+ vm-3 (z-1)")
+	require.Contains(t, view, "- vm-2 (z-1)")
+	require.Contains(t, view, "~ vm-1 (z-1): TERMINATED -> RUNNING")
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	require.Nil(t, m.changes, "esc should dismiss the changes")
+
+	// Later refreshes only update the snapshot.
+	model, cmd = m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"}}}})
+	m = model.(Model)
+	model, _ = m.Update(cmd())
+	require.Nil(t, model.(Model).changes)
+}
+
+func TestUpdate_SnapshotSkipsPartialLists(t *testing.T) {
+	vms := []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}}
+	for name, tt := range map[string]struct {
+		opts gcp.FetchOptions
+		msg  vmsMsg
+	}{
+		"truncated":      {msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: vms, Truncated: true}}},
+		"project errors": {msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: vms, ProjectErrors: []gcp.ProjectError{{ProjectID: "other", Err: errors.New("denied")}}}}},
+		"zone errors":    {msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: vms, ZoneErrors: []gcp.ZoneError{{ProjectID: "test-project", Zone: "z-2", Err: errors.New("unavailable")}}}}},
+		"picked zones":   {opts: gcp.FetchOptions{Zones: []string{"z-1"}}, msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: vms}}},
+		"status filter":  {opts: gcp.FetchOptions{Statuses: []string{"RUNNING"}}, msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: vms}}},
+		"fields":         {opts: gcp.FetchOptions{Fields: []gcp.Field{gcp.FieldNetwork}}, msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: vms}}},
+		"zone warnings":  {opts: gcp.FetchOptions{SkipZoneWarnings: true}, msg: vmsMsg{InstanceList: gcp.InstanceList{Instances: vms, SkippedZoneWarnings: 1}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			prev := []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}, {Name: "vm-2", Zone: "z-2", Status: "RUNNING"}}
+			require.NoError(t, cache.Save(dir, snapshotName("test-project"), prev))
+
+			m := NewModel(new(mocks.Client), "test-project", WithCacheDir(dir), WithFetchOptions(tt.opts))
+			model, cmd := m.Update(tt.msg)
+			require.Nil(t, cmd, "a partial list should neither be compared nor saved")
+			require.False(t, model.(Model).snapshotCompared, "the next complete list should still be compared")
+
+			var saved []gcp.Instance
+			_, err := cache.Load(dir, snapshotName("test-project"), &saved)
+			require.NoError(t, err)
+			require.Equal(t, prev, saved)
+		})
+	}
+}
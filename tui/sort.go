@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sortByColumn sorts the list by the nth column, counting the name as the
+// first. Picking the column already sorted by flips the direction. The
+// cursor stays on the selected instance.
+func (m Model) sortByColumn(n int) (tea.Model, tea.Cmd) {
+	cols := append([]string{"name"}, m.columns(m.dense)...)
+	if n > len(cols) {
+		m.message = fmt.Sprintf("There is no column %d; the list has %d.", n, len(cols))
+		return m, nil
+	}
+	current, ok := m.selected()
+	if col := cols[n-1]; col == m.sortColumn {
+		m.sortDesc = !m.sortDesc
+	} else {
+		m.sortColumn, m.sortDesc = col, false
+	}
+	m.message = ""
+	m.cursor = 0
+	if ok {
+		m.moveCursorTo(current)
+	}
+	return m, nil
+}
+
+// sortByAge sorts the list newest first, or back to the listing order if it
+// already is. The cursor stays on the selected instance.
+func (m Model) sortByAge() (tea.Model, tea.Cmd) {
+	current, ok := m.selected()
+	if m.sortColumn == "created" && m.sortDesc {
+		m.sortColumn = ""
+		m.message = "Showing instances in listing order."
+	} else {
+		m.sortColumn, m.sortDesc = "created", true
+		m.message = "Showing the newest instances first."
+	}
+	m.cursor = 0
+	if ok {
+		m.moveCursorTo(current)
+	}
+	return m, nil
+}
+
+// sortArrow shows the direction of the column sort, with an arrow when the
+// terminal is fancy enough for one.
+func (m Model) sortArrow() string {
+	switch {
+	case m.color && m.sortDesc:
+		return "↓"
+	case m.color:
+		return "↑"
+	case m.sortDesc:
+		return "desc"
+	default:
+		return "asc"
+	}
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortOrders_CoverListColumns(t *testing.T) {
+	for col := range listColumns {
+		require.Contains(t, sortOrders, col, "column %s cannot be sorted by", col)
+	}
+}
+
+func TestUpdate_SortByColumn(t *testing.T) {
+	m := queriesModel(t)
+	m.cursor = 2
+	require.Equal(t, "web-1", m.visible()[m.cursor].Name)
+
+	// The columns are name, status, flags and project.
+	m, _ = keyPress(t, m, "1")
+	require.Equal(t, []string{"db-1", "web-1", "web-2", "web-3", "web-4"}, names(m.visible()))
+	require.Equal(t, "web-1", m.visible()[m.cursor].Name, "the cursor should stay on the selected instance")
+	require.Contains(t, m.View(), "GCP VMs: (sort: name asc)")
+
+	m, _ = keyPress(t, m, "1")
+	require.Equal(t, []string{"web-4", "web-3", "web-2", "web-1", "db-1"}, names(m.visible()))
+	require.Contains(t, m.View(), "GCP VMs: (sort: name desc)")
+
+	m, _ = keyPress(t, m, "2")
+	require.Equal(t, []string{"web-2", "db-1", "web-3", "web-4", "web-1"}, names(m.visible()), "the sort should be stable")
+	require.Equal(t, "status", m.sortColumn)
+	require.False(t, m.sortDesc)
+
+	m, _ = keyPress(t, m, "4")
+	require.Equal(t, "project", m.sortColumn)
+
+	m, _ = keyPress(t, m, "9")
+	require.Equal(t, "project", m.sortColumn)
+	require.Equal(t, "There is no column 9; the list has 4.", m.message)
+}
+
+func TestUpdate_SortByAge(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project", WithSort("age"))
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{
+		{Name: "old", Status: "RUNNING", CreatedAt: "2023-01-01T00:00:00Z"},
+		{Name: "unknown", Status: "RUNNING", CreatedAt: "not a time"},
+		{Name: "new", Status: "RUNNING", CreatedAt: "2024-06-01T00:00:00Z"},
+	}}})
+	m = model.(Model)
+	require.Equal(t, []string{"new", "old", "unknown"}, names(m.visible()))
+	require.Contains(t, m.View(), "GCP VMs: (sort: created desc)")
+
+	m.cursor = 1
+	m, _ = keyPress(t, m, "A")
+	require.Equal(t, []string{"old", "unknown", "new"}, names(m.visible()), "A again goes back to the listing order")
+	require.Equal(t, "Showing instances in listing order.", m.message)
+	require.Equal(t, "old", m.visible()[m.cursor].Name, "the cursor should stay on the selected instance")
+
+	m, _ = keyPress(t, m, "A")
+	require.Equal(t, []string{"new", "old", "unknown"}, names(m.visible()))
+	require.Equal(t, "Showing the newest instances first.", m.message)
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/cache"
+	"gcp-rider/gcp"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// specsName is the cache entry holding the machine type specs.
+const specsName = "machine-types.json"
+
+// specsMsg reports how many machine types of the running instances could not
+// be sized, and whether the specs could be persisted.
+type specsMsg struct {
+	failed int
+	err    error
+}
+
+// loadSpecsCmd returns a command that fetches the specs of the machine types
+// of the running instances shown that are not cached yet. The specs saved
+// by earlier sessions are read first, the first time.
+func (m *Model) loadSpecsCmd() tea.Cmd {
+	ctx, client, specs, dir, restore := m.ctx, m.gcpClient, m.specs, m.cacheDir, !m.specsRestored
+	m.specsRestored = true
+	type machineType struct{ project, zone, name string }
+	var types []machineType
+	seen := make(map[machineType]bool)
+	for _, vm := range m.visible() {
+		mt := machineType{m.projectOf(vm), vm.Zone, vm.MachineType}
+		if vm.Status != "RUNNING" || vm.Zone == "" || vm.MachineType == "" || seen[mt] {
+			continue
+		}
+		seen[mt] = true
+		types = append(types, mt)
+	}
+	return func() tea.Msg {
+		if restore && dir != "" {
+			var entries map[string]gcp.CachedMachineType
+			if _, err := cache.Load(dir, specsName, &entries); err == nil {
+				specs.Restore(entries)
+			}
+		}
+		var msg specsMsg
+		fetched := false
+		for _, mt := range types {
+			if _, ok := specs.Lookup(mt.zone, mt.name); ok {
+				continue
+			}
+			if _, err := specs.Spec(ctx, client, mt.project, mt.zone, mt.name); err != nil {
+				msg.failed++
+				continue
+			}
+			fetched = true
+		}
+		if fetched && dir != "" {
+			msg.err = cache.Save(dir, specsName, specs.Entries())
+		}
+		return msg
+	}
+}
+
+// handleSpecs reports machine types that could not be sized.
+func (m Model) handleSpecs(msg specsMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.failed > 0:
+		m.message = fmt.Sprintf("Could not get the size of %d machine type(s).", msg.failed)
+	case msg.err != nil:
+		m.message = fmt.Sprintf("Could not remember the machine type sizes: %v", msg.err)
+	}
+	return m, nil
+}
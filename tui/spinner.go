@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+)
+
+// spinners are the bubbles spinner presets, selectable by name in the config.
+var spinners = map[string]spinner.Spinner{
+	"line":      spinner.Line,
+	"dot":       spinner.Dot,
+	"minidot":   spinner.MiniDot,
+	"jump":      spinner.Jump,
+	"pulse":     spinner.Pulse,
+	"points":    spinner.Points,
+	"globe":     spinner.Globe,
+	"moon":      spinner.Moon,
+	"monkey":    spinner.Monkey,
+	"meter":     spinner.Meter,
+	"hamburger": spinner.Hamburger,
+	"ellipsis":  spinner.Ellipsis,
+}
+
+// DefaultSpinner is used when the config does not name a spinner.
+const DefaultSpinner = "dot"
+
+// NewSpinner looks up the named spinner preset. Names are case-insensitive,
+// so both "MiniDot" and "minidot" work.
+func NewSpinner(name string) (spinner.Spinner, error) {
+	if name == "" {
+		name = DefaultSpinner
+	}
+	s, ok := spinners[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(spinners))
+		for n := range spinners {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return spinner.Spinner{}, fmt.Errorf("unknown spinner %q (want one of %s)", name, strings.Join(names, ", "))
+	}
+	return s, nil
+}
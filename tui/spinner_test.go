@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSpinner(t *testing.T) {
+	s, err := NewSpinner("")
+	require.NoError(t, err)
+	require.Equal(t, spinner.Dot.Frames, s.Frames, "the default should be the dot spinner")
+
+	s, err = NewSpinner("MiniDot")
+	require.NoError(t, err)
+	require.Equal(t, spinner.MiniDot.Frames, s.Frames, "names should be case-insensitive")
+
+	s, err = NewSpinner("line")
+	require.NoError(t, err)
+	require.Equal(t, spinner.Line.Frames, s.Frames)
+}
+
+func TestNewSpinner_UnknownName(t *testing.T) {
+	_, err := NewSpinner("wheel")
+	require.ErrorContains(t, err, `unknown spinner "wheel"`)
+	require.ErrorContains(t, err, "minidot")
+}
+
+func TestWithSpinner(t *testing.T) {
+	m := NewModel(nil, "test-project", WithSpinner(spinner.Line))
+	require.Equal(t, spinner.Line.Frames, m.spinner.Spinner.Frames)
+}
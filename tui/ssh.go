@@ -0,0 +1,200 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stderrTailSize is how much of the SSH command's stderr is kept for
+// inspecting why it failed.
+const stderrTailSize = 4096
+
+// transientSSHErrors are fragments of ssh/gcloud output that indicate the
+// instance is still booting and the connection is worth retrying.
+var transientSSHErrors = []string{
+	"connection refused",
+	"connection timed out",
+	"connection closed by",
+	"connection reset by peer",
+	"no route to host",
+}
+
+// sshDoneMsg is sent when an SSH session has ended.
+type sshDoneMsg struct {
+	vm     gcp.Instance
+	err    error
+	stderr string
+}
+
+// sshArgs returns the gcloud arguments used to SSH into an instance as user,
+// or as the user gcloud picks if it is empty. extra is appended verbatim
+// after our own flags, so it may hold further gcloud flags and, after a "--"
+// separator, flags for ssh itself.
+func sshArgs(vm gcp.Instance, projectID, user string, extra []string) []string {
+	target := vm.Name
+	if user != "" {
+		target = user + "@" + vm.Name
+	}
+	args := []string{"compute", "ssh", target, "--zone", vm.Zone, "--project", projectID}
+	return append(args, extra...)
+}
+
+// sshExtraArgs returns the user's extra SSH arguments: those given on the
+// command line, or else those in the config.
+func (m Model) sshExtraArgs() []string {
+	if m.sshExtra != nil {
+		return m.sshExtra
+	}
+	return m.cfg.SSHArgs
+}
+
+// sshCmd returns a command that hands the terminal over to an SSH session
+// and reports how it ended. The session goes through the connection opened
+// at socket, which stop closes once it has ended, or connects on its own
+// when socket is empty. While the session runs, bubbletea ignores the
+// interrupts it would otherwise turn into a quit, so a Ctrl+C typed before
+// ssh takes over the terminal only ends it and returns to the list.
+func (m Model) sshCmd(vm gcp.Instance, socket string, stop func()) tea.Cmd {
+	argv, err := m.sshCommand(vm)
+	if err == nil && socket != "" {
+		argv, err = m.muxCommand(vm, socket)
+	}
+	if err != nil {
+		if stop != nil {
+			stop()
+		}
+		return func() tea.Msg { return sshDoneMsg{vm: vm, err: err} }
+	}
+	tail := &tailBuffer{max: stderrTailSize}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stderr = io.MultiWriter(os.Stderr, tail)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if stop != nil {
+			stop()
+		}
+		return sshDoneMsg{vm: vm, err: err, stderr: tail.String()}
+	})
+}
+
+// ssh connects to vm, or explains why it can't when the instance is not
+// running, offering to start or resume it if it is at rest.
+func (m Model) ssh(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	switch gcp.ClassifyStatus(vm.Status) {
+	case gcp.StatusRunning:
+		remember := m.rememberSSH(vm)
+		model, cmd := m.connect(vm)
+		return model, tea.Batch(cmd, remember)
+	case gcp.StatusStopped:
+		verb, doing, done, action := "Start", "Starting", "Started", m.gcpClient.StartInstance
+		if vm.Status == "SUSPENDED" {
+			verb, doing, done, action = "Resume", "Resuming", "Resumed", m.gcpClient.ResumeInstance
+		}
+		m.askAction(doing, confirmation{
+			prompt:  fmt.Sprintf("%s is %s; %s it first?", vm.Name, vm.Status, strings.ToLower(verb)),
+			cmd:     m.instanceActionCmd(vm, verb, done, action),
+			loading: fmt.Sprintf("%s %s...", doing, vm.Name),
+		}, vm)
+	case gcp.StatusTransitional:
+		m.message = fmt.Sprintf("%s is %s; wait until it is RUNNING to connect.", vm.Name, vm.Status)
+	default:
+		m.message = fmt.Sprintf("%s is %s; it must be RUNNING to connect.", vm.Name, vm.Status)
+	}
+	return m, nil
+}
+
+// reconnect connects again to the instance of the last SSH session, using its
+// current state from the list.
+func (m Model) reconnect() (tea.Model, tea.Cmd) {
+	if m.lastSSH == nil {
+		m.message = "No SSH session to reconnect to yet."
+		return m, nil
+	}
+	last := *m.lastSSH
+	for _, vm := range m.vms {
+		if vm.Name == last.Name && vm.Zone == last.Zone && m.projectOf(vm) == m.projectOf(last) {
+			return m.ssh(vm)
+		}
+	}
+	m.message = fmt.Sprintf("%s is no longer in the list.", last.Name)
+	return m, nil
+}
+
+// handleSSHDone refreshes the list after a session, since the instance may
+// have been changed from inside it, and offers a retry if the session failed
+// with a transient error.
+func (m Model) handleSSHDone(msg sshDoneMsg) (tea.Model, tea.Cmd) {
+	m.retryVM = nil
+	if m.quitAfterSSH {
+		if msg.err != nil && !interrupted(msg.err) {
+			m.exitErr = fmt.Errorf("SSH to %s failed: %w", msg.vm.Name, msg.err)
+		}
+		return m.quit()
+	}
+	if model, cmd, ok := m.connectQueued(); ok {
+		return model, cmd
+	}
+	if msg.err == nil {
+		return m, m.refresh()
+	}
+	if interrupted(msg.err) {
+		m.message = fmt.Sprintf("SSH to %s was interrupted.", msg.vm.Name)
+		return m, m.refresh()
+	}
+	if isTransientSSHError(msg.stderr) {
+		vm := msg.vm
+		m.retryVM = &vm
+		m.message = fmt.Sprintf("SSH to %s failed, the instance may still be booting. Press %s to retry.", vm.Name, m.keys.first(actionRetrySSH))
+		return m, nil
+	}
+	m.message = fmt.Sprintf("SSH to %s failed: %v", msg.vm.Name, msg.err)
+	return m, nil
+}
+
+// interrupted reports whether the SSH command was ended by Ctrl+C, either by
+// the signal itself or by exiting with the conventional status 130.
+func interrupted(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() && ws.Signal() == syscall.SIGINT {
+		return true
+	}
+	return exitErr.ExitCode() == 130
+}
+
+// isTransientSSHError reports whether the output of a failed SSH command
+// looks like the instance was not ready to accept connections yet.
+func isTransientSSHError(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, fragment := range transientSSHErrors {
+		if strings.Contains(stderr, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// tailBuffer is an io.Writer that keeps only the last max bytes written.
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string { return string(t.buf) }
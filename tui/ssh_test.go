@@ -0,0 +1,248 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"os/exec"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHArgs(t *testing.T) {
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1"}
+	require.Equal(t, []string{"compute", "ssh", "vm-1", "--zone", "z-1", "--project", "test-project"}, sshArgs(vm, "test-project", "", nil))
+}
+
+func TestSSHArgs_Extra(t *testing.T) {
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1"}
+	require.Equal(t,
+		[]string{"compute", "ssh", "vm-1", "--zone", "z-1", "--project", "test-project", "--internal-ip", "--", "-A"},
+		sshArgs(vm, "test-project", "", []string{"--internal-ip", "--", "-A"}),
+		"our flags must come before the user's extras and their -- separator")
+}
+
+func TestSSHExtraArgs_FlagOverridesConfig(t *testing.T) {
+	cfg := config.Config{SSHArgs: []string{"--", "-A"}}
+	m := NewModel(new(mocks.Client), "test-project", WithConfig("", cfg, false))
+	require.Equal(t, []string{"--", "-A"}, m.sshExtraArgs())
+
+	m = NewModel(new(mocks.Client), "test-project", WithConfig("", cfg, false), WithSSHArgs([]string{"--", "-v"}))
+	require.Equal(t, []string{"--", "-v"}, m.sshExtraArgs())
+}
+
+func TestIsTransientSSHError(t *testing.T) {
+	require.True(t, isTransientSSHError("ssh: connect to host 10.0.0.1 port 22: Connection refused"))
+	require.True(t, isTransientSSHError("Connection closed by 10.0.0.1 port 22"))
+	require.False(t, isTransientSSHError("Permission denied (publickey)."))
+	require.False(t, isTransientSSHError(""))
+}
+
+func TestTailBuffer_KeepsLastBytes(t *testing.T) {
+	tail := &tailBuffer{max: 5}
+	tail.Write([]byte("hello "))
+	tail.Write([]byte("world"))
+	require.Equal(t, "world", tail.String())
+}
+
+func TestUpdate_SSHRetry(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1"}
+	m.vms = []gcp.Instance{vm}
+	m.loading = false
+
+	model, _ := m.Update(sshDoneMsg{vm: vm, err: errors.New("exit status 255"), stderr: "port 22: Connection refused"})
+	m = model.(Model)
+	require.NotNil(t, m.retryVM, "a transient failure should offer a retry")
+	require.Contains(t, m.message, "Press r to retry")
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = model.(Model)
+	require.NotNil(t, cmd, "r should launch SSH again")
+	require.Nil(t, m.retryVM)
+	require.Empty(t, m.message)
+}
+
+func TestUpdate_SSHRetryUsesKeyMap(t *testing.T) {
+	km, err := NewKeyMap(map[string][]string{actionRetrySSH: {"x"}})
+	require.NoError(t, err)
+	_, err = NewKeyMap(map[string][]string{actionRetrySSH: {"t"}})
+	require.ErrorContains(t, err, `key "t" is bound to both "retry-ssh" and "stopped"`)
+
+	m := NewModel(new(mocks.Client), "test-project", WithKeyMap(km))
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1"}
+	m.vms = []gcp.Instance{vm}
+	m.loading = false
+
+	model, _ := m.Update(sshDoneMsg{vm: vm, err: errors.New("exit status 255"), stderr: "port 22: Connection refused"})
+	m = model.(Model)
+	require.Contains(t, m.message, "Press x to retry")
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	require.NotNil(t, cmd, "the rebound key should launch SSH again")
+	require.Nil(t, model.(Model).retryVM)
+}
+
+func TestUpdate_SSHFailureNotRetryable(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1"}
+	m.vms = []gcp.Instance{vm}
+	m.loading = false
+
+	model, _ := m.Update(sshDoneMsg{vm: vm, err: errors.New("exit status 255"), stderr: "Permission denied (publickey)."})
+	m = model.(Model)
+	require.Nil(t, m.retryVM)
+	require.Contains(t, m.message, "SSH to vm-1 failed")
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	require.Nil(t, cmd, "r should do nothing without a pending retry")
+	require.Nil(t, model.(Model).retryVM)
+}
+
+// shellError runs script with sh and returns how it failed.
+func shellError(t *testing.T, script string) error {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	return exec.Command("sh", "-c", script).Run()
+}
+
+func TestInterrupted(t *testing.T) {
+	require.True(t, interrupted(shellError(t, "kill -INT $$")), "killed by SIGINT")
+	require.True(t, interrupted(shellError(t, "exit 130")), "exited after handling SIGINT")
+	require.False(t, interrupted(shellError(t, "exit 255")))
+	require.False(t, interrupted(errors.New("signal: interrupt")))
+}
+
+func TestUpdate_SSHDoneRefreshes(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1"}
+	m.vms = []gcp.Instance{vm}
+	m.loading = false
+
+	model, cmd := m.Update(sshDoneMsg{vm: vm})
+	m = model.(Model)
+	require.NotNil(t, cmd)
+	require.True(t, m.loading, "the list should be refreshed after the session")
+}
+
+func TestUpdate_SSHInterrupted(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1"}
+	m.vms = []gcp.Instance{vm}
+	m.loading = false
+
+	model, cmd := m.Update(sshDoneMsg{vm: vm, err: shellError(t, "kill -INT $$")})
+	m = model.(Model)
+	require.NotNil(t, cmd, "the TUI should keep running")
+	require.Equal(t, "SSH to vm-1 was interrupted.", m.message)
+	require.Nil(t, m.retryVM)
+
+	model, _ = m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{vm}}, seq: model.(Model).fetchSeq})
+	require.Contains(t, model.View(), "SSH to vm-1 was interrupted.")
+}
+
+func TestUpdate_SSHGuardOffersStart(t *testing.T) {
+	mockClient := new(mocks.Client)
+	mockClient.On("StartInstance", mock.Anything, "test-project", "z-1", "vm-1").Return(nil)
+
+	m := NewModel(mockClient, "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"}}
+	m.loading = false
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.Nil(t, cmd, "SSH should not be launched for a stopped instance")
+	require.Contains(t, m.View(), "vm-1 is TERMINATED; start it first? (y/n)")
+
+	model, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = model.(Model)
+	require.NotNil(t, cmd)
+	require.Equal(t, []tea.Msg{actionDoneMsg{"Started vm-1."}}, batchMsgs(cmd))
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestUpdate_SSHGuardOffersResume(t *testing.T) {
+	mockClient := new(mocks.Client)
+	mockClient.On("ResumeInstance", mock.Anything, "test-project", "z-1", "vm-1").Return(nil)
+
+	m := NewModel(mockClient, "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "SUSPENDED"}}
+	m.loading = false
+
+	m, cmd := keyPress(t, m, "enter")
+	require.Nil(t, cmd, "SSH should not be launched for a suspended instance")
+	require.Contains(t, m.View(), "vm-1 is SUSPENDED; resume it first? (y/n)")
+
+	m, cmd = keyPress(t, m, "y")
+	require.Contains(t, m.View(), "Resuming vm-1...")
+	require.Equal(t, []tea.Msg{actionDoneMsg{"Resumed vm-1."}}, batchMsgs(cmd))
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestUpdate_SSHGuardDecline(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"}}
+	m.loading = false
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model, cmd := model.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = model.(Model)
+	require.Nil(t, cmd)
+	require.Nil(t, m.confirm)
+	require.Equal(t, "Cancelled.", m.message)
+}
+
+func TestUpdate_SSHGuardTransitionalState(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "STAGING"}}
+	m.loading = false
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.Nil(t, cmd)
+	require.Nil(t, m.confirm, "only stopped instances can be started inline")
+	require.Equal(t, "vm-1 is STAGING; wait until it is RUNNING to connect.", m.message)
+}
+
+func TestUpdate_Reconnect(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{
+		{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+	}
+
+	m, _ = keyPress(t, m, ".")
+	require.Equal(t, "No SSH session to reconnect to yet.", m.message)
+
+	model, cmd := m.ssh(m.vms[1])
+	m = model.(Model)
+	require.NotNil(t, cmd)
+	require.Equal(t, "vm-2", m.lastSSH.Name)
+	m, _ = keyPress(t, m, "esc")
+
+	m.cursor = 0
+	m, cmd = keyPress(t, m, ".")
+	require.NotNil(t, cmd, "reconnect should ssh regardless of the cursor")
+	require.Equal(t, "vm-2", m.lastSSH.Name)
+	m, _ = keyPress(t, m, "esc")
+
+	// The latest state from the list is used.
+	m.vms[1].Status = "TERMINATED"
+	m, cmd = keyPress(t, m, ".")
+	require.Nil(t, cmd)
+	require.NotNil(t, m.confirm, "a stopped instance should be offered to start")
+	m.confirm = nil
+
+	m.vms = m.vms[:1]
+	m, _ = keyPress(t, m, ".")
+	require.Equal(t, "vm-2 is no longer in the list.", m.message)
+}
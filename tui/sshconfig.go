@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errSSHHostExists is returned when the SSH config already has an entry for
+// the instance.
+var errSSHHostExists = errors.New("already has an entry")
+
+// sshHostAlias returns the Host alias of an instance in the SSH config, in
+// the form gcloud compute config-ssh uses: "NAME.ZONE.PROJECT".
+func sshHostAlias(vm gcp.Instance, projectID string) string {
+	return vm.Name + "." + vm.Zone + "." + projectID
+}
+
+// sshConfigEntry returns an SSH config stanza for vm using the key and known
+// hosts file gcloud manages, logging in as user unless it is empty. With iap
+// set, the connection goes through an IAP tunnel instead of the external IP.
+func sshConfigEntry(vm gcp.Instance, projectID, user string, iap bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Host %s\n", sshHostAlias(vm, projectID))
+	if iap {
+		fmt.Fprintf(&b, "    HostName %s\n", vm.Name)
+		fmt.Fprintf(&b, "    ProxyCommand gcloud compute start-iap-tunnel %s %%p --listen-on-stdin --project=%s --zone=%s --verbosity=warning\n", vm.Name, projectID, vm.Zone)
+	} else {
+		fmt.Fprintf(&b, "    HostName %s\n", vm.ExternalIP)
+	}
+	if user != "" {
+		fmt.Fprintf(&b, "    User %s\n", user)
+	}
+	b.WriteString("    IdentityFile ~/.ssh/google_compute_engine\n")
+	b.WriteString("    UserKnownHostsFile ~/.ssh/google_compute_known_hosts\n")
+	if vm.ID != "" {
+		fmt.Fprintf(&b, "    HostKeyAlias compute.%s\n", vm.ID)
+	}
+	b.WriteString("    IdentitiesOnly yes\n")
+	b.WriteString("    CheckHostIP no\n")
+	return b.String()
+}
+
+// hasSSHHost reports whether config has a Host line naming alias.
+func hasSSHHost(config, alias string) bool {
+	scanner := bufio.NewScanner(strings.NewReader(config))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 1 && strings.EqualFold(fields[0], "Host") && slices.Contains(fields[1:], alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendSSHConfig appends entry to the SSH config at path, creating it if
+// needed. It returns errSSHHostExists without writing if the file already has
+// an entry for alias.
+func appendSSHConfig(path, alias, entry string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if hasSSHHost(string(data), alias) {
+		return errSSHHostExists
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	var sep string
+	if len(data) > 0 {
+		sep = "\n"
+		if !strings.HasSuffix(string(data), "\n") {
+			sep = "\n\n"
+		}
+	}
+	if _, err := f.WriteString(sep + entry); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sshConfigMsg reports the outcome of appending an SSH config entry.
+type sshConfigMsg struct {
+	alias string
+	err   error
+}
+
+// useIAP reports whether SSH to vm goes through an IAP tunnel: when the extra
+// SSH arguments ask for it, or when the instance has no external IP to reach.
+func (m Model) useIAP(vm gcp.Instance) bool {
+	return vm.ExternalIP == "" || slices.Contains(m.sshExtraArgs(), "--tunnel-through-iap")
+}
+
+// copySSHConfig copies the SSH config entry of vm to the clipboard.
+func (m Model) copySSHConfig(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	vm = m.located(vm)
+	m.message = fmt.Sprintf("Copied the SSH config entry for %s.", sshHostAlias(vm, m.projectOf(vm)))
+	return m, m.copyCmd(sshConfigEntry(vm, m.projectOf(vm), m.sshUser(vm), m.useIAP(vm)))
+}
+
+// copyCmd returns a command that puts text on the clipboard, writing the
+// escape sequence for it outside of Update.
+func (m Model) copyCmd(text string) tea.Cmd {
+	put := m.copy
+	return func() tea.Msg {
+		put(text)
+		return nil
+	}
+}
+
+// appendSSHConfigCmd returns a command that appends the SSH config entry of
+// vm to the user's SSH config.
+func (m Model) appendSSHConfigCmd(vm gcp.Instance) tea.Cmd {
+	vm = m.located(vm)
+	path, projectID := m.sshConfigPath, m.projectOf(vm)
+	alias, entry := sshHostAlias(vm, projectID), sshConfigEntry(vm, projectID, m.sshUser(vm), m.useIAP(vm))
+	return func() tea.Msg {
+		return sshConfigMsg{alias: alias, err: appendSSHConfig(path, alias, entry)}
+	}
+}
+
+// appendSSH appends the SSH config entry of vm, if there is an SSH config to
+// append to.
+func (m Model) appendSSH(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	if m.sshConfigPath == "" {
+		m.message = "No SSH config file to append to."
+		return m, nil
+	}
+	return m, m.appendSSHConfigCmd(vm)
+}
+
+// handleSSHConfig reports whether the entry was appended.
+func (m Model) handleSSHConfig(msg sshConfigMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case errors.Is(msg.err, errSSHHostExists):
+		m.message = fmt.Sprintf("%s already has an entry for %s; not adding it again.", m.sshConfigPath, msg.alias)
+	case msg.err != nil:
+		m.banner = fmt.Sprintf("Error: could not update %s: %v", m.sshConfigPath, msg.err)
+	default:
+		m.message = fmt.Sprintf("Added %s to %s; connect with: ssh %s", msg.alias, m.sshConfigPath, msg.alias)
+	}
+	return m, nil
+}
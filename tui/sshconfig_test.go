@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHConfigEntry(t *testing.T) {
+	vm := gcp.Instance{ID: "1234", Name: "web-1", Zone: "europe-west1-b", ExternalIP: "34.1.2.3"}
+	require.Equal(t, `Host web-1.europe-west1-b.shop-prod
+    HostName 34.1.2.3
+    IdentityFile ~/.ssh/google_compute_engine
+    UserKnownHostsFile ~/.ssh/google_compute_known_hosts
+    HostKeyAlias compute.1234
+    IdentitiesOnly yes
+    CheckHostIP no
+`, sshConfigEntry(vm, "shop-prod", "", false))
+
+	require.Equal(t, `Host web-1.europe-west1-b.shop-prod
+    HostName web-1
+    ProxyCommand gcloud compute start-iap-tunnel web-1 %p --listen-on-stdin --project=shop-prod --zone=europe-west1-b --verbosity=warning
+    IdentityFile ~/.ssh/google_compute_engine
+    UserKnownHostsFile ~/.ssh/google_compute_known_hosts
+    HostKeyAlias compute.1234
+    IdentitiesOnly yes
+    CheckHostIP no
+`, sshConfigEntry(vm, "shop-prod", "", true))
+}
+
+func TestHasSSHHost(t *testing.T) {
+	cfg := "Host github.com\n    User git\n\nhost bastion web-1.z-1.proj\n    HostName 10.0.0.1\n"
+	require.True(t, hasSSHHost(cfg, "web-1.z-1.proj"), "aliases are matched among several and regardless of the keyword's case")
+	require.True(t, hasSSHHost(cfg, "github.com"))
+	require.False(t, hasSSHHost(cfg, "web-1.z-1"))
+	require.False(t, hasSSHHost("    HostName web-1.z-1.proj\n", "web-1.z-1.proj"))
+	require.False(t, hasSSHHost("", "web-1.z-1.proj"))
+}
+
+func TestAppendSSHConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ssh", "config")
+
+	require.NoError(t, appendSSHConfig(path, "web-1.z-1.proj", "Host web-1.z-1.proj\n    HostName 34.1.2.3\n"))
+	require.ErrorIs(t, appendSSHConfig(path, "web-1.z-1.proj", "Host web-1.z-1.proj\n    HostName 34.1.2.4\n"), errSSHHostExists)
+	require.NoError(t, appendSSHConfig(path, "web-2.z-1.proj", "Host web-2.z-1.proj\n    HostName 34.1.2.5\n"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "Host web-1.z-1.proj\n    HostName 34.1.2.3\n\nHost web-2.z-1.proj\n    HostName 34.1.2.5\n", string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestUpdate_CopySSHConfig(t *testing.T) {
+	var copied string
+	m := NewModel(new(mocks.Client), "test-project", WithConfig("", config.Config{SSHArgs: []string{"--tunnel-through-iap"}}, false))
+	m.copy = func(text string) { copied = text }
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", ExternalIP: "34.1.2.3"}}
+
+	m, cmd := keyPress(t, m, "y")
+	require.Empty(t, copied, "the clipboard should be written by the command, not in Update")
+	require.Nil(t, cmd())
+	require.Contains(t, copied, "Host vm-1.z-1.test-project\n")
+	require.Contains(t, copied, "ProxyCommand gcloud compute start-iap-tunnel vm-1", "the SSH args ask for IAP")
+	require.Equal(t, "Copied the SSH config entry for vm-1.z-1.test-project.", m.message)
+}
+
+func TestUpdate_AppendSSHConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(path, []byte("Host github.com\n    User git"), 0o600))
+	m := NewModel(new(mocks.Client), "test-project", WithSSHConfigPath(path))
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", ExternalIP: "34.1.2.3"}}
+
+	_, cmd := keyPress(t, m, "Y")
+	model, _ := m.Update(cmd())
+	require.Equal(t, "Added vm-1.z-1.test-project to "+path+"; connect with: ssh vm-1.z-1.test-project", model.(Model).message)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "User git\n\nHost vm-1.z-1.test-project\n    HostName 34.1.2.3\n")
+
+	_, cmd = keyPress(t, m, "Y")
+	model, _ = m.Update(cmd())
+	require.Equal(t, path+" already has an entry for vm-1.z-1.test-project; not adding it again.", model.(Model).message)
+	again, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, data, again, "the file should be left alone")
+}
+
+func TestUpdate_AppendSSHConfigWithoutPath(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1"}}
+
+	m, cmd := keyPress(t, m, "Y")
+	require.Nil(t, cmd)
+	require.Equal(t, "No SSH config file to append to.", m.message)
+}
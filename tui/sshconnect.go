@@ -0,0 +1,244 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sshConnectTimeout is how long connecting to an instance may take before
+// giving up, e.g. when an IAP tunnel hangs.
+const sshConnectTimeout = 60 * time.Second
+
+// sshStopGrace is how long gcloud gets to shut its tunnel and ssh down after
+// being interrupted, before it is killed.
+const sshStopGrace = 3 * time.Second
+
+// sshSocketPoll is how often the control socket of a connection being
+// opened is looked for.
+const sshSocketPoll = 100 * time.Millisecond
+
+// errNoProbe is returned by an sshMaster that cannot open the connection
+// without a terminal, so the session is opened right away.
+var errNoProbe = errors.New("cannot connect without a terminal")
+
+// errPreSSHHook is returned when the pre-SSH hook of the config fails, so
+// the session is not opened.
+var errPreSSHHook = errors.New("pre-SSH hook failed")
+
+// sshMaster runs the given command line without a terminal to open the
+// connection of an SSH session in the background, shared through the
+// control socket at socket. It returns once the socket is ready, with stop
+// to close the connection, or once the command fails or ctx is done, with
+// the tail of its stderr.
+type sshMaster func(ctx context.Context, argv []string, socket string) (stop func(), stderr string, err error)
+
+// sshConnectMsg reports how connecting to an instance went before the
+// session was handed the terminal. Once connected, socket is the control
+// socket the session goes through and stop closes the connection.
+type sshConnectMsg struct {
+	// seq is that of the attempt the message reports on.
+	seq    int
+	vm     gcp.Instance
+	err    error
+	stderr string
+	socket string
+	stop   func()
+}
+
+// sshConnecting is a connection being opened for an SSH session.
+type sshConnecting struct {
+	// seq numbers the attempt, so that the outcome of an earlier attempt
+	// at the same instance cancelled with esc is not taken for this one.
+	seq     int
+	vm      gcp.Instance
+	started time.Time
+	cancel  context.CancelFunc
+}
+
+// masterFlags are the ssh flags that open a connection without a session
+// or prompts, to be shared through the control socket at socket.
+func masterFlags(socket string) []string {
+	return []string{"-oControlMaster=yes", "-oControlPath=" + socket, "-oControlPersist=no", "-oBatchMode=yes", "-N"}
+}
+
+// masterArgs returns the gcloud arguments that open the connection to vm
+// like sshArgs, in the background through the control socket at socket.
+func masterArgs(vm gcp.Instance, projectID, user, socket string, extra []string) []string {
+	args := sshArgs(vm, projectID, user, nil)
+	for _, flag := range masterFlags(socket) {
+		args = append(args, "--ssh-flag="+flag)
+	}
+	return append(args, extra...)
+}
+
+// openSSHMaster is the sshMaster used outside of tests. gcloud generates a
+// key on first use, prompting on the terminal, so without a key it returns
+// errNoProbe. Stopping or cancelling interrupts the command so that gcloud
+// can close the tunnel, and kills it if it does not exit in time.
+func openSSHMaster(ctx context.Context, argv []string, socket string) (func(), string, error) {
+	if argv[0] == "gcloud" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, "", errNoProbe
+		}
+		if _, err := os.Stat(filepath.Join(home, ".ssh", "google_compute_engine")); err != nil {
+			return nil, "", errNoProbe
+		}
+	}
+	tail := &tailBuffer{max: stderrTailSize}
+	// The connection outlives ctx, which only bounds opening it.
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = io.Discard
+	cmd.Stderr = tail
+	if err := cmd.Start(); err != nil {
+		return nil, "", err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	stop := func() {
+		cmd.Process.Signal(os.Interrupt)
+		select {
+		case <-done:
+		case <-time.After(sshStopGrace):
+			cmd.Process.Kill()
+			<-done
+		}
+	}
+	tick := time.NewTicker(sshSocketPoll)
+	defer tick.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err == nil {
+				err = errors.New("ssh exited before connecting")
+			}
+			return nil, tail.String(), err
+		case <-ctx.Done():
+			stop()
+			return nil, tail.String(), ctx.Err()
+		case <-tick.C:
+			if _, err := os.Stat(socket); err == nil {
+				return stop, "", nil
+			}
+		}
+	}
+}
+
+// runPreSSHHook runs the pre-SSH hook of the config for vm, if there is
+// one, until it exits or ctx is done, returning its output when it fails.
+func runPreSSHHook(ctx context.Context, run commandRunner, hook string, vm gcp.Instance, projectID string) (string, error) {
+	if hook == "" {
+		return "", nil
+	}
+	// The name after the script is $0; the instance follows as $1 to $3.
+	out, err := run(ctx, "sh", "-c", hook, "pre-ssh-hook", vm.Name, vm.Zone, projectID)
+	if err != nil {
+		return strings.TrimSpace(string(out)), fmt.Errorf("%w: %w", errPreSSHHook, err)
+	}
+	return "", nil
+}
+
+// connect runs the pre-SSH hook and opens the connection to vm in the
+// background before handing the terminal over to an SSH session that goes
+// through it, showing progress meanwhile so that a hanging connection can be
+// cancelled with esc.
+func (m Model) connect(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	if _, err := m.sshCommand(vm); err != nil {
+		m.message = fmt.Sprintf("Cannot SSH to %s: %v.", vm.Name, err)
+		return m.quitLaunch()
+	}
+	ctx, cancel := context.WithTimeout(m.ctx, sshConnectTimeout)
+	m.connectSeq++
+	seq := m.connectSeq
+	m.connecting = &sshConnecting{seq: seq, vm: vm, started: m.now(), cancel: cancel}
+	m.message = ""
+	master := m.master
+	run, hook, located, projectID := m.run, m.cfg.PreSSHHook, m.located(vm), m.projectOf(vm)
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		if out, err := runPreSSHHook(ctx, run, hook, located, projectID); err != nil {
+			return sshConnectMsg{seq: seq, vm: vm, err: err, stderr: out}
+		}
+		dir, err := os.MkdirTemp("", "gcp-rider-ssh-")
+		if err != nil {
+			return sshConnectMsg{seq: seq, vm: vm, err: err}
+		}
+		socket := filepath.Join(dir, "control")
+		argv, _ := m.masterCommand(vm, socket)
+		stop, stderr, err := master(ctx, argv, socket)
+		if err != nil {
+			os.RemoveAll(dir)
+			return sshConnectMsg{seq: seq, vm: vm, err: err, stderr: stderr}
+		}
+		return sshConnectMsg{seq: seq, vm: vm, socket: socket, stop: func() {
+			stop()
+			os.RemoveAll(dir)
+		}}
+	})
+}
+
+// cancelConnect stops opening the connection in progress, if any.
+func (m *Model) cancelConnect() {
+	if m.connecting != nil {
+		m.connecting.cancel()
+		m.connecting = nil
+	}
+}
+
+// updateConnecting handles key presses while connecting: esc cancels, and
+// everything else waits until the session has started.
+func (m Model) updateConnecting(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "esc" {
+		m.message = fmt.Sprintf("Cancelled SSH to %s.", m.connecting.vm.Name)
+		m.cancelConnect()
+		m.sshQueue = nil
+		return m.quitLaunch()
+	}
+	return m, nil
+}
+
+// handleSSHConnect opens the session through the connection once it is
+// open. A connection that fails for other reasons than the instance not
+// being ready still opens a session of its own, so that ssh can prompt or
+// explain on the terminal.
+func (m Model) handleSSHConnect(msg sshConnectMsg) (tea.Model, tea.Cmd) {
+	if m.connecting == nil || m.connecting.seq != msg.seq {
+		// Cancelled, but connected or failed meanwhile.
+		if msg.stop != nil {
+			go msg.stop()
+		}
+		return m, nil
+	}
+	m.cancelConnect()
+	switch {
+	case errors.Is(msg.err, errPreSSHHook):
+		m.sshQueue = nil
+		m.message = fmt.Sprintf("SSH to %s aborted, %v", msg.vm.Name, msg.err)
+		if msg.stderr != "" {
+			m.message += ": " + strings.ReplaceAll(msg.stderr, "\n", " ")
+		}
+		return m.quitLaunch()
+	case errors.Is(msg.err, context.DeadlineExceeded):
+		m.sshQueue = nil
+		m.message = fmt.Sprintf("SSH to %s did not connect within %s; gave up.", msg.vm.Name, sshConnectTimeout)
+		return m.quitLaunch()
+	case msg.err != nil && !errors.Is(msg.err, errNoProbe) && isTransientSSHError(msg.stderr):
+		return m.handleSSHDone(sshDoneMsg{vm: msg.vm, err: msg.err, stderr: msg.stderr})
+	}
+	return m, m.sshCmd(msg.vm, msg.socket, msg.stop)
+}
+
+// connectingView shows the connection in progress.
+func (m Model) connectingView() string {
+	elapsed := m.now().Sub(m.connecting.started).Truncate(time.Second)
+	return fmt.Sprintf("\n %s Connecting to %s (%s)... press esc to cancel.\n\n", m.spinner.View(), m.connecting.vm.Name, elapsed)
+}
@@ -0,0 +1,226 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func connectModel(t *testing.T, master sshMaster) Model {
+	t.Helper()
+	t.Setenv("TMPDIR", t.TempDir())
+	m := NewModel(new(mocks.Client), "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}}
+	m.loading = false
+	m.master = master
+	return m
+}
+
+// connectMsg runs the connection started by cmd and returns its result.
+func connectMsg(t *testing.T, cmd tea.Cmd) sshConnectMsg {
+	t.Helper()
+	for _, msg := range batchMsgs(cmd) {
+		if msg, ok := msg.(sshConnectMsg); ok {
+			return msg
+		}
+	}
+	t.Fatal("no connection was started")
+	return sshConnectMsg{}
+}
+
+func TestMasterArgs(t *testing.T) {
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1"}
+	require.Equal(t,
+		[]string{"compute", "ssh", "vm-1", "--zone", "z-1", "--project", "test-project",
+			"--ssh-flag=-oControlMaster=yes", "--ssh-flag=-oControlPath=/tmp/s", "--ssh-flag=-oControlPersist=no", "--ssh-flag=-oBatchMode=yes", "--ssh-flag=-N",
+			"--tunnel-through-iap", "--", "-A"},
+		masterArgs(vm, "test-project", "", "/tmp/s", []string{"--tunnel-through-iap", "--", "-A"}))
+}
+
+func TestUpdate_SSHConnectCancel(t *testing.T) {
+	var connectCtx context.Context
+	m := connectModel(t, func(ctx context.Context, args []string, socket string) (func(), string, error) {
+		connectCtx = ctx
+		<-ctx.Done()
+		return nil, "", ctx.Err()
+	})
+
+	m, cmd := keyPress(t, m, "enter")
+	require.NotNil(t, m.connecting)
+	require.Contains(t, m.View(), "Connecting to vm-1 (0s)... press esc to cancel.")
+
+	m, _ = keyPress(t, m, "j")
+	require.NotNil(t, m.connecting, "other keys should wait for the connection")
+
+	m, _ = keyPress(t, m, "esc")
+	require.Nil(t, m.connecting)
+	require.Contains(t, m.View(), "Cancelled SSH to vm-1.")
+
+	msg := connectMsg(t, cmd)
+	require.ErrorIs(t, connectCtx.Err(), context.Canceled, "esc should stop connecting")
+	model, cmd := m.Update(msg)
+	require.Nil(t, cmd, "a cancelled connection should not open a session")
+	require.Contains(t, model.View(), "Cancelled SSH to vm-1.")
+}
+
+func TestUpdate_SSHConnectedAfterCancel(t *testing.T) {
+	stopped := make(chan bool, 1)
+	m := connectModel(t, func(ctx context.Context, args []string, socket string) (func(), string, error) {
+		return func() { stopped <- true }, "", nil
+	})
+
+	m, cmd := keyPress(t, m, "enter")
+	msg := connectMsg(t, cmd)
+	require.NotEmpty(t, msg.socket)
+	m, _ = keyPress(t, m, "esc")
+	_, cmd = m.Update(msg)
+	require.Nil(t, cmd)
+	require.True(t, <-stopped, "a connection opened after esc should be closed")
+}
+
+func TestUpdate_SSHConnectRetriedAfterCancel(t *testing.T) {
+	attempts := 0
+	m := connectModel(t, func(ctx context.Context, args []string, socket string) (func(), string, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, "port 22: Connection refused", errors.New("exit status 255")
+		}
+		return func() {}, "", nil
+	})
+
+	m, first := keyPress(t, m, "enter")
+	m, _ = keyPress(t, m, "esc")
+	m, second := keyPress(t, m, "enter")
+	require.NotNil(t, m.connecting)
+
+	model, cmd := m.Update(connectMsg(t, first))
+	m = model.(Model)
+	require.Nil(t, cmd, "the outcome of the cancelled attempt should be dropped")
+	require.NotNil(t, m.connecting, "the retry should still be connecting")
+	require.Nil(t, m.retryVM)
+
+	model, cmd = m.Update(connectMsg(t, second))
+	m = model.(Model)
+	require.Nil(t, m.connecting)
+	require.NotNil(t, cmd, "the outcome of the retry should open the session")
+}
+
+func TestUpdate_SSHConnectOutcome(t *testing.T) {
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}
+	tests := []struct {
+		name    string
+		msg     sshConnectMsg
+		session bool
+		message string
+	}{
+		{"connected", sshConnectMsg{vm: vm, socket: "/tmp/s", stop: func() {}}, true, ""},
+		{"no terminal", sshConnectMsg{vm: vm, err: errNoProbe}, true, ""},
+		{"needs a terminal", sshConnectMsg{vm: vm, err: errors.New("exit status 255"), stderr: "Permission denied (publickey)."}, true, ""},
+		{"timed out", sshConnectMsg{vm: vm, err: context.DeadlineExceeded}, false, "SSH to vm-1 did not connect within 1m0s; gave up."},
+		{"booting", sshConnectMsg{vm: vm, err: errors.New("exit status 255"), stderr: "port 22: Connection refused"}, false, "Press r to retry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := connectModel(t, func(ctx context.Context, args []string, socket string) (func(), string, error) {
+				return func() {}, "", nil
+			})
+			m, _ = keyPress(t, m, "enter")
+
+			tt.msg.seq = m.connecting.seq
+			model, cmd := m.Update(tt.msg)
+			m = model.(Model)
+			require.Nil(t, m.connecting)
+			require.Equal(t, tt.session, cmd != nil)
+			require.Contains(t, m.message, tt.message)
+		})
+	}
+}
+
+func TestUpdate_SSHPreHook(t *testing.T) {
+	connected := false
+	m := connectModel(t, func(ctx context.Context, args []string, socket string) (func(), string, error) {
+		connected = true
+		return func() {}, "", nil
+	})
+	m.cfg.PreSSHHook = "vpn-up --check"
+	var ran []string
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		ran = append([]string{name}, args...)
+		return []byte("vpn: not logged in\n"), errors.New("exit status 1")
+	}
+
+	m, cmd := keyPress(t, m, "enter")
+	msg := connectMsg(t, cmd)
+	require.Equal(t, []string{"sh", "-c", "vpn-up --check", "pre-ssh-hook", "vm-1", "z-1", "test-project"}, ran)
+	require.False(t, connected, "a failed hook should stop before connecting")
+
+	model, cmd := m.Update(msg)
+	m = model.(Model)
+	require.Nil(t, cmd, "a failed hook should not open a session")
+	require.Nil(t, m.connecting)
+	require.Equal(t, "SSH to vm-1 aborted, pre-SSH hook failed: exit status 1: vpn: not logged in", m.message)
+
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) { return nil, nil }
+	m, cmd = keyPress(t, m, "enter")
+	model, cmd = m.Update(connectMsg(t, cmd))
+	require.True(t, connected)
+	require.NotNil(t, cmd, "the session should open once the hook succeeds")
+}
+
+func TestRunPreSSHHook_None(t *testing.T) {
+	run := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		t.Fatal("no command should run without a hook")
+		return nil, nil
+	}
+	out, err := runPreSSHHook(context.Background(), run, "", gcp.Instance{Name: "vm-1"}, "test-project")
+	require.NoError(t, err)
+	require.Empty(t, out)
+}
+
+func TestUpdate_SSHPreHookCancel(t *testing.T) {
+	m := connectModel(t, func(ctx context.Context, args []string, socket string) (func(), string, error) {
+		t.Fatal("a cancelled hook should stop before connecting")
+		return nil, "", nil
+	})
+	m.cfg.PreSSHHook = "vpn-up --wait"
+	var hookCtx context.Context
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		hookCtx = ctx
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	m, cmd := keyPress(t, m, "enter")
+	m, _ = keyPress(t, m, "esc")
+	msg := connectMsg(t, cmd)
+	require.ErrorIs(t, hookCtx.Err(), context.Canceled, "esc should stop the hook")
+	_, cmd = m.Update(msg)
+	require.Nil(t, cmd)
+}
+
+func TestUpdate_SSHRecentRunsPreHook(t *testing.T) {
+	m := connectModel(t, func(ctx context.Context, args []string, socket string) (func(), string, error) {
+		return func() {}, "", nil
+	})
+	m.cfg.PreSSHHook = "vpn-up"
+	var ran []string
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		ran = append([]string{name}, args...)
+		return nil, nil
+	}
+
+	// Targets of projects that are not loaded are connected to directly,
+	// still through the hook.
+	m.recent = []recentTarget{{Project: "other", Zone: "z-9", Name: "vm-9"}}
+	m, _ = keyPress(t, m, "h")
+	require.Equal(t, modeRecent, m.mode)
+	m, cmd := keyPress(t, m, "enter")
+	require.NotNil(t, m.connecting)
+	connectMsg(t, cmd)
+	require.Equal(t, []string{"sh", "-c", "vpn-up", "pre-ssh-hook", "vm-9", "z-9", "other"}, ran)
+}
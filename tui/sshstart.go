@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"gcp-rider/gcp"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// parseSSHTarget splits an SSH target of the form NAME, NAME.ZONE or
+// NAME.ZONE.PROJECT, as in the SSH config aliases, into its parts. Instance
+// names cannot hold dots, so the parts are unambiguous.
+func parseSSHTarget(target string) (name, zone, projectID string) {
+	parts := strings.SplitN(target, ".", 3)
+	name = parts[0]
+	if len(parts) > 1 {
+		zone = parts[1]
+	}
+	if len(parts) > 2 {
+		projectID = parts[2]
+	}
+	return name, zone, projectID
+}
+
+// sshTargetMatches returns the loaded instances that the SSH target names.
+func (m Model) sshTargetMatches(target string) []gcp.Instance {
+	name, zone, projectID := parseSSHTarget(target)
+	var matches []gcp.Instance
+	for _, vm := range m.vms {
+		if vm.Name == name && (zone == "" || vm.Zone == zone) && (projectID == "" || m.projectOf(vm) == projectID) {
+			matches = append(matches, vm)
+		}
+	}
+	return matches
+}
+
+// sshToTarget connects to the instance named on the command line once the
+// list has loaded, quitting after the session, without showing the list.
+// A stopped or suspended instance is offered to be started or resumed
+// first, and connected to once it runs. When several instances have that
+// name, the list is shown narrowed down to them to pick one.
+func (m Model) sshToTarget() (tea.Model, tea.Cmd) {
+	target := m.sshTarget
+	m.sshTarget = ""
+	matches := m.sshTargetMatches(target)
+	m.quitAfterSSH = true
+	switch len(matches) {
+	case 0:
+		m.exitErr = m.lookupErr(target)
+		return m.quit()
+	case 1:
+		vm := matches[0]
+		m.moveCursorTo(vm)
+		if gcp.ClassifyStatus(vm.Status) == gcp.StatusStopped {
+			m.sshTarget = target
+		}
+		model, cmd := m.ssh(vm)
+		if m := model.(Model); m.connecting == nil && m.confirm == nil {
+			return m.quitLaunch()
+		}
+		return model, cmd
+	}
+	name, _, _ := parseSSHTarget(target)
+	m.launching = false
+	m.filterRegex = true
+	m.setFilter("^" + regexp.QuoteMeta(name) + "$")
+	m.message = fmt.Sprintf("%d instances match %s; pick one and press %s to connect, or add the zone as in %s.%s.", len(matches), target, m.keys.first(actionSSH), name, matches[0].Zone)
+	return m, nil
+}
+
+// lookupErr explains why no loaded instance matches the SSH target: the
+// listing failed or was cut short where it could be, or there is none.
+func (m Model) lookupErr(target string) error {
+	var errs []error
+	for _, pe := range m.projectErrors {
+		errs = append(errs, pe)
+	}
+	for _, ze := range m.zoneErrors {
+		errs = append(errs, ze)
+	}
+	if m.skippedZones > 0 {
+		errs = append(errs, fmt.Errorf("%d zones could not be listed", m.skippedZones))
+	}
+	if m.truncated {
+		errs = append(errs, fmt.Errorf("the listing stopped at %d instances", len(m.vms)))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not look up %s: %w", target, errors.Join(errs...))
+	}
+	return fmt.Errorf("no instance matches %s in %s", target, strings.Join(m.projects, ", "))
+}
+
+// launchView shows what a session started with the SSH target waits for, in
+// place of the list.
+func (m Model) launchView() string {
+	if m.confirm != nil {
+		return "\n " + m.confirm.view() + "\n\n"
+	}
+	return ""
+}
+
+// quitLaunch ends a session started with the SSH target if it did not reach
+// the instance, with the status message as the reason.
+func (m Model) quitLaunch() (tea.Model, tea.Cmd) {
+	if !m.quitAfterSSH {
+		return m, nil
+	}
+	m.exitErr = errors.New(strings.TrimSuffix(m.message, "."))
+	return m.quit()
+}
+
+// ExitErr returns why the program quit without doing what it was started
+// for, e.g. when the SSH target matches no instance, or nil.
+func (m Model) ExitErr() error {
+	return m.exitErr
+}
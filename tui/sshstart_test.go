@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSSHTarget(t *testing.T) {
+	for target, want := range map[string][3]string{
+		"web-1":                     {"web-1", "", ""},
+		"web-1.us-central1-a":       {"web-1", "us-central1-a", ""},
+		"web-1.us-central1-a.shop":  {"web-1", "us-central1-a", "shop"},
+		"web-1.us-central1-a.a.b.c": {"web-1", "us-central1-a", "a.b.c"},
+	} {
+		name, zone, project := parseSSHTarget(target)
+		require.Equal(t, want, [3]string{name, zone, project}, target)
+	}
+}
+
+// launchModel returns a model started with the SSH target, with the given
+// instances loaded.
+func launchModel(t *testing.T, target string, vms ...gcp.Instance) (Model, tea.Cmd) {
+	t.Helper()
+	m := NewModel(new(mocks.Client), "test-project", WithSSHTarget(target))
+	m.master = func(ctx context.Context, argv []string, socket string) (func(), string, error) {
+		return func() {}, "", nil
+	}
+	model, cmd := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: vms}})
+	return model.(Model), cmd
+}
+
+func TestUpdate_SSHTargetConnects(t *testing.T) {
+	m, cmd := launchModel(t, "vm-2",
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+	)
+	require.NotNil(t, cmd)
+	require.NotNil(t, m.connecting)
+	require.Equal(t, "vm-2", m.connecting.vm.Name)
+
+	model, cmd := m.Update(sshDoneMsg{vm: m.connecting.vm})
+	require.NotNil(t, cmd)
+	require.Equal(t, tea.QuitMsg{}, cmd(), "the launcher should quit after the session")
+	require.NoError(t, model.(Model).ExitErr())
+
+	_, cmd = m.Update(sshDoneMsg{vm: m.connecting.vm, err: errors.New("exit status 255")})
+	require.Equal(t, tea.QuitMsg{}, cmd())
+}
+
+func TestUpdate_SSHTargetNoMatch(t *testing.T) {
+	m, cmd := launchModel(t, "vm-9", gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"})
+	require.Equal(t, tea.QuitMsg{}, cmd())
+	require.EqualError(t, m.ExitErr(), "no instance matches vm-9 in test-project")
+}
+
+func TestUpdate_SSHTargetAmbiguous(t *testing.T) {
+	vms := []gcp.Instance{
+		{Name: "web-1", Zone: "z-1", Status: "RUNNING"},
+		{Name: "web-1", Zone: "z-2", Status: "RUNNING"},
+		{Name: "web-10", Zone: "z-1", Status: "RUNNING"},
+	}
+	m, _ := launchModel(t, "web-1", vms...)
+	require.Nil(t, m.connecting)
+	require.Len(t, m.visible(), 2, "the list should show only the instances with that name")
+	require.Contains(t, m.View(), "2 instances match web-1; pick one and press enter to connect, or add the zone as in web-1.z-1.")
+
+	require.True(t, m.quitAfterSSH, "the session picked from the list should quit afterwards too")
+
+	m, _ = launchModel(t, "web-1.z-2", vms...)
+	require.NotNil(t, m.connecting, "the zone should pick one")
+	require.Equal(t, "z-2", m.connecting.vm.Zone)
+}
+
+func TestUpdate_SSHTargetListingErrors(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project", WithSSHTarget("vm-9"))
+	model, cmd := m.Update(vmsMsg{InstanceList: gcp.InstanceList{
+		Instances:  []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}},
+		ZoneErrors: []gcp.ZoneError{{ProjectID: "test-project", Zone: "z-2", Err: errors.New("unreachable")}},
+	}})
+	require.Equal(t, tea.QuitMsg{}, cmd())
+	require.EqualError(t, model.(Model).ExitErr(), "could not look up vm-9: z-2: unreachable")
+
+	m = NewModel(new(mocks.Client), "test-project", WithSSHTarget("vm-9"))
+	model, cmd = m.Update(errMsg{err: errors.New("permission denied")})
+	require.Equal(t, tea.QuitMsg{}, cmd())
+	require.EqualError(t, model.(Model).ExitErr(), "could not look up vm-9: permission denied")
+}
+
+func TestUpdate_SSHTargetNotRunning(t *testing.T) {
+	m, _ := launchModel(t, "vm-1", gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "STAGING"})
+	require.EqualError(t, m.ExitErr(), "vm-1 is STAGING; wait until it is RUNNING to connect")
+
+	m, cmd := launchModel(t, "vm-1", gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"})
+	require.Nil(t, cmd)
+	require.Equal(t, "\n vm-1 is TERMINATED; start it first? (y/n)\n\n", m.View(), "only the question should be shown")
+	m, cmd = keyPress(t, m, "y")
+	require.NotNil(t, cmd)
+	require.Contains(t, m.View(), "Starting vm-1...")
+
+	model, _ := m.Update(actionDoneMsg{"Started vm-1."})
+	model, cmd = model.(Model).Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}}}, seq: model.(Model).fetchSeq})
+	require.NotNil(t, model.(Model).connecting, "the instance should be connected to once started")
+
+	m, _ = launchModel(t, "vm-1", gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"})
+	m, cmd = keyPress(t, m, "n")
+	require.Equal(t, tea.QuitMsg{}, cmd())
+	require.EqualError(t, m.ExitErr(), "Cancelled")
+}
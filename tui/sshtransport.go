@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"slices"
+)
+
+// errNoExternalIP and errNoInternalIP are returned when direct SSH has no
+// address to connect to.
+var (
+	errNoExternalIP = errors.New("it has no external IP; set ssh_jump_host in the config to go through a jump host")
+	errNoInternalIP = errors.New("it has neither an external nor a known internal IP")
+)
+
+// directSSHArgs returns the ssh arguments that connect straight to the
+// external IP of vm as user, or as the local user if it is empty. Without an
+// external IP the connection goes through jumpHost to the internal IP, if
+// one is configured. Only the flags after the "--" separator of extra are
+// passed on, since the others are meant for gcloud.
+func directSSHArgs(vm gcp.Instance, user, jumpHost string, extra []string) ([]string, error) {
+	var args []string
+	host := vm.ExternalIP
+	if host == "" {
+		switch {
+		case jumpHost == "":
+			return nil, errNoExternalIP
+		case vm.InternalIP == "":
+			return nil, errNoInternalIP
+		}
+		host = vm.InternalIP
+		args = append(args, "-J", jumpHost)
+	}
+	if i := slices.Index(extra, "--"); i >= 0 {
+		args = append(args, extra[i+1:]...)
+	}
+	if user != "" {
+		host = user + "@" + host
+	}
+	return append(args, host), nil
+}
+
+// sshTransport returns how SSH sessions are opened: as set on the command
+// line, or else in the config, gcloud being the default.
+func (m Model) sshTransport() string {
+	if m.sshTransportSet != "" {
+		return m.sshTransportSet
+	}
+	if m.cfg.SSHTransport != "" {
+		return m.cfg.SSHTransport
+	}
+	return config.SSHTransportGcloud
+}
+
+// sshCommand returns the command line that opens an SSH session to vm.
+func (m Model) sshCommand(vm gcp.Instance) ([]string, error) {
+	projectID, user := m.projectOf(vm), m.sshUser(vm)
+	vm = m.located(vm)
+	if m.sshTransport() == config.SSHTransportDirect {
+		args, err := directSSHArgs(vm, user, m.cfg.SSHJumpHost, m.sshExtraArgs())
+		if err != nil {
+			return nil, err
+		}
+		return append([]string{"ssh"}, args...), nil
+	}
+	return append([]string{"gcloud"}, sshArgs(vm, projectID, user, m.sshExtraArgs())...), nil
+}
+
+// masterCommand returns the command line that opens the connection to vm
+// like sshCommand, but in the background and without prompting, shared
+// through the control socket at socket.
+func (m Model) masterCommand(vm gcp.Instance, socket string) ([]string, error) {
+	projectID, user := m.projectOf(vm), m.sshUser(vm)
+	vm = m.located(vm)
+	if m.sshTransport() == config.SSHTransportDirect {
+		args, err := directSSHArgs(vm, user, m.cfg.SSHJumpHost, m.sshExtraArgs())
+		if err != nil {
+			return nil, err
+		}
+		return append(append([]string{"ssh"}, masterFlags(socket)...), args...), nil
+	}
+	return append([]string{"gcloud"}, masterArgs(vm, projectID, user, socket, m.sshExtraArgs())...), nil
+}
+
+// muxCommand returns the command line of an SSH session to vm that goes
+// through the connection opened by masterCommand at socket rather than
+// connecting again. With gcloud, plain ssh is enough to reach the socket;
+// only the ssh flags of the extra arguments are passed on.
+func (m Model) muxCommand(vm gcp.Instance, socket string) ([]string, error) {
+	mux := []string{"ssh", "-oControlMaster=no", "-oControlPath=" + socket}
+	if m.sshTransport() == config.SSHTransportDirect {
+		argv, err := m.sshCommand(vm)
+		if err != nil {
+			return nil, err
+		}
+		return append(mux, argv[1:]...), nil
+	}
+	extra := m.sshExtraArgs()
+	if i := slices.Index(extra, "--"); i >= 0 {
+		mux = append(mux, extra[i+1:]...)
+	}
+	host := vm.Name
+	if user := m.sshUser(vm); user != "" {
+		host = user + "@" + host
+	}
+	return append(mux, host), nil
+}
@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"context"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectSSHArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		vm       gcp.Instance
+		user     string
+		jumpHost string
+		extra    []string
+		want     []string
+		err      error
+	}{
+		{"external IP", gcp.Instance{ExternalIP: "34.1.2.3", InternalIP: "10.0.0.7"}, "", "bastion", nil, []string{"34.1.2.3"}, nil},
+		{"user and ssh flags", gcp.Instance{ExternalIP: "34.1.2.3"}, "ops", "", []string{"--internal-ip", "--", "-A"}, []string{"-A", "ops@34.1.2.3"}, nil},
+		{"jump host", gcp.Instance{InternalIP: "10.0.0.7"}, "ops", "me@bastion", nil, []string{"-J", "me@bastion", "ops@10.0.0.7"}, nil},
+		{"no external IP", gcp.Instance{InternalIP: "10.0.0.7"}, "", "", nil, nil, errNoExternalIP},
+		{"no address", gcp.Instance{}, "", "bastion", nil, nil, errNoInternalIP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := directSSHArgs(tt.vm, tt.user, tt.jumpHost, tt.extra)
+			require.ErrorIs(t, err, tt.err)
+			require.Equal(t, tt.want, args)
+		})
+	}
+}
+
+func TestSSHCommand_Transports(t *testing.T) {
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1", ExternalIP: "34.1.2.3"}
+	cfg := config.Config{SSHArgs: []string{"--", "-A"}}
+	m := NewModel(new(mocks.Client), "test-project", WithConfig("", cfg, false))
+
+	argv, err := m.sshCommand(vm)
+	require.NoError(t, err)
+	require.Equal(t, []string{"gcloud", "compute", "ssh", "vm-1", "--zone", "z-1", "--project", "test-project", "--", "-A"}, argv)
+	argv, err = m.masterCommand(vm, "/tmp/s")
+	require.NoError(t, err)
+	require.Equal(t, []string{"gcloud", "compute", "ssh", "vm-1", "--zone", "z-1", "--project", "test-project",
+		"--ssh-flag=-oControlMaster=yes", "--ssh-flag=-oControlPath=/tmp/s", "--ssh-flag=-oControlPersist=no", "--ssh-flag=-oBatchMode=yes", "--ssh-flag=-N", "--", "-A"}, argv)
+	argv, err = m.muxCommand(vm, "/tmp/s")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ssh", "-oControlMaster=no", "-oControlPath=/tmp/s", "-A", "vm-1"}, argv)
+
+	cfg.SSHTransport = config.SSHTransportDirect
+	m = NewModel(new(mocks.Client), "test-project", WithConfig("", cfg, false))
+	argv, err = m.sshCommand(vm)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ssh", "-A", "34.1.2.3"}, argv)
+	argv, err = m.masterCommand(vm, "/tmp/s")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ssh", "-oControlMaster=yes", "-oControlPath=/tmp/s", "-oControlPersist=no", "-oBatchMode=yes", "-N", "-A", "34.1.2.3"}, argv)
+	argv, err = m.muxCommand(vm, "/tmp/s")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ssh", "-oControlMaster=no", "-oControlPath=/tmp/s", "-A", "34.1.2.3"}, argv)
+
+	m = NewModel(new(mocks.Client), "test-project", WithConfig("", cfg, false), WithSSHTransport(config.SSHTransportGcloud))
+	argv, err = m.sshCommand(vm)
+	require.NoError(t, err)
+	require.Equal(t, "gcloud", argv[0], "the flag should override the config")
+}
+
+func TestUpdate_SSHDirectWithoutAddress(t *testing.T) {
+	m := connectModel(t, func(ctx context.Context, argv []string, socket string) (func(), string, error) {
+		t.Fatal("nothing should connect without an address")
+		return nil, "", nil
+	})
+	m.sshTransportSet = config.SSHTransportDirect
+
+	m, cmd := keyPress(t, m, "enter")
+	require.Nil(t, m.connecting)
+	require.Equal(t, "Cannot SSH to vm-1: it has no external IP; set ssh_jump_host in the config to go through a jump host.", m.message)
+	require.Nil(t, cmd, "no connection should be attempted")
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"os/user"
+	"strings"
+)
+
+// imageUsers maps image name prefixes to the login user those images are
+// commonly set up with.
+var imageUsers = []struct{ prefix, user string }{
+	{"ubuntu", "ubuntu"},
+	{"debian", "debian"},
+	{"centos", "centos"},
+	{"rocky-linux", "rocky"},
+	{"fedora-coreos", "core"},
+	{"bitnami", "bitnami"},
+}
+
+// guessSSHUser guesses the user to log in to vm as. The guess is heuristic:
+// it is localUser if it has keys in the ssh-keys metadata, or else the usual
+// user of the image it was created from, or of the OS its boot disk is
+// licensed for when the image is not known. The users of other keys are never
+// picked, as they may well be someone else's. It is empty when there is no
+// good guess, such as with OS Login, which derives the user from the
+// account, so that gcloud picks the user as it normally would.
+func guessSSHUser(vm gcp.Instance, localUser string) string {
+	if strings.EqualFold(vm.Metadata["enable-oslogin"], "true") {
+		return ""
+	}
+	if localUser != "" && sshKeysHaveUser(vm.Metadata["ssh-keys"], localUser) {
+		return localUser
+	}
+	image := vm.Image
+	if image == "" {
+		image = vm.BootLicense
+	}
+	image = strings.TrimPrefix(image, "family/")
+	for _, iu := range imageUsers {
+		if strings.HasPrefix(image, iu.prefix) {
+			return iu.user
+		}
+	}
+	return ""
+}
+
+// sshKeysHaveUser reports whether one of the "USER:KEY" lines of ssh-keys
+// metadata belongs to name.
+func sshKeysHaveUser(keys, name string) bool {
+	for _, line := range strings.Split(keys, "\n") {
+		if user, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && user == name {
+			return true
+		}
+	}
+	return false
+}
+
+// localUsername returns the name of the user running gcp-rider, or "" if it
+// cannot be told.
+func localUsername() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+// sshUser returns the user to log in to vm as: the one set with
+// GCP_SSH_USER, or else a guess from the instance. It is empty when gcloud
+// should pick the user.
+func (m Model) sshUser(vm gcp.Instance) string {
+	if m.sshUserSet != "" {
+		return m.sshUserSet
+	}
+	return guessSSHUser(vm, m.localUser)
+}
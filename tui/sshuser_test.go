@@ -0,0 +1,37 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuessSSHUser(t *testing.T) {
+	tests := []struct {
+		name string
+		vm   gcp.Instance
+		want string
+	}{
+		{"image", gcp.Instance{Image: "ubuntu-2204-jammy-v20240126"}, "ubuntu"},
+		{"image family", gcp.Instance{Image: "family/rocky-linux-9"}, "rocky"},
+		{"unknown image", gcp.Instance{Image: "my-golden-image"}, ""},
+		{"boot license", gcp.Instance{BootLicense: "ubuntu-2204-lts"}, "ubuntu"},
+		{"own ssh keys", gcp.Instance{Image: "debian-12", Metadata: map[string]string{"ssh-keys": "bob:ssh-ed25519 AAAA\nalice:ssh-rsa BBBB alice@laptop"}}, "alice"},
+		{"someone else's ssh keys", gcp.Instance{Image: "debian-12", Metadata: map[string]string{"ssh-keys": "bob:ssh-ed25519 BBBB bob"}}, "debian"},
+		{"someone else's keys only", gcp.Instance{Image: "my-golden-image", Metadata: map[string]string{"ssh-keys": "bob:ssh-ed25519 BBBB bob"}}, ""},
+		{"os login", gcp.Instance{Image: "debian-12", Metadata: map[string]string{"enable-oslogin": "TRUE"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, guessSSHUser(tt.vm, "alice"))
+		})
+	}
+}
+
+func TestSSHUser_ConfiguredWins(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project", WithSSHUser("ops"))
+	require.Equal(t, "ops", m.sshUser(gcp.Instance{Image: "ubuntu-2204"}))
+	require.Equal(t, []string{"compute", "ssh", "ops@vm-1", "--zone", "z-1", "--project", "p"}, sshArgs(gcp.Instance{Name: "vm-1", Zone: "z-1"}, "p", "ops", nil))
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statusBarWidth is the widest the status bar in the header gets.
+const statusBarWidth = 20
+
+// statusSegments splits a bar of width cells between the running, stopped
+// and other instances in vms, in that order, in proportion to their counts.
+// Every status with instances gets at least one cell when width allows, so a
+// single stopped instance in a large fleet still shows.
+func statusSegments(vms []gcp.Instance, width int) [3]int {
+	var counts, cells [3]int
+	for _, vm := range vms {
+		switch gcp.ClassifyStatus(vm.Status) {
+		case gcp.StatusRunning:
+			counts[0]++
+		case gcp.StatusStopped:
+			counts[1]++
+		default:
+			counts[2]++
+		}
+	}
+	if len(vms) == 0 || width <= 0 {
+		return cells
+	}
+	used, largest := 0, 0
+	for i, n := range counts {
+		if n == 0 {
+			continue
+		}
+		cells[i] = max(1, (n*width+len(vms)/2)/len(vms))
+		used += cells[i]
+		if counts[i] > counts[largest] {
+			largest = i
+		}
+	}
+	// Rounding may leave the bar a cell or two off; the largest segment
+	// absorbs the difference as it is the least distorted by it.
+	cells[largest] = max(0, cells[largest]+width-used)
+	return cells
+}
+
+// statusBarView renders the share of running, stopped and other instances in
+// the list as a bar of colored blocks, fitting it after the last line of
+// header. It is empty without colors, as the blocks could not be told
+// apart, or when the terminal is too narrow for a useful bar.
+func (m Model) statusBarView(header string) string {
+	if !m.color {
+		return ""
+	}
+	header = header[strings.LastIndex(header, "\n")+1:]
+	width := statusBarWidth
+	if m.width > 0 {
+		width = min(width, m.width-lipgloss.Width(header)-1)
+	}
+	if width < 5 {
+		return ""
+	}
+	cells := statusSegments(m.visible(), width)
+	styles := [3]lipgloss.Style{m.theme.Running, m.theme.Stopped, m.theme.Transitional}
+	var b strings.Builder
+	for i, n := range cells {
+		if n > 0 {
+			b.WriteString(styles[i].Render(strings.Repeat("█", n)))
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return " " + b.String()
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func withStatuses(statuses ...string) []gcp.Instance {
+	vms := make([]gcp.Instance, len(statuses))
+	for i, s := range statuses {
+		vms[i] = gcp.Instance{Name: "vm-" + s, Status: s}
+	}
+	return vms
+}
+
+func withStatusList(status string, n int) []string {
+	statuses := make([]string, n)
+	for i := range statuses {
+		statuses[i] = status
+	}
+	return statuses
+}
+
+func TestStatusSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		width    int
+		want     [3]int
+	}{
+		{"empty", nil, 20, [3]int{0, 0, 0}},
+		{"all running", []string{"RUNNING", "RUNNING"}, 20, [3]int{20, 0, 0}},
+		{"split", []string{"RUNNING", "TERMINATED", "STOPPED", "PROVISIONING"}, 20, [3]int{5, 10, 5}},
+		{"rounding", []string{"RUNNING", "RUNNING", "SUSPENDED"}, 10, [3]int{7, 3, 0}},
+		{"minimum cell", append(withStatusList("RUNNING", 99), "STAGING"), 10, [3]int{9, 0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := statusSegments(withStatuses(tt.statuses...), tt.width)
+			require.Equal(t, tt.want, got)
+			if len(tt.statuses) > 0 {
+				require.Equal(t, tt.width, got[0]+got[1]+got[2])
+			}
+		})
+	}
+}
+
+func TestStatusBarView_OffWithoutColor(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.vms = withStatuses("RUNNING", "TERMINATED")
+	require.Empty(t, m.statusBarView("GCP VMs:"))
+
+	m.color = true
+	require.Contains(t, m.statusBarView("GCP VMs:"), "█")
+	m.width = 12
+	require.Empty(t, m.statusBarView("GCP VMs:"))
+}
@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"strings"
+)
+
+// summaryView renders instance counts and estimated hourly cost per machine
+// type, and the vCPUs and memory of the running instances whose machine type
+// is in specs.
+func summaryView(vms []gcp.Instance, prices map[string]float64, specs *gcp.MachineTypeCache) string {
+	summaries := gcp.SummarizeByMachineType(vms, prices)
+
+	var b strings.Builder
+	b.WriteString("Machine types:\n")
+	for _, s := range summaries {
+		cost := "?"
+		if s.Priced {
+			cost = fmt.Sprintf("$%.2f/h", s.HourlyCost)
+		}
+		b.WriteString(fmt.Sprintf("  %-18s %4d (%d running) %10s\n", orDash(s.MachineType), s.Count, s.Running, cost))
+	}
+
+	total, unpriced := gcp.TotalHourlyCost(summaries)
+	b.WriteString(fmt.Sprintf("  Estimated total: $%.2f/h", total))
+	if unpriced > 0 {
+		b.WriteString(fmt.Sprintf(" (%d running instances not priced)", unpriced))
+	}
+	b.WriteString("\n")
+
+	var cpus, memoryMB, unsized int
+	for _, vm := range vms {
+		if vm.Status != "RUNNING" {
+			continue
+		}
+		spec, ok := specs.Lookup(vm.Zone, vm.MachineType)
+		if !ok {
+			unsized++
+			continue
+		}
+		cpus += spec.CPUs
+		memoryMB += spec.MemoryMB
+	}
+	b.WriteString(fmt.Sprintf("  Running capacity: %d vCPUs, %.1f GB memory", cpus, float64(memoryMB)/1024))
+	if unsized > 0 {
+		b.WriteString(fmt.Sprintf(" (%d running instances not sized)", unsized))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"errors"
+	"gcp-rider/cache"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"gcp-rider/gcp/mocks"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryView(t *testing.T) {
+	vms := []gcp.Instance{
+		{Name: "a", Zone: "z-1", MachineType: "e2-small", Status: "RUNNING"},
+		{Name: "b", Zone: "z-1", MachineType: "e2-small", Status: "TERMINATED"},
+		{Name: "c", Zone: "z-1", MachineType: "custom-2-4096", Status: "RUNNING"},
+	}
+	specs := gcp.NewMachineTypeCache()
+	specs.Restore(map[string]gcp.CachedMachineType{"z-1/e2-small": {Spec: gcp.MachineTypeSpec{CPUs: 2, MemoryMB: 2048}, Fetched: time.Now()}})
+	view := summaryView(vms, map[string]float64{"e2-small": 0.25}, specs)
+
+	require.Contains(t, view, "e2-small              2 (1 running)    $0.25/h")
+	require.Contains(t, view, "custom-2-4096         1 (1 running)          ?")
+	require.Contains(t, view, "Estimated total: $0.25/h (1 running instances not priced)")
+	require.Contains(t, view, "Running capacity: 2 vCPUs, 2.0 GB memory (1 running instances not sized)")
+}
+
+func TestUpdate_SummaryFetchesSpecs(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "a", Zone: "z-1", MachineType: "e2-small", Status: "RUNNING"},
+		gcp.Instance{Name: "b", Zone: "z-1", MachineType: "e2-small", Status: "RUNNING"},
+		gcp.Instance{Name: "c", Zone: "z-1", MachineType: "n2-standard-4", Status: "TERMINATED"},
+	)
+	client.SetMachineTypeSpec("z-1", "e2-small", gcp.MachineTypeSpec{CPUs: 2, MemoryMB: 2048})
+	m := loadedModel(t, client)
+	m.cacheDir = t.TempDir()
+
+	m, cmd := keyPress(t, m, "c")
+	require.NotNil(t, cmd, "opening the summary should size the machine types")
+	model, _ := m.Update(cmd())
+	m = model.(Model)
+	require.Contains(t, m.View(), "Running capacity: 4 vCPUs, 4.0 GB memory\n")
+
+	var saved map[string]gcp.CachedMachineType
+	ok, err := cache.Load(m.cacheDir, specsName, &saved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Contains(t, saved, "z-1/e2-small")
+
+	// A new session reads the sizes back instead of fetching them.
+	client.SetError("GetMachineType", errors.New("boom"))
+	dir := m.cacheDir
+	m = loadedModel(t, client)
+	m.cacheDir = dir
+	m, cmd = keyPress(t, m, "c")
+	model, _ = m.Update(cmd())
+	m = model.(Model)
+	require.Contains(t, m.View(), "Running capacity: 4 vCPUs, 4.0 GB memory\n")
+	require.Empty(t, m.message)
+}
+
+func TestUpdate_SummaryToggle(t *testing.T) {
+	m := NewModel(new(mocks.Client), "", WithPrices(map[string]float64{"e2-small": 1}))
+	m.vms = []gcp.Instance{{Name: "a", MachineType: "e2-small", Status: "RUNNING"}}
+	m.loading = false
+	require.NotContains(t, m.View(), "Machine types:")
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = model.(Model)
+	require.Contains(t, m.View(), "Estimated total: $1.00/h")
+}
@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// terraformResource returns a google_compute_instance resource block for vm,
+// as a starting point for managing it with Terraform. Only the fields the
+// listing knows are filled in; the rest are left as TODO comments.
+func terraformResource(vm gcp.Instance, projectID string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "resource \"google_compute_instance\" %s {\n", hclString(vm.Name))
+	fmt.Fprintf(&b, "  project      = %s\n", hclString(projectID))
+	fmt.Fprintf(&b, "  name         = %s\n", hclString(vm.Name))
+	fmt.Fprintf(&b, "  zone         = %s\n", hclString(vm.Zone))
+	fmt.Fprintf(&b, "  machine_type = %s\n", hclString(vm.MachineType))
+	if vm.DeletionProtection {
+		b.WriteString("\n  deletion_protection = true\n")
+	}
+	if len(vm.Tags) > 0 {
+		fmt.Fprintf(&b, "\n  tags = %s\n", hclList(vm.Tags))
+	}
+	hclMap(&b, "labels", vm.Labels)
+	hclMap(&b, "metadata", vm.Metadata)
+
+	b.WriteString("\n  boot_disk {\n    initialize_params {\n")
+	if vm.Image != "" {
+		fmt.Fprintf(&b, "      image = %s\n", hclString(vm.Image))
+	} else {
+		b.WriteString("      # TODO: the boot image could not be derived.\n")
+	}
+	b.WriteString("      # TODO: the size and type of the boot disk could not be derived.\n")
+	b.WriteString("    }\n  }\n")
+	b.WriteString("  # TODO: attached disks other than the boot disk could not be derived.\n")
+
+	b.WriteString("\n  network_interface {\n")
+	if vm.Network != "" {
+		fmt.Fprintf(&b, "    network    = %s\n", hclString(vm.Network))
+		fmt.Fprintf(&b, "    subnetwork = %s\n", hclString(vm.Subnetwork))
+	} else {
+		b.WriteString("    # TODO: the network could not be derived.\n")
+	}
+	if vm.ExternalIP != "" {
+		fmt.Fprintf(&b, "    # TODO: set nat_ip if %s is a reserved address.\n", vm.ExternalIP)
+		b.WriteString("    access_config {}\n")
+	}
+	b.WriteString("  }\n")
+
+	b.WriteString("\n  scheduling {\n")
+	fmt.Fprintf(&b, "    automatic_restart   = %t\n", vm.AutomaticRestart)
+	if vm.OnHostMaintenance != "" {
+		fmt.Fprintf(&b, "    on_host_maintenance = %s\n", hclString(vm.OnHostMaintenance))
+	}
+	if vm.ProvisioningModel != "" {
+		fmt.Fprintf(&b, "    provisioning_model  = %s\n", hclString(vm.ProvisioningModel))
+	}
+	b.WriteString("  }\n")
+
+	if ra := vm.ReservationAffinity; ra != nil && ra.Type != "" {
+		b.WriteString("\n  reservation_affinity {\n")
+		fmt.Fprintf(&b, "    type = %s\n", hclString(ra.Type))
+		if ra.Key != "" {
+			b.WriteString("    specific_reservation {\n")
+			fmt.Fprintf(&b, "      key    = %s\n", hclString(ra.Key))
+			fmt.Fprintf(&b, "      values = %s\n", hclList(ra.Values))
+			b.WriteString("    }\n")
+		}
+		b.WriteString("  }\n")
+	}
+	if vm.ShieldedVM {
+		b.WriteString("\n  shielded_instance_config {\n")
+		b.WriteString("    # TODO: secure boot, vTPM and integrity monitoring could not be derived.\n")
+		b.WriteString("  }\n")
+	}
+	if vm.ConfidentialVM {
+		b.WriteString("\n  confidential_instance_config {\n    enable_confidential_compute = true\n  }\n")
+	}
+	b.WriteString("\n")
+	if len(vm.ResourcePolicies) > 0 {
+		fmt.Fprintf(&b, "  # TODO: resource_policies takes self links; the instance uses %s.\n", strings.Join(vm.ResourcePolicies, ", "))
+	}
+	b.WriteString("  # TODO: the service account and its scopes could not be derived.\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// hclMap writes a map attribute named name with the entries of m in key
+// order, aligned as terraform fmt does. Nothing is written for an empty map.
+func hclMap(b *strings.Builder, name string, m map[string]string) {
+	if len(m) == 0 {
+		return
+	}
+	keys := sortedKeys(m)
+	quoted := make([]string, len(keys))
+	width := 0
+	for i, k := range keys {
+		quoted[i] = hclKey(k)
+		width = max(width, len(quoted[i]))
+	}
+	fmt.Fprintf(b, "\n  %s = {\n", name)
+	for i, k := range keys {
+		fmt.Fprintf(b, "    %-*s = %s\n", width, quoted[i], hclString(m[k]))
+	}
+	b.WriteString("  }\n")
+}
+
+// hclKey returns k as a map key, quoted unless it is a valid identifier.
+func hclKey(k string) string {
+	if k == "" || !isIdentStart(rune(k[0])) || strings.ContainsFunc(k, func(r rune) bool {
+		return !isIdentStart(r) && r != '-' && (r < '0' || r > '9')
+	}) {
+		return hclString(k)
+	}
+	return k
+}
+
+// isIdentStart reports whether r can start an HCL identifier.
+func isIdentStart(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// hclList renders values as an HCL list of strings.
+func hclList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = hclString(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// hclString quotes s as an HCL string, escaping the sequences that would
+// otherwise start a template interpolation or directive.
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case r < ' ':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// copyTerraform copies a Terraform resource block for vm to the clipboard.
+func (m Model) copyTerraform(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	vm = m.located(vm)
+	m.message = fmt.Sprintf("Copied a Terraform resource for %s; check the TODO comments before applying it.", vm.Name)
+	return m, m.copyCmd(terraformResource(vm, m.projectOf(vm)))
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerraformResource(t *testing.T) {
+	vm := gcp.Instance{
+		Name:               "web-1",
+		Zone:               "europe-west1-b",
+		MachineType:        "e2-small",
+		Network:            "default",
+		Subnetwork:         "default",
+		ExternalIP:         "34.1.2.3",
+		Image:              "debian-12-bookworm-v20240515",
+		Tags:               []string{"http-server", "ssh"},
+		Labels:             map[string]string{"team": "web", "env": "prod"},
+		Metadata:           map[string]string{"startup-script": "#!/bin/sh\necho \"${HOME}\"", "enable-oslogin": "TRUE"},
+		DeletionProtection: true,
+		AutomaticRestart:   true,
+		OnHostMaintenance:  "MIGRATE",
+		ProvisioningModel:  "STANDARD",
+		ResourcePolicies:   []string{"daily-backup"},
+		ReservationAffinity: &gcp.ReservationAffinity{
+			Type:   "SPECIFIC_RESERVATION",
+			Key:    "compute.googleapis.com/reservation-name",
+			Values: []string{"res-1"},
+		},
+	}
+	require.Equal(t, `resource "google_compute_instance" "web-1" {
+  project      = "shop-prod"
+  name         = "web-1"
+  zone         = "europe-west1-b"
+  machine_type = "e2-small"
+
+  deletion_protection = true
+
+  tags = ["http-server", "ssh"]
+
+  labels = {
+    env  = "prod"
+    team = "web"
+  }
+
+  metadata = {
+    enable-oslogin = "TRUE"
+    startup-script = "#!/bin/sh\necho \"$${HOME}\""
+  }
+
+  boot_disk {
+    initialize_params {
+      image = "debian-12-bookworm-v20240515"
+      # TODO: the size and type of the boot disk could not be derived.
+    }
+  }
+  # TODO: attached disks other than the boot disk could not be derived.
+
+  network_interface {
+    network    = "default"
+    subnetwork = "default"
+    # TODO: set nat_ip if 34.1.2.3 is a reserved address.
+    access_config {}
+  }
+
+  scheduling {
+    automatic_restart   = true
+    on_host_maintenance = "MIGRATE"
+    provisioning_model  = "STANDARD"
+  }
+
+  reservation_affinity {
+    type = "SPECIFIC_RESERVATION"
+    specific_reservation {
+      key    = "compute.googleapis.com/reservation-name"
+      values = ["res-1"]
+    }
+  }
+
+  # TODO: resource_policies takes self links; the instance uses daily-backup.
+  # TODO: the service account and its scopes could not be derived.
+}
+`, terraformResource(vm, "shop-prod"))
+}
+
+func TestTerraformResource_MissingFields(t *testing.T) {
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1", MachineType: "e2-micro", ShieldedVM: true}
+	tf := terraformResource(vm, "p")
+	require.Contains(t, tf, "# TODO: the boot image could not be derived.")
+	require.Contains(t, tf, "# TODO: the network could not be derived.")
+	require.Contains(t, tf, "shielded_instance_config {\n    # TODO:")
+	require.NotContains(t, tf, "access_config", "instances without an external IP get none")
+	require.NotContains(t, tf, "labels")
+	require.NotContains(t, tf, "reservation_affinity")
+}
+
+func TestHCLKey(t *testing.T) {
+	require.Equal(t, "startup-script", hclKey("startup-script"))
+	require.Equal(t, `"1st"`, hclKey("1st"))
+	require.Equal(t, `"a.b"`, hclKey("a.b"))
+}
+
+func TestUpdate_CopyTerraform(t *testing.T) {
+	var copied string
+	m := NewModel(new(mocks.Client), "test-project")
+	m.copy = func(text string) { copied = text }
+	m.loading = false
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", MachineType: "e2-micro"}}
+
+	m, _ = keyPress(t, m, "i")
+	m, cmd := keyPress(t, m, "T")
+	require.Nil(t, cmd())
+	require.Contains(t, copied, "resource \"google_compute_instance\" \"vm-1\" {\n  project      = \"test-project\"\n")
+	require.Equal(t, "Copied a Terraform resource for vm-1; check the TODO comments before applying it.", m.message)
+	require.Contains(t, m.View(), "Copied a Terraform resource for vm-1")
+}
@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"io"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme is the set of styles used to render the interface.
+type Theme struct {
+	Running      lipgloss.Style
+	Stopped      lipgloss.Style
+	Transitional lipgloss.Style
+	Muted        lipgloss.Style
+}
+
+// palette holds the colors of a built-in theme.
+type palette struct {
+	running, stopped, transitional, muted string
+}
+
+// themes are the built-in color palettes, selectable by name in the config.
+var themes = map[string]palette{
+	"dark":  {running: "42", stopped: "203", transitional: "214", muted: "241"},
+	"light": {running: "28", stopped: "160", transitional: "130", muted: "245"},
+}
+
+// DefaultTheme is used when the config does not name a theme.
+const DefaultTheme = "dark"
+
+// NewTheme builds the named theme. When color is false every style renders
+// plain text, whatever the theme.
+func NewTheme(name string, color bool) (Theme, error) {
+	if name == "" {
+		name = DefaultTheme
+	}
+	p, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q", name)
+	}
+
+	// Styles are bound to a renderer with a fixed profile so that the color
+	// decision made at startup is honored regardless of terminal detection.
+	r := lipgloss.NewRenderer(io.Discard)
+	if color {
+		r.SetColorProfile(termenv.ANSI256)
+	} else {
+		r.SetColorProfile(termenv.Ascii)
+	}
+	return Theme{
+		Running:      r.NewStyle().Foreground(lipgloss.Color(p.running)),
+		Stopped:      r.NewStyle().Foreground(lipgloss.Color(p.stopped)),
+		Transitional: r.NewStyle().Foreground(lipgloss.Color(p.transitional)),
+		Muted:        r.NewStyle().Foreground(lipgloss.Color(p.muted)),
+	}, nil
+}
+
+// plainTheme renders everything without color.
+func plainTheme() Theme {
+	t, _ := NewTheme(DefaultTheme, false)
+	return t
+}
+
+// status renders an instance status in the color matching its state.
+// Unknown states are shown like transitional ones.
+func (t Theme) status(status string) string {
+	switch gcp.ClassifyStatus(status) {
+	case gcp.StatusRunning:
+		return t.Running.Render(status)
+	case gcp.StatusStopped:
+		return t.Stopped.Render(status)
+	default:
+		return t.Transitional.Render(status)
+	}
+}
@@ -0,0 +1,29 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTheme_UnknownName(t *testing.T) {
+	_, err := NewTheme("solarized", true)
+	require.ErrorContains(t, err, `unknown theme "solarized"`)
+}
+
+func TestTheme_StatusColor(t *testing.T) {
+	dark, err := NewTheme("dark", true)
+	require.NoError(t, err)
+	light, err := NewTheme("light", true)
+	require.NoError(t, err)
+
+	require.Contains(t, dark.status("RUNNING"), "\x1b[", "colored themes should emit escape codes")
+	require.NotEqual(t, dark.status("RUNNING"), light.status("RUNNING"), "themes should differ")
+	require.NotEqual(t, dark.status("RUNNING"), dark.status("TERMINATED"), "states should differ")
+}
+
+func TestTheme_NoColor(t *testing.T) {
+	theme, err := NewTheme("light", false)
+	require.NoError(t, err)
+	require.Equal(t, "RUNNING", theme.status("RUNNING"))
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/gcp"
+	"time"
+)
+
+// formatDuration renders d compactly using its two most significant units,
+// e.g. "3d 4h" or "12m".
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+// relativeTime renders an API timestamp relative to now, e.g. "3d 4h ago".
+// Empty timestamps render as "-" and unparseable ones are shown verbatim.
+func relativeTime(ts string, now time.Time) string {
+	if ts == "" {
+		return "-"
+	}
+	t, err := gcp.ParseTimestamp(ts)
+	if err != nil {
+		return ts
+	}
+	if t.After(now) {
+		return "in " + formatDuration(t.Sub(now))
+	}
+	return formatDuration(now.Sub(t)) + " ago"
+}
+
+// uptime renders how long vm has been running since it was last started, e.g.
+// "up 3d 4h". Instances that are not RUNNING or have no usable start time
+// render as "-".
+func uptime(vm gcp.Instance, now time.Time) string {
+	if vm.Status != "RUNNING" {
+		return "-"
+	}
+	started, err := gcp.ParseTimestamp(vm.LastStartAt)
+	if err != nil {
+		return "-"
+	}
+	return "up " + formatDuration(max(now.Sub(started), 0))
+}
+
+// maintenanceView renders the host maintenance announced for an instance
+// relative to now, e.g. "SCHEDULED in 3h 12m" or "SCHEDULED, ongoing, ends in
+// 40m". It renders as "-" when none is announced.
+func maintenanceView(mt *gcp.Maintenance, now time.Time) string {
+	if mt == nil {
+		return "-"
+	}
+	s := mt.Type
+	if s == "" {
+		s = "maintenance"
+	}
+	start, errStart := gcp.ParseTimestamp(mt.WindowStart)
+	end, errEnd := gcp.ParseTimestamp(mt.WindowEnd)
+	switch {
+	case mt.Status == "ONGOING":
+		s += ", ongoing"
+		if errEnd == nil && end.After(now) {
+			s += ", ends in " + formatDuration(end.Sub(now))
+		}
+	case errStart == nil && start.After(now):
+		s += " in " + formatDuration(start.Sub(now))
+	case errStart == nil:
+		s += ", due since " + formatDuration(now.Sub(start))
+	}
+	if mt.CanReschedule {
+		s += " (can be started early)"
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatDuration(t *testing.T) {
+	require.Equal(t, "<1m", formatDuration(30*time.Second))
+	require.Equal(t, "12m", formatDuration(12*time.Minute))
+	require.Equal(t, "2h 5m", formatDuration(2*time.Hour+5*time.Minute))
+	require.Equal(t, "3d 4h", formatDuration(76*time.Hour+10*time.Minute))
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	require.Equal(t, "3d 4h ago", relativeTime("2025-03-07T08:00:00.000-00:00", now))
+	require.Equal(t, "2h 0m ago", relativeTime("2025-03-10T03:00:00-07:00", now), "offsets should be honored")
+	require.Equal(t, "in 1h 0m", relativeTime("2025-03-10T13:00:00Z", now))
+	require.Equal(t, "-", relativeTime("", now))
+	require.Equal(t, "yesterday", relativeTime("yesterday", now), "unparseable values fall back to the raw string")
+}
+
+func TestUptime(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	require.Equal(t, "up 3d 4h", uptime(gcp.Instance{Status: "RUNNING", LastStartAt: "2025-03-07T08:00:00Z"}, now))
+	require.Equal(t, "up <1m", uptime(gcp.Instance{Status: "RUNNING", LastStartAt: "2025-03-10T12:05:00Z"}, now), "clock skew should not show a negative uptime")
+	require.Equal(t, "-", uptime(gcp.Instance{Status: "TERMINATED", LastStartAt: "2025-03-07T08:00:00Z"}, now))
+	require.Equal(t, "-", uptime(gcp.Instance{Status: "RUNNING"}, now))
+}
+
+func TestMaintenanceView(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	require.Equal(t, "-", maintenanceView(nil, now))
+	require.Equal(t, "SCHEDULED in 3h 0m", maintenanceView(&gcp.Maintenance{Type: "SCHEDULED", Status: "PENDING", WindowStart: "2025-03-10T15:00:00Z"}, now))
+	require.Equal(t, "SCHEDULED, ongoing, ends in 40m", maintenanceView(&gcp.Maintenance{Type: "SCHEDULED", Status: "ONGOING", WindowStart: "2025-03-10T11:00:00Z", WindowEnd: "2025-03-10T12:40:00Z"}, now))
+	require.Equal(t, "SCHEDULED, due since 1h 0m", maintenanceView(&gcp.Maintenance{Type: "SCHEDULED", Status: "PENDING", WindowStart: "2025-03-10T11:00:00Z"}, now))
+	require.Equal(t, "UNSCHEDULED (can be started early)", maintenanceView(&gcp.Maintenance{Type: "UNSCHEDULED", CanReschedule: true}, now), "a missing window is left out")
+	require.Equal(t, "maintenance in 2d 0h", maintenanceView(&gcp.Maintenance{WindowStart: "2025-03-12T12:00:00Z"}, now))
+}
+
+func TestView_DetailTimestamps(t *testing.T) {
+	m := NewModel(new(mocks.Client), "")
+	m.now = func() time.Time { return time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC) }
+	m.vms = []gcp.Instance{{
+		Name: "vm-1", Status: "RUNNING", CreatedAt: "2025-01-01T00:00:00Z", LastStartAt: "2025-03-10T11:30:00Z",
+		Maintenance: &gcp.Maintenance{Type: "SCHEDULED", Status: "PENDING", WindowStart: "2025-03-10T17:00:00Z"},
+	}}
+	m.loading = false
+	m.mode = modeDetail
+
+	view := m.View()
+	require.Contains(t, view, "Created:      68d 12h ago")
+	require.Contains(t, view, "Last started: 30m ago")
+	require.Contains(t, view, "Last stopped: -")
+	require.Contains(t, view, "Uptime:       up 30m")
+	require.Contains(t, view, "Maintenance:         SCHEDULED in 5h 0m")
+}
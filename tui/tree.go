@@ -0,0 +1,265 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"gcp-rider/gcp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// networkNode is a network of the network tree, with the subnets of its
+// instances.
+type networkNode struct {
+	name    string
+	subnets []subnetNode
+}
+
+// subnetNode is a subnet of the network tree, with its instances.
+type subnetNode struct {
+	name      string
+	instances []gcp.Instance
+}
+
+// networkTree groups vms under their network and subnet, both in name order,
+// keeping the order of vms within a subnet. Instances without a known
+// network or subnet are grouped under "-".
+func networkTree(vms []gcp.Instance) []networkNode {
+	var tree []networkNode
+	for _, vm := range vms {
+		network, subnet := orDash(vm.Network), orDash(vm.Subnetwork)
+		i, found := slices.BinarySearchFunc(tree, network, func(n networkNode, name string) int { return cmp.Compare(n.name, name) })
+		if !found {
+			tree = slices.Insert(tree, i, networkNode{name: network})
+		}
+		subnets := tree[i].subnets
+		j, found := slices.BinarySearchFunc(subnets, subnet, func(s subnetNode, name string) int { return cmp.Compare(s.name, name) })
+		if !found {
+			subnets = slices.Insert(subnets, j, subnetNode{name: subnet})
+		}
+		subnets[j].instances = append(subnets[j].instances, vm)
+		tree[i].subnets = subnets
+	}
+	return tree
+}
+
+// treeRow is a line of the network tree view: a network, a subnet, or an
+// instance when vm is set.
+type treeRow struct {
+	depth int
+	// key identifies the node for collapsing: "NETWORK" or
+	// "NETWORK/SUBNET". It is empty for instances.
+	key       string
+	label     string
+	count     int
+	collapsed bool
+	vm        *gcp.Instance
+}
+
+// treeRows flattens tree into the rows shown, leaving out what is under the
+// collapsed nodes.
+func treeRows(tree []networkNode, collapsed map[string]bool) []treeRow {
+	var rows []treeRow
+	for _, n := range tree {
+		count := 0
+		for _, s := range n.subnets {
+			count += len(s.instances)
+		}
+		rows = append(rows, treeRow{depth: 0, key: n.name, label: n.name, count: count, collapsed: collapsed[n.name]})
+		if collapsed[n.name] {
+			continue
+		}
+		for _, s := range n.subnets {
+			key := n.name + "/" + s.name
+			rows = append(rows, treeRow{depth: 1, key: key, label: s.name, count: len(s.instances), collapsed: collapsed[key]})
+			if collapsed[key] {
+				continue
+			}
+			for i := range s.instances {
+				rows = append(rows, treeRow{depth: 2, label: s.instances[i].Name, vm: &s.instances[i]})
+			}
+		}
+	}
+	return rows
+}
+
+// selectable reports whether the cursor can rest on r: instances, and
+// collapsed nodes as they stand in for the instances they hide.
+func (r treeRow) selectable() bool {
+	return r.vm != nil || r.collapsed
+}
+
+// nextTreeRow returns the first selectable row after from in the direction
+// of delta, or from if there is none.
+func nextTreeRow(rows []treeRow, from, delta int) int {
+	for i := from + delta; i >= 0 && i < len(rows); i += delta {
+		if rows[i].selectable() {
+			return i
+		}
+	}
+	return from
+}
+
+// treeRows returns the rows of the network tree of the visible instances.
+func (m Model) treeRows() []treeRow {
+	return treeRows(networkTree(m.visible()), m.treeCollapsed)
+}
+
+// treeRowsHeight returns how many rows of the tree fit on the screen, or -1
+// while the terminal size is unknown.
+func (m Model) treeRowsHeight() int {
+	if m.height <= 0 {
+		return -1
+	}
+	// Leave room for the title, the hint and the blank lines around.
+	return max(1, m.height-strings.Count(m.envView(), "\n")-strings.Count(m.hintView(), "\n")-4)
+}
+
+// scrollTree scrolls the tree as little as possible for the cursor row to
+// be shown, leaving no blank lines below the last row.
+func (m *Model) scrollTree() {
+	height, rows := m.treeRowsHeight(), len(m.treeRows())
+	if height < 0 {
+		m.treeOffset = 0
+		return
+	}
+	m.treeOffset = min(m.treeOffset, m.treeCursor)
+	if m.treeCursor >= m.treeOffset+height {
+		m.treeOffset = m.treeCursor - height + 1
+	}
+	m.treeOffset = max(0, min(m.treeOffset, rows-height))
+}
+
+// openTree shows the visible instances grouped by network and subnet, with
+// the cursor on the selected instance.
+func (m Model) openTree() (tea.Model, tea.Cmd) {
+	m.message = ""
+	m.mode = modeTree
+	m.treeOffset = 0
+	current, ok := m.selected()
+	rows := m.treeRows()
+	m.treeCursor = nextTreeRow(rows, -1, 1)
+	for i, r := range rows {
+		if ok && r.vm != nil && m.instanceKey(*r.vm) == m.instanceKey(current) {
+			m.treeCursor = i
+		}
+	}
+	return m, nil
+}
+
+// setCollapsed collapses or expands the node with key, moving the cursor
+// onto it.
+func (m *Model) setCollapsed(key string, collapsed bool) {
+	c := make(map[string]bool, len(m.treeCollapsed)+1)
+	for k, v := range m.treeCollapsed {
+		c[k] = v
+	}
+	if collapsed {
+		c[key] = true
+	} else {
+		delete(c, key)
+	}
+	m.treeCollapsed = c
+	for i, r := range m.treeRows() {
+		if r.key == key {
+			m.treeCursor = i
+			return
+		}
+	}
+}
+
+// updateTree handles key presses in the network tree: up and down move
+// between instances, left collapses the subnet or network of the cursor,
+// right expands it again and enter selects the instance in the list.
+func (m Model) updateTree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := m.treeRows()
+	if len(rows) == 0 {
+		if msg.String() == "q" {
+			return m.quit()
+		}
+		m.mode = modeList
+		return m, nil
+	}
+	m.treeCursor = max(0, min(m.treeCursor, len(rows)-1))
+	row := rows[m.treeCursor]
+	switch msg.String() {
+	case "q":
+		return m.quit()
+	case "up", "k":
+		m.treeCursor = nextTreeRow(rows, m.treeCursor, -1)
+	case "down", "j":
+		m.treeCursor = nextTreeRow(rows, m.treeCursor, 1)
+	case "left", "h":
+		// Collapse the node holding the cursor: the subnet of an
+		// instance, or the network of a collapsed subnet.
+		for i := m.treeCursor - 1; i >= 0; i-- {
+			if rows[i].depth == row.depth-1 {
+				m.setCollapsed(rows[i].key, true)
+				break
+			}
+		}
+	case "right", "l", " ":
+		if row.collapsed {
+			m.setCollapsed(row.key, false)
+			m.treeCursor = nextTreeRow(m.treeRows(), m.treeCursor, 1)
+		}
+	case "enter":
+		if row.collapsed {
+			m.setCollapsed(row.key, false)
+			m.treeCursor = nextTreeRow(m.treeRows(), m.treeCursor, 1)
+			return m, nil
+		}
+		if row.vm != nil {
+			m.mode = modeList
+			m.moveCursorTo(*row.vm)
+		}
+	case "esc", m.keys.first(actionTree):
+		m.mode = modeList
+	}
+	return m, nil
+}
+
+// treeView renders the network tree.
+func (m Model) treeView() string {
+	var b strings.Builder
+	b.WriteString(m.envView())
+	b.WriteString("Instances by network and subnet:\n\n")
+	expanded, folded := "-", "+"
+	if m.color {
+		expanded, folded = "▾", "▸"
+	}
+	rows := m.treeRows()
+	shown := rows[min(m.treeOffset, len(rows)):]
+	if height := m.treeRowsHeight(); height >= 0 && len(shown) > height {
+		shown = shown[:height]
+	}
+	for j, r := range shown {
+		i := m.treeOffset + j
+		marker := " "
+		if i == m.treeCursor {
+			marker = ">"
+		}
+		indent := strings.Repeat("  ", r.depth)
+		if r.vm != nil {
+			b.WriteString(m.fitLine(fmt.Sprintf("%s %s[%s] %s", marker, indent, m.displayName(*r.vm), m.theme.status(r.vm.Status)), 4) + "\n")
+			continue
+		}
+		fold := expanded
+		if r.collapsed {
+			fold = folded
+		}
+		kind := "network"
+		if r.depth == 1 {
+			kind = "subnet"
+		}
+		line := fmt.Sprintf("%s %s%s %s %s %s", marker, indent, fold, kind, r.label, m.muted(fmt.Sprintf("(%d %s)", r.count, plural(r.count, "instance", "instances"))))
+		b.WriteString(m.fitLine(line, 4) + "\n")
+	}
+	if len(m.visible()) == 0 {
+		b.WriteString("  No instances to show.\n")
+	}
+	b.WriteString("\n" + m.hintView())
+	return b.String()
+}
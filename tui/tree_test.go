@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// treeInstances are spread over two networks, one with two subnets.
+var treeInstances = []gcp.Instance{
+	{Name: "web-1", Zone: "z-1", Status: "RUNNING", Network: "prod", Subnetwork: "web"},
+	{Name: "db-1", Zone: "z-1", Status: "RUNNING", Network: "prod", Subnetwork: "data"},
+	{Name: "dev-1", Zone: "z-1", Status: "TERMINATED", Network: "default", Subnetwork: "default"},
+	{Name: "web-2", Zone: "z-1", Status: "RUNNING", Network: "prod", Subnetwork: "web"},
+	{Name: "lean-1", Zone: "z-1", Status: "RUNNING"},
+}
+
+// treeLabels returns the label of each row, indented by its depth.
+func treeLabels(rows []treeRow) []string {
+	labels := make([]string, len(rows))
+	for i, r := range rows {
+		labels[i] = r.label
+		for range r.depth {
+			labels[i] = "  " + labels[i]
+		}
+	}
+	return labels
+}
+
+func TestNetworkTree(t *testing.T) {
+	tree := networkTree(treeInstances)
+	require.Equal(t, []string{
+		"-",
+		"  -",
+		"    lean-1",
+		"default",
+		"  default",
+		"    dev-1",
+		"prod",
+		"  data",
+		"    db-1",
+		"  web",
+		"    web-1",
+		"    web-2",
+	}, treeLabels(treeRows(tree, nil)))
+
+	rows := treeRows(tree, map[string]bool{"prod/web": true, "default": true})
+	require.Equal(t, []string{"-", "  -", "    lean-1", "default", "prod", "  data", "    db-1", "  web"}, treeLabels(rows))
+	require.Equal(t, 2, rows[7].count, "collapsed nodes still count their instances")
+	require.Equal(t, 3, rows[4].count)
+	require.Empty(t, networkTree(nil))
+}
+
+func TestNextTreeRow(t *testing.T) {
+	rows := treeRows(networkTree(treeInstances), map[string]bool{"default": true})
+	// Rows: -, -, lean-1, default (collapsed), prod, data, db-1, web, web-1, web-2.
+	require.Equal(t, 2, nextTreeRow(rows, -1, 1))
+	require.Equal(t, 3, nextTreeRow(rows, 2, 1), "collapsed nodes can be selected")
+	require.Equal(t, 6, nextTreeRow(rows, 3, 1), "expanded nodes are skipped")
+	require.Equal(t, 8, nextTreeRow(rows, 6, 1))
+	require.Equal(t, 9, nextTreeRow(rows, 9, 1), "the cursor stays on the last row")
+	require.Equal(t, 3, nextTreeRow(rows, 6, -1))
+	require.Equal(t, 2, nextTreeRow(rows, 2, -1))
+}
+
+func TestUpdate_TreeNavigation(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = treeInstances
+	m.cursor = 1
+
+	m, _ = keyPress(t, m, "T")
+	require.Equal(t, modeTree, m.mode)
+	view := m.View()
+	require.Contains(t, view, "Instances by network and subnet:")
+	require.Contains(t, view, "  - network prod (3 instances)\n")
+	require.Contains(t, view, ">     [db-1] RUNNING\n", "the cursor should start on the selected instance")
+
+	m, _ = keyPress(t, m, "down")
+	require.Contains(t, m.View(), ">     [web-1] RUNNING\n", "subnet rows are skipped")
+
+	m, _ = keyPress(t, m, "left")
+	view = m.View()
+	require.Contains(t, view, ">   + subnet web (2 instances)\n")
+	require.NotContains(t, view, "web-2")
+
+	m, _ = keyPress(t, m, "left")
+	require.Contains(t, m.View(), "> + network prod (3 instances)\n")
+	require.NotContains(t, m.View(), "db-1")
+
+	m, _ = keyPress(t, m, "up")
+	require.Contains(t, m.View(), ">     [dev-1] TERMINATED\n")
+
+	m, _ = keyPress(t, m, "down")
+	m, _ = keyPress(t, m, "right")
+	require.Contains(t, m.View(), ">     [db-1] RUNNING\n", "expanding moves onto the first instance")
+	require.Contains(t, m.View(), "+ subnet web (2 instances)", "inner nodes stay collapsed")
+
+	m, _ = keyPress(t, m, "up")
+	m, _ = keyPress(t, m, "enter")
+	require.Equal(t, modeList, m.mode)
+	vm, _ := m.selected()
+	require.Equal(t, "dev-1", vm.Name, "enter should select the instance in the list")
+}
+
+func TestUpdate_TreeEmpty(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+
+	m, _ = keyPress(t, m, "T")
+	require.Contains(t, m.View(), "No instances to show.")
+	m, _ = keyPress(t, m, "down")
+	require.Equal(t, modeList, m.mode)
+}
+
+func TestUpdate_TreeScrolls(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	m.loading = false
+	m.vms = treeInstances
+	m.height = 9
+
+	m, _ = keyPress(t, m, "T")
+	for range 5 {
+		m, _ = keyPress(t, m, "k")
+	}
+	view := m.View()
+	require.Contains(t, view, "[lean-1]")
+	require.NotContains(t, view, "[db-1]", "only the rows that fit should be shown")
+
+	for range 4 {
+		m, _ = keyPress(t, m, "j")
+	}
+	view = m.View()
+	require.Contains(t, view, ">     [web-2] RUNNING\n", "the tree should scroll with the cursor")
+	require.Contains(t, view, "[db-1]")
+	require.NotContains(t, view, "[lean-1]")
+	require.NotContains(t, view, "network prod")
+}
@@ -3,95 +3,922 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"gcp-rider/config"
 	"gcp-rider/gcp"
-	"os/exec"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
 )
 
 // gcpClient is an interface that defines the methods we need from the gcp package.
 type gcpClient interface {
-	FetchInstances(ctx context.Context, projectID string) ([]gcp.Instance, error)
+	FetchInstances(ctx context.Context, projectID string, opts gcp.FetchOptions) (gcp.InstanceList, error)
+	GetInstance(ctx context.Context, projectID, zone, name string) (gcp.Instance, error)
+	SetMachineType(ctx context.Context, projectID, zone, name, machineType string) error
+	SetDeletionProtection(ctx context.Context, projectID, zone, name string, enabled bool) error
+	StartInstance(ctx context.Context, projectID, zone, name string) error
+	SuspendInstance(ctx context.Context, projectID, zone, name string) error
+	ResumeInstance(ctx context.Context, projectID, zone, name string) error
+	FetchLogs(ctx context.Context, projectID, instanceID string, limit int) ([]gcp.LogEntry, error)
+	FetchFirewallRules(ctx context.Context, projectID string) ([]gcp.FirewallRule, error)
+	GetInstanceIAM(ctx context.Context, projectID, zone, name string) ([]gcp.IAMBinding, error)
+	TestInstancePermissions(ctx context.Context, projectID, zone, name string, permissions []string) ([]string, error)
+	ListZones(ctx context.Context, projectID string) ([]string, error)
+	GetMachineType(ctx context.Context, projectID, zone, machineType string) (gcp.MachineTypeSpec, error)
+	RegionQuotas(ctx context.Context, projectID, region string) ([]gcp.Quota, error)
 	Close() error
 }
 
+// mode identifies which screen or prompt is currently receiving input.
+type mode int
+
+const (
+	modeList mode = iota
+	modeDetail
+	modeMachineType
+	modeLogs
+	modeFilter
+	modeZones
+	modeRecent
+	modeNote
+	modeBulkConfirm
+	modeQueries
+	modeTree
+	modeHistory
+	modeRegions
+	modeUnlock
+)
+
 // Model represents the state of the TUI application.
 type Model struct {
 	gcpClient gcpClient
+	// ctx is cancelled by cancel when the program quits, stopping the
+	// fetches and other API calls still running.
+	ctx       context.Context
+	cancel    context.CancelFunc
 	projectID string
-	vms       []gcp.Instance
-	cursor    int
-	loading   bool
-	spinner   spinner.Model
-	err       error
+	// projects lists every project shown; it holds just projectID unless
+	// several projects were requested.
+	projects []string
+	vms      []gcp.Instance
+	cursor   int
+	// offset is the first row shown when the list does not fit the screen.
+	offset   int
+	loading  bool
+	spinner  spinner.Model
+	err      error
+	mode     mode
+	input    textinput.Model
+	message  string
+	logs     viewport.Model
+	prevMode mode
+	dense    bool
+	// sshExtra overrides the extra SSH arguments from the config when set.
+	sshExtra []string
+	// sshTransportSet overrides the SSH transport from the config when set.
+	sshTransportSet string
+	// sshUserSet is the SSH user set with GCP_SSH_USER; when empty, the
+	// user is guessed from the instance. localUser is the user running
+	// gcp-rider, the one gcloud logs in as by default.
+	sshUserSet string
+	localUser  string
+	// lastSSH is the instance of the most recent SSH session.
+	lastSSH *gcp.Instance
+	// sshQueue are the marked instances still to connect to, one after the
+	// other, when SSH sessions cannot be opened side by side in tmux.
+	sshQueue []gcp.Instance
+	// tmux is set when running inside tmux, where the marked instances are
+	// connected to in windows of their own.
+	tmux bool
+	// retryVM is set when the last SSH session failed transiently and can be retried.
+	retryVM *gcp.Instance
+	// connecting is the connection being opened for an SSH session, and
+	// master how it is opened.
+	connecting *sshConnecting
+	master     sshMaster
+	// connectSeq numbers the connection attempts; only the outcome of the
+	// latest one is acted on.
+	connectSeq int
+	// recent are the most recent SSH targets, most recent first.
+	recent       []recentTarget
+	recentCursor int
+	// treeCursor is the row of the network tree the cursor is on,
+	// treeOffset the first row shown and treeCollapsed the keys of its
+	// collapsed nodes.
+	treeCursor    int
+	treeOffset    int
+	treeCollapsed map[string]bool
+	// history holds the actions taken during the session, oldest first,
+	// and historyBack how many of the latest are scrolled past.
+	history     []historyEntry
+	historyBack int
+	width       int
+	height      int
+	keys        KeyMap
+	theme       Theme
+	// prices is the hourly price table used by the summary panel.
+	prices      map[string]float64
+	showSummary bool
+	// specs caches the sizes of machine types for the summary panel, and
+	// specsRestored is set once those of earlier sessions were read.
+	specs         *gcp.MachineTypeCache
+	specsRestored bool
+	run           commandRunner
+	// copy puts text on the clipboard of the terminal.
+	copy func(text string)
+	// sshConfigPath is the SSH config that instance entries are appended to.
+	sshConfigPath string
+	now           func() time.Time
+	// cacheDir is where state is persisted between sessions; empty disables persistence.
+	cacheDir string
+	// snapshotCompared is set once the startup list has been compared against the previous session.
+	snapshotCompared bool
+	changes          *gcp.InstanceDiff
+	fetchOpts        gcp.FetchOptions
+	// observeFetch, when set, is told how every listing went.
+	observeFetch func(list gcp.InstanceList, err error, took time.Duration)
+	// confirm is a pending yes/no question shown in place of the help line.
+	confirm *confirmation
+	// truncated is set when the last fetch stopped at fetchOpts.MaxResults.
+	truncated bool
+	// projectErrors lists the projects that failed to load in the last fetch.
+	projectErrors []gcp.ProjectError
+	// zoneErrors lists the zones that failed to load in the last fetch, and
+	// skippedZones counts those left out of it by SkipZoneWarnings.
+	zoneErrors   []gcp.ZoneError
+	skippedZones int
+	// loadingText is shown next to the spinner while loading is true.
+	loadingText string
+	// banner is an action error shown below the list until the next keypress.
+	banner string
+	// outcome is the summary of the last action while it is shown in the footer.
+	outcome *outcome
+	// filter is the query narrowing the list; see parseFilter.
+	filter      string
+	filterInput textinput.Model
+	// filterRegex matches the filter as a regular expression against names.
+	// filterRE is the last valid pattern and filterErr the compile error of
+	// the current query, if any.
+	filterRegex bool
+	filterRE    *regexp.Regexp
+	filterErr   error
+	// filterAnchor is the instance selected when the filter was opened.
+	filterAnchor gcp.Instance
+	// filterSeq numbers the debounced filter updates; only the tick of the
+	// latest one applies.
+	filterSeq int
+	// shown caches the instances visible returned after the last message.
+	shown *visibleCache
+	// listVersion counts the changes to vms, pinned and granted, so that
+	// shown is worked out again after any of them.
+	listVersion int
+	// search is the last filter query typed, which the next and previous
+	// match keys jump between; searchRE is its pattern if it was a regex.
+	search   string
+	searchRE *regexp.Regexp
+	// marked are the instances marked for a bulk action, keyed by
+	// instanceKey, and bulk is the bulk action awaiting confirmation.
+	marked map[string]bool
+	bulk   *bulkAction
+	// notes are the local notes attached to instances, keyed by noteKey,
+	// and noteTarget the instance whose note is being edited.
+	notes      map[string]string
+	noteTarget gcp.Instance
+	// query is the saved query in use, if any, and queryCursor the entry
+	// selected in the saved query picker.
+	query       *config.SavedQuery
+	queryCursor int
+	// sortColumn is the column the list is sorted by with the number keys,
+	// if any, and sortDesc whether it is sorted in descending order.
+	sortColumn string
+	sortDesc   bool
+	// accessibleOnly hides the instances the caller likely cannot SSH to,
+	// judged from the permissions in granted, keyed by noteKey.
+	accessibleOnly bool
+	granted        map[string][]string
+	// refreshing holds the instances being fetched again on their own,
+	// keyed by instanceKey.
+	refreshing map[string]bool
+	// whoami looks up the account the credentials act as, shown with the
+	// projects in identity once known.
+	whoami   func(context.Context) (string, error)
+	identity string
+	// mouse is whether clicks and the wheel are handled.
+	mouse bool
+	// sshTarget names the instance to connect to once the list has loaded;
+	// see parseSSHTarget. launching is set until then, and for as long as
+	// the target alone is dealt with, without showing the list.
+	// quitAfterSSH quits once that session is over, and exitErr is why the
+	// program quit without connecting.
+	sshTarget    string
+	launching    bool
+	quitAfterSSH bool
+	exitErr      error
+	// wrap is whether lines wider than the terminal are wrapped rather than
+	// cut short, in the list and the detail view.
+	wrap bool
+	// selectionNotice tells that the selected instance was deleted or moved
+	// by a reload, until dismissed.
+	selectionNotice string
+	// hideStopped leaves TERMINATED and SUSPENDED instances out of the list.
+	hideStopped bool
+	// externalOnly leaves instances without an external IP out of the list.
+	externalOnly bool
+	// verbose shows raw API errors next to the friendly explanations.
+	verbose bool
+	// quiet leaves out everything but the instance rows and what a key
+	// press is waiting on; see quietFooterView.
+	quiet bool
+	// cfg is the config loaded from configPath, kept so it can be reloaded.
+	cfg        config.Config
+	configPath string
+	// color is whether the theme uses colors.
+	color bool
+	// exposure holds the firewall rules last loaded for an instance.
+	exposure *exposure
+	// quotas holds the quotas last loaded for a region.
+	quotas *regionQuotas
+	// access holds the IAM bindings last loaded for an instance.
+	access *access
+	// detail scrolls the detail view. detailFocus is the index of the long
+	// label or metadata value that e expands, and expanded holds the ids of
+	// the expanded ones.
+	detail      viewport.Model
+	detailFocus int
+	expanded    map[string]bool
+	// pickZones opens the zone picker instead of loading every zone at startup.
+	pickZones bool
+	// zones are the zones offered by the zone picker, zoneSelected those
+	// currently ticked and zoneOffset the first one shown.
+	zones        []string
+	zoneSelected map[string]bool
+	zoneCursor   int
+	zoneOffset   int
+	// fetchSeq identifies the latest fetch, so that progress reported by
+	// earlier ones is ignored.
+	fetchSeq int
+	// pinned holds the names of instances kept at the top of the list.
+	pinned map[string]bool
+	// dnd holds the instances marked do not disturb, keyed like notes.
+	dnd map[string]bool
+	// regionQuery is the search of the region reference, regionSearching
+	// set while it is typed, and regionOffset the first region shown.
+	regionQuery     string
+	regionSearching bool
+	regionOffset    int
+	// unlocked holds the sensitive projects typed to unlock the actions
+	// that change their instances for the session, and unlocking the one
+	// being typed.
+	unlocked  map[string]bool
+	unlocking string
 }
 
-// vmsMsg is a message sent when the list of VMs has been fetched.
-type vmsMsg []gcp.Instance
+// vmsMsg is a message sent when the list of VMs has been fetched. seq is
+// the fetchSeq of the fetch, so that the result of an earlier fetch that
+// finishes late does not replace that of a later one.
+type vmsMsg struct {
+	gcp.InstanceList
+	seq int
+}
 
-// errMsg is a message sent when an error occurs.
-type errMsg struct{ err error }
+// errMsg is a message sent when fetching instances fails. It replaces the
+// whole view, since there is nothing left to show. seq is as for vmsMsg.
+type errMsg struct {
+	err error
+	seq int
+}
 
 func (e errMsg) Error() string { return e.err.Error() }
 
+func (e errMsg) Unwrap() error { return e.err }
+
+// actionErrMsg is sent when an action against an instance fails. It is shown
+// in a banner so the list stays visible. action names what was attempted,
+// e.g. "start vm-1", to record it in the history; it is empty for failures
+// that are not actions against an instance.
+type actionErrMsg struct {
+	err    error
+	action string
+}
+
+// actionDoneMsg is sent when an action against an instance has completed.
+type actionDoneMsg struct{ message string }
+
+// Option customizes a Model created by NewModel.
+type Option func(*Model)
+
+// WithKeyMap sets the key bindings used by the list view.
+func WithKeyMap(km KeyMap) Option {
+	return func(m *Model) { m.keys = km }
+}
+
+// WithTheme sets the styles used to render the interface.
+func WithTheme(t Theme) Option {
+	return func(m *Model) { m.theme = t }
+}
+
+// WithSpinner sets the animation shown while loading.
+func WithSpinner(s spinner.Spinner) Option {
+	return func(m *Model) { m.spinner.Spinner = s }
+}
+
+// WithPrices sets the hourly price table used for cost estimates.
+func WithPrices(prices map[string]float64) Option {
+	return func(m *Model) { m.prices = prices }
+}
+
+// WithCacheDir enables persisting state, such as instance snapshots, in dir.
+func WithCacheDir(dir string) Option {
+	return func(m *Model) { m.cacheDir = dir }
+}
+
+// WithSSHConfigPath sets the SSH config file that entries for instances are
+// appended to, usually ~/.ssh/config.
+func WithSSHConfigPath(path string) Option {
+	return func(m *Model) { m.sshConfigPath = path }
+}
+
+// WithFetchOptions sets the options used whenever instances are fetched.
+func WithFetchOptions(opts gcp.FetchOptions) Option {
+	return func(m *Model) { m.fetchOpts = opts }
+}
+
+// WithProjects shows the instances of several projects in one list.
+func WithProjects(projectIDs []string) Option {
+	return func(m *Model) { m.projects = projectIDs }
+}
+
+// WithSort sorts the list by the named sort order until another is picked.
+// "age" sorts it by creation time, newest first.
+func WithSort(name string) Option {
+	return func(m *Model) {
+		if name == "age" {
+			m.sortColumn, m.sortDesc = "created", true
+			return
+		}
+		m.sortColumn = name
+	}
+}
+
+// WithVerbose shows raw API errors alongside friendlier explanations.
+func WithVerbose(verbose bool) Option {
+	return func(m *Model) { m.verbose = verbose }
+}
+
+// WithQuiet shows just the instance rows in the list, leaving out the header,
+// the key help and the status lines. Keys work the same.
+func WithQuiet(quiet bool) Option {
+	return func(m *Model) { m.quiet = quiet }
+}
+
+// WithConfig records the config the model was built from so that it can be
+// reloaded from path. color is whether reloaded themes use colors.
+func WithConfig(path string, cfg config.Config, color bool) Option {
+	return func(m *Model) {
+		m.configPath = path
+		m.cfg = cfg
+		m.color = color
+		m.hideStopped = cfg.HideStopped
+		m.mouse = cfg.Mouse
+	}
+}
+
+// WithSSHArgs appends args to every gcloud compute ssh invocation, in place
+// of the ssh_args from the config.
+func WithSSHArgs(args []string) Option {
+	return func(m *Model) { m.sshExtra = args }
+}
+
+// WithSSHTransport opens SSH sessions over transport, one of
+// config.SSHTransports, in place of the ssh_transport from the config.
+func WithSSHTransport(transport string) Option {
+	return func(m *Model) { m.sshTransportSet = transport }
+}
+
+// WithTmux opens the SSH sessions to several marked instances in tmux
+// windows of their own, rather than one after the other.
+func WithTmux(inTmux bool) Option {
+	return func(m *Model) { m.tmux = inTmux }
+}
+
+// WithFetchObserver tells observe how every listing of the instances went
+// and how long it took, e.g. to export metrics. It is called from the
+// goroutine of the listing.
+func WithFetchObserver(observe func(list gcp.InstanceList, err error, took time.Duration)) Option {
+	return func(m *Model) { m.observeFetch = observe }
+}
+
+// WithIdentity shows the account that whoami reports above the list,
+// together with the projects.
+func WithIdentity(whoami func(context.Context) (string, error)) Option {
+	return func(m *Model) { m.whoami = whoami }
+}
+
+// WithSSHTarget connects to the instance named by target as soon as the
+// list has loaded, and quits after the session.
+func WithSSHTarget(target string) Option {
+	return func(m *Model) { m.sshTarget, m.launching = target, true }
+}
+
+// WithSSHUser logs in as user over SSH rather than guessing the user from the
+// instance.
+func WithSSHUser(user string) Option {
+	return func(m *Model) { m.sshUserSet = user }
+}
+
+// WithZonePicker asks which zones to load at startup, rather than loading
+// every zone.
+func WithZonePicker(pick bool) Option {
+	return func(m *Model) {
+		m.pickZones = pick
+		if pick {
+			m.loadingText = "Loading zones..."
+		}
+	}
+}
+
 // NewModel creates a new TUI model with its dependencies.
-func NewModel(client gcpClient, projectID string) Model {
+func NewModel(client gcpClient, projectID string, opts ...Option) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	return Model{
-		gcpClient: client,
-		projectID: projectID,
-		loading:   true,
-		spinner:   s,
+	m := Model{
+		gcpClient:   client,
+		projectID:   projectID,
+		projects:    []string{projectID},
+		loading:     true,
+		loadingText: "Loading VMs...",
+		spinner:     s,
+		input:       textinput.New(),
+		filterInput: textinput.New(),
+		logs:        viewport.New(80, 20),
+		keys:        DefaultKeyMap(),
+		theme:       plainTheme(),
+		prices:      gcp.HourlyPrices(nil),
+		run:         runCommand,
+		master:      openSSHMaster,
+		specs:       gcp.NewMachineTypeCache(),
+		copy:        termenv.Copy,
+		now:         time.Now,
+		localUser:   localUsername(),
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(&m)
 	}
+	return m
+}
+
+// quit cancels the fetches still running and ends the program.
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	m.cancel()
+	return m, tea.Quit
 }
 
 // Init is the first command run when the application starts.
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, m.fetchVmsCmd)
+	load := m.streamVmsCmd(m.fetchSeq)
+	if m.pickZones {
+		load = m.listZonesCmd
+	}
+	cmds := []tea.Cmd{m.spinner.Tick, load}
+	if m.cacheDir != "" {
+		cmds = append(cmds, m.loadPinsCmd)
+	}
+	if m.configPath != "" {
+		cmds = append(cmds, m.loadRecentCmd, m.loadNotesCmd, m.loadDNDCmd)
+	}
+	if m.whoami != nil {
+		cmds = append(cmds, m.loadIdentityCmd)
+	}
+	if m.mouse {
+		cmds = append(cmds, tea.EnableMouseCellMotion)
+	}
+	return tea.Batch(cmds...)
 }
 
 // fetchVmsCmd is a command that fetches the VMs from GCP.
 func (m Model) fetchVmsCmd() tea.Msg {
-	vms, err := m.gcpClient.FetchInstances(context.Background(), m.projectID)
+	return m.fetchVms(m.fetchOpts, m.fetchSeq)
+}
+
+// fetchVms fetches the VMs of every shown project with the given options,
+// until the program quits. seq identifies the fetch in its result.
+func (m Model) fetchVms(opts gcp.FetchOptions, seq int) tea.Msg {
+	start := m.now()
+	var list gcp.InstanceList
+	var err error
+	if m.multiProject() {
+		list, err = gcp.FetchInstancesMulti(m.ctx, m.gcpClient, m.projects, opts)
+	} else {
+		list, err = m.gcpClient.FetchInstances(m.ctx, m.projectID, opts)
+	}
+	if m.observeFetch != nil {
+		m.observeFetch(list, err, m.now().Sub(start))
+	}
 	if err != nil {
-		return errMsg{err}
+		return errMsg{err: err, seq: seq}
+	}
+	return vmsMsg{InstanceList: list, seq: seq}
+}
+
+// located returns vm with its zone filled in from the default zone of its
+// project in the config, if the instance's own zone is not known.
+func (m Model) located(vm gcp.Instance) gcp.Instance {
+	if vm.Zone == "" {
+		vm.Zone = m.cfg.ProjectZones[m.projectOf(vm)]
+	}
+	return vm
+}
+
+// multiProject reports whether instances from several projects are shown.
+func (m Model) multiProject() bool {
+	return len(m.projects) > 1
+}
+
+// projectOf returns the project an instance belongs to.
+func (m Model) projectOf(vm gcp.Instance) string {
+	if vm.ProjectID != "" {
+		return vm.ProjectID
+	}
+	return m.projectID
+}
+
+// setMachineTypeCmd returns a command that resizes the given instance.
+func (m Model) setMachineTypeCmd(vm gcp.Instance, machineType string) tea.Cmd {
+	return func() tea.Msg {
+		err := m.gcpClient.SetMachineType(m.ctx, m.projectOf(vm), m.located(vm).Zone, vm.Name, machineType)
+		if err != nil {
+			return actionErrMsg{err: err, action: fmt.Sprintf("change %s to %s", vm.Name, machineType)}
+		}
+		return actionDoneMsg{fmt.Sprintf("Changed %s to %s.", vm.Name, machineType)}
+	}
+}
+
+// instanceAction is a client method acting on a single instance.
+type instanceAction func(ctx context.Context, projectID, zone, name string) error
+
+// instanceActionCmd returns a command that runs action against vm and
+// reports it with the given verb and its past tense, e.g. "Start" and
+// "Started".
+func (m Model) instanceActionCmd(vm gcp.Instance, verb, done string, action instanceAction) tea.Cmd {
+	ctx, projectID, zone := m.ctx, m.projectOf(vm), m.located(vm).Zone
+	return func() tea.Msg {
+		if err := action(ctx, projectID, zone, vm.Name); err != nil {
+			return actionErrMsg{err: err, action: strings.ToLower(verb) + " " + vm.Name}
+		}
+		return actionDoneMsg{fmt.Sprintf("%s %s.", done, vm.Name)}
+	}
+}
+
+// suspend asks to suspend vm if it is running.
+func (m Model) suspend(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	switch gcp.ClassifyStatus(vm.Status) {
+	case gcp.StatusRunning:
+	case gcp.StatusTransitional:
+		m.message = fmt.Sprintf("%s is %s; wait until it is RUNNING to suspend it.", vm.Name, vm.Status)
+		return m, nil
+	default:
+		m.message = fmt.Sprintf("%s is %s; only running instances can be suspended.", vm.Name, vm.Status)
+		return m, nil
+	}
+	m.askAction("Suspending", confirmation{prompt: fmt.Sprintf("Suspend %s?", vm.Name), cmd: m.instanceActionCmd(vm, "Suspend", "Suspended", m.gcpClient.SuspendInstance)}, vm)
+	return m, nil
+}
+
+// resume asks to resume vm if it is suspended.
+func (m Model) resume(vm gcp.Instance) (tea.Model, tea.Cmd) {
+	if vm.Status != "SUSPENDED" {
+		m.message = fmt.Sprintf("%s is %s; only suspended instances can be resumed.", vm.Name, vm.Status)
+		return m, nil
 	}
-	return vmsMsg(vms)
+	m.askAction("Resuming", confirmation{prompt: fmt.Sprintf("Resume %s?", vm.Name), cmd: m.instanceActionCmd(vm, "Resume", "Resumed", m.gcpClient.ResumeInstance)}, vm)
+	return m, nil
 }
 
-// Update handles messages and updates the model.
+// Update handles messages and updates the model, keeping the instances shown
+// until the list or what narrows it changes, and scrolling the list, the
+// tree and the zone picker to keep the cursor on screen.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	m, ok := model.(Model)
+	if !ok {
+		return model, cmd
+	}
+	m.cacheVisible()
+	switch {
+	case m.showsRows():
+		m.scrollToCursor()
+	case m.mode == modeTree:
+		m.scrollTree()
+	case m.mode == modeZones:
+		m.scrollZones()
+	}
+	return m, cmd
+}
+
+// update handles messages and updates the model.
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return m, tea.Quit
-		case "up", "k":
+		if msg.String() == "ctrl+c" {
+			m.cancelConnect()
+			return m.quit()
+		}
+		m.banner = ""
+		if m.connecting != nil {
+			return m.updateConnecting(msg)
+		}
+		if m.confirm != nil {
+			return m.updateConfirm(msg)
+		}
+		switch m.mode {
+		case modeDetail:
+			return m.updateDetail(msg)
+		case modeMachineType:
+			return m.updateMachineType(msg)
+		case modeLogs:
+			return m.updateLogs(msg)
+		case modeFilter:
+			return m.updateFilter(msg)
+		case modeZones:
+			return m.updateZones(msg)
+		case modeRecent:
+			return m.updateRecent(msg)
+		case modeNote:
+			return m.updateNote(msg)
+		case modeBulkConfirm:
+			return m.updateBulkConfirm(msg)
+		case modeQueries:
+			return m.updateQueries(msg)
+		case modeTree:
+			return m.updateTree(msg)
+		case modeHistory:
+			return m.updateHistory(msg)
+		case modeRegions:
+			return m.updateRegions(msg)
+		case modeUnlock:
+			return m.updateUnlock(msg)
+		}
+		if m.retryVM != nil {
+			vm := *m.retryVM
+			m.retryVM = nil
+			m.message = ""
+			if m.keys.matches(actionRetrySSH, msg.String()) {
+				return m.connect(vm)
+			}
+		}
+		switch key := msg.String(); {
+		case key == "esc" && m.selectionNotice != "":
+			m.selectionNotice = ""
+		case m.selectionNotice != "" && m.guardsSelection(key):
+			m.message = "The selection changed; press esc once you have checked it."
+		case key == "esc" && len(m.marked) > 0:
+			m.marked = nil
+		case key == "esc" && m.changes != nil:
+			m.changes = nil
+		case key == "esc" && m.query != nil:
+			m.applyQuery(nil)
+		case key == "esc" && m.filter != "":
+			m.setFilter("")
+		case m.keys.matches(actionQuit, key):
+			return m.quit()
+		case m.keys.matches(actionUp, key):
 			if m.cursor > 0 {
 				m.cursor--
 			}
-		case "down", "j":
-			if m.cursor < len(m.vms)-1 {
+		case m.keys.matches(actionDown, key):
+			if m.cursor < len(m.visible())-1 {
 				m.cursor++
 			}
-		case "enter":
-			if len(m.vms) == 0 {
-				return m, nil
+		case m.keys.matches(actionDense, key):
+			m.dense = !m.dense
+		case m.keys.matches(actionWrap, key):
+			m.toggleWrap()
+		case m.keys.matches(actionSummary, key):
+			m.showSummary = !m.showSummary
+			if m.showSummary {
+				return m, m.loadSpecsCmd()
+			}
+		case m.keys.matches(actionRefreshOne, key):
+			if vm, ok := m.selected(); ok {
+				return m.refreshInstance(vm)
 			}
-			vm := m.vms[m.cursor]
-			cmd := exec.Command("gcloud", "compute", "ssh", vm.Name, "--zone", vm.Zone, "--project", m.projectID)
-			return m, tea.ExecProcess(cmd, nil)
+		case m.keys.matches(actionDetail, key):
+			if _, ok := m.selected(); ok {
+				m.mode = modeDetail
+				m.message = ""
+				m.detailFocus = 0
+				m.expanded = nil
+				m.detail.GotoTop()
+			}
+		case m.keys.matches(actionLogs, key):
+			if _, ok := m.selected(); ok {
+				return m.openLogs()
+			}
+		case m.keys.matches(actionReconnect, key):
+			return m.reconnect()
+		case m.keys.matches(actionRecent, key):
+			return m.openRecent()
+		case m.keys.matches(actionTree, key):
+			return m.openTree()
+		case m.keys.matches(actionHistory, key):
+			return m.openHistory()
+		case m.keys.matches(actionRegions, key):
+			return m.openRegions()
+		case m.keys.matches(actionUnlock, key):
+			return m.openUnlock()
+		case m.keys.matches(actionNextMatch, key):
+			return m.jumpToMatch(1)
+		case m.keys.matches(actionPrevMatch, key):
+			return m.jumpToMatch(-1)
+		case m.keys.matches(actionZones, key):
+			return m.openZones()
+		case m.keys.matches(actionReload, key):
+			return m.reloadConfig()
+		case m.keys.matches(actionFilter, key):
+			return m.openFilter()
+		case m.keys.matches(actionStopped, key):
+			return m.toggleStopped()
+		case m.keys.matches(actionExternal, key):
+			return m.toggleExternal()
+		case m.keys.matches(actionAccessible, key):
+			return m.toggleAccessible()
+		case m.keys.matches(actionMouse, key):
+			return m.toggleMouse()
+		case m.keys.matches(actionQueries, key):
+			return m.openQueries()
+		case m.keys.matches(actionNote, key):
+			if vm, ok := m.selected(); ok {
+				return m.openNote(vm)
+			}
+		case m.keys.matches(actionCopySSH, key):
+			if vm, ok := m.selected(); ok {
+				return m.copySSHConfig(vm)
+			}
+		case m.keys.matches(actionAppendSSH, key):
+			if vm, ok := m.selected(); ok {
+				return m.appendSSH(vm)
+			}
+		case m.keys.matches(actionDoNotDisturb, key):
+			if vm, ok := m.selected(); ok {
+				return m.toggleDND(vm)
+			}
+		case m.keys.matches(actionPin, key):
+			if vm, ok := m.selected(); ok {
+				return m.togglePin(vm)
+			}
+		case m.keys.matches(actionMark, key):
+			if vm, ok := m.selected(); ok {
+				return m.toggleMark(vm)
+			}
+		case m.keys.matches(actionSuspend, key) && len(m.marked) > 0:
+			return m.askBulk(bulkAction{verb: "Suspend", doing: "Suspending", done: "Suspended", from: "RUNNING", run: m.gcpClient.SuspendInstance})
+		case m.keys.matches(actionResume, key) && len(m.marked) > 0:
+			return m.askBulk(bulkAction{verb: "Resume", doing: "Resuming", done: "Resumed", from: "SUSPENDED", run: m.gcpClient.ResumeInstance})
+		case m.keys.matches(actionSuspend, key):
+			if vm, ok := m.selected(); ok {
+				return m.suspend(vm)
+			}
+		case m.keys.matches(actionResume, key):
+			if vm, ok := m.selected(); ok {
+				return m.resume(vm)
+			}
+		case m.keys.matches(actionGcloud, key):
+			if vm, ok := m.selected(); ok {
+				return m, m.setGcloudDefaultsCmd(vm)
+			}
+		case m.keys.matches(actionSSH, key) && len(m.marked) > 0:
+			return m.sshMarked()
+		case m.keys.matches(actionSSH, key):
+			if vm, ok := m.selected(); ok {
+				return m.ssh(vm)
+			}
+		case m.keys.matches(actionNewest, key):
+			return m.sortByAge()
+		case len(key) == 1 && key >= "1" && key <= "9":
+			return m.sortByColumn(int(key[0] - '0'))
 		}
+	case fetchProgressMsg:
+		return m.handleFetchProgress(msg)
+	case fetchThrottledMsg:
+		return m.handleFetchThrottled(msg)
 	case vmsMsg:
-		m.vms = msg
+		if msg.seq != m.fetchSeq {
+			return m, nil
+		}
+		prev, selected := m.selected()
+		before, row := m.visible(), m.cursor-m.offset
+		m.vms = msg.Instances
+		m.listVersion++
+		m.truncated = msg.Truncated
+		m.projectErrors = msg.ProjectErrors
+		m.zoneErrors = msg.ZoneErrors
+		m.skippedZones = msg.SkippedZoneWarnings
 		m.loading = false
+		if n := len(m.visible()); m.cursor >= n {
+			m.cursor = max(n-1, 0)
+		}
+		if selected {
+			m.reconcileSelection(prev, before)
+		}
+		m.sortPinned()
+		m.keepScreenRow(row)
+		var cmd tea.Cmd
+		if m.cacheDir != "" && m.listedAll() {
+			compare := !m.snapshotCompared
+			m.snapshotCompared = true
+			cmd = m.saveSnapshotCmd(m.vms, compare)
+		}
+		if m.sshTarget != "" {
+			model, ssh := m.sshToTarget()
+			return model, tea.Batch(cmd, ssh)
+		}
+		return m, cmd
+	case snapshotMsg:
+		return m.handleSnapshot(msg)
+	case pinsMsg:
+		return m.handlePins(msg)
+	case recentMsg:
+		return m.handleRecent(msg)
+	case notesMsg:
+		return m.handleNotes(msg)
+	case dndMsg:
+		return m.handleDND(msg)
+	case configMsg:
+		return m.applyConfig(msg)
+	case pinsSavedMsg:
+		return m.handlePinsSaved(msg)
+	case sshConfigMsg:
+		return m.handleSSHConfig(msg)
+	case specsMsg:
+		return m.handleSpecs(msg)
+	case sshConnectMsg:
+		return m.handleSSHConnect(msg)
+	case sshDoneMsg:
+		return m.handleSSHDone(msg)
+	case tmuxSessionsMsg:
+		return m.handleTmuxSessions(msg)
+	case gcloudDefaultsMsg:
+		return m.handleGcloudDefaults(msg)
+	case logsMsg:
+		return m.showLogs(msg)
+	case firewallMsg:
+		return m.showFirewall(msg)
+	case quotasMsg:
+		return m.showQuotas(msg)
+	case iamMsg:
+		return m.showIAM(msg)
+	case filterTickMsg:
+		return m.applyTypedFilter(msg)
+	case accessMsg:
+		return m.showAccessible(msg)
+	case instanceMsg:
+		return m.handleInstance(msg)
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+	case identityMsg:
+		return m.handleIdentity(msg)
+	case zonesMsg:
+		return m.showZones(msg)
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.logs.Width, m.logs.Height = logsViewportSize(msg.Width, msg.Height)
+		m.detail.Width, m.detail.Height = detailViewportSize(msg.Width, msg.Height)
+	case actionDoneMsg:
+		m.record(msg.message, false)
+		return m, tea.Batch(m.showOutcome(msg.message), m.refresh())
+	case bulkDoneMsg:
+		return m.finishBulk(msg)
+	case outcomeTickMsg:
+		return m.advanceOutcome(msg)
 	case errMsg:
+		if msg.seq != m.fetchSeq {
+			return m, nil
+		}
+		if m.launching {
+			m.exitErr = fmt.Errorf("could not look up %s: %w", m.sshTarget, msg.err)
+			return m.quit()
+		}
 		m.err = msg
 		m.loading = false
+	case actionErrMsg:
+		if msg.action != "" {
+			m.record(fmt.Sprintf("Could not %s: %v", msg.action, msg.err), true)
+		}
+		m.banner = m.actionErrView(msg.err)
+		m.loading = false
+		if m.launching {
+			m.exitErr = msg.err
+			return m.quit()
+		}
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
@@ -100,26 +927,444 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// startLoading shows the spinner with the given text while cmd runs.
+func (m *Model) startLoading(text string, cmd tea.Cmd) tea.Cmd {
+	m.loading = true
+	m.loadingText = text
+	return tea.Batch(m.spinner.Tick, cmd)
+}
+
+// updateDetail handles key presses while the detail view is shown.
+func (m Model) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key := msg.String(); {
+	case m.keys.matches(actionQuit, key):
+		return m.quit()
+	case key == "esc" || key == "backspace" || m.keys.matches(actionDetail, key):
+		m.mode = modeList
+		m.message = ""
+		return m, nil
+	case m.keys.matches(actionLogs, key):
+		return m.openLogs()
+	case m.keys.matches(actionFirewall, key):
+		vm, _ := m.selected()
+		m.message = ""
+		return m, m.startLoading("Loading firewall rules...", m.fetchFirewallCmd(vm))
+	case m.keys.matches(actionIAM, key):
+		vm, _ := m.selected()
+		m.message = ""
+		return m, m.startLoading("Loading IAM policy...", m.fetchIAMCmd(vm))
+	case m.keys.matches(actionQuotas, key):
+		vm, _ := m.selected()
+		m.message = ""
+		return m, m.startLoading("Loading quotas...", m.fetchQuotasCmd(vm))
+	case m.keys.matches(actionMachineType, key):
+		vm, _ := m.selected()
+		if vm.Status != "TERMINATED" {
+			m.message = fmt.Sprintf("%s is %s; stop it before changing the machine type.", vm.Name, vm.Status)
+			return m, nil
+		}
+		if !m.allowAction("Changing the machine type", vm) {
+			return m, nil
+		}
+		m.mode = modeMachineType
+		m.message = ""
+		m.input.SetValue("")
+		m.input.Placeholder = vm.MachineType
+		return m, m.input.Focus()
+	case key == "tab" || key == "shift+tab":
+		vm, _ := m.selected()
+		delta := 1
+		if key == "shift+tab" {
+			delta = -1
+		}
+		m.moveFieldFocus(vm, delta)
+		return m, nil
+	case m.keys.matches(actionExpand, key):
+		vm, _ := m.selected()
+		m.toggleField(vm)
+		return m, nil
+	case m.keys.matches(actionDeletionProtection, key):
+		return m.toggleDeletionProtection()
+	case m.keys.matches(actionTerraform, key):
+		vm, _ := m.selected()
+		return m.copyTerraform(vm)
+	case m.keys.matches(actionWrap, key):
+		m.toggleWrap()
+		return m, nil
+	}
+	// Anything else scrolls the details.
+	vm, _ := m.selected()
+	var cmd tea.Cmd
+	m.detail.SetContent(m.fitLines(m.detailBody(vm), 16))
+	m.detail, cmd = m.detail.Update(msg)
+	return m, cmd
+}
+
+// updateMachineType handles key presses while the machine type prompt is open.
+func (m Model) updateMachineType(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeDetail
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		machineType := strings.TrimSpace(m.input.Value())
+		if machineType == "" {
+			return m, nil
+		}
+		vm, _ := m.selected()
+		m.mode = modeDetail
+		m.input.Blur()
+		return m, m.runAction("Changing the machine type", confirmation{
+			prompt:  fmt.Sprintf("Change %s to %s?", vm.Name, machineType),
+			cmd:     m.setMachineTypeCmd(vm, machineType),
+			loading: "Changing machine type...",
+		}, vm)
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
 // View renders the user interface.
 func (m Model) View() string {
 	if m.err != nil {
-		return fmt.Sprintf("\nAn error occurred: %v\n\nPress q to quit.\n", m.err)
+		return m.errorView()
 	}
 
+	if m.connecting != nil {
+		return m.connectingView()
+	}
 	if m.loading {
-		return fmt.Sprintf("\n %s Loading VMs...\n\n", m.spinner.View())
+		return fmt.Sprintf("\n %s %s\n\n", m.spinner.View(), m.loadingText)
+	}
+	if m.launching {
+		return m.launchView()
+	}
+
+	switch m.mode {
+	case modeDetail, modeMachineType:
+		return m.detailView()
+	case modeLogs:
+		return m.logsView()
+	case modeZones:
+		return m.zonesView()
+	case modeRecent:
+		return m.recentView()
+	case modeBulkConfirm:
+		return m.bulkView()
+	case modeQueries:
+		return m.queriesView()
+	case modeTree:
+		return m.treeView()
+	case modeHistory:
+		return m.historyView()
+	case modeRegions:
+		return m.regionsView()
+	case modeUnlock:
+		return m.unlockView()
+	}
+
+	header, footer := m.listChrome()
+	return header + m.rowsView(m.rowsHeight(header, footer)) + footer
+}
+
+// listChrome returns what the list shows above and below the instance rows,
+// in the plain, dense or quiet view.
+func (m Model) listChrome() (header, footer string) {
+	switch {
+	case m.quiet:
+		return "", m.quietFooterView()
+	case m.dense:
+		return m.envView(), m.denseFooterView()
 	}
+	return m.headerView(), m.footerView()
+}
 
+// footerView renders the lines of the list view below the instances.
+func (m Model) footerView() string {
 	var b strings.Builder
-	b.WriteString("GCP VMs:\n\n")
-	for i, vm := range m.vms {
-		cursor := " "
-		if m.cursor == i {
-			cursor = ">"
+	for _, pe := range m.projectErrors {
+		b.WriteString(fmt.Sprintf("\nCould not load %s: %v", pe.ProjectID, pe.Err))
+		var disabled *gcp.ComputeDisabledError
+		if errors.As(pe.Err, &disabled) {
+			b.WriteString(fmt.Sprintf(" (enable it with: %s)", disabled.EnableCommand()))
 		}
-		b.WriteString(fmt.Sprintf("%s [%s]\n", cursor, vm.Name))
 	}
+	if len(m.projectErrors) > 0 {
+		b.WriteString("\n")
+	}
+	if len(m.zoneErrors) > 0 {
+		b.WriteString("\n" + zoneErrorsView(m.zoneErrors))
+	}
+	if m.skippedZones > 0 {
+		b.WriteString(fmt.Sprintf("\nLoaded with %d zones unavailable; run without -skip-zone-warnings to see which\n", m.skippedZones))
+	}
+
+	if f := m.filterView(); f != "" {
+		b.WriteString("\n" + f)
+	}
+	if h := m.hiddenView(); h != "" {
+		b.WriteString("\n" + h)
+	}
+	if e := m.externalView(); e != "" {
+		b.WriteString("\n" + e)
+	}
+	if a := m.accessibleView(); a != "" {
+		b.WriteString("\n" + a)
+	}
+	if m.showSummary {
+		b.WriteString("\n" + summaryView(m.visible(), m.prices, m.specs))
+	}
+	if m.changes != nil {
+		b.WriteString("\n" + changesView(*m.changes))
+	}
+
+	if n := m.selectionNoticeView(); n != "" {
+		b.WriteString("\n" + n)
+	}
+	if m.message != "" {
+		b.WriteString("\n" + m.messageView() + "\n")
+	}
+	if m.banner != "" {
+		b.WriteString("\n" + m.banner + "\n")
+	}
+	if m.confirm != nil {
+		b.WriteString("\n" + m.confirm.view() + "\n")
+		return b.String()
+	}
+	if m.mode == modeNote {
+		b.WriteString("\n" + m.noteView())
+		return b.String()
+	}
+	b.WriteString("\n" + m.hintView())
+	return b.String()
+}
+
+// headerView renders the lines of the list view above the instances.
+func (m Model) headerView() string {
+	var b strings.Builder
+	b.WriteString(m.envView())
+	b.WriteString("GCP VMs:")
+	if len(m.fetchOpts.Zones) > 0 {
+		b.WriteString(fmt.Sprintf(" (zones: %s)", strings.Join(m.fetchOpts.Zones, ", ")))
+	}
+	if len(m.fetchOpts.Statuses) > 0 {
+		b.WriteString(fmt.Sprintf(" (status: %s)", strings.Join(m.fetchOpts.Statuses, ", ")))
+	}
+	if m.query != nil {
+		b.WriteString(fmt.Sprintf(" (query: %s)", m.query.Name))
+	}
+	if m.sortColumn != "" {
+		b.WriteString(fmt.Sprintf(" (sort: %s %s)", m.sortColumn, m.sortArrow()))
+	}
+	if m.truncated {
+		b.WriteString(fmt.Sprintf(" (showing first %d, list truncated)", len(m.vms)))
+	}
+	b.WriteString(m.statusBarView(b.String()))
+	b.WriteString("\n\n")
+	return b.String()
+}
+
+// zoneErrorsView summarizes the zones that could not be listed.
+func zoneErrorsView(errs []gcp.ZoneError) string {
+	zones := make([]string, len(errs))
+	for i, ze := range errs {
+		zones[i] = ze.Zone
+	}
+	noun := "zones"
+	if len(errs) == 1 {
+		noun = "zone"
+	}
+	return fmt.Sprintf("Loaded with %d %s unavailable: %s\n", len(errs), noun, strings.Join(zones, ", "))
+}
 
+// actionErrView renders the banner for a failed action, explaining errors
+// with a known cause and keeping the raw error for verbose mode.
+func (m Model) actionErrView(err error) string {
+	var exhausted *gcp.ZoneExhaustedError
+	if !errors.As(err, &exhausted) {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	s := fmt.Sprintf("Error: zone %s has no capacity for %s right now; try again later or move it to another zone.", exhausted.Zone, exhausted.Instance)
+	if m.verbose {
+		s += fmt.Sprintf(" Details: %v", exhausted.Err)
+	}
+	return s
+}
+
+// errorView renders a fetch error that left nothing to show.
+func (m Model) errorView() string {
+	var disabled *gcp.ComputeDisabledError
+	if !errors.As(m.err, &disabled) {
+		return fmt.Sprintf("\nAn error occurred: %v\n\nPress q to quit.\n", m.err)
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\nThe Compute Engine API is not enabled for project %s.\n\n", disabled.ProjectID))
+	b.WriteString(fmt.Sprintf("Enable it with:\n  %s\n\nor in the console:\n  %s\n", disabled.EnableCommand(), disabled.EnableURL()))
+	if m.verbose {
+		b.WriteString(fmt.Sprintf("\nDetails: %v\n", disabled.Err))
+	}
 	b.WriteString("\nPress q to quit.\n")
 	return b.String()
-}
\ No newline at end of file
+}
+
+// denseFooterView renders the lines of the dense view below the instances,
+// without padding, so that as many instances as possible fit on screen.
+func (m Model) denseFooterView() string {
+	var b strings.Builder
+	b.WriteString(m.filterView())
+	b.WriteString(m.hiddenView())
+	b.WriteString(m.externalView())
+	b.WriteString(m.accessibleView())
+	if m.mode == modeNote {
+		b.WriteString(m.noteView())
+	}
+	b.WriteString(m.selectionNoticeView())
+	if m.message != "" {
+		b.WriteString(m.messageView() + "\n")
+	}
+	if m.banner != "" {
+		b.WriteString(m.banner + "\n")
+	}
+	return b.String()
+}
+
+// rowView renders the i-th row of the list or the dense view, fitted to the
+// terminal width; wrapped rows take several lines.
+func (m Model) rowView(i int, vm gcp.Instance) string {
+	name := "[" + m.displayName(vm) + "]"
+	if m.dense {
+		name = m.displayName(vm)
+	}
+	row := m.cursorMarker(i) + m.markMarker(vm) + m.pinMarker(vm) + name
+	row += m.columnsView(vm, m.columns(m.dense)) + m.noteColumn(vm) + m.dndColumn(vm) + m.refreshingColumn(vm)
+	return m.fitLine(row, 4)
+}
+
+// displayName returns how vm is named in the list and details: its name,
+// or "NAME.ZONE.PROJECT" when the config asks for full names.
+func (m Model) displayName(vm gcp.Instance) string {
+	if m.cfg.NameDisplay == config.NameDisplayFull {
+		return sshHostAlias(vm, m.projectOf(vm))
+	}
+	return vm.Name
+}
+
+// zone returns how a zone is shown in the list.
+func (m Model) zone(zone string) string {
+	if m.cfg.AbbreviateZones {
+		return gcp.AbbreviateZone(zone)
+	}
+	return zone
+}
+
+// cursorMarker returns the marker shown in front of the i-th row.
+func (m Model) cursorMarker(i int) string {
+	if m.cursor == i {
+		return ">"
+	}
+	return " "
+}
+
+// detailView renders the details of the instance under the cursor, scrolled
+// within the terminal once its size is known.
+func (m Model) detailView() string {
+	vm, _ := m.selected()
+
+	var b strings.Builder
+	body := m.fitLines(m.detailBody(vm), 16)
+	if m.height > 0 {
+		vp := m.detail
+		vp.SetContent(body)
+		body = vp.View() + "\n"
+	}
+	b.WriteString(body)
+
+	if m.mode == modeMachineType {
+		b.WriteString("\nNew machine type: " + m.input.View() + "\n")
+		b.WriteString("\n" + m.hintView())
+		return b.String()
+	}
+
+	if m.message != "" {
+		b.WriteString("\n" + m.messageView() + "\n")
+	}
+	if m.banner != "" {
+		b.WriteString("\n" + m.banner + "\n")
+	}
+	if m.confirm != nil {
+		b.WriteString("\n" + m.confirm.view() + "\n")
+		return b.String()
+	}
+	b.WriteString("\n" + m.hintView())
+	return b.String()
+}
+
+// detailBody renders the scrollable part of the detail view.
+func (m Model) detailBody(vm gcp.Instance) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s\n\n", m.displayName(vm)))
+	b.WriteString(fmt.Sprintf("  Project:      %s\n", m.projectOf(vm)))
+	b.WriteString(fmt.Sprintf("  Zone:         %s\n", vm.Zone))
+	b.WriteString(fmt.Sprintf("  Status:       %s\n", m.theme.status(vm.Status)))
+	b.WriteString(fmt.Sprintf("  Machine type: %s\n", vm.MachineType))
+	b.WriteString(fmt.Sprintf("  Hostname:     %s\n", orDash(vm.Hostname)))
+	b.WriteString(fmt.Sprintf("  Image:        %s\n", imageView(vm)))
+	b.WriteString(fmt.Sprintf("  Network:      %s\n", orDash(vm.Network)))
+	b.WriteString(fmt.Sprintf("  Subnetwork:   %s\n", orDash(vm.Subnetwork)))
+	b.WriteString(fmt.Sprintf("  External IP:  %s\n", orDash(vm.ExternalIP)))
+	b.WriteString(fmt.Sprintf("  Tags:         %s\n", orDash(strings.Join(vm.Tags, ", "))))
+	b.WriteString(fmt.Sprintf("  Policies:     %s\n", orDash(strings.Join(vm.ResourcePolicies, ", "))))
+	b.WriteString(fmt.Sprintf("  Note:         %s\n", orDash(m.notes[m.noteKey(vm)])))
+	b.WriteString(fmt.Sprintf("  Created:      %s\n", relativeTime(vm.CreatedAt, m.now())))
+	b.WriteString(fmt.Sprintf("  Last started: %s\n", relativeTime(vm.LastStartAt, m.now())))
+	b.WriteString(fmt.Sprintf("  Last stopped: %s\n", relativeTime(vm.LastStopAt, m.now())))
+	b.WriteString(fmt.Sprintf("  Uptime:       %s\n", uptime(vm, m.now())))
+	b.WriteString("\nSecurity:\n")
+	b.WriteString(fmt.Sprintf("  Shielded VM:         %s\n", yesNo(vm.ShieldedVM)))
+	b.WriteString(fmt.Sprintf("  Confidential VM:     %s\n", yesNo(vm.ConfidentialVM)))
+	b.WriteString(fmt.Sprintf("  Deletion protection: %s\n", yesNo(vm.DeletionProtection)))
+	b.WriteString("\nScheduling:\n")
+	b.WriteString(fmt.Sprintf("  Automatic restart:   %s\n", yesNo(vm.AutomaticRestart)))
+	b.WriteString(fmt.Sprintf("  On host maintenance: %s\n", orDash(vm.OnHostMaintenance)))
+	b.WriteString(fmt.Sprintf("  Provisioning model:  %s\n", orDash(vm.ProvisioningModel)))
+	b.WriteString(fmt.Sprintf("  Maintenance:         %s\n", maintenanceView(vm.Maintenance, m.now())))
+	b.WriteString(fmt.Sprintf("  Reservation:         %s\n", reservationView(vm.ReservationAffinity)))
+	labels, metadata := detailFields(vm)
+	focused := m.focusedField(vm)
+	b.WriteString(m.fieldsView("Labels", labels, focused))
+	b.WriteString(m.fieldsView("Metadata", metadata, focused))
+	b.WriteString(m.firewallView(vm))
+	b.WriteString(m.quotasView(vm))
+	b.WriteString(m.iamView(vm))
+	return b.String()
+}
+
+// orDash returns s, or "-" if s is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// imageView renders the image vm was created from or else, as the list calls
+// leave the image out, the license of its boot disk, saying which it is.
+func imageView(vm gcp.Instance) string {
+	switch {
+	case vm.Image != "":
+		return vm.Image
+	case vm.BootLicense != "":
+		return vm.BootLicense + " (boot disk license)"
+	}
+	return "-"
+}
+
+// yesNo renders a boolean for display.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -1,10 +1,15 @@
 package tui
 
 import (
+	"context"
 	"errors"
+	"gcp-rider/config"
 	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
 	"gcp-rider/gcp/mocks"
+	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/mock"
@@ -14,7 +19,7 @@ import (
 func TestUpdate_VMFetchSuccess(t *testing.T) {
 	mockClient := new(mocks.Client)
 	expectedVMs := []gcp.Instance{{Name: "vm-1", Zone: "z-1"}}
-	mockClient.On("FetchInstances", mock.Anything, "test-project").Return(expectedVMs, nil)
+	mockClient.On("FetchInstances", mock.Anything, "test-project", gcp.FetchOptions{}).Return(gcp.InstanceList{Instances: expectedVMs}, nil)
 
 	m := NewModel(mockClient, "test-project")
 
@@ -32,7 +37,7 @@ func TestUpdate_VMFetchSuccess(t *testing.T) {
 func TestUpdate_VMFetchError(t *testing.T) {
 	mockClient := new(mocks.Client)
 	expectedErr := errors.New("fetch failed")
-	mockClient.On("FetchInstances", mock.Anything, "test-project").Return(nil, expectedErr)
+	mockClient.On("FetchInstances", mock.Anything, "test-project", gcp.FetchOptions{}).Return(gcp.InstanceList{}, expectedErr)
 
 	m := NewModel(mockClient, "test-project")
 
@@ -51,6 +56,63 @@ func TestUpdate_VMFetchError(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+func TestFetchObserver(t *testing.T) {
+	mockClient := new(mocks.Client)
+	expectedErr := errors.New("fetch failed")
+	mockClient.On("FetchInstances", mock.Anything, "test-project", gcp.FetchOptions{}).Return(gcp.InstanceList{}, expectedErr)
+
+	var observed []error
+	m := NewModel(mockClient, "test-project", WithFetchObserver(func(_ gcp.InstanceList, err error, _ time.Duration) {
+		observed = append(observed, err)
+	}))
+	m.fetchVmsCmd()
+
+	require.Len(t, observed, 1, "every listing should be observed once")
+	require.ErrorIs(t, observed[0], expectedErr)
+}
+
+func TestView_ComputeDisabled(t *testing.T) {
+	raw := errors.New("googleapi: Error 403: Compute Engine API has not been used in project 1 before or it is disabled")
+	m := NewModel(new(mocks.Client), "test-project")
+	model, _ := m.Update(errMsg{err: &gcp.ComputeDisabledError{ProjectID: "test-project", Err: raw}})
+	m = model.(Model)
+
+	view := m.View()
+	require.Contains(t, view, "The Compute Engine API is not enabled for project test-project.")
+	require.Contains(t, view, "gcloud services enable compute.googleapis.com --project test-project")
+	require.NotContains(t, view, "Error 403", "the raw error is only shown in verbose mode")
+
+	m.verbose = true
+	require.Contains(t, m.View(), "Details: googleapi: Error 403")
+}
+
+func TestView_ZoneErrors(t *testing.T) {
+	m := NewModel(new(mocks.Client), "test-project")
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{
+		Instances: []gcp.Instance{{Name: "vm-1", Zone: "us-central1-a", Status: "RUNNING"}},
+		ZoneErrors: []gcp.ZoneError{
+			{Zone: "us-east1-b", Err: errors.New("unreachable")},
+			{Zone: "europe-west1-b", Err: errors.New("unreachable")},
+		},
+	}})
+	view := model.(Model).View()
+	require.Contains(t, view, "[vm-1]")
+	require.Contains(t, view, "Loaded with 2 zones unavailable: us-east1-b, europe-west1-b")
+}
+
+func TestView_StatusFilter(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "TERMINATED"},
+	)
+	m := NewModel(client, "test-project", WithFetchOptions(gcp.FetchOptions{Statuses: []string{"RUNNING"}}))
+	model, _ := m.Update(m.fetchVmsCmd())
+
+	view := model.(Model).View()
+	require.Contains(t, view, "GCP VMs: (status: RUNNING)")
+	require.NotContains(t, view, "vm-2")
+}
+
 func TestUpdate_CursorMovement(t *testing.T) {
 	mockClient := new(mocks.Client)
 	m := NewModel(mockClient, "")
@@ -64,4 +126,214 @@ func TestUpdate_CursorMovement(t *testing.T) {
 	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("up")})
 	m = model.(Model)
 	require.Equal(t, 0, m.cursor, "cursor should be 0 after moving up")
-}
\ No newline at end of file
+}
+func TestUpdate_MachineTypeRequiresStopped(t *testing.T) {
+	mockClient := new(mocks.Client)
+	m := NewModel(mockClient, "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}}
+	m.loading = false
+	m.mode = modeDetail
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	m = model.(Model)
+
+	require.Nil(t, cmd, "no command should run for a running instance")
+	require.Equal(t, modeDetail, m.mode, "should stay in the detail view")
+	require.Contains(t, m.message, "stop it before changing the machine type")
+	mockClient.AssertNotCalled(t, "SetMachineType", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdate_MachineTypeSubmit(t *testing.T) {
+	mockClient := new(mocks.Client)
+	mockClient.On("SetMachineType", mock.Anything, "test-project", "z-1", "vm-1", "e2-small").Return(nil)
+
+	m := NewModel(mockClient, "test-project")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "TERMINATED", MachineType: "e2-medium"}}
+	m.loading = false
+	m.mode = modeDetail
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	m = model.(Model)
+	require.Equal(t, modeMachineType, m.mode, "should open the machine type prompt")
+
+	m.input.SetValue("e2-small")
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.True(t, m.loading, "expected loading while the resize runs")
+
+	msg := m.setMachineTypeCmd(m.vms[m.cursor], "e2-small")()
+	require.IsType(t, actionDoneMsg{}, msg)
+
+	model, cmd := m.Update(msg)
+	m = model.(Model)
+	require.NotNil(t, cmd, "expected a refresh after the resize")
+	require.Contains(t, m.message, "Changed vm-1 to e2-small.")
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestUpdate_QuitCancelsActions(t *testing.T) {
+	mockClient := new(mocks.Client)
+	var ctxs []context.Context
+	record := func(args mock.Arguments) { ctxs = append(ctxs, args.Get(0).(context.Context)) }
+	mockClient.On("SetMachineType", mock.Anything, "test-project", "z-1", "vm-1", "e2-small").Run(record).Return(context.Canceled)
+	mockClient.On("StartInstance", mock.Anything, "test-project", "z-1", "vm-1").Run(record).Return(context.Canceled)
+	mockClient.On("ListZones", mock.Anything, "test-project").Run(record).Return(nil, context.Canceled)
+
+	m := NewModel(mockClient, "test-project")
+	vm := gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "TERMINATED"}
+	m.vms = []gcp.Instance{vm}
+	resize, start := m.setMachineTypeCmd(vm, "e2-small"), m.instanceActionCmd(vm, "Start", "Started", mockClient.StartInstance)
+	m.quit()
+
+	resize()
+	start()
+	m.listZonesCmd()
+	require.Len(t, ctxs, 3)
+	for _, ctx := range ctxs {
+		require.ErrorIs(t, ctx.Err(), context.Canceled, "quitting should cancel the calls still running")
+	}
+}
+
+func TestUpdate_LogsShown(t *testing.T) {
+	mockClient := new(mocks.Client)
+	entries := []gcp.LogEntry{
+		{Timestamp: "t2", Severity: "ERROR", Message: "second"},
+		{Timestamp: "t1", Severity: "INFO", Message: "first"},
+	}
+	mockClient.On("FetchLogs", mock.Anything, "test-project", "42", logLimit).Return(entries, nil)
+
+	m := NewModel(mockClient, "test-project")
+	m.vms = []gcp.Instance{{ID: "42", Name: "vm-1", Zone: "z-1"}}
+	m.loading = false
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	m = model.(Model)
+	require.True(t, m.loading, "expected loading while logs are fetched")
+
+	model, _ = m.Update(m.fetchLogsCmd(m.vms[0])())
+	m = model.(Model)
+	require.Equal(t, modeLogs, m.mode, "expected the log viewer to open")
+	view := m.View()
+	require.Less(t, strings.Index(view, "first"), strings.Index(view, "second"), "oldest entry should be shown first")
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	require.Equal(t, modeList, m.mode, "esc should return to the list")
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestUpdate_LogsDisabled(t *testing.T) {
+	mockClient := new(mocks.Client)
+	mockClient.On("FetchLogs", mock.Anything, "test-project", "42", logLimit).Return(nil, gcp.ErrLoggingDisabled)
+
+	m := NewModel(mockClient, "test-project")
+	m.vms = []gcp.Instance{{ID: "42", Name: "vm-1", Zone: "z-1"}}
+	m.loading = false
+
+	model, _ := m.Update(m.fetchLogsCmd(m.vms[0])())
+	m = model.(Model)
+
+	require.NoError(t, m.err, "a disabled API should not be a fatal error")
+	require.Equal(t, modeList, m.mode)
+	require.Contains(t, m.message, "gcloud services enable logging.googleapis.com")
+}
+
+func TestView_DenseToggle(t *testing.T) {
+	mockClient := new(mocks.Client)
+	m := NewModel(mockClient, "")
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "z-1", Status: "RUNNING"}, {Name: "vm-2", Zone: "z-2", Status: "TERMINATED"}}
+	m.loading = false
+	m.cursor = 1
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	m = model.(Model)
+	require.True(t, m.dense, "v should enable the dense view")
+	require.Equal(t, "  vm-1 z-1 RUNNING\n> vm-2 z-2 TERMINATED\n", m.View())
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	m = model.(Model)
+	require.False(t, m.dense, "v should toggle the dense view off again")
+	require.Contains(t, m.View(), "> [vm-2]")
+}
+
+func TestView_DenseAbbreviatedZones(t *testing.T) {
+	m := NewModel(new(mocks.Client), "", WithConfig("", config.Config{AbbreviateZones: true}, false))
+	m.vms = []gcp.Instance{{Name: "vm-1", Zone: "us-central1-a", Status: "RUNNING"}, {Name: "vm-2", Zone: "moon-base1-a", Status: "RUNNING"}}
+	m.loading = false
+	m.dense = true
+
+	require.Equal(t, "> vm-1 usc1-a RUNNING\n  vm-2 moon-base1-a RUNNING\n", m.View())
+}
+
+func TestView_FullNames(t *testing.T) {
+	m := NewModel(new(mocks.Client), "shop-dev", WithConfig("", config.Config{NameDisplay: config.NameDisplayFull}, false))
+	m.vms = []gcp.Instance{{Name: "web-1", Zone: "z-1", Status: "RUNNING"}, {Name: "web-1", Zone: "z-1", Status: "RUNNING", ProjectID: "shop-prod"}}
+	m.loading = false
+	m.dense = true
+
+	require.Equal(t, "> web-1.z-1.shop-dev z-1 RUNNING\n  web-1.z-1.shop-prod z-1 RUNNING\n", m.View())
+
+	m.mode = modeDetail
+	require.True(t, strings.HasPrefix(m.View(), "web-1.z-1.shop-dev\n"), "the details should be titled with the full name")
+}
+
+func TestView_DetailScheduling(t *testing.T) {
+	m := NewModel(new(mocks.Client), "")
+	m.vms = []gcp.Instance{{Name: "vm-1", AutomaticRestart: true, OnHostMaintenance: "MIGRATE"}}
+	m.loading = false
+	m.mode = modeDetail
+
+	view := m.View()
+	require.Contains(t, view, "Automatic restart:   yes")
+	require.Contains(t, view, "On host maintenance: MIGRATE")
+	require.Contains(t, view, "Provisioning model:  -")
+	require.Contains(t, view, "Reservation:         -")
+	require.Contains(t, view, "Hostname:     -")
+}
+
+func TestView_DetailImage(t *testing.T) {
+	m := NewModel(new(mocks.Client), "")
+	m.vms = []gcp.Instance{
+		{Name: "vm-1", Image: "family/debian-12", BootLicense: "debian-12-bookworm"},
+		{Name: "vm-2", BootLicense: "debian-12-bookworm"},
+		{Name: "vm-3"},
+	}
+	m.loading = false
+	m.mode = modeDetail
+
+	require.Contains(t, m.View(), "Image:        family/debian-12\n")
+	m.cursor = 1
+	require.Contains(t, m.View(), "Image:        debian-12-bookworm (boot disk license)\n", "the license should stand in for the image the list leaves out")
+	m.cursor = 2
+	require.Contains(t, m.View(), "Image:        -\n")
+}
+
+func TestView_Truncated(t *testing.T) {
+	m := NewModel(new(mocks.Client), "")
+	model, _ := m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-1"}, {Name: "vm-2"}}, Truncated: true}})
+	m = model.(Model)
+	require.Contains(t, m.View(), "(showing first 2, list truncated)")
+
+	model, _ = m.Update(vmsMsg{InstanceList: gcp.InstanceList{Instances: []gcp.Instance{{Name: "vm-1"}}}})
+	require.NotContains(t, model.(Model).View(), "truncated")
+}
+
+func TestUpdate_MultiProjectFetch(t *testing.T) {
+	mockClient := new(mocks.Client)
+	mockClient.On("FetchInstances", mock.Anything, "proj-a", gcp.FetchOptions{}).
+		Return(gcp.InstanceList{Instances: []gcp.Instance{{ProjectID: "proj-a", Name: "vm-a"}}}, nil)
+	mockClient.On("FetchInstances", mock.Anything, "proj-b", gcp.FetchOptions{}).
+		Return(gcp.InstanceList{}, errors.New("permission denied"))
+
+	m := NewModel(mockClient, "proj-a", WithProjects([]string{"proj-a", "proj-b"}))
+	model, _ := m.Update(m.fetchVmsCmd())
+	m = model.(Model)
+
+	require.NoError(t, m.err, "one failing project should not fail the whole list")
+	view := m.View()
+	require.Contains(t, view, "[vm-a]  proj-a")
+	require.Contains(t, view, "Could not load proj-b: permission denied")
+	mockClient.AssertExpectations(t)
+}
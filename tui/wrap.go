@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// toggleWrap switches between cutting long lines short and wrapping them,
+// in the list and the detail view.
+func (m *Model) toggleWrap() {
+	m.wrap = !m.wrap
+	m.message = "Long lines are cut short."
+	if m.wrap {
+		m.message = "Long lines are wrapped."
+	}
+}
+
+// fitLine makes line fit the terminal width: cut short with an ellipsis, or
+// with wrap on, wrapped onto lines indented by indent cells. line must not
+// hold a newline. It is returned as is while the width is unknown.
+func (m Model) fitLine(line string, indent int) string {
+	if m.width <= 0 || ansi.StringWidth(line) <= m.width {
+		return line
+	}
+	if !m.wrap {
+		return ansi.Truncate(line, m.width, "…")
+	}
+	if m.width-indent < 20 {
+		indent = 0
+	}
+	lines := strings.Split(ansi.Wrap(line, m.width-indent, ""), "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.Repeat(" ", indent) + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fitLines applies fitLine to every line of s, so that each line of the
+// result takes one line of the terminal and scrolling counts them right.
+func (m Model) fitLines(s string, indent int) string {
+	if m.width <= 0 {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = m.fitLine(line, indent)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestView_WrapToggle(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "vm-with-a-rather-long-name-1", Zone: "z-1", Status: "RUNNING"},
+		gcp.Instance{Name: "vm-2", Zone: "z-1", Status: "RUNNING"},
+	)
+	m := loadedModel(t, client)
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 24, Height: 40})
+	m = model.(Model)
+
+	view := m.View()
+	require.Contains(t, view, "> [vm-with-a-rather-lon…\n", "long rows should be cut short")
+
+	m, _ = keyPress(t, m, "w")
+	require.True(t, m.wrap)
+	require.Contains(t, m.View(), "Long lines are wrapped.")
+	row := m.rowView(0, m.vms[0])
+	require.Equal(t, 1, strings.Count(row, "\n"), "the long row should take two lines")
+	for _, line := range strings.Split(m.View(), "\n") {
+		require.LessOrEqual(t, ansi.StringWidth(line), 24, "line %q is wider than the terminal", line)
+	}
+
+	// A click on the row below the wrapped one selects it.
+	m.mouse = true
+	model, _ = m.Update(tea.MouseMsg{Y: m.rowsTop() + 2, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	require.Equal(t, 1, model.(Model).cursor)
+}
+
+func TestDetailView_WrapShowsLongValues(t *testing.T) {
+	script := "#!/bin/sh\n" + strings.Repeat("echo hello; ", 10)
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "vm-1", Zone: "z-1", Status: "RUNNING", Metadata: map[string]string{"startup-script": script}})
+	m := loadedModel(t, client)
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 70, Height: 80})
+	m = model.(Model)
+
+	m, _ = keyPress(t, m, "i")
+	view := m.View()
+	require.Contains(t, view, "(2 lines, e to expand)")
+	require.Contains(t, m.keyHint(), "w to wrap long lines")
+
+	m, _ = keyPress(t, m, "w")
+	view = m.View()
+	require.NotContains(t, view, "e to expand", "wrapping should show long values in full")
+	require.Contains(t, view, "echo hello; echo hello;")
+	require.Contains(t, m.keyHint(), "w to cut long lines short")
+}
@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/cache"
+	"gcp-rider/gcp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// zonesMsg carries the zones offered by the zone picker, along with those
+// chosen the last time.
+type zonesMsg struct {
+	zones      []string
+	remembered []string
+	err        error
+}
+
+// zonesName is the cache entry holding the zones last picked for a project,
+// or a "+"-joined set of projects.
+func zonesName(projects string) string {
+	return "zones-" + projects + ".json"
+}
+
+// listZonesCmd lists the zones of the project for the zone picker.
+func (m Model) listZonesCmd() tea.Msg {
+	zones, err := m.gcpClient.ListZones(m.ctx, m.projectID)
+	if err != nil {
+		return zonesMsg{err: err}
+	}
+	var remembered []string
+	if m.cacheDir != "" {
+		// A missing or unreadable entry only means nothing is preselected.
+		cache.Load(m.cacheDir, zonesName(strings.Join(m.projects, "+")), &remembered)
+	}
+	return zonesMsg{zones: zones, remembered: remembered}
+}
+
+// openZones starts loading the zones for the zone picker.
+func (m Model) openZones() (tea.Model, tea.Cmd) {
+	m.message = ""
+	return m, m.startLoading("Loading zones...", m.listZonesCmd)
+}
+
+// showZones opens the zone picker, nearest zones first when a home region is
+// set. If the zones cannot be listed, every zone is loaded instead. When no
+// zones were picked before, the default zones of the projects in the config
+// are preselected.
+func (m Model) showZones(msg zonesMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Could not list zones, loading all of them: %v", msg.err)
+		m.fetchOpts.Zones = nil
+		return m, m.refresh()
+	}
+	m.zones = gcp.OrderZones(msg.zones, m.fetchOpts.HomeRegion)
+	m.zoneSelected = make(map[string]bool)
+	for _, z := range msg.remembered {
+		m.zoneSelected[z] = true
+	}
+	for _, z := range m.fetchOpts.Zones {
+		m.zoneSelected[z] = true
+	}
+	if len(m.zoneSelected) == 0 {
+		for _, p := range m.projects {
+			if z := m.cfg.ProjectZones[p]; slices.Contains(m.zones, z) {
+				m.zoneSelected[z] = true
+			}
+		}
+	}
+	m.zoneCursor, m.zoneOffset = 0, 0
+	m.mode = modeZones
+	return m, nil
+}
+
+// updateZones handles key presses in the zone picker.
+func (m Model) updateZones(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key := msg.String(); key {
+	case "q":
+		return m.quit()
+	case "up", "k":
+		if m.zoneCursor > 0 {
+			m.zoneCursor--
+		}
+	case "down", "j":
+		if m.zoneCursor < len(m.zones)-1 {
+			m.zoneCursor++
+		}
+	case " ":
+		if len(m.zones) > 0 {
+			z := m.zones[m.zoneCursor]
+			m.zoneSelected[z] = !m.zoneSelected[z]
+		}
+	case "esc":
+		m.mode = modeList
+		m.fetchOpts.Zones = nil
+		return m, m.refresh()
+	case "enter":
+		var picked []string
+		for _, z := range m.zones {
+			if m.zoneSelected[z] {
+				picked = append(picked, z)
+			}
+		}
+		if len(picked) == 0 {
+			m.message = "Select at least one zone, or press esc to load all zones."
+			return m, nil
+		}
+		m.mode = modeList
+		m.message = ""
+		m.fetchOpts.Zones = picked
+		return m, tea.Batch(m.refresh(), m.saveZonesCmd(picked))
+	}
+	return m, nil
+}
+
+// saveZonesCmd returns a command that remembers the picked zones.
+func (m Model) saveZonesCmd(zones []string) tea.Cmd {
+	if m.cacheDir == "" {
+		return nil
+	}
+	dir, name := m.cacheDir, zonesName(strings.Join(m.projects, "+"))
+	return func() tea.Msg {
+		if err := cache.Save(dir, name, zones); err != nil {
+			return actionErrMsg{err: fmt.Errorf("could not remember the picked zones: %w", err)}
+		}
+		return nil
+	}
+}
+
+// zoneRowsHeight returns how many zones of the picker fit on the screen, or
+// -1 while the terminal size is unknown.
+func (m Model) zoneRowsHeight() int {
+	if m.height <= 0 {
+		return -1
+	}
+	// Leave room for the title, the message, the hint and the blank lines
+	// around.
+	chrome := 3 + strings.Count(m.hintView(), "\n")
+	if m.message != "" {
+		chrome += 2
+	}
+	return max(1, m.height-chrome)
+}
+
+// scrollZones scrolls the zone picker as little as possible for the cursor
+// to be shown, leaving no blank lines below the last zone.
+func (m *Model) scrollZones() {
+	height := m.zoneRowsHeight()
+	if height < 0 {
+		m.zoneOffset = 0
+		return
+	}
+	m.zoneOffset = min(m.zoneOffset, m.zoneCursor)
+	if m.zoneCursor >= m.zoneOffset+height {
+		m.zoneOffset = m.zoneCursor - height + 1
+	}
+	m.zoneOffset = max(0, min(m.zoneOffset, len(m.zones)-height))
+}
+
+// zonesView renders the zones of the picker that fit on the screen.
+func (m Model) zonesView() string {
+	var b strings.Builder
+	b.WriteString("Pick the zones to load:\n\n")
+	shown := m.zones[min(m.zoneOffset, len(m.zones)):]
+	if height := m.zoneRowsHeight(); height >= 0 && len(shown) > height {
+		shown = shown[:height]
+	}
+	for j, z := range shown {
+		i := m.zoneOffset + j
+		marker := " "
+		if i == m.zoneCursor {
+			marker = ">"
+		}
+		check := " "
+		if m.zoneSelected[z] {
+			check = "x"
+		}
+		b.WriteString(fmt.Sprintf("%s [%s] %s", marker, check, z))
+		if r, ok := gcp.LookupRegion(gcp.ZoneRegion(z)); ok {
+			b.WriteString(" " + m.theme.Muted.Render(r.Location))
+		}
+		b.WriteString("\n")
+	}
+	if m.message != "" {
+		b.WriteString("\n" + m.message + "\n")
+	}
+	b.WriteString("\n" + m.hintView())
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"fmt"
+	"gcp-rider/cache"
+	"gcp-rider/config"
+	"gcp-rider/gcp"
+	"gcp-rider/gcp/gcptest"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZonePicker(t *testing.T) {
+	dir := t.TempDir()
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "a-1", Zone: "us-central1-a"},
+		gcp.Instance{Name: "b-1", Zone: "europe-west1-b"},
+		gcp.Instance{Name: "c-1", Zone: "asia-east1-c"},
+	)
+	m := NewModel(client, "test-project", WithZonePicker(true), WithCacheDir(dir),
+		WithFetchOptions(gcp.FetchOptions{HomeRegion: "europe-west2"}))
+	require.Contains(t, m.View(), "Loading zones...")
+
+	model, _ := m.Update(m.listZonesCmd())
+	m = model.(Model)
+	require.Equal(t, modeZones, m.mode)
+	require.Equal(t, []string{"europe-west1-b", "us-central1-a", "asia-east1-c"}, m.zones, "nearest zones should come first")
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.Equal(t, modeZones, m.mode, "enter needs at least one zone")
+	require.Contains(t, m.View(), "Select at least one zone")
+
+	m, _ = keyPress(t, m, " ")
+	m, _ = keyPress(t, m, "j")
+	m, _ = keyPress(t, m, " ")
+	require.Contains(t, m.View(), "> [x] us-central1-a")
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	require.NotNil(t, cmd)
+	require.Equal(t, modeList, m.mode)
+	require.Equal(t, []string{"europe-west1-b", "us-central1-a"}, m.fetchOpts.Zones)
+	require.Nil(t, m.saveZonesCmd(m.fetchOpts.Zones)())
+
+	model, _ = m.Update(m.fetchVmsCmd())
+	m = model.(Model)
+	require.Equal(t, []string{"b-1", "a-1"}, names(m.vms))
+	require.Contains(t, m.View(), "GCP VMs: (zones: europe-west1-b, us-central1-a)")
+
+	var saved []string
+	ok, err := cache.Load(dir, zonesName("test-project"), &saved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"europe-west1-b", "us-central1-a"}, saved)
+
+	// The next session preselects the remembered zones.
+	m = NewModel(client, "test-project", WithZonePicker(true), WithCacheDir(dir))
+	model, _ = m.Update(m.listZonesCmd())
+	m = model.(Model)
+	require.True(t, m.zoneSelected["us-central1-a"])
+	require.False(t, m.zoneSelected["asia-east1-c"])
+}
+
+func TestZonePicker_EscLoadsAllZones(t *testing.T) {
+	client := gcptest.NewFakeClient(gcp.Instance{Name: "a-1", Zone: "us-central1-a"})
+	m := NewModel(client, "test-project", WithZonePicker(true))
+	model, _ := m.Update(m.listZonesCmd())
+	model, cmd := model.(Model).Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	require.NotNil(t, cmd)
+	require.True(t, m.loading)
+	require.Empty(t, m.fetchOpts.Zones)
+}
+
+func TestZonePicker_PreselectsProjectZones(t *testing.T) {
+	client := gcptest.NewFakeClient(
+		gcp.Instance{Name: "a-1", Zone: "us-central1-a"},
+		gcp.Instance{Name: "b-1", Zone: "europe-west1-b"},
+	)
+	cfg := config.Config{ProjectZones: map[string]string{"test-project": "europe-west1-b", "other-project": "us-central1-a"}}
+	m := NewModel(client, "test-project", WithZonePicker(true), WithConfig("", cfg, false))
+
+	model, _ := m.Update(m.listZonesCmd())
+	m = model.(Model)
+	require.Equal(t, map[string]bool{"europe-west1-b": true}, m.zoneSelected, "only the zones of the loaded projects should be preselected")
+}
+
+func TestZonePicker_Scrolls(t *testing.T) {
+	var vms []gcp.Instance
+	for i := range 30 {
+		vms = append(vms, gcp.Instance{Name: fmt.Sprintf("vm-%d", i), Zone: fmt.Sprintf("zone-%02d", i)})
+	}
+	m := NewModel(gcptest.NewFakeClient(vms...), "test-project", WithZonePicker(true))
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 10})
+	model, _ = model.(Model).Update(m.listZonesCmd())
+	m = model.(Model)
+	require.Len(t, m.zones, 30)
+
+	view := m.View()
+	require.Equal(t, 10, strings.Count(view, "\n"), "the picker should fit the window:\n%s", view)
+	require.Contains(t, view, "Pick the zones to load:")
+	require.Contains(t, view, "> [ ] zone-00")
+
+	for range 20 {
+		m, _ = keyPress(t, m, "j")
+	}
+	view = m.View()
+	require.Equal(t, 10, strings.Count(view, "\n"), "the picker should fit the window:\n%s", view)
+	require.Contains(t, view, "Pick the zones to load:", "the title should stay on screen")
+	require.Contains(t, view, "> [ ] zone-20")
+	require.NotContains(t, view, "zone-00")
+
+	for range 20 {
+		m, _ = keyPress(t, m, "k")
+	}
+	require.Equal(t, 0, m.zoneOffset)
+	require.Contains(t, m.View(), "> [ ] zone-00")
+}